@@ -16,6 +16,7 @@ type AccessKey struct {
 	Expires           Time     `json:"expires" note:"key is no longer valid after this time"`
 	InactivityTimeout Time     `json:"inactivityTimeout" note:"key must be used by this time to remain valid"`
 	Scopes            []string `json:"scopes" note:"additional access level scopes that control what an access key can do"`
+	RateLimit         int      `json:"rateLimit,omitempty" note:"number of requests per minute allowed for this key. 0 means only the organization limit applies" example:"600"`
 }
 
 type ListAccessKeysRequest struct {
@@ -36,6 +37,8 @@ type CreateAccessKeyRequest struct {
 	Name              string   `json:"name"`
 	Expiry            Duration `json:"expiry" note:"maximum time valid"`
 	InactivityTimeout Duration `json:"inactivityTimeout" note:"key must be used within this duration to remain valid"`
+	RateLimit         int      `json:"rateLimit" note:"number of requests per minute allowed for this key. 0 means only the organization limit applies" example:"600"`
+	Destination       string   `json:"destination,omitempty" note:"Name of the destination a connector access key is bound to. A bound key can only send activity and session recordings for that destination" example:"production"`
 }
 
 func (r CreateAccessKeyRequest) ValidationRules() []validate.ValidationRule {
@@ -44,6 +47,7 @@ func (r CreateAccessKeyRequest) ValidationRules() []validate.ValidationRule {
 		validate.Required("userID", r.UserID),
 		validate.Required("expiry", r.Expiry),
 		validate.Required("inactivityTimeout", r.InactivityTimeout),
+		validate.IntRule{Name: "rateLimit", Value: r.RateLimit, Min: validate.Int(0)},
 	}
 }
 
@@ -81,6 +85,12 @@ func (req ListAccessKeysRequest) SetPage(page int) Paginatable {
 	return req
 }
 
+func (req ListAccessKeysRequest) SetCursor(cursor string) Paginatable {
+	req.PaginationRequest.Cursor = cursor
+
+	return req
+}
+
 type DeleteAccessKeyRequest struct {
 	Name string `form:"name" note:"Name of the access key to delete" example:"cicdkey"`
 }
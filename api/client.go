@@ -243,6 +243,8 @@ func (c Client) ListUsers(ctx context.Context, req ListUsersRequest) (*ListRespo
 		"limit":                {strconv.Itoa(req.Limit)},
 		"showSystem":           {strconv.FormatBool(req.ShowSystem)},
 		"publicKeyFingerprint": {req.PublicKeyFingerprint},
+		"lastUpdateIndex":      {strconv.FormatInt(req.LastUpdateIndex, 10)},
+		"cursor":               {req.Cursor},
 	})
 }
 
@@ -282,6 +284,8 @@ func (c Client) ListGroups(ctx context.Context, req ListGroupsRequest) (*ListRes
 	return get[ListResponse[Group]](ctx, c, "/api/groups", Query{
 		"name": {req.Name}, "userID": {req.UserID.String()},
 		"page": {strconv.Itoa(req.Page)}, "limit": {strconv.Itoa(req.Limit)},
+		"cursor":          {req.Cursor},
+		"lastUpdateIndex": {strconv.FormatInt(req.LastUpdateIndex, 10)},
 	})
 }
 
@@ -306,6 +310,7 @@ func (c Client) ListProviders(ctx context.Context, req ListProvidersRequest) (*L
 	return get[ListResponse[Provider]](ctx, c, "/api/providers", Query{
 		"name": {req.Name},
 		"page": {strconv.Itoa(req.Page)}, "limit": {strconv.Itoa(req.Limit)},
+		"lastUpdateIndex": {strconv.FormatInt(req.LastUpdateIndex, 10)},
 	})
 }
 
@@ -331,6 +336,14 @@ func (c Client) DeleteOrganization(ctx context.Context, id uid.ID) error {
 	return delete(ctx, c, fmt.Sprintf("/api/organizations/%s", id), Query{})
 }
 
+func (c Client) UpdateOrganizationRateLimits(ctx context.Context, req *UpdateOrganizationRateLimitsRequest) (*Organization, error) {
+	return put[Organization](ctx, c, fmt.Sprintf("/api/organizations/%s/ratelimits", req.ID), req)
+}
+
+func (c Client) GetRateLimitUsage(ctx context.Context, id uid.ID) (*GetRateLimitUsageResponse, error) {
+	return get[GetRateLimitUsageResponse](ctx, c, fmt.Sprintf("/api/organizations/%s/ratelimits", id), Query{})
+}
+
 func (c Client) GetProvider(ctx context.Context, id uid.ID) (*Provider, error) {
 	return get[Provider](ctx, c, fmt.Sprintf("/api/providers/%s", id), Query{})
 }
@@ -353,16 +366,19 @@ func (c Client) DeleteProvider(ctx context.Context, id uid.ID) error {
 
 func (c Client) ListGrants(ctx context.Context, req ListGrantsRequest) (*ListResponse[Grant], error) {
 	return get[ListResponse[Grant]](ctx, c, "/api/grants", Query{
-		"user":            {req.User.String()},
-		"group":           {req.Group.String()},
-		"resource":        {req.Resource},
-		"destination":     {req.Destination},
-		"privilege":       {req.Privilege},
-		"showInherited":   {strconv.FormatBool(req.ShowInherited)},
-		"showSystem":      {strconv.FormatBool(req.ShowSystem)},
-		"page":            {strconv.Itoa(req.Page)},
-		"limit":           {strconv.Itoa(req.Limit)},
-		"lastUpdateIndex": {strconv.FormatInt(req.LastUpdateIndex, 10)},
+		"user":               {req.User.String()},
+		"group":              {req.Group.String()},
+		"resource":           {req.Resource},
+		"destination":        {req.Destination},
+		"privilege":          {req.Privilege},
+		"showInherited":      {strconv.FormatBool(req.ShowInherited)},
+		"showSystem":         {strconv.FormatBool(req.ShowSystem)},
+		"showSubjectDetails": {strconv.FormatBool(req.ShowSubjectDetails)},
+		"showGroupMembers":   {strconv.FormatBool(req.ShowGroupMembers)},
+		"page":               {strconv.Itoa(req.Page)},
+		"limit":              {strconv.Itoa(req.Limit)},
+		"cursor":             {req.Cursor},
+		"lastUpdateIndex":    {strconv.FormatInt(req.LastUpdateIndex, 10)},
 	})
 }
 
@@ -384,6 +400,7 @@ func (c Client) ListDestinations(ctx context.Context, req ListDestinationsReques
 		"unique_id": {req.UniqueID},
 		"kind":      {req.Kind},
 		"page":      {strconv.Itoa(req.Page)}, "limit": {strconv.Itoa(req.Limit)},
+		"lastUpdateIndex": {strconv.FormatInt(req.LastUpdateIndex, 10)},
 	})
 }
 
@@ -399,6 +416,94 @@ func (c Client) DeleteDestination(ctx context.Context, id uid.ID) error {
 	return delete(ctx, c, fmt.Sprintf("/api/destinations/%s", id), Query{})
 }
 
+func (c Client) CreateDestinationActivity(ctx context.Context, req *CreateDestinationActivityRequest) error {
+	_, err := post[EmptyResponse](ctx, c, "/api/destination-activity", req)
+	return err
+}
+
+func (c Client) ListDestinationActivity(ctx context.Context, req ListDestinationActivityRequest) (*ListResponse[DestinationActivity], error) {
+	return get[ListResponse[DestinationActivity]](ctx, c, "/api/destination-activity", Query{
+		"destination": {req.Destination},
+		"user":        {req.User},
+		"since":       {req.Since},
+		"page":        {strconv.Itoa(req.Page)}, "limit": {strconv.Itoa(req.Limit)},
+	})
+}
+
+func (c Client) CreateSessionRecording(ctx context.Context, req *CreateSessionRecordingRequest) (*SessionRecording, error) {
+	return post[SessionRecording](ctx, c, "/api/session-recordings", req)
+}
+
+func (c Client) ListSessionRecordings(ctx context.Context, req ListSessionRecordingsRequest) (*ListResponse[SessionRecording], error) {
+	return get[ListResponse[SessionRecording]](ctx, c, "/api/session-recordings", Query{
+		"destination": {req.Destination},
+		"userID":      {req.UserID.String()},
+		"page":        {strconv.Itoa(req.Page)}, "limit": {strconv.Itoa(req.Limit)},
+	})
+}
+
+func (c Client) GetSessionRecording(ctx context.Context, id uid.ID) (*SessionRecording, error) {
+	return get[SessionRecording](ctx, c, fmt.Sprintf("/api/session-recordings/%s", id), Query{})
+}
+
+func (c Client) GetSessionRecordingContent(ctx context.Context, id uid.ID) (*SessionRecordingContent, error) {
+	return get[SessionRecordingContent](ctx, c, fmt.Sprintf("/api/session-recordings/%s/content", id), Query{})
+}
+
+func (c Client) ListRoleTemplates(ctx context.Context, req ListRoleTemplatesRequest) (*ListResponse[RoleTemplate], error) {
+	return get[ListResponse[RoleTemplate]](ctx, c, "/api/role-templates", Query{
+		"name": {req.Name},
+		"page": {strconv.Itoa(req.Page)}, "limit": {strconv.Itoa(req.Limit)},
+	})
+}
+
+func (c Client) GetRoleTemplate(ctx context.Context, id uid.ID) (*RoleTemplate, error) {
+	return get[RoleTemplate](ctx, c, fmt.Sprintf("/api/role-templates/%s", id), Query{})
+}
+
+func (c Client) CreateRoleTemplate(ctx context.Context, req *CreateRoleTemplateRequest) (*RoleTemplate, error) {
+	return post[RoleTemplate](ctx, c, "/api/role-templates", req)
+}
+
+func (c Client) UpdateRoleTemplate(ctx context.Context, req UpdateRoleTemplateRequest) (*RoleTemplate, error) {
+	return put[RoleTemplate](ctx, c, fmt.Sprintf("/api/role-templates/%s", req.ID.String()), &req)
+}
+
+func (c Client) DeleteRoleTemplate(ctx context.Context, id uid.ID) error {
+	return delete(ctx, c, fmt.Sprintf("/api/role-templates/%s", id), Query{})
+}
+
+func (c Client) ListProxyPolicies(ctx context.Context, req ListProxyPoliciesRequest) (*ListResponse[ProxyPolicy], error) {
+	return get[ListResponse[ProxyPolicy]](ctx, c, "/api/proxy-policies", Query{
+		"destination": {req.Destination},
+		"page":        {strconv.Itoa(req.Page)}, "limit": {strconv.Itoa(req.Limit)},
+	})
+}
+
+func (c Client) GetProxyPolicy(ctx context.Context, id uid.ID) (*ProxyPolicy, error) {
+	return get[ProxyPolicy](ctx, c, fmt.Sprintf("/api/proxy-policies/%s", id), Query{})
+}
+
+func (c Client) CreateProxyPolicy(ctx context.Context, req *CreateProxyPolicyRequest) (*ProxyPolicy, error) {
+	return post[ProxyPolicy](ctx, c, "/api/proxy-policies", req)
+}
+
+func (c Client) UpdateProxyPolicy(ctx context.Context, req UpdateProxyPolicyRequest) (*ProxyPolicy, error) {
+	return put[ProxyPolicy](ctx, c, fmt.Sprintf("/api/proxy-policies/%s", req.ID.String()), &req)
+}
+
+func (c Client) DeleteProxyPolicy(ctx context.Context, id uid.ID) error {
+	return delete(ctx, c, fmt.Sprintf("/api/proxy-policies/%s", id), Query{})
+}
+
+func (c Client) ListBackgroundJobs(ctx context.Context) (*ListResponse[BackgroundJob], error) {
+	return get[ListResponse[BackgroundJob]](ctx, c, "/api/debug/jobs", Query{})
+}
+
+func (c Client) RunBackgroundJob(ctx context.Context, name string) (*BackgroundJob, error) {
+	return post[BackgroundJob](ctx, c, fmt.Sprintf("/api/debug/jobs/%s/run", url.PathEscape(name)), &EmptyRequest{})
+}
+
 func (c Client) ListAccessKeys(ctx context.Context, req ListAccessKeysRequest) (*ListResponse[AccessKey], error) {
 	return get[ListResponse[AccessKey]](ctx, c, "/api/access-keys", Query{
 		"userID":       {req.UserID.String()},
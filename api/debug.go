@@ -0,0 +1,30 @@
+package api
+
+import (
+	"github.com/infrahq/infra/internal/validate"
+)
+
+// BackgroundJob is a periodic job run by the server, and the most recent run
+// of that job by any server.
+type BackgroundJob struct {
+	Name     string            `json:"name" note:"Name of the job" example:"DeleteExpiredRateLimits"`
+	Interval Duration          `json:"interval" note:"Time between runs of the job"`
+	LastRun  *BackgroundJobRun `json:"lastRun,omitempty" note:"Most recent run of the job. Omitted if the job has not run"`
+}
+
+type BackgroundJobRun struct {
+	StartedAt Time     `json:"startedAt" note:"Time the job started"`
+	Duration  Duration `json:"duration" note:"Time taken to run the job"`
+	Error     string   `json:"error,omitempty" note:"Error returned by the job. Omitted if the job was successful"`
+	Hostname  string   `json:"hostname" note:"Hostname of the server that ran the job" example:"infra-server-7d9f8b6c4-x2x5z"`
+}
+
+type RunBackgroundJobRequest struct {
+	Name string `uri:"name" json:"-"`
+}
+
+func (r RunBackgroundJobRequest) ValidationRules() []validate.ValidationRule {
+	return []validate.ValidationRule{
+		validate.Required("name", r.Name),
+	}
+}
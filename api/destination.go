@@ -39,6 +39,7 @@ type ListDestinationsRequest struct {
 	Name     string `form:"name" note:"Name of the destination" example:"production-cluster"`
 	Kind     string `form:"kind" note:"Kind of destination. eg. kubernetes or ssh or postgres" example:"kubernetes"`
 	UniqueID string `form:"unique_id" note:"Unique ID generated by the connector" example:"94c2c570a20311180ec325fd56"`
+	BlockingRequest
 	PaginationRequest
 }
 
@@ -96,6 +97,12 @@ func (req ListDestinationsRequest) SetPage(page int) Paginatable {
 	return req
 }
 
+func (req ListDestinationsRequest) SetCursor(cursor string) Paginatable {
+	req.PaginationRequest.Cursor = cursor
+
+	return req
+}
+
 func validateDestinationName(value string) validate.StringRule {
 	rule := ValidateName(value)
 	// dots are not allowed in destination name, because it would make grants
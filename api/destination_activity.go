@@ -0,0 +1,64 @@
+package api
+
+import (
+	"github.com/infrahq/infra/internal/validate"
+	"github.com/infrahq/infra/uid"
+)
+
+// DestinationActivity is a record of a single request proxied by a connector
+// to a destination.
+type DestinationActivity struct {
+	ID          uid.ID   `json:"id" note:"ID of the activity record" example:"4yJ3n3D8E2"`
+	Destination string   `json:"destination" note:"Name of the destination that received the request" example:"production"`
+	Time        Time     `json:"time" note:"Time the request was received by the connector"`
+	User        string   `json:"user" note:"Name of the user that made the request" example:"jeff@example.com"`
+	Groups      []string `json:"groups,omitempty" note:"Names of the groups of the user" example:"['developers']"`
+	Verb        string   `json:"verb" note:"Verb of the request" example:"get"`
+	APIGroup    string   `json:"apiGroup,omitempty" note:"API group of the resource" example:"apps"`
+	Resource    string   `json:"resource,omitempty" note:"Resource of the request" example:"pods"`
+	Subresource string   `json:"subresource,omitempty" note:"Subresource of the request" example:"exec"`
+	Namespace   string   `json:"namespace,omitempty" note:"Namespace of the resource" example:"default"`
+	Name        string   `json:"name,omitempty" note:"Name of the resource" example:"web-1"`
+	Path        string   `json:"path" note:"Path of the request" example:"/api/v1/namespaces/default/pods/web-1"`
+	StatusCode  int      `json:"statusCode" note:"HTTP status code of the response" example:"200"`
+	LatencyMS   int64    `json:"latencyMS" note:"Time taken to respond to the request, in milliseconds" example:"12"`
+}
+
+// MaxDestinationActivityBatch is the maximum number of records that can be
+// sent in a single CreateDestinationActivityRequest.
+const MaxDestinationActivityBatch = 1000
+
+type CreateDestinationActivityRequest struct {
+	Destination string                `json:"destination" note:"Name of the destination that received the requests" example:"production"`
+	Records     []DestinationActivity `json:"records" note:"Activity records. The id and destination fields of each record are ignored"`
+}
+
+func (r CreateDestinationActivityRequest) ValidationRules() []validate.ValidationRule {
+	return []validate.ValidationRule{
+		validate.Required("destination", r.Destination),
+		validate.Required("records", r.Records),
+	}
+}
+
+type ListDestinationActivityRequest struct {
+	Destination string `form:"destination" note:"Name of the destination" example:"production"`
+	User        string `form:"user" note:"Name of the user" example:"jeff@example.com"`
+	Since       string `form:"since" note:"Only return activity after this time, in RFC3339 format" example:"2023-01-25T10:00:00Z"`
+	PaginationRequest
+}
+
+func (r ListDestinationActivityRequest) ValidationRules() []validate.ValidationRule {
+	// no-op ValidationRules implementation so that the rules from the
+	// embedded PaginationRequest struct are not applied twice.
+	return nil
+}
+
+func (req ListDestinationActivityRequest) SetPage(page int) Paginatable {
+	req.PaginationRequest.Page = page
+	return req
+}
+
+func (req ListDestinationActivityRequest) SetCursor(cursor string) Paginatable {
+	req.PaginationRequest.Cursor = cursor
+	return req
+}
@@ -20,6 +20,19 @@ type Grant struct {
 	Group     uid.ID `json:"group,omitempty" note:"GroupID for a group being granted access" example:"3zMaadcd2U"`
 	Privilege string `json:"privilege" note:"a role or permission" example:"admin"`
 	Resource  string `json:"resource" note:"a resource name in Infra's Universal Resource Notation" example:"production.namespace"`
+
+	// The fields below are only set when ListGrantsRequest.ShowSubjectDetails is true.
+	// GroupMembers is only set when ListGrantsRequest.ShowGroupMembers is also true.
+	UserName         string             `json:"userName,omitempty" note:"Name of the user being granted access" example:"bob@example.com"`
+	UserSSHLoginName string             `json:"userSSHLoginName,omitempty" note:"Username of the user on SSH destinations" example:"bob"`
+	GroupName        string             `json:"groupName,omitempty" note:"Name of the group being granted access" example:"dev"`
+	GroupMembers     []GrantGroupMember `json:"groupMembers,omitempty" note:"Members of the group being granted access"`
+}
+
+type GrantGroupMember struct {
+	ID           uid.ID `json:"id" note:"ID of the user" example:"6hNnjfjVcc"`
+	Name         string `json:"name" note:"Name of the user" example:"bob@example.com"`
+	SSHLoginName string `json:"sshLoginName" note:"Username of the user on SSH destinations" example:"bob"`
 }
 
 type CreateGrantResponse struct {
@@ -42,6 +55,12 @@ type ListGrantsRequest struct {
 	Privilege     string `form:"privilege" example:"view" note:"a role or permission"`
 	ShowInherited bool   `form:"showInherited" note:"if true, this field includes grants that the user inherits through groups" example:"true"`
 	ShowSystem    bool   `form:"showSystem" note:"if true, this shows the connector and other internal grants" example:"false"`
+	// ShowSubjectDetails is used by connectors to avoid looking up each user
+	// and group. It requires the admin, view, or connector role.
+	ShowSubjectDetails bool `form:"showSubjectDetails" note:"if true, this includes the name of the user or group, and the SSH login names. Requires the admin, view, or connector role" example:"false"`
+	// ShowGroupMembers is used by connectors that need the members of every
+	// group, like the SSH connector.
+	ShowGroupMembers bool `form:"showGroupMembers" note:"if true, this includes the members of each group. Only used when showSubjectDetails is true" example:"false"`
 	BlockingRequest
 	PaginationRequest
 }
@@ -79,7 +98,13 @@ func (r ListGrantsRequest) validateLastUpdateIndex() *validate.Failure {
 	// query parameters can be set
 	switch {
 	case r.Destination != "":
-		if fields := r.fieldsWithValues("destination", "lastUpdateIndex"); len(fields) > 0 {
+		if fields := r.fieldsWithValues("destination", "lastUpdateIndex", "showSubjectDetails", "showGroupMembers", "page", "limit"); len(fields) > 0 {
+			return validate.Fail("lastUpdateIndex",
+				fmt.Sprintf("can not be used with %v parameter(s)", strings.Join(fields, ",")))
+		}
+
+	case r.User != 0:
+		if fields := r.fieldsWithValues("user", "showInherited", "lastUpdateIndex", "page", "limit"); len(fields) > 0 {
 			return validate.Fail("lastUpdateIndex",
 				fmt.Sprintf("can not be used with %v parameter(s)", strings.Join(fields, ",")))
 		}
@@ -125,6 +150,12 @@ func (r ListGrantsRequest) fieldsWithValues(ignored ...string) []string {
 	if r.ShowInherited && !ignore("showInherited") {
 		add("showInherited")
 	}
+	if r.ShowSubjectDetails && !ignore("showSubjectDetails") {
+		add("showSubjectDetails")
+	}
+	if r.ShowGroupMembers && !ignore("showGroupMembers") {
+		add("showGroupMembers")
+	}
 	if r.LastUpdateIndex != 0 && !ignore("lastUpdateIndex") {
 		add("lastUpdateIndex")
 	}
@@ -142,6 +173,11 @@ func (r ListGrantsRequest) SetPage(page int) Paginatable {
 	return r
 }
 
+func (r ListGrantsRequest) SetCursor(cursor string) Paginatable {
+	r.PaginationRequest.Cursor = cursor
+	return r
+}
+
 // GrantRequest defines a grant request which can be used for creating or deleting grants
 type GrantRequest struct {
 	User      uid.ID `json:"user" note:"ID of the user granted access" example:"6kdoMDd6PA"`
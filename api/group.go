@@ -18,6 +18,7 @@ type ListGroupsRequest struct {
 	Name string `form:"name" note:"Name of the group to retrieve" example:"admins"`
 	// UserID filters the results to only groups where this user is a member.
 	UserID uid.ID `form:"userID" note:"UserID of a user who is a member of the group"`
+	BlockingRequest
 	PaginationRequest
 }
 
@@ -55,3 +56,9 @@ func (req ListGroupsRequest) SetPage(page int) Paginatable {
 
 	return req
 }
+
+func (req ListGroupsRequest) SetCursor(cursor string) Paginatable {
+	req.PaginationRequest.Cursor = cursor
+
+	return req
+}
@@ -12,6 +12,13 @@ type Organization struct {
 	Updated        Time     `json:"updated"`
 	Domain         string   `json:"domain"`
 	AllowedDomains []string `json:"allowedDomains" note:"domains which can be used to login to this organization" example:"['example.com', 'infrahq.com']"`
+
+	RateLimits OrganizationRateLimits `json:"rateLimits"`
+}
+
+type OrganizationRateLimits struct {
+	RequestsPerMinute      int `json:"requestsPerMinute" note:"Number of API requests per minute allowed for the organization. 0 uses the server default" example:"5000"`
+	LoginAttemptsPerMinute int `json:"loginAttemptsPerMinute" note:"Number of password login attempts per minute allowed for each user. 0 uses the server default" example:"10"`
 }
 
 type GetOrganizationRequest struct {
@@ -74,7 +81,48 @@ func (r UpdateOrganizationRequest) ValidationRules() []validate.ValidationRule {
 	}
 }
 
+type UpdateOrganizationRateLimitsRequest struct {
+	ID                     uid.ID `uri:"id" json:"-"`
+	RequestsPerMinute      int    `json:"requestsPerMinute" note:"Number of API requests per minute allowed for the organization. 0 uses the server default" example:"5000"`
+	LoginAttemptsPerMinute int    `json:"loginAttemptsPerMinute" note:"Number of password login attempts per minute allowed for each user. 0 uses the server default" example:"10"`
+}
+
+func (r UpdateOrganizationRateLimitsRequest) ValidationRules() []validate.ValidationRule {
+	return []validate.ValidationRule{
+		validate.Required("id", r.ID),
+		validate.IntRule{Name: "requestsPerMinute", Value: r.RequestsPerMinute, Min: validate.Int(0)},
+		validate.IntRule{Name: "loginAttemptsPerMinute", Value: r.LoginAttemptsPerMinute, Min: validate.Int(0)},
+	}
+}
+
+type GetRateLimitUsageRequest struct {
+	ID IDOrSelf `uri:"id"`
+}
+
+// RateLimitUsage is the current state of a rate limit.
+type RateLimitUsage struct {
+	Limit      int      `json:"limit" note:"Number of requests allowed per minute" example:"5000"`
+	Remaining  int      `json:"remaining" note:"Number of requests remaining before the limit is reached" example:"4870"`
+	ResetAfter Duration `json:"resetAfter" note:"Time until the remaining requests returns to the limit" example:"1m"`
+}
+
+type AccessKeyRateLimitUsage struct {
+	ID    uid.ID         `json:"id" note:"ID of the access key"`
+	Name  string         `json:"name" note:"Name of the access key" example:"cicdkey"`
+	Usage RateLimitUsage `json:"usage"`
+}
+
+type GetRateLimitUsageResponse struct {
+	Organization RateLimitUsage            `json:"organization"`
+	AccessKeys   []AccessKeyRateLimitUsage `json:"accessKeys" note:"Usage of the access keys that have a rate limit"`
+}
+
 func (req ListOrganizationsRequest) SetPage(page int) Paginatable {
 	req.PaginationRequest.Page = page
 	return req
 }
+
+func (req ListOrganizationsRequest) SetCursor(cursor string) Paginatable {
+	req.PaginationRequest.Cursor = cursor
+	return req
+}
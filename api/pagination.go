@@ -4,15 +4,24 @@ import "github.com/infrahq/infra/internal/validate"
 
 type Paginatable interface {
 	SetPage(page int) Paginatable
+	SetCursor(cursor string) Paginatable
 }
 
 type PaginationRequest struct {
 	Page  int `form:"page" note:"Page number to retrieve" example:"1"`
 	Limit int `form:"limit" note:"Number of objects to retrieve per page (up to 1000)" example:"100"`
+	// Cursor is used instead of Page by endpoints that support cursor
+	// pagination. The response does not include TotalPages or TotalCount
+	// when Cursor is set.
+	Cursor string `form:"cursor" note:"Retrieve the page after this cursor. Set this to the value of nextCursor from the previous response. Only supported when listing users, groups, and grants" example:"eyJpZCI6MTIzfQ"`
 }
 
 func (p PaginationRequest) ValidationRules() []validate.ValidationRule {
 	return []validate.ValidationRule{
+		validate.MutuallyExclusive(
+			validate.Field{Name: "page", Value: p.Page},
+			validate.Field{Name: "cursor", Value: p.Cursor},
+		),
 		validate.IntRule{
 			Name:  "page",
 			Value: p.Page,
@@ -32,4 +41,7 @@ type PaginationResponse struct {
 	Limit      int `json:"limit" note:"Number of objects per page" example:"100"`
 	TotalPages int `json:"totalPages" note:"Total number of pages" example:"5"`
 	TotalCount int `json:"totalCount" note:"Total number of objects" example:"485"`
+	// NextCursor is only set by endpoints that support cursor pagination,
+	// when there may be more results.
+	NextCursor string `json:"nextCursor,omitempty" note:"Cursor for the next page of results, empty when there are no more results" example:"eyJpZCI6MTIzfQ"`
 }
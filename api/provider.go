@@ -82,6 +82,7 @@ func (r UpdateProviderRequest) ValidationRules() []validate.ValidationRule {
 
 type ListProvidersRequest struct {
 	Name string `form:"name" example:"okta" note:"Name of the provider"`
+	BlockingRequest
 	PaginationRequest
 }
 
@@ -96,3 +97,9 @@ func (req ListProvidersRequest) SetPage(page int) Paginatable {
 
 	return req
 }
+
+func (req ListProvidersRequest) SetCursor(cursor string) Paginatable {
+	req.PaginationRequest.Cursor = cursor
+
+	return req
+}
@@ -0,0 +1,159 @@
+package api
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/infrahq/infra/internal/validate"
+	"github.com/infrahq/infra/uid"
+)
+
+// ProxyPolicy denies requests proxied by a connector that match its rule, even
+// when the request would be allowed by the roles granted to the user.
+type ProxyPolicy struct {
+	ID          uid.ID          `json:"id" note:"ID of the policy" example:"4yJ3n3D8E2"`
+	Name        string          `json:"name" note:"Name of the policy" example:"no-exec-kube-system"`
+	Destination string          `json:"destination" note:"Name of the destination the policy applies to. Applies to all destinations when empty" example:"production"`
+	Reason      string          `json:"reason" note:"Reason returned to the user when a request is denied" example:"exec is not allowed in kube-system"`
+	Rule        ProxyPolicyRule `json:"rule" note:"Requests matching this rule are denied"`
+	Created     Time            `json:"created" note:"Date the policy was created"`
+	Updated     Time            `json:"updated" note:"Date the policy was updated"`
+}
+
+// ProxyPolicyRule matches a request to a destination. Every non-empty field
+// must match for the rule to match. An empty field matches any value.
+type ProxyPolicyRule struct {
+	Verbs        []string        `json:"verbs,omitempty" note:"Kubernetes verbs the rule matches" example:"['create']"`
+	Resources    []string        `json:"resources,omitempty" note:"Resources the rule matches" example:"['pods']"`
+	Subresources []string        `json:"subresources,omitempty" note:"Subresources the rule matches" example:"['exec', 'attach']"`
+	Namespaces   []string        `json:"namespaces,omitempty" note:"Namespaces the rule matches" example:"['kube-system']"`
+	Users        []string        `json:"users,omitempty" note:"Names of users the rule matches" example:"['jeff@example.com']"`
+	Groups       []string        `json:"groups,omitempty" note:"Names of groups the rule matches" example:"['contractors']"`
+	Schedule     *PolicySchedule `json:"schedule,omitempty" note:"When set, the rule only matches during this schedule"`
+}
+
+// PolicySchedule is a recurring weekly time window.
+type PolicySchedule struct {
+	Days     []string `json:"days,omitempty" note:"Days of the week, all days when empty" example:"['mon', 'tue', 'wed', 'thu', 'fri']"`
+	Start    string   `json:"start" note:"Start of the window, as HH:MM" example:"09:00"`
+	End      string   `json:"end" note:"End of the window, as HH:MM" example:"17:00"`
+	TimeZone string   `json:"timeZone,omitempty" note:"IANA time zone name, defaults to UTC" example:"America/Toronto"`
+	Outside  bool     `json:"outside,omitempty" note:"When true the schedule matches any time outside of the window"`
+}
+
+func (s PolicySchedule) ValidationRules() []validate.ValidationRule {
+	return []validate.ValidationRule{
+		validate.Required("start", s.Start),
+		validate.Required("end", s.End),
+	}
+}
+
+var scheduleDays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// Matches returns true if t is within the schedule. An error is returned if
+// any of the fields of the schedule are not valid.
+func (s PolicySchedule) Matches(t time.Time) (bool, error) {
+	loc := time.UTC
+	if s.TimeZone != "" {
+		var err error
+		loc, err = time.LoadLocation(s.TimeZone)
+		if err != nil {
+			return false, fmt.Errorf("invalid time zone %q", s.TimeZone)
+		}
+	}
+	t = t.In(loc)
+
+	start, err := time.Parse("15:04", s.Start)
+	if err != nil {
+		return false, fmt.Errorf("invalid start time %q, expected HH:MM", s.Start)
+	}
+	end, err := time.Parse("15:04", s.End)
+	if err != nil {
+		return false, fmt.Errorf("invalid end time %q, expected HH:MM", s.End)
+	}
+
+	dayMatches := len(s.Days) == 0
+	for _, day := range s.Days {
+		weekday, ok := scheduleDays[strings.ToLower(day)]
+		if !ok {
+			return false, fmt.Errorf("invalid day %q", day)
+		}
+		if weekday == t.Weekday() {
+			dayMatches = true
+		}
+	}
+
+	minutes := t.Hour()*60 + t.Minute()
+	startMinutes := start.Hour()*60 + start.Minute()
+	endMinutes := end.Hour()*60 + end.Minute()
+
+	var inWindow bool
+	if startMinutes <= endMinutes {
+		inWindow = minutes >= startMinutes && minutes < endMinutes
+	} else {
+		// the window crosses midnight
+		inWindow = minutes >= startMinutes || minutes < endMinutes
+	}
+
+	return (dayMatches && inWindow) != s.Outside, nil
+}
+
+type ListProxyPoliciesRequest struct {
+	Destination string `form:"destination" note:"Name of a destination. Returns policies for that destination and policies that apply to all destinations" example:"production"`
+	PaginationRequest
+}
+
+func (r ListProxyPoliciesRequest) ValidationRules() []validate.ValidationRule {
+	// no-op ValidationRules implementation so that the rules from the
+	// embedded PaginationRequest struct are not applied twice.
+	return nil
+}
+
+func (req ListProxyPoliciesRequest) SetPage(page int) Paginatable {
+	req.PaginationRequest.Page = page
+	return req
+}
+
+func (req ListProxyPoliciesRequest) SetCursor(cursor string) Paginatable {
+	req.PaginationRequest.Cursor = cursor
+	return req
+}
+
+type CreateProxyPolicyRequest struct {
+	Name        string          `json:"name" note:"Name of the policy" example:"no-exec-kube-system"`
+	Destination string          `json:"destination" note:"Name of the destination the policy applies to. Applies to all destinations when empty" example:"production"`
+	Reason      string          `json:"reason" note:"Reason returned to the user when a request is denied" example:"exec is not allowed in kube-system"`
+	Rule        ProxyPolicyRule `json:"rule" note:"Requests matching this rule are denied"`
+}
+
+func (r CreateProxyPolicyRequest) ValidationRules() []validate.ValidationRule {
+	return []validate.ValidationRule{
+		validate.Required("name", r.Name),
+		ValidateName(r.Name),
+	}
+}
+
+type UpdateProxyPolicyRequest struct {
+	ID          uid.ID          `uri:"id" json:"-"`
+	Name        string          `json:"name" note:"Name of the policy" example:"no-exec-kube-system"`
+	Destination string          `json:"destination" note:"Name of the destination the policy applies to. Applies to all destinations when empty" example:"production"`
+	Reason      string          `json:"reason" note:"Reason returned to the user when a request is denied" example:"exec is not allowed in kube-system"`
+	Rule        ProxyPolicyRule `json:"rule" note:"Requests matching this rule are denied"`
+}
+
+func (r UpdateProxyPolicyRequest) ValidationRules() []validate.ValidationRule {
+	return []validate.ValidationRule{
+		validate.Required("id", r.ID),
+		validate.Required("name", r.Name),
+		ValidateName(r.Name),
+	}
+}
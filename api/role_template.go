@@ -0,0 +1,99 @@
+package api
+
+import (
+	"github.com/infrahq/infra/internal/validate"
+	"github.com/infrahq/infra/uid"
+)
+
+// RoleTemplate is a named set of rules that connectors create as a role on
+// every destination. A grant with the template name as the privilege has the
+// same meaning on every destination.
+type RoleTemplate struct {
+	ID      uid.ID     `json:"id" note:"ID of the role template" example:"4yJ3n3D8E2"`
+	Name    string     `json:"name" note:"Name of the role template, used as the privilege in grants" example:"debug"`
+	Rules   []RoleRule `json:"rules" note:"Rules granted by the role"`
+	Created Time       `json:"created" note:"Date the role template was created"`
+	Updated Time       `json:"updated" note:"Date the role template was updated"`
+}
+
+// RoleRule describes a set of actions allowed by a role. For Kubernetes
+// destinations the fields have the same meaning as a PolicyRule in a
+// ClusterRole.
+type RoleRule struct {
+	APIGroups       []string `json:"apiGroups,omitempty" note:"API groups that contain the resources" example:"['']"`
+	Resources       []string `json:"resources,omitempty" note:"Resources the rule applies to" example:"['pods', 'pods/log']"`
+	ResourceNames   []string `json:"resourceNames,omitempty" note:"Optional list of resource names the rule applies to"`
+	NonResourceURLs []string `json:"nonResourceURLs,omitempty" note:"Non-resource URLs the rule applies to" example:"['/healthz']"`
+	Verbs           []string `json:"verbs" note:"Verbs allowed by the rule" example:"['get', 'list', 'watch']"`
+}
+
+func (r RoleRule) ValidationRules() []validate.ValidationRule {
+	return []validate.ValidationRule{
+		validate.Required("verbs", r.Verbs),
+		validate.RequireAnyOf(
+			validate.Field{Name: "resources", Value: r.Resources},
+			validate.Field{Name: "nonResourceURLs", Value: r.NonResourceURLs},
+		),
+	}
+}
+
+type ListRoleTemplatesRequest struct {
+	Name string `form:"name" note:"Name of the role template" example:"debug"`
+	PaginationRequest
+}
+
+func (r ListRoleTemplatesRequest) ValidationRules() []validate.ValidationRule {
+	// no-op ValidationRules implementation so that the rules from the
+	// embedded PaginationRequest struct are not applied twice.
+	return nil
+}
+
+func (req ListRoleTemplatesRequest) SetPage(page int) Paginatable {
+	req.PaginationRequest.Page = page
+	return req
+}
+
+func (req ListRoleTemplatesRequest) SetCursor(cursor string) Paginatable {
+	req.PaginationRequest.Cursor = cursor
+	return req
+}
+
+type CreateRoleTemplateRequest struct {
+	Name  string     `json:"name" note:"Name of the role template" example:"debug"`
+	Rules []RoleRule `json:"rules" note:"Rules granted by the role"`
+}
+
+func (r CreateRoleTemplateRequest) ValidationRules() []validate.ValidationRule {
+	return []validate.ValidationRule{
+		validate.Required("name", r.Name),
+		validateRoleTemplateName(r.Name),
+		validate.Required("rules", r.Rules),
+	}
+}
+
+type UpdateRoleTemplateRequest struct {
+	ID    uid.ID     `uri:"id" json:"-"`
+	Name  string     `json:"name" note:"Name of the role template" example:"debug"`
+	Rules []RoleRule `json:"rules" note:"Rules granted by the role"`
+}
+
+func (r UpdateRoleTemplateRequest) ValidationRules() []validate.ValidationRule {
+	return []validate.ValidationRule{
+		validate.Required("id", r.ID),
+		validate.Required("name", r.Name),
+		validateRoleTemplateName(r.Name),
+		validate.Required("rules", r.Rules),
+	}
+}
+
+func validateRoleTemplateName(value string) validate.StringRule {
+	rule := ValidateName(value)
+	// the name is used as the name of a ClusterRole, which must be a valid
+	// path segment, and as the privilege of a grant.
+	rule.CharacterRanges = []validate.CharRange{
+		validate.AlphabetLower,
+		validate.Numbers,
+		validate.Dash, validate.Dot,
+	}
+	return rule
+}
@@ -0,0 +1,71 @@
+package api
+
+import (
+	"github.com/infrahq/infra/internal/validate"
+	"github.com/infrahq/infra/uid"
+)
+
+// SessionRecording describes a recording of an SSH session to a destination.
+// The content of the recording is retrieved with GetSessionRecordingContent.
+type SessionRecording struct {
+	ID          uid.ID `json:"id" note:"ID of the recording" example:"4yJ3n3D8E2"`
+	Destination string `json:"destination" note:"Name of the destination" example:"bastion"`
+	UserID      uid.ID `json:"userID" note:"ID of the user" example:"3zMaadcd2U"`
+	User        string `json:"user" note:"Name of the user" example:"jeff@example.com"`
+	LoginName   string `json:"loginName" note:"Name of the local user on the destination" example:"jeff"`
+	StartedAt   Time   `json:"startedAt" note:"Time the session started"`
+	EndedAt     Time   `json:"endedAt" note:"Time the session ended"`
+	Size        int64  `json:"size" note:"Size of the recording in bytes" example:"20480"`
+	Created     Time   `json:"created" note:"Time the recording was uploaded"`
+}
+
+// SessionRecordingContent is the recording in asciicast v2 format.
+// See https://github.com/asciinema/asciinema/blob/develop/doc/asciicast-v2.md.
+type SessionRecordingContent struct {
+	ID      uid.ID `json:"id" note:"ID of the recording" example:"4yJ3n3D8E2"`
+	Content []byte `json:"content" note:"Base64 encoded recording in asciicast v2 format"`
+}
+
+// MaxSessionRecordingSize is the maximum size of the content of a recording.
+const MaxSessionRecordingSize = 64 * 1024 * 1024
+
+type CreateSessionRecordingRequest struct {
+	Destination string `json:"destination" note:"Name of the destination" example:"bastion"`
+	UserID      uid.ID `json:"userID" note:"ID of the user" example:"3zMaadcd2U"`
+	LoginName   string `json:"loginName" note:"Name of the local user on the destination" example:"jeff"`
+	StartedAt   Time   `json:"startedAt" note:"Time the session started"`
+	EndedAt     Time   `json:"endedAt" note:"Time the session ended"`
+	Content     []byte `json:"content" note:"Base64 encoded recording in asciicast v2 format"`
+}
+
+func (r CreateSessionRecordingRequest) ValidationRules() []validate.ValidationRule {
+	return []validate.ValidationRule{
+		validate.Required("destination", r.Destination),
+		validate.Required("userID", r.UserID),
+		validate.Required("loginName", r.LoginName),
+		validate.Required("startedAt", r.StartedAt),
+		validate.Required("content", r.Content),
+	}
+}
+
+type ListSessionRecordingsRequest struct {
+	Destination string `form:"destination" note:"Name of the destination" example:"bastion"`
+	UserID      uid.ID `form:"userID" note:"ID of the user" example:"3zMaadcd2U"`
+	PaginationRequest
+}
+
+func (r ListSessionRecordingsRequest) ValidationRules() []validate.ValidationRule {
+	// no-op ValidationRules implementation so that the rules from the
+	// embedded PaginationRequest struct are not applied twice.
+	return nil
+}
+
+func (req ListSessionRecordingsRequest) SetPage(page int) Paginatable {
+	req.PaginationRequest.Page = page
+	return req
+}
+
+func (req ListSessionRecordingsRequest) SetCursor(cursor string) Paginatable {
+	req.PaginationRequest.Cursor = cursor
+	return req
+}
@@ -32,6 +32,7 @@ type ListUsersRequest struct {
 	IDs                  []uid.ID `form:"ids" note:"List of User IDs"`
 	ShowSystem           bool     `form:"showSystem" note:"if true, this shows the connector and other internal users" example:"false"`
 	PublicKeyFingerprint string   `form:"publicKeyFingerprint" note:"Find the user with a public key that matches this SHA256 fingerprint."`
+	BlockingRequest
 	PaginationRequest
 }
 
@@ -82,6 +83,12 @@ func (req ListUsersRequest) SetPage(page int) Paginatable {
 	return req
 }
 
+func (req ListUsersRequest) SetCursor(cursor string) Paginatable {
+	req.PaginationRequest.Cursor = cursor
+
+	return req
+}
+
 type AddUserPublicKeyRequest struct {
 	Name string `json:"name" note:"Name of the public key, often the name of the device used to create it"`
 	// PublicKey is the key type and base64 encoded public key as it would appear
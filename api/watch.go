@@ -0,0 +1,94 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+)
+
+// watch calls list until ctx is done, or fn returns an error. The first call
+// to list returns immediately, later calls block until the results have
+// changed. fn is called with every result that is different from the
+// previous result.
+func watch[T any](
+	ctx context.Context,
+	list func(ctx context.Context, lastUpdateIndex int64) (*ListResponse[T], error),
+	fn func(*ListResponse[T]) error,
+) error {
+	var lastUpdateIndex int64
+	for {
+		resp, err := list(ctx, lastUpdateIndex)
+		var apiError Error
+		switch {
+		case ctx.Err() != nil:
+			return ctx.Err()
+		case errors.As(err, &apiError) && apiError.Code == http.StatusNotModified:
+			// the blocking request timed out without any changes
+			continue
+		case err != nil:
+			return err
+		}
+
+		if err := fn(resp); err != nil {
+			return err
+		}
+		lastUpdateIndex = resp.LastUpdateIndex.Index
+		if lastUpdateIndex == 0 {
+			// the server did not return an index, block until anything changes
+			lastUpdateIndex = 1
+		}
+	}
+}
+
+// WatchUsers calls fn with the users that match req, and again each time the
+// users change. WatchUsers returns when ctx is done, or when fn or the request
+// returns an error. req.LastUpdateIndex is ignored.
+func (c Client) WatchUsers(ctx context.Context, req ListUsersRequest, fn func(*ListResponse[User]) error) error {
+	return watch(ctx, func(ctx context.Context, lastUpdateIndex int64) (*ListResponse[User], error) {
+		req.LastUpdateIndex = lastUpdateIndex
+		return c.ListUsers(ctx, req)
+	}, fn)
+}
+
+// WatchGroups calls fn with the groups that match req, and again each time the
+// groups, or the members of the groups, change. WatchGroups returns when ctx is
+// done, or when fn or the request returns an error. req.LastUpdateIndex is
+// ignored.
+func (c Client) WatchGroups(ctx context.Context, req ListGroupsRequest, fn func(*ListResponse[Group]) error) error {
+	return watch(ctx, func(ctx context.Context, lastUpdateIndex int64) (*ListResponse[Group], error) {
+		req.LastUpdateIndex = lastUpdateIndex
+		return c.ListGroups(ctx, req)
+	}, fn)
+}
+
+// WatchDestinations calls fn with the destinations that match req, and again
+// each time the destinations change. WatchDestinations returns when ctx is
+// done, or when fn or the request returns an error. req.LastUpdateIndex is
+// ignored.
+func (c Client) WatchDestinations(ctx context.Context, req ListDestinationsRequest, fn func(*ListResponse[Destination]) error) error {
+	return watch(ctx, func(ctx context.Context, lastUpdateIndex int64) (*ListResponse[Destination], error) {
+		req.LastUpdateIndex = lastUpdateIndex
+		return c.ListDestinations(ctx, req)
+	}, fn)
+}
+
+// WatchProviders calls fn with the providers that match req, and again each
+// time the providers change. WatchProviders returns when ctx is done, or when
+// fn or the request returns an error. req.LastUpdateIndex is ignored.
+func (c Client) WatchProviders(ctx context.Context, req ListProvidersRequest, fn func(*ListResponse[Provider]) error) error {
+	return watch(ctx, func(ctx context.Context, lastUpdateIndex int64) (*ListResponse[Provider], error) {
+		req.LastUpdateIndex = lastUpdateIndex
+		return c.ListProviders(ctx, req)
+	}, fn)
+}
+
+// WatchGrants calls fn with the grants that match req, and again each time the
+// grants change. req.Destination or req.User is required, see ListGrantsRequest.
+// WatchGrants returns when ctx is done, or when fn or the request returns an
+// error. req.LastUpdateIndex is ignored.
+func (c Client) WatchGrants(ctx context.Context, req ListGrantsRequest, fn func(*ListResponse[Grant]) error) error {
+	return watch(ctx, func(ctx context.Context, lastUpdateIndex int64) (*ListResponse[Grant], error) {
+		req.LastUpdateIndex = lastUpdateIndex
+		return c.ListGrants(ctx, req)
+	}, fn)
+}
@@ -0,0 +1,74 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestClient_WatchUsers(t *testing.T) {
+	var mu sync.Mutex
+	var indexes []string
+	handler := func(resp http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/api/users" {
+			resp.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		mu.Lock()
+		lastUpdateIndex := r.URL.Query().Get("lastUpdateIndex")
+		indexes = append(indexes, lastUpdateIndex)
+		calls := len(indexes)
+		mu.Unlock()
+
+		switch {
+		case lastUpdateIndex == "0" || lastUpdateIndex == "":
+			resp.Header().Set("Last-Update-Index", "5")
+			resp.WriteHeader(http.StatusOK)
+			_, _ = resp.Write([]byte(`{"items": [{"name": "a@example.com"}]}`))
+		case calls == 2:
+			// the blocking request timed out
+			resp.WriteHeader(http.StatusNotModified)
+		default:
+			resp.Header().Set("Last-Update-Index", "7")
+			resp.WriteHeader(http.StatusOK)
+			_, _ = resp.Write([]byte(`{"items": [{"name": "a@example.com"}, {"name": "b@example.com"}]}`))
+		}
+	}
+	srv := httptest.NewServer(http.HandlerFunc(handler))
+	t.Cleanup(srv.Close)
+
+	c := Client{
+		Name:      "testing",
+		Version:   "version",
+		URL:       srv.URL,
+		AccessKey: "the-access-key",
+	}
+
+	errStop := errors.New("stop")
+	var results [][]string
+	err := c.WatchUsers(context.Background(), ListUsersRequest{}, func(resp *ListResponse[User]) error {
+		var names []string
+		for _, user := range resp.Items {
+			names = append(names, user.Name)
+		}
+		results = append(results, names)
+		if len(results) == 2 {
+			return errStop
+		}
+		return nil
+	})
+	assert.ErrorIs(t, err, errStop)
+
+	expected := [][]string{
+		{"a@example.com"},
+		{"a@example.com", "b@example.com"},
+	}
+	assert.DeepEqual(t, results, expected)
+	assert.DeepEqual(t, indexes, []string{"0", "5", "5"})
+}
@@ -40,6 +40,26 @@ func CreateAccessKey(rCtx RequestContext, accessKey *models.AccessKey) (string,
 	if err != nil && accessKey.IssuedFor != rCtx.Authenticated.User.ID {
 		return "", HandleAuthErr(err, "access key", "create", models.InfraAdminRole)
 	}
+	isAdmin := err == nil
+
+	if destination := accessKey.Destination(); destination != "" {
+		if connector := data.InfraConnectorIdentity(rCtx.DBTxn); connector.ID != accessKey.IssuedFor {
+			return "", fmt.Errorf("%w: only connector access keys can be bound to a destination", internal.ErrBadRequest)
+		}
+	}
+
+	// a key bound to a destination can only create keys for the same destination
+	if rCtx.Authenticated.AccessKey != nil && !isAdmin {
+		bound := rCtx.Authenticated.AccessKey.Destination()
+		switch destination := accessKey.Destination(); {
+		case bound == "" && destination != "":
+			return "", fmt.Errorf("%w: only an admin can bind an access key to a destination", ErrNotAuthorized)
+		case bound != "" && destination == "":
+			accessKey.Scopes = append(accessKey.Scopes, models.ScopeDestinationPrefix+bound)
+		case bound != destination:
+			return "", fmt.Errorf("%w: the access key is bound to destination %q", ErrNotAuthorized, bound)
+		}
+	}
 
 	body, err := data.CreateAccessKey(rCtx.DBTxn, accessKey)
 	if err != nil {
@@ -1,6 +1,10 @@
 package access
 
 import (
+	"errors"
+	"fmt"
+
+	"github.com/infrahq/infra/internal"
 	"github.com/infrahq/infra/internal/server/data"
 	"github.com/infrahq/infra/internal/server/models"
 	"github.com/infrahq/infra/uid"
@@ -31,3 +35,39 @@ func DeleteDestination(rCtx RequestContext, id uid.ID) error {
 
 	return data.DeleteDestination(rCtx.DBTxn, id)
 }
+
+// authorizeDestinationRecord checks that the caller can create records, like
+// activity or session recordings, for destination. An admin can create records
+// for any destination. A connector key bound to a destination can only create
+// records for that destination. Connector keys created before keys could be
+// bound are not bound to a destination, and can create records for any
+// destination registered in the organization.
+func authorizeDestinationRecord(rCtx RequestContext, resource string, destination string) error {
+	if err := IsAuthorized(rCtx, models.InfraAdminRole); err == nil {
+		return nil
+	}
+
+	roles := []string{models.InfraAdminRole, models.InfraConnectorRole}
+	if err := IsAuthorized(rCtx, models.InfraConnectorRole); err != nil {
+		return HandleAuthErr(err, resource, "create", roles...)
+	}
+
+	var bound string
+	if rCtx.Authenticated.AccessKey != nil {
+		bound = rCtx.Authenticated.AccessKey.Destination()
+	}
+	switch {
+	case bound == "":
+		_, err := data.GetDestination(rCtx.DBTxn, data.GetDestinationOptions{ByName: destination})
+		switch {
+		case errors.Is(err, internal.ErrNotFound):
+			return fmt.Errorf("%w: destination %q is not registered", ErrNotAuthorized, destination)
+		case err != nil:
+			return err
+		}
+	case bound != destination:
+		return fmt.Errorf("%w: the connector access key is bound to destination %q, not %q",
+			ErrNotAuthorized, bound, destination)
+	}
+	return nil
+}
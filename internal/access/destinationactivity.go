@@ -0,0 +1,40 @@
+package access
+
+import (
+	"fmt"
+
+	"github.com/infrahq/infra/api"
+	"github.com/infrahq/infra/internal"
+	"github.com/infrahq/infra/internal/server/data"
+	"github.com/infrahq/infra/internal/server/models"
+)
+
+// CreateDestinationActivity creates the activity records sent by the connector
+// of destination. Every record must be for that destination.
+func CreateDestinationActivity(rCtx RequestContext, destination string, records []models.DestinationActivity) error {
+	if err := authorizeDestinationRecord(rCtx, "destination activity", destination); err != nil {
+		return err
+	}
+
+	if len(records) > api.MaxDestinationActivityBatch {
+		return fmt.Errorf("%w: at most %d records can be sent in a single request",
+			internal.ErrBadRequest, api.MaxDestinationActivityBatch)
+	}
+	for _, record := range records {
+		if record.DestinationName != destination {
+			return fmt.Errorf("%w: activity record for destination %q sent for %q",
+				internal.ErrBadRequest, record.DestinationName, destination)
+		}
+	}
+
+	return data.CreateDestinationActivity(rCtx.DBTxn, records)
+}
+
+func ListDestinationActivity(rCtx RequestContext, opts data.ListDestinationActivityOptions) ([]models.DestinationActivity, error) {
+	roles := []string{models.InfraAdminRole, models.InfraViewRole}
+	if err := IsAuthorized(rCtx, roles...); err != nil {
+		return nil, HandleAuthErr(err, "destination activity", "list", roles...)
+	}
+
+	return data.ListDestinationActivity(rCtx.DBTxn, opts)
+}
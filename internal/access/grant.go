@@ -5,7 +5,6 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
-	"time"
 
 	"github.com/infrahq/infra/internal"
 	"github.com/infrahq/infra/internal/logging"
@@ -39,6 +38,10 @@ func ListGrants(rCtx RequestContext, opts data.ListGrantsOptions, lastUpdateInde
 		switch {
 		case rCtx.Authenticated.User == nil:
 			return ListGrantsResponse{}, err
+		case opts.IncludeSubjectDetails:
+			// subject details include the members of groups and the login
+			// names of other users.
+			return ListGrantsResponse{}, HandleAuthErr(ErrNotAuthorized, "grants with subject details", "list", roles...)
 		case subject.Kind == models.SubjectKindUser && rCtx.Authenticated.User.ID == subject.ID:
 			// authorized because the request is for their own grants
 		case subject.Kind == models.SubjectKindGroup && userInGroup(rCtx.DBTxn, rCtx.Authenticated.User.ID, subject.ID):
@@ -55,56 +58,63 @@ func ListGrants(rCtx RequestContext, opts data.ListGrantsOptions, lastUpdateInde
 		return ListGrantsResponse{Grants: result}, err
 	}
 
+	listenOpts := data.ListenForNotifyOptions{
+		GrantsByDestination: opts.ByDestination,
+		OrgID:               rCtx.DBTxn.OrganizationID(),
+	}
+	if opts.ByDestination == "" {
+		// blocking requests for the grants of a user are filtered by the user,
+		// and the groups of the user when inherited grants are included.
+		listenOpts.GrantsByUser = subject.ID
+		if opts.IncludeInheritedFromGroups {
+			listenOpts.GrantsByUserGroups, err = data.ListGroupIDsForUser(rCtx.DBTxn, subject.ID)
+			if err != nil {
+				return ListGrantsResponse{}, err
+			}
+		}
+	}
+
 	// Close the request scoped txn to avoid long-running transactions.
 	if err := rCtx.DBTxn.Rollback(); err != nil {
 		return ListGrantsResponse{}, err
 	}
 
-	listenOpts := data.ListenForNotifyOptions{
-		GrantsByDestination: opts.ByDestination,
-		OrgID:               rCtx.DBTxn.OrganizationID(),
-	}
+	// The listener does not hold a database connection while it waits,
+	// notifications are received by a single connection shared by all
+	// listeners.
 	listener, err := data.ListenForNotify(rCtx.Request.Context(), rCtx.DataDB, listenOpts)
 	if err != nil {
 		return ListGrantsResponse{}, fmt.Errorf("listen for notify: %w", err)
 	}
-	defer func() {
-		// use a context with a separate deadline so that we still release
-		// when the request timeout is reached
-		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
-		defer cancel()
-		if err := listener.Release(ctx); err != nil {
-			logging.L.Error().Err(err).Msg("failed to release listener conn")
-		}
-	}()
+	defer listener.Release()
 
 	result, err := listGrantsWithMaxUpdateIndex(rCtx, opts)
 	if err != nil {
 		return result, err
 	}
 
-	// The query returned results that are new to the client
-	if result.MaxUpdateIndex > lastUpdateIndex {
-		return result, nil
-	}
+	for {
+		// The query returned results that are new to the client
+		if result.MaxUpdateIndex > lastUpdateIndex {
+			return result, nil
+		}
 
-	err = listener.WaitForNotification(rCtx.Request.Context())
-	switch {
-	case errors.Is(err, context.DeadlineExceeded):
-		return result, internal.ErrNotModified
-	case err != nil:
-		return result, fmt.Errorf("waiting for notify: %w", err)
-	}
+		// Notifications for group membership changes are not filtered by
+		// destination or user, so the query may return the same results. Wait
+		// for another notification until the request times out.
+		err = listener.WaitForNotification(rCtx.Request.Context())
+		switch {
+		case errors.Is(err, context.DeadlineExceeded):
+			return result, internal.ErrNotModified
+		case err != nil:
+			return result, fmt.Errorf("waiting for notify: %w", err)
+		}
 
-	result, err = listGrantsWithMaxUpdateIndex(rCtx, opts)
-	if err != nil {
-		return result, err
+		result, err = listGrantsWithMaxUpdateIndex(rCtx, opts)
+		if err != nil {
+			return result, err
+		}
 	}
-
-	// TODO: check if the maxIndex > lastUpdateIndex, and start waiting for
-	// notification again when it's false. When we include group membership
-	// changes in the query this will be an optimization.
-	return result, nil
 }
 
 func listGrantsWithMaxUpdateIndex(rCtx RequestContext, opts data.ListGrantsOptions) (ListGrantsResponse, error) {
@@ -123,9 +133,12 @@ func listGrantsWithMaxUpdateIndex(rCtx RequestContext, opts data.ListGrantsOptio
 		return ListGrantsResponse{}, err
 	}
 
-	maxUpdateIndex, err := data.GrantsMaxUpdateIndex(tx, data.GrantsMaxUpdateIndexOptions{
-		ByDestination: opts.ByDestination,
-	})
+	indexOpts := data.GrantsMaxUpdateIndexOptions{ByDestination: opts.ByDestination}
+	if opts.ByDestination == "" {
+		indexOpts.ByUser = opts.BySubject.ID
+		indexOpts.IncludeInheritedFromGroups = opts.IncludeInheritedFromGroups
+	}
+	maxUpdateIndex, err := data.GrantsMaxUpdateIndex(tx, indexOpts)
 	return ListGrantsResponse{Grants: result, MaxUpdateIndex: maxUpdateIndex}, err
 }
 
@@ -81,3 +81,48 @@ func UpdateOrganization(rCtx RequestContext, org *models.Organization) error {
 	}
 	return fmt.Errorf("%w: %s", ErrNotAuthorized, "you may only update your own organization")
 }
+
+// UpdateOrganizationRateLimits updates the rate limit settings of the
+// organization. Only support admins may change rate limits, because the
+// limits protect the server from any one organization.
+func UpdateOrganizationRateLimits(rCtx RequestContext, id uid.ID, rateLimit, loginRateLimit int) (*models.Organization, error) {
+	err := IsAuthorized(rCtx, models.InfraSupportAdminRole)
+	if err != nil {
+		return nil, HandleAuthErr(err, "organization rate limits", "update", models.InfraSupportAdminRole)
+	}
+
+	org, err := data.GetOrganization(rCtx.DBTxn, data.GetOrganizationOptions{ByID: id})
+	if err != nil {
+		return nil, err
+	}
+	org.RateLimit = rateLimit
+	org.LoginRateLimit = loginRateLimit
+	if err := data.UpdateOrganization(rCtx.DBTxn, org); err != nil {
+		return nil, err
+	}
+	return org, nil
+}
+
+// GetOrganizationRateLimits returns the organization with the rate limit
+// settings, and the access keys of the organization that have a rate limit.
+// Admins may get the rate limits of their own organization.
+func GetOrganizationRateLimits(rCtx RequestContext, id uid.ID) (*models.Organization, []models.AccessKey, error) {
+	roles := []string{models.InfraSupportAdminRole}
+	if user := rCtx.Authenticated.User; user != nil && user.OrganizationID == id {
+		roles = append(roles, models.InfraAdminRole)
+	}
+	if err := IsAuthorized(rCtx, roles...); err != nil {
+		return nil, nil, HandleAuthErr(err, "organization rate limits", "get", roles...)
+	}
+
+	org, err := data.GetOrganization(rCtx.DBTxn, data.GetOrganizationOptions{ByID: id})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	keys, err := data.ListAccessKeys(rCtx.DBTxn.WithOrgID(id), data.ListAccessKeyOptions{OnlyRateLimited: true})
+	if err != nil {
+		return nil, nil, err
+	}
+	return org, keys, nil
+}
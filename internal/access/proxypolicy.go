@@ -0,0 +1,49 @@
+package access
+
+import (
+	"github.com/infrahq/infra/internal/server/data"
+	"github.com/infrahq/infra/internal/server/models"
+	"github.com/infrahq/infra/uid"
+)
+
+func ListProxyPolicies(rCtx RequestContext, opts data.ListProxyPoliciesOptions) ([]models.ProxyPolicy, error) {
+	roles := []string{models.InfraAdminRole, models.InfraViewRole, models.InfraConnectorRole}
+	if err := IsAuthorized(rCtx, roles...); err != nil {
+		return nil, HandleAuthErr(err, "proxy policies", "list", roles...)
+	}
+
+	return data.ListProxyPolicies(rCtx.DBTxn, opts)
+}
+
+func GetProxyPolicy(rCtx RequestContext, id uid.ID) (*models.ProxyPolicy, error) {
+	roles := []string{models.InfraAdminRole, models.InfraViewRole, models.InfraConnectorRole}
+	if err := IsAuthorized(rCtx, roles...); err != nil {
+		return nil, HandleAuthErr(err, "proxy policy", "get", roles...)
+	}
+
+	return data.GetProxyPolicy(rCtx.DBTxn, id)
+}
+
+func CreateProxyPolicy(rCtx RequestContext, policy *models.ProxyPolicy) error {
+	if err := IsAuthorized(rCtx, models.InfraAdminRole); err != nil {
+		return HandleAuthErr(err, "proxy policy", "create", models.InfraAdminRole)
+	}
+
+	return data.CreateProxyPolicy(rCtx.DBTxn, policy)
+}
+
+func UpdateProxyPolicy(rCtx RequestContext, policy *models.ProxyPolicy) error {
+	if err := IsAuthorized(rCtx, models.InfraAdminRole); err != nil {
+		return HandleAuthErr(err, "proxy policy", "update", models.InfraAdminRole)
+	}
+
+	return data.UpdateProxyPolicy(rCtx.DBTxn, policy)
+}
+
+func DeleteProxyPolicy(rCtx RequestContext, id uid.ID) error {
+	if err := IsAuthorized(rCtx, models.InfraAdminRole); err != nil {
+		return HandleAuthErr(err, "proxy policy", "delete", models.InfraAdminRole)
+	}
+
+	return data.DeleteProxyPolicy(rCtx.DBTxn, id)
+}
@@ -0,0 +1,49 @@
+package access
+
+import (
+	"github.com/infrahq/infra/internal/server/data"
+	"github.com/infrahq/infra/internal/server/models"
+	"github.com/infrahq/infra/uid"
+)
+
+func ListRoleTemplates(rCtx RequestContext, opts data.ListRoleTemplatesOptions) ([]models.RoleTemplate, error) {
+	roles := []string{models.InfraAdminRole, models.InfraViewRole, models.InfraConnectorRole}
+	if err := IsAuthorized(rCtx, roles...); err != nil {
+		return nil, HandleAuthErr(err, "role templates", "list", roles...)
+	}
+
+	return data.ListRoleTemplates(rCtx.DBTxn, opts)
+}
+
+func GetRoleTemplate(rCtx RequestContext, id uid.ID) (*models.RoleTemplate, error) {
+	roles := []string{models.InfraAdminRole, models.InfraViewRole, models.InfraConnectorRole}
+	if err := IsAuthorized(rCtx, roles...); err != nil {
+		return nil, HandleAuthErr(err, "role template", "get", roles...)
+	}
+
+	return data.GetRoleTemplate(rCtx.DBTxn, data.GetRoleTemplateOptions{ByID: id})
+}
+
+func CreateRoleTemplate(rCtx RequestContext, template *models.RoleTemplate) error {
+	if err := IsAuthorized(rCtx, models.InfraAdminRole); err != nil {
+		return HandleAuthErr(err, "role template", "create", models.InfraAdminRole)
+	}
+
+	return data.CreateRoleTemplate(rCtx.DBTxn, template)
+}
+
+func UpdateRoleTemplate(rCtx RequestContext, template *models.RoleTemplate) error {
+	if err := IsAuthorized(rCtx, models.InfraAdminRole); err != nil {
+		return HandleAuthErr(err, "role template", "update", models.InfraAdminRole)
+	}
+
+	return data.UpdateRoleTemplate(rCtx.DBTxn, template)
+}
+
+func DeleteRoleTemplate(rCtx RequestContext, id uid.ID) error {
+	if err := IsAuthorized(rCtx, models.InfraAdminRole); err != nil {
+		return HandleAuthErr(err, "role template", "delete", models.InfraAdminRole)
+	}
+
+	return data.DeleteRoleTemplate(rCtx.DBTxn, id)
+}
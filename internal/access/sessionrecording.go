@@ -0,0 +1,50 @@
+package access
+
+import (
+	"github.com/infrahq/infra/internal/server/data"
+	"github.com/infrahq/infra/internal/server/models"
+	"github.com/infrahq/infra/uid"
+)
+
+// CreateSessionRecording creates a recording sent by the connector of the
+// destination of the recording.
+func CreateSessionRecording(rCtx RequestContext, recording *models.SessionRecording) error {
+	if err := authorizeDestinationRecord(rCtx, "session recording", recording.DestinationName); err != nil {
+		return err
+	}
+
+	user, err := data.GetIdentity(rCtx.DBTxn, data.GetIdentityOptions{ByID: recording.UserID})
+	if err != nil {
+		return err
+	}
+	recording.UserName = user.Name
+
+	return data.CreateSessionRecording(rCtx.DBTxn, recording)
+}
+
+func GetSessionRecording(rCtx RequestContext, id uid.ID) (*models.SessionRecording, error) {
+	roles := []string{models.InfraAdminRole}
+	if err := IsAuthorized(rCtx, roles...); err != nil {
+		return nil, HandleAuthErr(err, "session recording", "get", roles...)
+	}
+
+	return data.GetSessionRecording(rCtx.DBTxn, id)
+}
+
+func GetSessionRecordingContent(rCtx RequestContext, id uid.ID) (*models.SessionRecording, error) {
+	roles := []string{models.InfraAdminRole}
+	if err := IsAuthorized(rCtx, roles...); err != nil {
+		return nil, HandleAuthErr(err, "session recording", "get", roles...)
+	}
+
+	return data.GetSessionRecordingContent(rCtx.DBTxn, id)
+}
+
+func ListSessionRecordings(rCtx RequestContext, opts data.ListSessionRecordingsOptions) ([]models.SessionRecording, error) {
+	roles := []string{models.InfraAdminRole}
+	if err := IsAuthorized(rCtx, roles...); err != nil {
+		return nil, HandleAuthErr(err, "session recordings", "list", roles...)
+	}
+
+	return data.ListSessionRecordings(rCtx.DBTxn, opts)
+}
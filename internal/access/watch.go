@@ -0,0 +1,103 @@
+package access
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/infrahq/infra/internal"
+	"github.com/infrahq/infra/internal/server/data"
+	"github.com/infrahq/infra/uid"
+)
+
+type WatchListResponse[T any] struct {
+	Items          []T
+	MaxUpdateIndex int64
+}
+
+// WatchList calls list to return the items visible to the request, and the
+// maximum update index of table. When lastUpdateIndex is non-zero WatchList
+// blocks until the maximum update index of table is greater than
+// lastUpdateIndex, or the request context is done.
+//
+// The update index is for the entire table, not only the items returned by
+// list, so a watch may return the same items when a different row changes.
+//
+// list is called with a RequestContext that uses a new transaction each time,
+// so list must perform any authorization checks.
+func WatchList[T any](
+	rCtx RequestContext,
+	table data.WatchTable,
+	lastUpdateIndex int64,
+	list func(rCtx RequestContext) ([]T, error),
+) (WatchListResponse[T], error) {
+	if lastUpdateIndex == 0 {
+		return listWithMaxUpdateIndex(rCtx, table, list)
+	}
+
+	// Close the request scoped txn to avoid long-running transactions.
+	orgID := rCtx.DBTxn.OrganizationID()
+	if err := rCtx.DBTxn.Rollback(); err != nil {
+		return WatchListResponse[T]{}, err
+	}
+
+	listenOpts := data.ListenForNotifyOptions{UpdatesToTable: table, OrgID: orgID}
+	listener, err := data.ListenForNotify(rCtx.Request.Context(), rCtx.DataDB, listenOpts)
+	if err != nil {
+		return WatchListResponse[T]{}, fmt.Errorf("listen for notify: %w", err)
+	}
+	defer listener.Release()
+
+	for {
+		result, err := watchListInTxn(rCtx, orgID, table, list)
+		if err != nil {
+			return result, err
+		}
+
+		// The query returned results that are new to the client
+		if result.MaxUpdateIndex > lastUpdateIndex {
+			return result, nil
+		}
+
+		err = listener.WaitForNotification(rCtx.Request.Context())
+		switch {
+		case errors.Is(err, context.DeadlineExceeded):
+			return result, internal.ErrNotModified
+		case err != nil:
+			return result, fmt.Errorf("waiting for notify: %w", err)
+		}
+	}
+}
+
+func watchListInTxn[T any](
+	rCtx RequestContext,
+	orgID uid.ID,
+	table data.WatchTable,
+	list func(rCtx RequestContext) ([]T, error),
+) (WatchListResponse[T], error) {
+	tx, err := rCtx.DataDB.Begin(rCtx.Request.Context(), &sql.TxOptions{
+		ReadOnly:  true,
+		Isolation: sql.LevelRepeatableRead,
+	})
+	if err != nil {
+		return WatchListResponse[T]{}, err
+	}
+	defer logError(tx.Rollback, "failed to rollback transaction")
+
+	rCtx.DBTxn = tx.WithOrgID(orgID)
+	return listWithMaxUpdateIndex(rCtx, table, list)
+}
+
+func listWithMaxUpdateIndex[T any](
+	rCtx RequestContext,
+	table data.WatchTable,
+	list func(rCtx RequestContext) ([]T, error),
+) (WatchListResponse[T], error) {
+	items, err := list(rCtx)
+	if err != nil {
+		return WatchListResponse[T]{}, err
+	}
+	maxUpdateIndex, err := data.MaxUpdateIndex(rCtx.DBTxn, table)
+	return WatchListResponse[T]{Items: items, MaxUpdateIndex: maxUpdateIndex}, err
+}
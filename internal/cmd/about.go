@@ -2,11 +2,14 @@ package cmd
 
 import (
 	"math"
+	"strings"
 	"time"
 
 	sprite "github.com/pdevine/go-asciisprite"
 	tm "github.com/pdevine/go-asciisprite/termbox"
 	"github.com/spf13/cobra"
+
+	"github.com/infrahq/infra/internal"
 )
 
 type triangle struct {
@@ -16,17 +19,63 @@ type triangle struct {
 	Color rune
 }
 
-func newAboutCmd() *cobra.Command {
-	return &cobra.Command{
+func newAboutCmd(cli *CLI) *cobra.Command {
+	var output outputOptions
+	cmd := &cobra.Command{
 		Use:     "about",
 		Short:   "Display information about Infra",
 		Args:    NoArgs,
 		GroupID: groupOther,
 		Hidden:  false,
 		RunE: func(cmd *cobra.Command, _ []string) error {
+			if !output.isTable() {
+				info := aboutInfo{
+					Version:      strings.TrimPrefix(internal.FullVersion(), "v"),
+					URL:          aboutURL,
+					Contributors: aboutContributors,
+				}
+				type row struct {
+					Version      string `header:"VERSION"`
+					URL          string `header:"URL"`
+					Contributors string `header:"CONTRIBUTORS"`
+				}
+				rows := []row{{
+					Version:      info.Version,
+					URL:          info.URL,
+					Contributors: strings.Join(info.Contributors, ", "),
+				}}
+				return writeOutput(cli, output, info, rows)
+			}
 			return about()
 		},
 	}
+
+	addFormatFlag(cmd.Flags(), &output)
+	return cmd
+}
+
+const aboutURL = "https://github.com/infrahq/infra"
+
+var aboutContributors = []string{
+	"BruceMacD",
+	"dnephin",
+	"FSHA",
+	"hoyyeva",
+	"j-sneh",
+	"jmorganca",
+	"kimskimchi",
+	"mchiang0610",
+	"mxyng",
+	"pdevine",
+	"ssoroka",
+	"technovangelist",
+}
+
+// aboutInfo is the output of 'infra about' in the json and yaml formats.
+type aboutInfo struct {
+	Version      string   `json:"version"`
+	URL          string   `json:"url"`
+	Contributors []string `json:"contributors"`
 }
 
 func newTriangle(a, b, c *point3D, ch rune) *triangle {
@@ -143,26 +192,9 @@ func newScrollText(width, height int) *scrollText {
 	}
 	f := sprite.NewJRSMFont()
 
-	text := []string{
-		"Contributors",
-		"",
-		"BruceMacD",
-		"dnephin",
-		"FSHA",
-		"hoyyeva",
-		"j-sneh",
-		"jmorganca",
-		"kimskimchi",
-		"mchiang0610",
-		"mxyng",
-		"pdevine",
-		"ssoroka",
-		"technovangelist",
-		"",
-		"",
-		"Join us!",
-		"Visit github.com/infrahq/infra",
-	}
+	text := []string{"Contributors", ""}
+	text = append(text, aboutContributors...)
+	text = append(text, "", "", "Join us!", "Visit "+strings.TrimPrefix(aboutURL, "https://"))
 
 	buf := ""
 	for _, t := range text {
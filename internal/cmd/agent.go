@@ -2,12 +2,15 @@ package cmd
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io/fs"
+	"net/http"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"text/tabwriter"
 	"time"
 
 	"github.com/cenkalti/backoff/v4"
@@ -15,13 +18,16 @@ import (
 	"github.com/spf13/cobra"
 	"golang.org/x/sync/errgroup"
 
+	"github.com/infrahq/infra/api"
 	"github.com/infrahq/infra/internal"
+	"github.com/infrahq/infra/internal/format"
 	"github.com/infrahq/infra/internal/logging"
 	"github.com/infrahq/infra/internal/repeat"
+	"github.com/infrahq/infra/uid"
 )
 
-func newAgentCmd() *cobra.Command {
-	return &cobra.Command{
+func newAgentCmd(cli *CLI) *cobra.Command {
+	cmd := &cobra.Command{
 		Use:    "agent",
 		Short:  "Start the Infra agent",
 		Long:   "Start the Infra agent that runs to sync Infra state",
@@ -36,27 +42,10 @@ func newAgentCmd() *cobra.Command {
 
 			group, ctx := errgroup.WithContext(context.Background())
 			group.Go(func() error {
-				backOff := &backoff.ExponentialBackOff{
-					InitialInterval:     time.Minute,
-					MaxInterval:         2 * time.Minute,
-					RandomizationFactor: 0.05,
-					Multiplier:          1.5,
-					// MaxElapsedTime is the duration the infra agent should
-					// continue to run without a successful sync. After this time
-					// the agent will exit.
-					MaxElapsedTime: 5 * time.Minute,
-				}
-				waiter := repeat.NewWaiter(backOff)
-				for {
-					if err := syncKubeConfig(ctx); err != nil {
-						logging.L.Warn().Err(err).Msg("failed to sync kubeconfig")
-					} else {
-						waiter.Reset()
-					}
-					if err := waiter.Wait(ctx); err != nil {
-						return err
-					}
-				}
+				return runAgentSync(ctx, filepath.Join(infraDir, "agent.json"))
+			})
+			group.Go(func() error {
+				return serveUnlockCache(ctx, filepath.Join(infraDir, "agent.sock"), unlockedKeys)
 			})
 			// add the next agent task here
 
@@ -66,6 +55,342 @@ func newAgentCmd() *cobra.Command {
 			return err
 		},
 	}
+
+	cmd.AddCommand(newAgentStatusCmd(cli))
+	return cmd
+}
+
+// agentBlockingRequestTimeout is the longest time the agent waits for a
+// response to a blocking request. The server responds to blocking requests
+// before this timeout, so this only applies when the connection to the server
+// was lost, for example when a laptop goes to sleep.
+const agentBlockingRequestTimeout = 7 * time.Minute
+
+// agentFullSyncInterval is the time between full syncs of the kubeconfig and
+// ssh config. The blocking request only returns when grants change, so changes
+// to destinations, like a new endpoint or certificate, are applied by the next
+// full sync.
+const agentFullSyncInterval = 5 * time.Minute
+
+// agentPollInterval is the time between syncs when the server does not support
+// blocking requests for the grants of a user.
+const agentPollInterval = time.Minute
+
+// runAgentSync keeps the kubeconfig and ssh config of the current user in sync
+// with their grants until ctx is cancelled. Blocking requests are used to
+// receive changes to grants as soon as they happen. Errors are retried with
+// backoff, and the agent never gives up, so that it recovers when the server
+// becomes reachable again, or when the user logs in again.
+func runAgentSync(ctx context.Context, statusFilename string) error {
+	backOff := &backoff.ExponentialBackOff{
+		InitialInterval:     2 * time.Second,
+		MaxInterval:         2 * time.Minute,
+		RandomizationFactor: 0.2,
+		Multiplier:          2,
+		// retry forever
+		MaxElapsedTime: 0,
+	}
+	waiter := repeat.NewWaiter(backOff)
+
+	state := &agentSyncState{
+		status: agentStatus{
+			PID:       os.Getpid(),
+			Version:   internal.FullVersion(),
+			StartedAt: time.Now().UTC(),
+		},
+	}
+	for {
+		err := state.sync(ctx)
+		state.status.record(err)
+		if err != nil {
+			logging.L.Warn().Err(err).Msg("failed to sync grants")
+		} else {
+			waiter.Reset()
+		}
+		if err := writeAgentStatus(statusFilename, state.status); err != nil {
+			logging.L.Warn().Err(err).Msg("failed to write agent status")
+		}
+
+		// wait for a short duration between requests to allow batches of
+		// updates to apply before querying again, and to prevent unnecessary
+		// load when part of the operation is failing.
+		if err := waiter.Wait(ctx); err != nil {
+			return err
+		}
+	}
+}
+
+type agentSyncState struct {
+	host            string
+	userID          uid.ID
+	lastUpdateIndex int64
+	lastFullSync    time.Time
+	// polling is true when the server does not support blocking requests for
+	// the grants of a user. Every sync is a full sync.
+	polling bool
+	status  agentStatus
+}
+
+// sync blocks until the grants of the current user change, then updates the
+// kubeconfig and ssh config. sync returns nil without any changes when the
+// blocking request times out. Once agentFullSyncInterval has passed since the
+// last update, sync updates the config without waiting for grants to change.
+// When the server does not support blocking requests, sync waits until
+// agentPollInterval has passed since the last update instead.
+func (s *agentSyncState) sync(ctx context.Context) error {
+	hostConfig, err := currentHostConfig()
+	if err != nil {
+		return err
+	}
+	if hostConfig.Host != s.host || hostConfig.UserID != s.userID {
+		// the user logged in to a different server, or as a different user
+		s.host = hostConfig.Host
+		s.userID = hostConfig.UserID
+		s.lastUpdateIndex = 1
+		s.lastFullSync = time.Time{}
+		s.polling = false
+		s.status.Server = hostConfig.Host
+		s.status.LastUpdateIndex = 0
+	}
+	if hostConfig.UserID == 0 {
+		return fmt.Errorf("no active identity, run 'infra login' to start a new session")
+	}
+
+	opts, err := defaultClientOpts()
+	if err != nil {
+		return err
+	}
+	client, err := NewAPIClient(opts)
+	if err != nil {
+		return err
+	}
+	client.Name = "agent"
+	// blocking requests are limited by the context timeout
+	client.HTTP.Timeout = 0
+
+	ctx, cancel := context.WithTimeout(ctx, agentBlockingRequestTimeout)
+	defer cancel()
+
+	req := api.ListGrantsRequest{User: hostConfig.UserID, ShowInherited: true}
+	// The first request after login returns immediately, because
+	// lastUpdateIndex is 1, so it is already a full sync.
+	fullSync := s.lastUpdateIndex > 1 && time.Since(s.lastFullSync) >= agentFullSyncInterval
+	if s.polling {
+		select {
+		case <-time.After(time.Until(s.lastFullSync.Add(agentPollInterval))):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		fullSync = true
+	}
+
+	var grants []api.Grant
+	var updateIndex int64
+	if !fullSync {
+		req.BlockingRequest = api.BlockingRequest{LastUpdateIndex: s.lastUpdateIndex}
+		resp, err := client.ListGrants(ctx, req)
+		switch {
+		case api.ErrorStatusCode(err) == http.StatusNotModified:
+			// not modified is expected when there are no changes
+			logging.L.Debug().Int64("updateIndex", s.lastUpdateIndex).Msg("no updated grants from server")
+			s.status.LastCheck = time.Now().UTC()
+			return nil
+		case isBlockingRequestUnsupported(err):
+			logging.L.Info().Msg("server does not support blocking requests for grants, polling for changes")
+			s.polling = true
+			fullSync = true
+		case err != nil:
+			return fmt.Errorf("list grants: %w", err)
+		default:
+			grants, updateIndex = resp.Items, resp.LastUpdateIndex.Index
+		}
+	}
+	if fullSync {
+		// a full sync is not a blocking request, so the grants are paginated
+		req.BlockingRequest = api.BlockingRequest{}
+		grants, err = listAll(ctx, client.ListGrants, req)
+		if err != nil {
+			return fmt.Errorf("list grants: %w", err)
+		}
+	}
+	logging.L.Info().
+		Int64("updateIndex", updateIndex).
+		Int("grants", len(grants)).
+		Msg("received grants from server")
+
+	user, err := client.GetUser(ctx, hostConfig.UserID)
+	if err != nil {
+		return fmt.Errorf("get user: %w", err)
+	}
+
+	destinations, err := listAll(ctx, client.ListDestinations, api.ListDestinationsRequest{})
+	if err != nil {
+		return fmt.Errorf("list destinations: %w", err)
+	}
+
+	var kubernetes, ssh []api.Destination
+	for _, destination := range destinations {
+		switch destination.Kind {
+		case "kubernetes":
+			kubernetes = append(kubernetes, destination)
+		case "ssh":
+			ssh = append(ssh, destination)
+		}
+	}
+
+	if err := writeKubeconfig(user, kubernetes, grants); err != nil {
+		return fmt.Errorf("update kubeconfig: %w", err)
+	}
+	if err := syncSSHConfig(ctx, client, hostConfig, user, ssh, grants); err != nil {
+		return fmt.Errorf("update ssh config: %w", err)
+	}
+
+	// Only update lastUpdateIndex once the entire operation was a success. The
+	// response to a full sync is not a blocking request, so it does not
+	// include an update index.
+	if !fullSync {
+		s.lastUpdateIndex = updateIndex
+		s.status.LastUpdateIndex = s.lastUpdateIndex
+	}
+	s.lastFullSync = time.Now()
+	s.status.LastCheck = time.Now().UTC()
+	s.status.LastSync = s.status.LastCheck
+	return nil
+}
+
+// isBlockingRequestUnsupported returns true when err is the response from a
+// server that does not support blocking requests for the grants of a user.
+// Older servers reject lastUpdateIndex with "requires a supported filter".
+func isBlockingRequestUnsupported(err error) bool {
+	var apiErr api.Error
+	if !errors.As(err, &apiErr) || apiErr.Code != http.StatusBadRequest {
+		return false
+	}
+	for _, field := range apiErr.FieldErrors {
+		if field.FieldName == "lastUpdateIndex" {
+			return true
+		}
+	}
+	return false
+}
+
+// agentStatus is written to ~/.infra/agent.json by the agent, and read by
+// the 'infra agent status' command.
+type agentStatus struct {
+	PID       int       `json:"pid"`
+	Version   string    `json:"version"`
+	StartedAt time.Time `json:"startedAt"`
+	Server    string    `json:"server,omitempty"`
+	// LastCheck is the last time the agent received a response from the
+	// server, even if the response contained no changes.
+	LastCheck time.Time `json:"lastCheck"`
+	// LastSync is the last time the agent updated the local config files.
+	LastSync        time.Time `json:"lastSync"`
+	LastUpdateIndex int64     `json:"lastUpdateIndex"`
+	LastError       string    `json:"lastError,omitempty"`
+	LastErrorAt     time.Time `json:"lastErrorAt"`
+}
+
+func (s *agentStatus) record(err error) {
+	if err == nil {
+		s.LastError = ""
+		return
+	}
+	s.LastError = err.Error()
+	s.LastErrorAt = time.Now().UTC()
+}
+
+func writeAgentStatus(filename string, status agentStatus) error {
+	content, err := json.MarshalIndent(status, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// write to a temporary file and rename, so that 'infra agent status' never
+	// reads a partial file.
+	tmp, err := os.CreateTemp(filepath.Dir(filename), "agent-status-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(content); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filename)
+}
+
+func readAgentStatus(filename string) (*agentStatus, error) {
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	status := &agentStatus{}
+	if err := json.Unmarshal(content, status); err != nil {
+		return nil, fmt.Errorf("decode agent status: %w", err)
+	}
+	return status, nil
+}
+
+func newAgentStatusCmd(cli *CLI) *cobra.Command {
+	return &cobra.Command{
+		Use:   "status",
+		Short: "Display the status of the Infra agent",
+		Args:  NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			infraDir, err := infraHomeDir()
+			if err != nil {
+				return err
+			}
+			return agentStatusOutput(cli, filepath.Join(infraDir, "agent.json"))
+		},
+	}
+}
+
+func agentStatusOutput(cli *CLI, statusFilename string) error {
+	running, err := configAgentRunning()
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(cli.Stdout, 0, 0, 1, ' ', tabwriter.AlignRight)
+	defer w.Flush()
+
+	fmt.Fprintln(w)
+	if !running {
+		fmt.Fprintf(w, "Agent:\t not running, run 'infra login' to start the agent\n")
+		fmt.Fprintln(w)
+		return nil
+	}
+
+	status, err := readAgentStatus(statusFilename)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		pid, _ := readStoredAgentProcessID()
+		fmt.Fprintf(w, "Agent:\t running (pid %d)\n", pid)
+		fmt.Fprintln(w)
+		return nil
+	case err != nil:
+		return err
+	}
+
+	fmt.Fprintf(w, "Agent:\t running (pid %d)\n", status.PID)
+	fmt.Fprintf(w, "Version:\t %s\n", status.Version)
+	if status.Server != "" {
+		fmt.Fprintf(w, "Server:\t %s\n", status.Server)
+	}
+	fmt.Fprintf(w, "Started:\t %s\n", format.HumanTime(status.StartedAt, "unknown"))
+	fmt.Fprintf(w, "Last check:\t %s\n", format.HumanTime(status.LastCheck, "never"))
+	fmt.Fprintf(w, "Last sync:\t %s\n", format.HumanTime(status.LastSync, "never"))
+	if status.LastError != "" {
+		fmt.Fprintf(w, "Last error:\t %s (%s)\n", status.LastError, format.HumanTimeLower(status.LastErrorAt, "unknown"))
+	}
+	fmt.Fprintln(w)
+	return nil
 }
 
 // configAgentRunning checks if the agent process stored in config is still running
@@ -107,6 +432,11 @@ func execAgent() error {
 
 	// use the current infra executable to start the agent
 	cmd := exec.Command(infraExe, "agent")
+	// run the agent from the home directory, so that a .infra.yaml in the
+	// working directory of the login command does not select the context.
+	if homeDir, err := os.UserHomeDir(); err == nil {
+		cmd.Dir = homeDir
+	}
 	if err := cmd.Start(); err != nil {
 		return err
 	}
@@ -158,18 +488,3 @@ func writeAgentConfig(pid int) error {
 
 	return nil
 }
-
-// syncKubeConfig updates the local kubernetes configuration from Infra grants
-func syncKubeConfig(_ context.Context) error {
-	opts, err := defaultClientOpts()
-	if err != nil {
-		return err
-	}
-	client, err := NewAPIClient(opts)
-	if err != nil {
-		return err
-	}
-	client.Name = "agent"
-
-	return updateKubeconfig(client)
-}
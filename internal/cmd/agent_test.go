@@ -1,10 +1,24 @@
 package cmd
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"gotest.tools/v3/assert"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/infrahq/infra/api"
+	"github.com/infrahq/infra/uid"
 )
 
 func TestProcessRunning(t *testing.T) {
@@ -31,3 +45,218 @@ func TestProcessRunning(t *testing.T) {
 		assert.Equal(t, result, tc.expected)
 	}
 }
+
+func TestAgentSyncState_Sync(t *testing.T) {
+	home := setupEnv(t)
+
+	userID := uid.New()
+	grantsCh := make(chan api.ListResponse[api.Grant], 1)
+	var fullSyncGrants []api.Grant
+	var blockingUnsupported bool
+	handler := func(resp http.ResponseWriter, req *http.Request) {
+		var body any
+		switch req.URL.Path {
+		case "/api/grants":
+			query := req.URL.Query()
+			assert.Equal(t, query.Get("user"), userID.String())
+			assert.Equal(t, query.Get("showInherited"), "true")
+
+			if query.Get("lastUpdateIndex") == "0" {
+				// full syncs are paginated, with two grants on each page
+				page, _ := strconv.Atoi(query.Get("page"))
+				start, end := (page-1)*2, page*2
+				if end > len(fullSyncGrants) {
+					end = len(fullSyncGrants)
+				}
+				body = api.ListResponse[api.Grant]{
+					Items:              fullSyncGrants[start:end],
+					PaginationResponse: api.PaginationResponse{Page: page, TotalPages: (len(fullSyncGrants) + 1) / 2},
+				}
+				break
+			}
+			if blockingUnsupported {
+				resp.WriteHeader(http.StatusBadRequest)
+				body = api.Error{
+					Code: http.StatusBadRequest,
+					FieldErrors: []api.FieldError{
+						{FieldName: "lastUpdateIndex", Errors: []string{"requires a supported filter"}},
+					},
+				}
+				break
+			}
+			select {
+			case grants := <-grantsCh:
+				resp.Header().Set("Last-Update-Index", strconv.FormatInt(grants.LastUpdateIndex.Index, 10))
+				body = grants
+			default:
+				resp.WriteHeader(http.StatusNotModified)
+				return
+			}
+		case "/api/users/" + userID.String():
+			body = api.User{ID: userID, Name: "testuser@example.com"}
+		case "/api/destinations":
+			body = api.ListResponse[api.Destination]{
+				Items: []api.Destination{
+					{
+						Name:       "prod",
+						Kind:       "kubernetes",
+						Connected:  true,
+						Connection: api.DestinationConnection{URL: "prod.example.com", CA: destinationCA},
+					},
+					{
+						Name:       "staging",
+						Kind:       "kubernetes",
+						Connected:  true,
+						Connection: api.DestinationConnection{URL: "staging.example.com", CA: destinationCA},
+					},
+					{
+						Name:       "dev",
+						Kind:       "kubernetes",
+						Connected:  true,
+						Connection: api.DestinationConnection{URL: "dev.example.com", CA: destinationCA},
+					},
+				},
+				Count: 3,
+			}
+		default:
+			resp.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		assert.Check(t, json.NewEncoder(resp).Encode(body))
+	}
+	srv := httptest.NewTLSServer(http.HandlerFunc(handler))
+	t.Cleanup(srv.Close)
+
+	cfg := newTestClientConfig(srv, api.User{ID: userID})
+	assert.NilError(t, writeConfig(&cfg))
+
+	kubeContexts := func(t *testing.T) []string {
+		t.Helper()
+		kubeconfig, err := clientcmd.LoadFromFile(filepath.Join(home, "kube.config"))
+		assert.NilError(t, err)
+		var names []string
+		for name := range kubeconfig.Contexts {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return names
+	}
+
+	ctx := context.Background()
+	state := &agentSyncState{}
+
+	grantsCh <- api.ListResponse[api.Grant]{
+		Items:           []api.Grant{{User: userID, Privilege: "view", Resource: "prod"}},
+		LastUpdateIndex: api.LastUpdateIndex{Index: 10},
+	}
+	assert.NilError(t, state.sync(ctx))
+	assert.Equal(t, state.lastUpdateIndex, int64(10))
+	assert.DeepEqual(t, kubeContexts(t), []string{"infra:prod"})
+
+	t.Run("not modified", func(t *testing.T) {
+		assert.NilError(t, state.sync(ctx))
+		assert.Equal(t, state.lastUpdateIndex, int64(10))
+		assert.Assert(t, !state.status.LastCheck.IsZero())
+	})
+
+	t.Run("grants changed", func(t *testing.T) {
+		grantsCh <- api.ListResponse[api.Grant]{
+			Items: []api.Grant{
+				{User: userID, Privilege: "view", Resource: "prod"},
+				{User: userID, Privilege: "view", Resource: "staging"},
+			},
+			LastUpdateIndex: api.LastUpdateIndex{Index: 12},
+		}
+		assert.NilError(t, state.sync(ctx))
+		assert.Equal(t, state.lastUpdateIndex, int64(12))
+		assert.DeepEqual(t, kubeContexts(t), []string{"infra:prod", "infra:staging"})
+	})
+
+	t.Run("full sync", func(t *testing.T) {
+		fullSyncGrants = []api.Grant{
+			{User: userID, Privilege: "view", Resource: "prod"},
+			{User: userID, Privilege: "view", Resource: "staging"},
+			{User: userID, Privilege: "view", Resource: "dev"},
+		}
+		assert.NilError(t, state.sync(ctx))
+		// not long enough since the last sync, the blocking request is not modified
+		assert.DeepEqual(t, kubeContexts(t), []string{"infra:prod", "infra:staging"})
+
+		state.lastFullSync = time.Now().Add(-agentFullSyncInterval)
+		assert.NilError(t, state.sync(ctx))
+		assert.Equal(t, state.lastUpdateIndex, int64(12))
+		assert.DeepEqual(t, kubeContexts(t), []string{"infra:dev", "infra:prod", "infra:staging"})
+	})
+
+	t.Run("server without blocking requests", func(t *testing.T) {
+		blockingUnsupported = true
+		defer func() { blockingUnsupported = false }()
+
+		state.lastFullSync = time.Now()
+		fullSyncGrants = []api.Grant{
+			{User: userID, Privilege: "view", Resource: "prod"},
+			{User: userID, Privilege: "view", Resource: "dev"},
+		}
+		assert.NilError(t, state.sync(ctx))
+		assert.Assert(t, state.polling)
+		assert.DeepEqual(t, kubeContexts(t), []string{"infra:dev", "infra:prod"})
+
+		// polls once agentPollInterval has passed
+		fullSyncGrants = []api.Grant{{User: userID, Privilege: "view", Resource: "prod"}}
+		state.lastFullSync = time.Now().Add(-agentPollInterval)
+		assert.NilError(t, state.sync(ctx))
+		assert.DeepEqual(t, kubeContexts(t), []string{"infra:prod"})
+	})
+
+	t.Run("logged out", func(t *testing.T) {
+		cfg.Hosts[0].UserID = 0
+		assert.NilError(t, writeConfig(&cfg))
+
+		err := state.sync(ctx)
+		assert.ErrorContains(t, err, "no active identity")
+		assert.Equal(t, state.lastUpdateIndex, int64(1))
+	})
+}
+
+func TestAgentStatusCmd(t *testing.T) {
+	home := setupEnv(t)
+
+	t.Run("not running", func(t *testing.T) {
+		ctx, bufs := PatchCLI(context.Background())
+		err := Run(ctx, "agent", "status")
+		assert.NilError(t, err)
+		assert.Assert(t, strings.Contains(bufs.Stdout.String(), "not running"), bufs.Stdout.String())
+	})
+
+	t.Run("running", func(t *testing.T) {
+		assert.NilError(t, writeAgentConfig(os.Getpid()))
+
+		status := agentStatus{
+			PID:             os.Getpid(),
+			Version:         "0.1.2",
+			StartedAt:       time.Now().Add(-3 * time.Hour),
+			Server:          "infra.example.com",
+			LastCheck:       time.Now().Add(-5 * time.Minute),
+			LastUpdateIndex: 10,
+			LastError:       "connection refused",
+			LastErrorAt:     time.Now().Add(-2 * time.Hour),
+		}
+		assert.NilError(t, writeAgentStatus(filepath.Join(home, ".infra", "agent.json"), status))
+
+		ctx, bufs := PatchCLI(context.Background())
+		err := Run(ctx, "agent", "status")
+		assert.NilError(t, err)
+
+		expected := fmt.Sprintf(`
+      Agent: running (pid %d)
+    Version: 0.1.2
+     Server: infra.example.com
+    Started: 3 hours ago
+ Last check: 5 minutes ago
+  Last sync: never
+ Last error: connection refused (2 hours ago)
+
+`, os.Getpid())
+		assert.Equal(t, bufs.Stdout.String(), expected)
+	})
+}
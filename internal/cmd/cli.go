@@ -25,6 +25,9 @@ type CLI struct {
 type RootOptions struct {
 	LogLevel            string
 	SkipAPIVersionCheck bool
+	// NonInteractive and Format are the preferences passed to plugins.
+	NonInteractive bool
+	Format         outputFormat
 }
 
 // Output a string to CLI.Stdout. Output is like fmt.Printf except that it always
@@ -113,30 +113,19 @@ func NewAPIClient(opts *APIClientOpts) (*api.Client, error) {
 }
 
 func logoutCurrent() {
-	config, err := readConfig()
+	config, err := readConfigWithoutAccessKeys()
 	if err != nil {
 		logging.Debugf("logging out: read config: %s", err)
 		return
 	}
 
-	var host *ClientHostConfig
-	for i := range config.Hosts {
-		if config.Hosts[i].Current {
-			host = &config.Hosts[i]
-			break
-		}
-	}
-
-	if host == nil {
+	i, err := config.selectedHostIndex()
+	if err != nil {
 		return
 	}
+	clearSession(&config.Hosts[i])
 
-	host.AccessKey = ""
-	host.Expires = api.Time{}
-	host.UserID = 0
-	host.Name = ""
-
-	if err := writeConfig(config); err != nil {
+	if err := writeConfigWithoutAccessKeys(config); err != nil {
 		logging.Debugf("logging out: write config: %s", err)
 		return
 	}
@@ -188,10 +177,20 @@ func NewRootCmd(cli *CLI) *cobra.Command {
 			}
 			return nil
 		},
+		Args:              cobra.ArbitraryArgs,
+		ValidArgsFunction: completePlugins,
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) > 0 {
+				return runPlugin(cli, cmd, args)
+			}
 			return cmd.Help()
 		},
 	}
+	// Flags after the name of a plugin are passed to the plugin
+	rootCmd.Flags().SetInterspersed(false)
+	addNonInteractiveFlag(rootCmd.Flags(), &cli.RootOptions.NonInteractive)
+	cli.RootOptions.Format = outputTable
+	rootCmd.Flags().Var(&cli.RootOptions.Format, "format", "Output format of plugins [table|json|yaml|csv]")
 
 	rootCmd.AddGroup(
 		&cobra.Group{
@@ -213,6 +212,9 @@ func NewRootCmd(cli *CLI) *cobra.Command {
 		newLogoutCmd(cli),
 		newListCmd(cli),
 		newUseCmd(cli),
+		newContextCmd(cli),
+		newSSHCmd(cli),
+		newSCPCmd(cli),
 
 		// Management commands
 		newDestinationsCmd(cli),
@@ -224,14 +226,17 @@ func NewRootCmd(cli *CLI) *cobra.Command {
 
 		// Other commands
 		newInfoCmd(cli),
+		newCredentialsCmd(cli),
+		newKubeconfigCmd(cli),
+		newPluginsCmd(cli),
+		newDoctorCmd(cli),
 		newVersionCmd(cli),
 
 		// Hidden commands
 		newTokensCmd(cli),
 		newServerCmd(),
 		newConnectorCmd(),
-		newAgentCmd(),
-		newSSHCmd(cli),
+		newAgentCmd(cli),
 		newSSHDCmd(cli))
 
 	rootCmd.PersistentFlags().Bool("help", false, "Display help")
@@ -239,7 +244,7 @@ func NewRootCmd(cli *CLI) *cobra.Command {
 	rootCmd.PersistentFlags().BoolVar(&cli.RootOptions.SkipAPIVersionCheck, "skip-version-check", false, "Skip checking if the CLI is ahead of the server version")
 
 	rootCmd.SetHelpCommandGroupID(groupOther)
-	rootCmd.AddCommand(newAboutCmd())
+	rootCmd.AddCommand(newAboutCmd(cli))
 	rootCmd.AddCommand(newCompletionsCmd())
 	rootCmd.SetUsageTemplate(usageTemplate())
 	return rootCmd
@@ -250,10 +255,6 @@ func addNonInteractiveFlag(flags *pflag.FlagSet, bind *bool) {
 	flags.BoolVar(bind, "non-interactive", isNonInteractiveMode, "Disable all prompts for input")
 }
 
-func addFormatFlag(flags *pflag.FlagSet, bind *string) {
-	flags.StringVar(bind, "format", "", "Output format [json|yaml]")
-}
-
 func usageTemplate() string {
 	return `Usage:{{if .Runnable}}
   {{.UseLine}}{{end}}{{if .HasAvailableSubCommands}}
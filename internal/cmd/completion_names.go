@@ -0,0 +1,377 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/infrahq/infra/api"
+	"github.com/infrahq/infra/internal/logging"
+	"github.com/infrahq/infra/internal/server/models"
+)
+
+const (
+	// completionCacheTTL is how long cached completions are used before they
+	// are requested from the server again.
+	completionCacheTTL = time.Minute
+	// completionCacheMaxAge is how long cached completions are used when the
+	// server can not be reached.
+	completionCacheMaxAge = 15 * time.Minute
+	// completionTimeout limits the time spent waiting for the server, so that
+	// tab completion does not hang when the server is slow.
+	completionTimeout = 5 * time.Second
+)
+
+// completionSource is a kind of name that can be completed, and the function
+// used to list the names from the server.
+type completionSource struct {
+	key   string
+	fetch func(ctx context.Context, client *api.Client) ([]string, error)
+}
+
+var (
+	completeUsers = completionSource{key: "users", fetch: func(ctx context.Context, client *api.Client) ([]string, error) {
+		users, err := listAll(ctx, client.ListUsers, api.ListUsersRequest{})
+		return names(users, func(u api.User) string { return u.Name }), err
+	}}
+	completeGroups = completionSource{key: "groups", fetch: func(ctx context.Context, client *api.Client) ([]string, error) {
+		groups, err := listAll(ctx, client.ListGroups, api.ListGroupsRequest{})
+		return names(groups, func(g api.Group) string { return g.Name }), err
+	}}
+	completeProviders = completionSource{key: "providers", fetch: func(ctx context.Context, client *api.Client) ([]string, error) {
+		providers, err := listAll(ctx, client.ListProviders, api.ListProvidersRequest{})
+		return names(providers, func(p api.Provider) string { return p.Name }), err
+	}}
+	completeDestinations = completionSource{key: "destinations", fetch: func(ctx context.Context, client *api.Client) ([]string, error) {
+		destinations, err := listAll(ctx, client.ListDestinations, api.ListDestinationsRequest{})
+		return names(destinations, func(d api.Destination) string { return d.Name }), err
+	}}
+	completeSSHDestinations = completionSource{key: "ssh-destinations", fetch: func(ctx context.Context, client *api.Client) ([]string, error) {
+		destinations, err := listAll(ctx, client.ListDestinations, api.ListDestinationsRequest{Kind: "ssh"})
+		return names(destinations, func(d api.Destination) string { return d.Name }), err
+	}}
+	// completeResources completes destinations, and the resources of each
+	// destination in the format DESTINATION.RESOURCE.
+	completeResources = completionSource{key: "resources", fetch: func(ctx context.Context, client *api.Client) ([]string, error) {
+		destinations, err := listAll(ctx, client.ListDestinations, api.ListDestinationsRequest{})
+		if err != nil {
+			return nil, err
+		}
+		result := []string{"infra"}
+		for _, d := range destinations {
+			result = append(result, d.Name)
+			for _, resource := range d.Resources {
+				result = append(result, d.Name+"."+resource)
+			}
+		}
+		return result, nil
+	}}
+	// completeUseResources completes the destinations and namespaces that the
+	// user has been granted access to.
+	completeUseResources = completionSource{key: "use", fetch: func(ctx context.Context, client *api.Client) ([]string, error) {
+		user, err := client.GetUserSelf(ctx)
+		if err != nil {
+			return nil, err
+		}
+		grants, err := listAll(ctx, client.ListGrants, api.ListGrantsRequest{User: user.ID, ShowInherited: true})
+		if err != nil {
+			return nil, err
+		}
+		destinations, err := listAll(ctx, client.ListDestinations, api.ListDestinationsRequest{})
+		if err != nil {
+			return nil, err
+		}
+
+		result := make([]string, 0, len(grants))
+		for _, g := range grants {
+			for _, d := range destinations {
+				if strings.HasPrefix(g.Resource, d.Name) {
+					result = append(result, g.Resource)
+					break
+				}
+			}
+		}
+		return result, nil
+	}}
+	completeKeys = completionSource{key: "keys", fetch: func(ctx context.Context, client *api.Client) ([]string, error) {
+		user, err := client.GetUserSelf(ctx)
+		if err != nil {
+			return nil, err
+		}
+		keys, err := listAll(ctx, client.ListAccessKeys, api.ListAccessKeysRequest{UserID: user.ID})
+		return names(keys, func(k api.AccessKey) string { return k.Name }), err
+	}}
+)
+
+// completeRoles returns the source of roles for the destination. When
+// destination is empty the roles of all destinations are completed.
+func completeRoles(destination string) completionSource {
+	destination, _, _ = strings.Cut(destination, ".")
+	if destination == "infra" {
+		return completionSource{key: "roles/infra", fetch: func(context.Context, *api.Client) ([]string, error) {
+			return []string{models.InfraAdminRole, models.InfraViewRole, models.InfraConnectorRole}, nil
+		}}
+	}
+
+	return completionSource{key: "roles/" + destination, fetch: func(ctx context.Context, client *api.Client) ([]string, error) {
+		destinations, err := listAll(ctx, client.ListDestinations, api.ListDestinationsRequest{Name: destination})
+		if err != nil {
+			return nil, err
+		}
+		result := []string{models.BasePermissionConnect}
+		for _, d := range destinations {
+			result = append(result, d.Roles...)
+		}
+		return result, nil
+	}}
+}
+
+func names[T any](items []T, name func(T) string) []string {
+	result := make([]string, 0, len(items))
+	for _, item := range items {
+		result = append(result, name(item))
+	}
+	return result
+}
+
+// completeArgs returns a completion function that completes each positional
+// argument from the sources at the same position.
+func completeArgs(positions ...[]completionSource) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) >= len(positions) {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		return completeFrom(positions[len(args)]...)
+	}
+}
+
+// completeFlag registers a completion function for the flag. It panics when
+// the command has no flag with name, because that is a programming error.
+func completeFlag(cmd *cobra.Command, name string, fn func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective)) {
+	if err := cmd.RegisterFlagCompletionFunc(name, fn); err != nil {
+		panic(err)
+	}
+}
+
+// completeNamesFrom returns a completion function that completes the names
+// from sources, regardless of the arguments.
+func completeNamesFrom(sources ...completionSource) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+		return completeFrom(sources...)
+	}
+}
+
+// completeGrantArgs completes the USER|GROUP and DESTINATION arguments of the
+// grants commands. Groups are completed when the --group flag is set.
+func completeGrantArgs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	switch len(args) {
+	case 0:
+		if isGroup, _ := cmd.Flags().GetBool("group"); isGroup {
+			return completeFrom(completeGroups)
+		}
+		return completeFrom(completeUsers)
+	case 1:
+		return completeFrom(completeResources)
+	default:
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// completeGrantRole completes the --role flag with the roles of the
+// destination argument, or the --destination flag.
+func completeGrantRole(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	var destination string
+	switch {
+	case len(args) > 1:
+		destination = args[1]
+	case cmd.Flags().Lookup("destination") != nil:
+		destination, _ = cmd.Flags().GetString("destination")
+	}
+	return completeFrom(completeRoles(destination))
+}
+
+// completeFrom returns the sorted names from all the sources.
+func completeFrom(sources ...completionSource) ([]string, cobra.ShellCompDirective) {
+	var result []string
+	for _, source := range sources {
+		values, err := completionValues(source)
+		if err != nil {
+			logging.Debugf("completion for %v: %v", source.key, err)
+			return nil, cobra.ShellCompDirectiveError
+		}
+		result = append(result, values...)
+	}
+
+	sort.Strings(result)
+	return dedupe(result), cobra.ShellCompDirectiveNoFileComp
+}
+
+func dedupe(sorted []string) []string {
+	result := sorted[:0]
+	for i, value := range sorted {
+		if i > 0 && value == sorted[i-1] {
+			continue
+		}
+		result = append(result, value)
+	}
+	return result
+}
+
+type completionCache struct {
+	Entries map[string]completionCacheEntry `json:"entries"`
+}
+
+type completionCacheEntry struct {
+	Updated time.Time `json:"updated"`
+	Values  []string  `json:"values"`
+}
+
+// completionValues returns the names from source. Names are read from the
+// completion cache when they were cached less than completionCacheTTL ago.
+// Otherwise they are requested from the server, and when the server can not
+// be reached, names cached less than completionCacheMaxAge ago are used.
+//
+// The access key is only read when the names are requested from the server,
+// and never prompts for a passphrase, so that tab completion does not wait
+// for a locked credential store.
+func completionValues(source completionSource) ([]string, error) {
+	config, err := readConfigWithoutAccessKeys()
+	if err != nil {
+		return nil, err
+	}
+	i, err := config.selectedHostIndex()
+	if err != nil {
+		return nil, err
+	}
+	hostConfig := &config.Hosts[i]
+	filename, err := completionCacheFilename(hostConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	cache := readCompletionCache(filename)
+	entry, cached := cache.Entries[source.key]
+	age := time.Since(entry.Updated)
+	if cached && age < completionCacheTTL {
+		return entry.Values, nil
+	}
+
+	values, err := fetchCompletionValues(config, hostConfig, source)
+	if err != nil {
+		if cached && age < completionCacheMaxAge {
+			logging.Debugf("using cached completions for %v: %v", source.key, err)
+			return entry.Values, nil
+		}
+		return nil, err
+	}
+
+	cache.Entries[source.key] = completionCacheEntry{Updated: time.Now(), Values: values}
+	if err := writeCompletionCache(filename, cache); err != nil {
+		logging.Debugf("write completion cache: %v", err)
+	}
+	return values, nil
+}
+
+func fetchCompletionValues(config *ClientConfig, hostConfig *ClientHostConfig, source completionSource) ([]string, error) {
+	if err := loadAccessKeyWithoutPrompt(config, hostConfig); err != nil {
+		return nil, err
+	}
+	opts, err := apiClientFromHostConfig(hostConfig)
+	if err != nil {
+		return nil, err
+	}
+	client, err := NewAPIClient(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), completionTimeout)
+	defer cancel()
+	return source.fetch(ctx, client)
+}
+
+// completionCacheFilename returns the filename of the completion cache for the
+// host and user.
+func completionCacheFilename(hostConfig *ClientHostConfig) (string, error) {
+	infraDir, err := infraHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return tokenCacheFilename(filepath.Join(infraDir, "completion"), hostConfig), nil
+}
+
+// readCompletionCache reads the completion cache. A cache that is missing or
+// can not be read is treated as empty, because the names can be requested
+// from the server again.
+func readCompletionCache(filename string) *completionCache {
+	cache := &completionCache{}
+	content, err := os.ReadFile(filename)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		logging.Debugf("read completion cache: %v", err)
+	default:
+		if err := json.Unmarshal(content, cache); err != nil {
+			logging.Debugf("decode completion cache: %v", err)
+		}
+	}
+	if cache.Entries == nil {
+		cache.Entries = make(map[string]completionCacheEntry)
+	}
+	return cache
+}
+
+// writeCompletionCache writes the cache to a temporary file that replaces the
+// cache, so that concurrent completions never read a partial file.
+func writeCompletionCache(filename string, cache *completionCache) error {
+	content, err := json.Marshal(cache)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(filename), 0o700); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(filename), filepath.Base(filename)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(content); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), filename); err != nil {
+		return fmt.Errorf("replace completion cache: %w", err)
+	}
+	return nil
+}
+
+// clearCompletionCache removes the completion cache for the host and user. It
+// is called on logout so that names are not completed after the session has
+// ended.
+func clearCompletionCache(hostConfig *ClientHostConfig) {
+	if hostConfig.UserID == 0 {
+		return
+	}
+	filename, err := completionCacheFilename(hostConfig)
+	if err != nil {
+		logging.Debugf("completion cache directory: %s", err)
+		return
+	}
+	if err := os.Remove(filename); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		logging.Debugf("remove completion cache: %s", err)
+	}
+}
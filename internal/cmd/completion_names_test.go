@@ -0,0 +1,198 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+
+	"github.com/infrahq/infra/api"
+	"github.com/infrahq/infra/uid"
+)
+
+func TestCompletion(t *testing.T) {
+	setupEnv(t)
+
+	complete := func(t *testing.T, args ...string) ([]string, string) {
+		t.Helper()
+		var buf bytes.Buffer
+		cmd := NewRootCmd(newCLI(context.Background()))
+		cmd.SetOut(&buf)
+		cmd.SetErr(io.Discard)
+		cmd.SetArgs(append([]string{"__complete"}, args...))
+		assert.NilError(t, cmd.Execute())
+
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		return lines[:len(lines)-1], lines[len(lines)-1]
+	}
+
+	userID := uid.New()
+	requests := make(map[string]int)
+	handler := func(resp http.ResponseWriter, req *http.Request) {
+		requests[req.URL.Path]++
+
+		var body any
+		switch req.URL.Path {
+		case "/api/users/self":
+			body = api.User{ID: userID, Name: "janedoe@example.com"}
+		case "/api/users":
+			body = api.ListResponse[api.User]{
+				Items: []api.User{{Name: "janedoe@example.com"}, {Name: "johndoe@example.com"}},
+				Count: 2,
+			}
+		case "/api/groups":
+			body = api.ListResponse[api.Group]{Items: []api.Group{{Name: "admins"}}, Count: 1}
+		case "/api/destinations":
+			destinations := []api.Destination{
+				{Name: "prod", Kind: "kubernetes", Resources: []string{"default", "web"}, Roles: []string{"view", "edit"}},
+				{Name: "bastion", Kind: "ssh"},
+			}
+			if name := req.URL.Query().Get("name"); name != "" {
+				assert.Equal(t, name, "prod")
+				destinations = destinations[:1]
+			}
+			body = api.ListResponse[api.Destination]{Items: destinations, Count: len(destinations)}
+		case "/api/providers":
+			body = api.ListResponse[api.Provider]{Items: []api.Provider{{Name: "okta"}, {Name: "infra"}}, Count: 2}
+		case "/api/access-keys":
+			assert.Equal(t, req.URL.Query().Get("userID"), userID.String())
+			body = api.ListResponse[api.AccessKey]{Items: []api.AccessKey{{Name: "ci"}}, Count: 1}
+		default:
+			resp.WriteHeader(http.StatusNotFound)
+			return
+		}
+		assert.Check(t, json.NewEncoder(resp).Encode(body))
+	}
+	srv := httptest.NewTLSServer(http.HandlerFunc(handler))
+	t.Cleanup(srv.Close)
+
+	cfg := newTestClientConfig(srv, api.User{ID: userID})
+	assert.NilError(t, writeConfig(&cfg))
+
+	t.Run("not logged in", func(t *testing.T) {
+		t.Setenv("HOME", t.TempDir())
+		t.Setenv("USERPROFILE", os.Getenv("HOME"))
+
+		values, directive := complete(t, "users", "remove", "")
+		assert.Equal(t, len(values), 0)
+		assert.Equal(t, directive, ":1")
+	})
+
+	t.Run("users and groups", func(t *testing.T) {
+		values, directive := complete(t, "groups", "adduser", "")
+		assert.DeepEqual(t, values, []string{"janedoe@example.com", "johndoe@example.com"})
+		assert.Equal(t, directive, ":4")
+
+		values, _ = complete(t, "groups", "adduser", "janedoe@example.com", "")
+		assert.DeepEqual(t, values, []string{"admins"})
+
+		values, _ = complete(t, "grants", "add", "--group", "")
+		assert.DeepEqual(t, values, []string{"admins"})
+	})
+
+	t.Run("resources and roles", func(t *testing.T) {
+		values, _ := complete(t, "grants", "add", "janedoe@example.com", "")
+		assert.DeepEqual(t, values, []string{"bastion", "infra", "prod", "prod.default", "prod.web"})
+
+		values, _ = complete(t, "grants", "add", "janedoe@example.com", "prod.web", "--role", "")
+		assert.DeepEqual(t, values, []string{"connect", "edit", "view"})
+
+		values, _ = complete(t, "grants", "add", "janedoe@example.com", "infra", "--role", "")
+		assert.DeepEqual(t, values, []string{"admin", "connector", "view"})
+	})
+
+	t.Run("providers, keys, and destinations", func(t *testing.T) {
+		values, _ := complete(t, "providers", "remove", "")
+		assert.DeepEqual(t, values, []string{"infra", "okta"})
+
+		values, _ = complete(t, "keys", "remove", "")
+		assert.DeepEqual(t, values, []string{"ci"})
+
+		values, _ = complete(t, "destinations", "remove", "")
+		assert.DeepEqual(t, values, []string{"bastion", "prod"})
+
+		values, _ = complete(t, "destinations", "remove", "prod", "")
+		assert.Equal(t, len(values), 0)
+	})
+
+	t.Run("cached", func(t *testing.T) {
+		before := requests["/api/users"]
+		values, _ := complete(t, "users", "edit", "")
+		assert.DeepEqual(t, values, []string{"janedoe@example.com", "johndoe@example.com"})
+		assert.Equal(t, requests["/api/users"], before)
+	})
+
+	t.Run("locked credential store", func(t *testing.T) {
+		t.Setenv("INFRA_CREDENTIAL_PASSPHRASE", "correct horse battery staple")
+		t.Cleanup(unlockedKeys.clear)
+		locked := cfg
+		locked.CredentialStore = credentialStoreEncryptedFile
+		assert.NilError(t, writeConfig(&locked))
+		t.Cleanup(func() {
+			assert.NilError(t, writeConfig(&cfg))
+		})
+		unlockedKeys.clear()
+		os.Unsetenv("INFRA_CREDENTIAL_PASSPHRASE")
+
+		before := requests["/api/providers"]
+		values, _ := complete(t, "providers", "remove", "")
+		assert.DeepEqual(t, values, []string{"infra", "okta"})
+
+		// expire the cache, so that completion needs the access key
+		filename, err := completionCacheFilename(&cfg.Hosts[0])
+		assert.NilError(t, err)
+		cache := readCompletionCache(filename)
+		entry := cache.Entries["providers"]
+		entry.Updated = time.Now().Add(-2 * completionCacheMaxAge)
+		cache.Entries["providers"] = entry
+		assert.NilError(t, writeCompletionCache(filename, cache))
+
+		values, directive := complete(t, "providers", "remove", "")
+		assert.Equal(t, len(values), 0)
+		assert.Equal(t, directive, ":1")
+		assert.Equal(t, requests["/api/providers"], before)
+	})
+
+	t.Run("server not reachable", func(t *testing.T) {
+		srv.Close()
+
+		values, _ := complete(t, "users", "edit", "")
+		assert.DeepEqual(t, values, []string{"janedoe@example.com", "johndoe@example.com"})
+
+		// expire the cache, so that completion requests the server
+		filename, err := completionCacheFilename(&cfg.Hosts[0])
+		assert.NilError(t, err)
+		cache := readCompletionCache(filename)
+		entry := cache.Entries["users"]
+		entry.Updated = time.Now().Add(-2 * completionCacheTTL)
+		cache.Entries["users"] = entry
+		assert.NilError(t, writeCompletionCache(filename, cache))
+
+		values, _ = complete(t, "users", "edit", "")
+		assert.DeepEqual(t, values, []string{"janedoe@example.com", "johndoe@example.com"})
+
+		entry.Updated = time.Now().Add(-2 * completionCacheMaxAge)
+		cache.Entries["users"] = entry
+		assert.NilError(t, writeCompletionCache(filename, cache))
+
+		values, directive := complete(t, "users", "edit", "")
+		assert.Equal(t, len(values), 0)
+		assert.Equal(t, directive, ":1")
+	})
+
+	t.Run("cleared on logout", func(t *testing.T) {
+		filename, err := completionCacheFilename(&cfg.Hosts[0])
+		assert.NilError(t, err)
+		clearCompletionCache(&cfg.Hosts[0])
+		_, err = os.Stat(filename)
+		assert.Assert(t, os.IsNotExist(err))
+	})
+}
@@ -2,12 +2,17 @@ package cmd
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"time"
 
+	"sigs.k8s.io/yaml"
+
 	"github.com/infrahq/infra/api"
+	"github.com/infrahq/infra/internal/logging"
 	"github.com/infrahq/infra/uid"
 )
 
@@ -23,6 +28,12 @@ type ClientConfigVersion struct {
 type ClientConfig struct {
 	ClientConfigVersion `json:",inline"`
 	Hosts               []ClientHostConfig `json:"hosts"`
+	// CredentialStore is the name of the store used for the access keys of
+	// the hosts. See newCredentialStore.
+	CredentialStore string `json:"credential-store,omitempty"`
+	// KubeconfigFile is the file that Infra contexts are written to, instead
+	// of the default kubeconfig. See 'infra kubeconfig use-file'.
+	KubeconfigFile string `json:"kubeconfig-file,omitempty"`
 }
 
 type ClientHostConfig struct {
@@ -34,6 +45,9 @@ type ClientHostConfig struct {
 	ProviderID    uid.ID   `json:"provider-id,omitempty"`
 	Expires       api.Time `json:"expires"`
 	Current       bool     `json:"current"`
+	// Context is the name used to select this host with 'infra context use',
+	// INFRA_CONTEXT, or a .infra.yaml file. When empty the name is Host.
+	Context string `json:"context,omitempty"`
 	// TrustedCertificate is the PEM encoded TLS certificate used by the server
 	// that was verified and trusted by the user as part of login.
 	TrustedCertificate string `json:"trusted-certificate"`
@@ -48,6 +62,14 @@ func (c *ClientHostConfig) isExpired() bool {
 	return time.Now().After(time.Time(c.Expires))
 }
 
+// contextName returns the name of the context for this host.
+func (c *ClientHostConfig) contextName() string {
+	if c.Context != "" {
+		return c.Context
+	}
+	return c.Host
+}
+
 func infraHomeDir() (string, error) {
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
@@ -73,6 +95,21 @@ func initInfraHomeDir() (string, error) {
 }
 
 func readConfig() (*ClientConfig, error) {
+	config, err := readConfigWithoutAccessKeys()
+	if err != nil {
+		return nil, err
+	}
+	if err := loadAccessKeys(config); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// readConfigWithoutAccessKeys reads the config file without reading access
+// keys from the credential store, so that commands that do not use the access
+// keys work when the store is locked or broken. Use
+// writeConfigWithoutAccessKeys to write the config.
+func readConfigWithoutAccessKeys() (*ClientConfig, error) {
 	infraDir, err := initInfraHomeDir()
 	if err != nil {
 		return nil, err
@@ -110,22 +147,71 @@ func readConfig() (*ClientConfig, error) {
 }
 
 func writeConfig(config *ClientConfig) error {
+	return writeConfigFile(config, true)
+}
+
+// writeConfigWithoutAccessKeys writes a config read by
+// readConfigWithoutAccessKeys. Access keys set in config are saved to the
+// credential store. The access keys of hosts that are logged out, or removed,
+// are erased from the store when it can be updated. The access keys of other
+// hosts are left in the store.
+func writeConfigWithoutAccessKeys(config *ClientConfig) error {
+	return writeConfigFile(config, false)
+}
+
+func writeConfigFile(config *ClientConfig, keysLoaded bool) error {
 	infraDir, err := initInfraHomeDir()
 	if err != nil {
 		return err
 	}
 
+	unlock, err := lockConfigFile(infraDir)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return writeConfigFileLocked(infraDir, config, keysLoaded)
+}
+
+// writeConfigFileLocked writes the config file. The caller must hold the lock
+// acquired by lockConfigFile.
+func writeConfigFileLocked(infraDir string, config *ClientConfig, keysLoaded bool) error {
+	filename := filepath.Join(infraDir, "config")
+	config, err := storeAccessKeys(config, filename, keysLoaded)
+	if err != nil {
+		return err
+	}
+
 	contents, err := json.Marshal(config)
 	if err != nil {
 		return err
 	}
 
-	return os.WriteFile(filepath.Join(infraDir, "config"), contents, 0o600)
+	return os.WriteFile(filename, contents, 0o600)
+}
+
+// lockConfigFile blocks until it acquires the lock shared by every process
+// that writes the config file. The returned func releases the lock.
+func lockConfigFile(infraDir string) (func(), error) {
+	lock, err := os.OpenFile(filepath.Join(infraDir, "config.lock"), os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	if err := lockFile(lock); err != nil {
+		_ = lock.Close()
+		return nil, fmt.Errorf("lock config file: %w", err)
+	}
+	return func() {
+		if err := unlockFile(lock); err != nil {
+			logging.Debugf("unlock config file: %v", err)
+		}
+		_ = lock.Close()
+	}, nil
 }
 
 // Save (create or update) the current hostconfig
 func saveHostConfig(hostConfig ClientHostConfig) error {
-	config, err := readConfig()
+	config, err := readConfigWithoutAccessKeys()
 	if err != nil {
 		return err
 	}
@@ -144,24 +230,120 @@ func saveHostConfig(hostConfig ClientHostConfig) error {
 		config.Hosts = append(config.Hosts, hostConfig)
 	}
 
-	if err := writeConfig(config); err != nil {
+	if err := writeConfigWithoutAccessKeys(config); err != nil {
 		return err
 	}
 
 	return nil
 }
 
+// currentHostConfig returns the host config of the selected context. See
+// ClientConfig.selectedHostIndex for how a context is selected.
 func currentHostConfig() (*ClientHostConfig, error) {
 	cfg, err := readConfig()
 	if err != nil {
 		return nil, err
 	}
 
-	for i, c := range cfg.Hosts {
-		if c.Current {
-			return &cfg.Hosts[i], nil
+	i, err := cfg.selectedHostIndex()
+	if err != nil {
+		return nil, err
+	}
+	return &cfg.Hosts[i], nil
+}
+
+// findContext returns the index of the host with the context name, or -1 if
+// there is no context with that name.
+func (c *ClientConfig) findContext(name string) int {
+	for i := range c.Hosts {
+		if c.Hosts[i].contextName() == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// selectedHostIndex returns the index of the host for the selected context.
+// The context is selected by the first of:
+//
+//  1. the INFRA_CONTEXT environment variable
+//  2. a .infra.yaml file in the working directory, or one of its parents
+//  3. the current context, set by 'infra login' or 'infra context use'
+func (c *ClientConfig) selectedHostIndex() (int, error) {
+	name, source, err := selectedContext()
+	if err != nil {
+		return -1, err
+	}
+	if name != "" {
+		if i := c.findContext(name); i >= 0 {
+			return i, nil
+		}
+		return -1, fmt.Errorf("%w: context %q selected by %v does not exist",
+			ErrConfigNotFound, name, source)
+	}
+
+	for i := range c.Hosts {
+		if c.Hosts[i].Current {
+			return i, nil
 		}
 	}
+	return -1, ErrConfigNotFound
+}
 
-	return nil, ErrConfigNotFound
+// projectConfigFilename is the name of the file that selects a context for
+// all commands run in the directory that contains the file, and all of its
+// subdirectories.
+const projectConfigFilename = ".infra.yaml"
+
+type projectConfig struct {
+	Context string `json:"context"`
+}
+
+// selectedContext returns the name of the context selected by the
+// INFRA_CONTEXT environment variable, or by a .infra.yaml file, and a
+// description of where the selection came from. selectedContext returns an
+// empty name when the current context should be used.
+func selectedContext() (name string, source string, err error) {
+	if name := os.Getenv("INFRA_CONTEXT"); name != "" {
+		return name, "INFRA_CONTEXT", nil
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		// the working directory may have been removed, use the current context
+		logging.Debugf("working directory: %v", err)
+		return "", "", nil
+	}
+
+	filename, cfg, err := findProjectConfig(wd)
+	if err != nil || cfg == nil {
+		return "", "", err
+	}
+	return cfg.Context, filename, nil
+}
+
+// findProjectConfig searches dir, and each of its parents, for a .infra.yaml
+// file. It returns the first file found, or a nil projectConfig if no file
+// exists.
+func findProjectConfig(dir string) (string, *projectConfig, error) {
+	for {
+		filename := filepath.Join(dir, projectConfigFilename)
+		content, err := os.ReadFile(filename)
+		switch {
+		case err == nil:
+			cfg := &projectConfig{}
+			if err := yaml.Unmarshal(content, cfg); err != nil {
+				return "", nil, fmt.Errorf("read %v: %w", filename, err)
+			}
+			return filename, cfg, nil
+		case !errors.Is(err, fs.ErrNotExist):
+			return "", nil, err
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil, nil
+		}
+		dir = parent
+	}
 }
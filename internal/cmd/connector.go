@@ -3,6 +3,7 @@ package cmd
 import (
 	"os"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 
@@ -68,6 +69,11 @@ func newConnectorCmd() *cobra.Command {
 	cmd.Flags().String("ca-cert", "", "Path to CA certificate file")
 	cmd.Flags().String("ca-key", "", "Path to CA key file")
 	cmd.Flags().Bool("server-skip-tls-verify", false, "Skip verifying server TLS certificates")
+	cmd.Flags().Bool("leader-election-enabled", false, "Use a Kubernetes Lease to elect a leader when running multiple replicas")
+	cmd.Flags().Bool("policy-enabled", false, "Deny proxied requests that match a proxy policy")
+	cmd.Flags().Bool("activity-enabled", false, "Record every proxied request and send the records to the server")
+	cmd.Flags().String("activity-fallback-file", "", "File where activity records are written when they can not be sent to the server")
+	cmd.Flags().Bool("ssh-recording-enabled", false, "Record interactive ssh sessions and upload the recordings to the server")
 
 	return cmd
 }
@@ -84,11 +90,31 @@ func defaultConnectorOptions() connector.Options {
 		},
 		Kind: "kubernetes",
 		SSH: connector.SSHOptions{
-			Group:          "infra-users",
-			SSHDConfigPath: "/etc/ssh/sshd_config",
+			Group:             "infra-users",
+			SSHDConfigPath:    "/etc/ssh/sshd_config",
+			ManagedConfigPath: "/etc/ssh/sshd_config.d/infra.conf",
+			Recording: connector.SessionRecordingOptions{
+				SocketPath:     "/run/infra/session-recording.sock",
+				SudoersPath:    "/etc/sudoers.d/infra-session-recording",
+				SpoolDir:       "/var/lib/infra/recordings",
+				UploadInterval: time.Minute,
+			},
 		},
 		Server: connector.ServerOptions{
 			URL: types.URL{Scheme: "https", Host: "api.infrahq.com"},
 		},
+		LeaderElection: connector.LeaderElectionOptions{
+			LeaseName:     "infra-connector",
+			LeaseDuration: 15 * time.Second,
+			RenewDeadline: 10 * time.Second,
+			RetryPeriod:   2 * time.Second,
+		},
+		Policy: connector.PolicyOptions{
+			SyncInterval: 30 * time.Second,
+		},
+		Activity: connector.ActivityOptions{
+			BatchSize:     100,
+			FlushInterval: 10 * time.Second,
+		},
 	}
 }
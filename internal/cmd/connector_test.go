@@ -412,6 +412,29 @@ addr:
 ssh:
   group: the-group
   sshdConfigPath: /opt/sshd
+  managedConfigPath: /opt/sshd.d/infra.conf
+  recording:
+    enabled: true
+    socketPath: /run/recording.sock
+    sudoersPath: /opt/sudoers.d/infra
+    spoolDir: /var/spool/recordings
+    uploadInterval: 2m
+
+leaderElection:
+  enabled: true
+  leaseName: the-lease
+  identity: the-pod
+  leaseDuration: 30s
+  renewDeadline: 20s
+  retryPeriod: 5s
+policy:
+  enabled: true
+  syncInterval: 1m
+activity:
+  enabled: true
+  batchSize: 50
+  flushInterval: 5s
+  fallbackFile: /var/log/infra/activity.log
 `,
 			expected: func() connector.Options {
 				return connector.Options{
@@ -431,8 +454,34 @@ ssh:
 					CACert: "/path/to/cert",
 					CAKey:  "/path/to/key",
 					SSH: connector.SSHOptions{
-						Group:          "the-group",
-						SSHDConfigPath: "/opt/sshd",
+						Group:             "the-group",
+						SSHDConfigPath:    "/opt/sshd",
+						ManagedConfigPath: "/opt/sshd.d/infra.conf",
+						Recording: connector.SessionRecordingOptions{
+							Enabled:        true,
+							SocketPath:     "/run/recording.sock",
+							SudoersPath:    "/opt/sudoers.d/infra",
+							SpoolDir:       "/var/spool/recordings",
+							UploadInterval: 2 * time.Minute,
+						},
+					},
+					LeaderElection: connector.LeaderElectionOptions{
+						Enabled:       true,
+						LeaseName:     "the-lease",
+						Identity:      "the-pod",
+						LeaseDuration: 30 * time.Second,
+						RenewDeadline: 20 * time.Second,
+						RetryPeriod:   5 * time.Second,
+					},
+					Policy: connector.PolicyOptions{
+						Enabled:      true,
+						SyncInterval: time.Minute,
+					},
+					Activity: connector.ActivityOptions{
+						Enabled:       true,
+						BatchSize:     50,
+						FlushInterval: 5 * time.Second,
+						FallbackFile:  "/var/log/infra/activity.log",
 					},
 				}
 			},
@@ -0,0 +1,229 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/infrahq/infra/internal/logging"
+)
+
+func newContextCmd(cli *CLI) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "context",
+		Short: "Manage the servers you are logged in to",
+		Long: `Manage the servers you are logged in to.
+
+Each server you log in to is saved as a context. The context used by a
+command is selected by the first of:
+
+  1. the INFRA_CONTEXT environment variable
+  2. a .infra.yaml file in the working directory, or one of its parents,
+     with a 'context' field
+  3. the current context, set by 'infra login' or 'infra context use'`,
+		Aliases: []string{"contexts"},
+		GroupID: groupCore,
+	}
+
+	cmd.AddCommand(newContextListCmd(cli))
+	cmd.AddCommand(newContextUseCmd(cli))
+	cmd.AddCommand(newContextRenameCmd(cli))
+	cmd.AddCommand(newContextDeleteCmd(cli))
+
+	return cmd
+}
+
+// contextInfo is a context in the json and yaml output of 'infra context list'.
+type contextInfo struct {
+	Name     string `json:"name"`
+	Server   string `json:"server"`
+	User     string `json:"user,omitempty"`
+	LoggedIn bool   `json:"loggedIn"`
+	Current  bool   `json:"current"`
+}
+
+func newContextListCmd(cli *CLI) *cobra.Command {
+	var output outputOptions
+	cmd := &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List contexts",
+		Args:    NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			config, err := readConfigWithoutAccessKeys()
+			if err != nil {
+				return err
+			}
+
+			if output.isTable() && len(config.Hosts) == 0 {
+				cli.Output("No contexts found, use 'infra login' to add a context")
+				return nil
+			}
+
+			selected, err := config.selectedHostIndex()
+			if err != nil {
+				logging.Debugf("selected context: %v", err)
+			}
+
+			type row struct {
+				Selected string `header:"Current"`
+				Name     string `header:"Name"`
+				Server   string `header:"Server"`
+				User     string `header:"User"`
+			}
+
+			contexts := make([]contextInfo, 0, len(config.Hosts))
+			rows := make([]row, 0, len(config.Hosts))
+			for i, host := range config.Hosts {
+				// the access keys are not read, because listing contexts
+				// does not need the credential store
+				info := contextInfo{
+					Name:     host.contextName(),
+					Server:   host.Host,
+					User:     host.Name,
+					LoggedIn: host.UserID != 0 && host.Name != "" && !host.isExpired(),
+					Current:  i == selected,
+				}
+				contexts = append(contexts, info)
+
+				r := row{Name: info.Name, Server: info.Server, User: info.User}
+				if info.Current {
+					r.Selected = "*"
+				}
+				if !info.LoggedIn {
+					r.User = "(logged out)"
+				}
+				rows = append(rows, r)
+			}
+			if err := writeOutput(cli, output, contexts, rows); err != nil {
+				return err
+			}
+
+			if name, source, _ := selectedContext(); name != "" && output.isTable() {
+				fmt.Fprintf(cli.Stderr, "\nContext %q is selected by %v\n", name, source)
+			}
+			return nil
+		},
+	}
+
+	addFormatFlag(cmd.Flags(), &output)
+	return cmd
+}
+
+func newContextUseCmd(cli *CLI) *cobra.Command {
+	return &cobra.Command{
+		Use:   "use CONTEXT",
+		Short: "Set the current context",
+		Example: `# Use the staging context
+$ infra context use staging`,
+		Args: ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			config, err := readConfig()
+			if err != nil {
+				return err
+			}
+
+			idx := config.findContext(args[0])
+			if idx < 0 {
+				return Error{Message: fmt.Sprintf("context %q not found, use 'infra context list' to see the available contexts", args[0])}
+			}
+			for i := range config.Hosts {
+				config.Hosts[i].Current = i == idx
+			}
+			if err := writeConfig(config); err != nil {
+				return err
+			}
+			fmt.Fprintf(cli.Stderr, "Switched to context %q.\n", args[0])
+
+			if name, source, _ := selectedContext(); name != "" && name != args[0] {
+				fmt.Fprintf(cli.Stderr, "Context %q is still selected by %v in this shell.\n", name, source)
+				return nil
+			}
+
+			// update the kubeconfig to use the destinations from the new context
+			if config.Hosts[idx].isLoggedIn() {
+				client, err := cli.apiClient()
+				if err != nil {
+					return err
+				}
+				return updateKubeconfig(client)
+			}
+			return nil
+		},
+	}
+}
+
+func newContextRenameCmd(cli *CLI) *cobra.Command {
+	return &cobra.Command{
+		Use:   "rename CONTEXT NEW_NAME",
+		Short: "Rename a context",
+		Example: `# Rename the context for infra.example.com
+$ infra context rename infra.example.com prod`,
+		Args: ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			oldName, newName := args[0], args[1]
+
+			config, err := readConfigWithoutAccessKeys()
+			if err != nil {
+				return err
+			}
+
+			idx := config.findContext(oldName)
+			if idx < 0 {
+				return Error{Message: fmt.Sprintf("context %q not found, use 'infra context list' to see the available contexts", oldName)}
+			}
+			if existing := config.findContext(newName); existing >= 0 && existing != idx {
+				return Error{Message: fmt.Sprintf("context %q already exists", newName)}
+			}
+
+			config.Hosts[idx].Context = newName
+			if err := writeConfigWithoutAccessKeys(config); err != nil {
+				return err
+			}
+
+			// the kubeconfig refers to the context by name
+			if err := renameKubeconfigInfraContext(oldName, newName); err != nil {
+				return err
+			}
+
+			fmt.Fprintf(cli.Stderr, "Context %q renamed to %q.\n", oldName, newName)
+			return nil
+		},
+	}
+}
+
+func newContextDeleteCmd(cli *CLI) *cobra.Command {
+	return &cobra.Command{
+		Use:     "delete CONTEXT",
+		Aliases: []string{"remove", "rm"},
+		Short:   "Log out and delete a context",
+		Args:    ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			config, err := readConfigForLogout()
+			if err != nil {
+				return err
+			}
+
+			idx := config.findContext(args[0])
+			if idx < 0 {
+				return Error{Message: fmt.Sprintf("context %q not found, use 'infra context list' to see the available contexts", args[0])}
+			}
+
+			wasCurrent := config.Hosts[idx].Current
+			logoutOfServer(&config.Hosts[idx])
+			config.Hosts = append(config.Hosts[:idx], config.Hosts[idx+1:]...)
+
+			if wasCurrent {
+				if err := clearKubeconfig(); err != nil {
+					return err
+				}
+			}
+			if err := writeConfigWithoutAccessKeys(config); err != nil {
+				return err
+			}
+
+			fmt.Fprintf(cli.Stderr, "Context %q deleted.\n", args[0])
+			return nil
+		},
+	}
+}
@@ -0,0 +1,161 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/fs"
+	"gotest.tools/v3/golden"
+)
+
+func TestContextCmd(t *testing.T) {
+	setupEnv(t)
+
+	setup := func(t *testing.T) {
+		t.Helper()
+		cfg := ClientConfig{
+			ClientConfigVersion: clientConfigVersion,
+			Hosts: []ClientHostConfig{
+				{Host: "staging.example.com:443", UserID: 1234, Name: "alice@example.com", Current: true},
+				{Host: "prod.example.com:443", UserID: 5678, Name: "alice@example.com", Context: "prod"},
+			},
+		}
+		assert.NilError(t, writeConfig(&cfg))
+	}
+
+	t.Run("list", func(t *testing.T) {
+		setup(t)
+		ctx, bufs := PatchCLI(context.Background())
+		err := Run(ctx, "context", "list")
+		assert.NilError(t, err)
+
+		golden.Assert(t, bufs.Stdout.String(), t.Name())
+	})
+
+	t.Run("list json", func(t *testing.T) {
+		setup(t)
+		ctx, bufs := PatchCLI(context.Background())
+		err := Run(ctx, "context", "list", "--format=json")
+		assert.NilError(t, err)
+
+		var contexts []contextInfo
+		assert.NilError(t, json.Unmarshal(bufs.Stdout.Bytes(), &contexts))
+		expected := []contextInfo{
+			{Name: "staging.example.com:443", Server: "staging.example.com:443", User: "alice@example.com", Current: true},
+			{Name: "prod", Server: "prod.example.com:443", User: "alice@example.com"},
+		}
+		assert.DeepEqual(t, contexts, expected)
+	})
+
+	t.Run("use", func(t *testing.T) {
+		setup(t)
+		ctx, bufs := PatchCLI(context.Background())
+		err := Run(ctx, "context", "use", "prod")
+		assert.NilError(t, err)
+		assert.Equal(t, bufs.Stderr.String(), "Switched to context \"prod\".\n")
+
+		host, err := currentHostConfig()
+		assert.NilError(t, err)
+		assert.Equal(t, host.Host, "prod.example.com:443")
+	})
+
+	t.Run("use unknown context", func(t *testing.T) {
+		setup(t)
+		ctx, _ := PatchCLI(context.Background())
+		err := Run(ctx, "context", "use", "dev")
+		assert.ErrorContains(t, err, `context "dev" not found`)
+	})
+
+	t.Run("rename", func(t *testing.T) {
+		setup(t)
+		ctx, _ := PatchCLI(context.Background())
+		err := Run(ctx, "context", "rename", "staging.example.com:443", "staging")
+		assert.NilError(t, err)
+
+		host, err := currentHostConfig()
+		assert.NilError(t, err)
+		assert.Equal(t, host.contextName(), "staging")
+
+		err = Run(ctx, "context", "rename", "staging", "prod")
+		assert.ErrorContains(t, err, `context "prod" already exists`)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		setup(t)
+		ctx, _ := PatchCLI(context.Background())
+		err := Run(ctx, "context", "delete", "prod")
+		assert.NilError(t, err)
+
+		cfg, err := readConfig()
+		assert.NilError(t, err)
+		assert.Equal(t, len(cfg.Hosts), 1)
+		assert.Equal(t, cfg.Hosts[0].Host, "staging.example.com:443")
+	})
+}
+
+func TestCurrentHostConfig_SelectedContext(t *testing.T) {
+	setupEnv(t)
+
+	cfg := ClientConfig{
+		ClientConfigVersion: clientConfigVersion,
+		Hosts: []ClientHostConfig{
+			{Host: "staging.example.com", Current: true},
+			{Host: "prod.example.com", Context: "prod"},
+		},
+	}
+	assert.NilError(t, writeConfig(&cfg))
+
+	dir := fs.NewDir(t, t.Name(),
+		fs.WithFile(projectConfigFilename, "context: prod\n"),
+		fs.WithDir("sub"))
+	chdir(t, dir.Path())
+
+	t.Run("current context", func(t *testing.T) {
+		chdir(t, t.TempDir())
+		host, err := currentHostConfig()
+		assert.NilError(t, err)
+		assert.Equal(t, host.Host, "staging.example.com")
+	})
+
+	t.Run("project config in parent directory", func(t *testing.T) {
+		chdir(t, dir.Join("sub"))
+		host, err := currentHostConfig()
+		assert.NilError(t, err)
+		assert.Equal(t, host.Host, "prod.example.com")
+	})
+
+	t.Run("INFRA_CONTEXT overrides project config", func(t *testing.T) {
+		t.Setenv("INFRA_CONTEXT", "staging.example.com")
+		host, err := currentHostConfig()
+		assert.NilError(t, err)
+		assert.Equal(t, host.Host, "staging.example.com")
+	})
+
+	t.Run("unknown context", func(t *testing.T) {
+		t.Setenv("INFRA_CONTEXT", "dev")
+		_, err := currentHostConfig()
+		assert.ErrorIs(t, err, ErrConfigNotFound)
+		assert.ErrorContains(t, err, `context "dev" selected by INFRA_CONTEXT does not exist`)
+	})
+
+	t.Run("invalid project config", func(t *testing.T) {
+		invalid := fs.NewDir(t, t.Name(), fs.WithFile(projectConfigFilename, "context: [\n"))
+		chdir(t, invalid.Path())
+		_, err := currentHostConfig()
+		assert.ErrorContains(t, err, filepath.Join(invalid.Path(), projectConfigFilename))
+	})
+}
+
+func chdir(t *testing.T, dir string) {
+	t.Helper()
+	orig, err := os.Getwd()
+	assert.NilError(t, err)
+	assert.NilError(t, os.Chdir(dir))
+	t.Cleanup(func() {
+		assert.NilError(t, os.Chdir(orig))
+	})
+}
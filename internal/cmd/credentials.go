@@ -0,0 +1,531 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/infrahq/infra/internal/logging"
+)
+
+// credentialStore stores the access keys of the hosts in the client config
+// outside of the config file. Access keys are identified by the host.
+type credentialStore interface {
+	// Get returns the access key for the host, or errCredentialNotFound.
+	Get(host string) (string, error)
+	Store(host, accessKey string) error
+	Erase(host string) error
+}
+
+var errCredentialNotFound = errors.New("credentials not found")
+
+const (
+	// credentialStorePlaintext stores access keys in the config file. This is
+	// the default.
+	credentialStorePlaintext = "plaintext"
+	// credentialStoreEncryptedFile stores access keys in a file encrypted by a
+	// key derived from a passphrase.
+	credentialStoreEncryptedFile = "encrypted-file"
+
+	// credentialHelperPrefix is the prefix of the name of the program used by
+	// any other credential store.
+	credentialHelperPrefix = "infra-credential-"
+)
+
+// newCredentialStore returns the credential store with name, or nil when
+// access keys are stored in the config file. Any name other than a built-in
+// store uses the credential helper program infra-credential-<name>.
+func newCredentialStore(name string) (credentialStore, error) {
+	switch name {
+	case "", credentialStorePlaintext:
+		return nil, nil
+	case credentialStoreEncryptedFile:
+		filename, err := encryptedCredentialsFilename()
+		if err != nil {
+			return nil, err
+		}
+		return &encryptedFileStore{filename: filename}, nil
+	default:
+		return &helperCredentialStore{program: credentialHelperPrefix + name}, nil
+	}
+}
+
+// loadAccessKeys reads the access key of each host from the credential store
+// of the config. Access keys found in the config file are moved to the
+// credential store, which migrates config files that were written before the
+// credential store was configured.
+func loadAccessKeys(config *ClientConfig) error {
+	store, err := newCredentialStore(config.CredentialStore)
+	if err != nil || store == nil {
+		return err
+	}
+
+	var migrate bool
+	for i := range config.Hosts {
+		host := &config.Hosts[i]
+		if host.AccessKey != "" {
+			migrate = true
+			continue
+		}
+
+		key, err := store.Get(host.Host)
+		switch {
+		case errors.Is(err, errCredentialNotFound):
+			continue
+		case err != nil:
+			return fmt.Errorf("read access key for %v from credential store %q: %w",
+				host.Host, config.CredentialStore, err)
+		}
+		host.AccessKey = key
+	}
+
+	if migrate {
+		return migrateAccessKeys()
+	}
+	return nil
+}
+
+// loadAccessKeyWithoutPrompt reads the access key of host from the credential
+// store of config. Unlike loadAccessKeys it never prompts for a passphrase, and
+// access keys found in the config file are used without moving them to the
+// store, so that it can be used by tab completion.
+func loadAccessKeyWithoutPrompt(config *ClientConfig, host *ClientHostConfig) error {
+	if host.AccessKey != "" {
+		return nil
+	}
+	store, err := newCredentialStore(config.CredentialStore)
+	if err != nil || store == nil {
+		return err
+	}
+	if fileStore, ok := store.(*encryptedFileStore); ok {
+		fileStore.noPrompt = true
+	}
+
+	key, err := store.Get(host.Host)
+	switch {
+	case errors.Is(err, errCredentialNotFound):
+		return nil
+	case err != nil:
+		return fmt.Errorf("read access key for %v from credential store %q: %w",
+			host.Host, config.CredentialStore, err)
+	}
+	host.AccessKey = key
+	return nil
+}
+
+// migrateAccessKeys moves the access keys found in the config file to the
+// credential store. The config file is read again while holding the lock on
+// the config file, so that a config written by another process after this
+// process read it is not overwritten, and the access keys are moved once.
+func migrateAccessKeys() error {
+	infraDir, err := initInfraHomeDir()
+	if err != nil {
+		return err
+	}
+
+	unlock, err := lockConfigFile(infraDir)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	config, err := readConfigWithoutAccessKeys()
+	if err != nil {
+		return err
+	}
+
+	var migrate bool
+	for _, host := range config.Hosts {
+		if host.AccessKey != "" {
+			migrate = true
+		}
+	}
+	if !migrate {
+		return nil
+	}
+
+	logging.Debugf("moving access keys to credential store %q", config.CredentialStore)
+	return writeConfigFileLocked(infraDir, config, false)
+}
+
+// storeAccessKeys saves the access keys in config to the credential store of
+// the config, and returns a copy of config without the access keys to write
+// to the config file. The access keys of hosts that are in the existing config
+// file at filename, but not in config, are erased.
+//
+// When keysLoaded is false the access keys of config were not read from the
+// store, so only the access keys set in config are saved. The access keys of
+// hosts that are logged out, or removed, are erased when the store can be
+// updated, so that a locked or broken store does not prevent logout.
+func storeAccessKeys(config *ClientConfig, filename string, keysLoaded bool) (*ClientConfig, error) {
+	store, err := newCredentialStore(config.CredentialStore)
+	if err != nil || store == nil {
+		return config, err
+	}
+
+	out := *config
+	out.Hosts = make([]ClientHostConfig, len(config.Hosts))
+	hosts := make(map[string]bool, len(config.Hosts))
+	for i, host := range config.Hosts {
+		hosts[host.Host] = true
+		out.Hosts[i] = host
+		out.Hosts[i].AccessKey = ""
+
+		if !keysLoaded {
+			switch {
+			case host.AccessKey != "":
+				if err := store.Store(host.Host, host.AccessKey); err != nil {
+					return nil, fmt.Errorf("save access key for %v to credential store %q: %w",
+						host.Host, config.CredentialStore, err)
+				}
+			case host.UserID == 0:
+				tryEraseAccessKey(store, host.Host)
+			}
+			continue
+		}
+
+		existing, err := store.Get(host.Host)
+		switch {
+		case errors.Is(err, errCredentialNotFound):
+		case err != nil:
+			return nil, fmt.Errorf("read access key for %v from credential store %q: %w",
+				host.Host, config.CredentialStore, err)
+		}
+
+		switch {
+		case host.AccessKey == existing:
+		case host.AccessKey == "":
+			if err := eraseAccessKey(store, host.Host); err != nil {
+				return nil, err
+			}
+		default:
+			if err := store.Store(host.Host, host.AccessKey); err != nil {
+				return nil, fmt.Errorf("save access key for %v to credential store %q: %w",
+					host.Host, config.CredentialStore, err)
+			}
+		}
+	}
+
+	previous, err := previousHosts(filename)
+	if err != nil {
+		return nil, err
+	}
+	for _, host := range previous {
+		switch {
+		case hosts[host]:
+		case !keysLoaded:
+			tryEraseAccessKey(store, host)
+		default:
+			if err := eraseAccessKey(store, host); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return &out, nil
+}
+
+// previousHosts returns the hosts in the config file at filename, without
+// reading their access keys.
+func previousHosts(filename string) ([]string, error) {
+	contents, err := os.ReadFile(filename)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return nil, nil
+	case err != nil:
+		return nil, err
+	}
+
+	var config ClientConfig
+	if err := json.Unmarshal(contents, &config); err != nil {
+		return nil, err
+	}
+	hosts := make([]string, 0, len(config.Hosts))
+	for _, host := range config.Hosts {
+		hosts = append(hosts, host.Host)
+	}
+	return hosts, nil
+}
+
+func eraseAccessKey(store credentialStore, host string) error {
+	if err := store.Erase(host); err != nil && !errors.Is(err, errCredentialNotFound) {
+		return fmt.Errorf("erase access key for %v: %w", host, err)
+	}
+	return nil
+}
+
+// tryEraseAccessKey erases the access key for host, and warns when the store
+// can not be updated.
+func tryEraseAccessKey(store credentialStore, host string) {
+	if err := eraseAccessKey(store, host); err != nil {
+		logging.Warnf("%v, use 'infra credentials reset' to remove all access keys from the credential store", err)
+	}
+}
+
+// helperCredentialStore stores access keys using an external program, with
+// the same protocol as docker credential helpers. The program is called with
+// get, store, or erase as its only argument. The host is written to stdin of
+// get and erase, and get prints a JSON helperCredential to stdout. The JSON
+// helperCredential to store is written to stdin of store.
+type helperCredentialStore struct {
+	program string
+}
+
+type helperCredential struct {
+	ServerURL string
+	Username  string
+	Secret    string
+}
+
+func (s *helperCredentialStore) Get(host string) (string, error) {
+	out, err := s.run("get", []byte(host))
+	if err != nil {
+		return "", err
+	}
+
+	var cred helperCredential
+	if err := json.Unmarshal(out, &cred); err != nil {
+		return "", fmt.Errorf("%v get: decode output: %w", s.program, err)
+	}
+	if cred.Secret == "" {
+		return "", errCredentialNotFound
+	}
+	return cred.Secret, nil
+}
+
+func (s *helperCredentialStore) Store(host, accessKey string) error {
+	input, err := json.Marshal(helperCredential{
+		ServerURL: host,
+		Username:  "access-key",
+		Secret:    accessKey,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = s.run("store", input)
+	return err
+}
+
+func (s *helperCredentialStore) Erase(host string) error {
+	_, err := s.run("erase", []byte(host))
+	return err
+}
+
+func (s *helperCredentialStore) run(action string, input []byte) ([]byte, error) {
+	cmd := exec.Command(s.program, action)
+	cmd.Stdin = bytes.NewReader(input)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		// helpers print errors to stdout
+		msg := strings.TrimSpace(string(out))
+		if msg == "" {
+			msg = strings.TrimSpace(stderr.String())
+		}
+		if strings.Contains(msg, errCredentialNotFound.Error()) {
+			return nil, errCredentialNotFound
+		}
+		return nil, fmt.Errorf("%v %v: %w: %v", s.program, action, err, msg)
+	}
+	return out, nil
+}
+
+func newCredentialsCmd(cli *CLI) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "credentials",
+		Short: "Manage where access keys are stored",
+		Long: `Manage where access keys are stored.
+
+By default access keys are stored in the config file. The credential store
+can be one of:
+
+  plaintext        store access keys in ~/.infra/config
+  encrypted-file   store access keys in a file encrypted with a passphrase
+  NAME             store access keys using the credential helper program
+                   infra-credential-NAME, which implements the protocol used
+                   by docker credential helpers
+
+The passphrase of the encrypted-file store is read from the
+INFRA_CREDENTIAL_PASSPHRASE environment variable, or prompted for when it is
+needed. The infra agent keeps the store unlocked for a while after the
+passphrase is entered. The agent starts with the store locked, so the
+passphrase is requested again after the agent is restarted.
+
+If the passphrase is lost, or the credential helper no longer works, use
+'infra credentials reset' to remove the access keys and log in again.`,
+		GroupID: groupOther,
+	}
+
+	cmd.AddCommand(newCredentialsUseCmd(cli))
+	cmd.AddCommand(newCredentialsUnlockCmd(cli))
+	cmd.AddCommand(newCredentialsLockCmd(cli))
+	cmd.AddCommand(newCredentialsResetCmd(cli))
+	return cmd
+}
+
+func newCredentialsUseCmd(cli *CLI) *cobra.Command {
+	return &cobra.Command{
+		Use:   "use STORE",
+		Short: "Set the credential store, and move access keys to it",
+		Example: `# Store access keys in an encrypted file
+$ infra credentials use encrypted-file
+
+# Store access keys with infra-credential-osxkeychain
+$ infra credentials use osxkeychain`,
+		Args: ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := args[0]
+			store, err := newCredentialStore(name)
+			if err != nil {
+				return err
+			}
+			if helper, ok := store.(*helperCredentialStore); ok {
+				if _, err := exec.LookPath(helper.program); err != nil {
+					return Error{Message: fmt.Sprintf("credential helper %v was not found in PATH", helper.program)}
+				}
+			}
+
+			config, err := readConfigWithoutAccessKeys()
+			if err != nil {
+				return err
+			}
+			current := config.CredentialStore
+			if current == "" {
+				current = credentialStorePlaintext
+			}
+			if current == name {
+				fmt.Fprintf(cli.Stderr, "Access keys are already stored in %q.\n", name)
+				return nil
+			}
+			previous, err := newCredentialStore(config.CredentialStore)
+			if err != nil {
+				return err
+			}
+			if err := loadAccessKeys(config); err != nil {
+				logging.Warnf("%v", err)
+				fmt.Fprintf(cli.Stderr, "Access keys could not be read from %q, log in again to store new access keys.\n", current)
+				for i := range config.Hosts {
+					clearSession(&config.Hosts[i])
+				}
+			}
+
+			config.CredentialStore = name
+			if err := writeConfig(config); err != nil {
+				return err
+			}
+
+			if previous != nil {
+				for _, host := range config.Hosts {
+					if err := eraseAccessKey(previous, host.Host); err != nil {
+						logging.Warnf("failed to remove access key from the previous credential store: %v", err)
+					}
+				}
+			}
+
+			fmt.Fprintf(cli.Stderr, "Access keys are stored in %q.\n", name)
+			return nil
+		},
+	}
+}
+
+func newCredentialsUnlockCmd(cli *CLI) *cobra.Command {
+	var timeout time.Duration
+	cmd := &cobra.Command{
+		Use:   "unlock",
+		Short: "Unlock the encrypted-file credential store",
+		Long: `Unlock the encrypted-file credential store.
+
+The infra agent keeps the store unlocked until the timeout, so that commands
+run by other programs, like kubectl, can read access keys without prompting
+for the passphrase.`,
+		Args: NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			config, err := readConfigWithoutAccessKeys()
+			if err != nil {
+				return err
+			}
+			store, err := newCredentialStore(config.CredentialStore)
+			if err != nil {
+				return err
+			}
+			fileStore, ok := store.(*encryptedFileStore)
+			if !ok {
+				return Error{Message: "the credential store is not encrypted-file, use 'infra credentials use encrypted-file' to set it"}
+			}
+
+			if err := fileStore.unlock(timeout); err != nil {
+				return err
+			}
+			fmt.Fprintf(cli.Stderr, "Credential store unlocked for %v.\n", timeout)
+			return nil
+		},
+	}
+
+	cmd.Flags().DurationVar(&timeout, "timeout", defaultUnlockTimeout, "Time until the credential store is locked again")
+	return cmd
+}
+
+func newCredentialsLockCmd(cli *CLI) *cobra.Command {
+	return &cobra.Command{
+		Use:   "lock",
+		Short: "Lock the encrypted-file credential store",
+		Args:  NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			lockCredentials()
+			cli.Output("Credential store locked.")
+			return nil
+		},
+	}
+}
+
+func newCredentialsResetCmd(cli *CLI) *cobra.Command {
+	return &cobra.Command{
+		Use:   "reset",
+		Short: "Remove all access keys from the credential store",
+		Long: `Remove all access keys from the credential store, and log out of all servers.
+
+Use reset when the passphrase of the encrypted-file store is lost, or when the
+credential helper no longer works. The passphrase is not required. Access keys
+are not revoked on the servers, use 'infra login' to log in again.`,
+		Args: NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			config, err := readConfigWithoutAccessKeys()
+			if err != nil {
+				return err
+			}
+			store, err := newCredentialStore(config.CredentialStore)
+			if err != nil {
+				return err
+			}
+			if fileStore, ok := store.(*encryptedFileStore); ok {
+				if err := os.Remove(fileStore.filename); err != nil && !errors.Is(err, fs.ErrNotExist) {
+					return err
+				}
+				lockCredentials()
+			}
+
+			for i := range config.Hosts {
+				clearSession(&config.Hosts[i])
+			}
+			// erases the access keys from a credential helper
+			if err := writeConfigWithoutAccessKeys(config); err != nil {
+				return err
+			}
+			if err := clearKubeconfig(); err != nil {
+				logging.Warnf("failed to remove Infra contexts from the kubeconfig: %v", err)
+			}
+
+			fmt.Fprintln(cli.Stderr, "Access keys removed. Use 'infra login' to log in again.")
+			return nil
+		},
+	}
+}
@@ -0,0 +1,206 @@
+package cmd
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/infrahq/infra/internal/logging"
+)
+
+// defaultUnlockTimeout is the time a passphrase-derived key is kept in memory
+// after the passphrase was entered.
+const defaultUnlockTimeout = time.Hour
+
+// unlockCache holds the keys that decrypt encrypted credential files, by salt,
+// until they expire.
+type unlockCache struct {
+	mu   sync.Mutex
+	keys map[string]unlockedKey
+}
+
+type unlockedKey struct {
+	key     []byte
+	expires time.Time
+}
+
+// unlockedKeys are the keys unlocked by this process. The infra agent serves
+// these keys to other processes.
+var unlockedKeys = &unlockCache{}
+
+func (c *unlockCache) get(salt []byte) []byte {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	id := base64.StdEncoding.EncodeToString(salt)
+	entry, ok := c.keys[id]
+	if !ok {
+		return nil
+	}
+	if time.Now().After(entry.expires) {
+		delete(c.keys, id)
+		return nil
+	}
+	return entry.key
+}
+
+func (c *unlockCache) put(salt, key []byte, timeout time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.keys == nil {
+		c.keys = map[string]unlockedKey{}
+	}
+	id := base64.StdEncoding.EncodeToString(salt)
+	c.keys[id] = unlockedKey{key: key, expires: time.Now().Add(timeout)}
+}
+
+func (c *unlockCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.keys = nil
+}
+
+// agentSocketFilename is the unix socket used to request unlocked keys from
+// the infra agent.
+func agentSocketFilename() (string, error) {
+	infraDir, err := infraHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(infraDir, "agent.sock"), nil
+}
+
+type unlockRequest struct {
+	// Action is one of get, put, or clear.
+	Action  string        `json:"action"`
+	Salt    []byte        `json:"salt,omitempty"`
+	Key     []byte        `json:"key,omitempty"`
+	Timeout time.Duration `json:"timeout,omitempty"`
+}
+
+type unlockResponse struct {
+	Key   []byte `json:"key,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// serveUnlockCache accepts requests on the unix socket at filename, to get,
+// put, or clear the keys in cache, until ctx is done. Only the user can
+// connect to the socket.
+func serveUnlockCache(ctx context.Context, filename string, cache *unlockCache) error {
+	if err := os.Remove(filename); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	listener, err := net.Listen("unix", filename)
+	if err != nil {
+		return fmt.Errorf("listen for unlock requests: %w", err)
+	}
+	if err := os.Chmod(filename, 0o600); err != nil {
+		_ = listener.Close()
+		return err
+	}
+
+	go func() {
+		<-ctx.Done()
+		_ = listener.Close()
+	}()
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		go handleUnlockRequest(conn, cache)
+	}
+}
+
+func handleUnlockRequest(conn net.Conn, cache *unlockCache) {
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	var req unlockRequest
+	var resp unlockResponse
+	if err := json.NewDecoder(conn).Decode(&req); err != nil {
+		logging.Debugf("decode unlock request: %v", err)
+		return
+	}
+
+	switch req.Action {
+	case "get":
+		resp.Key = cache.get(req.Salt)
+	case "put":
+		cache.put(req.Salt, req.Key, req.Timeout)
+	case "clear":
+		cache.clear()
+	default:
+		resp.Error = fmt.Sprintf("unknown action %q", req.Action)
+	}
+
+	if err := json.NewEncoder(conn).Encode(resp); err != nil {
+		logging.Debugf("encode unlock response: %v", err)
+	}
+}
+
+// requestUnlockCache sends req to the infra agent and returns its response.
+func requestUnlockCache(req unlockRequest) (*unlockResponse, error) {
+	filename, err := agentSocketFilename()
+	if err != nil {
+		return nil, err
+	}
+	conn, err := net.DialTimeout("unix", filename, time.Second)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return nil, err
+	}
+	var resp unlockResponse
+	if err := json.NewDecoder(conn).Decode(&resp); err != nil {
+		return nil, err
+	}
+	if resp.Error != "" {
+		return nil, errors.New(resp.Error)
+	}
+	return &resp, nil
+}
+
+// agentUnlockedKey returns the key for salt unlocked by the infra agent, or nil
+// if the agent does not have the key.
+func agentUnlockedKey(salt []byte) ([]byte, error) {
+	resp, err := requestUnlockCache(unlockRequest{Action: "get", Salt: salt})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Key, nil
+}
+
+// agentUnlockCredentials sends the key for salt to the infra agent, which keeps
+// it until timeout.
+func agentUnlockCredentials(salt, key []byte, timeout time.Duration) error {
+	_, err := requestUnlockCache(unlockRequest{Action: "put", Salt: salt, Key: key, Timeout: timeout})
+	return err
+}
+
+// lockCredentials removes all the keys from this process and the infra agent.
+// It is not an error when the agent is not running.
+func lockCredentials() {
+	unlockedKeys.clear()
+
+	if _, err := requestUnlockCache(unlockRequest{Action: "clear"}); err != nil {
+		logging.Debugf("lock credentials in agent: %v", err)
+	}
+}
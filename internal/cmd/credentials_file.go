@@ -0,0 +1,351 @@
+package cmd
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"golang.org/x/crypto/scrypt"
+	"golang.org/x/term"
+
+	"github.com/infrahq/infra/internal/logging"
+)
+
+// encryptedFileVersion is the version of the format of the encrypted
+// credentials file. Version 1 uses scrypt to derive a key from the passphrase,
+// and AES-256-GCM to encrypt the access keys.
+const encryptedFileVersion = 1
+
+// encryptedFileStore stores access keys in a file encrypted with a key derived
+// from a passphrase. Derived keys are cached in memory by unlockedKeys, and by
+// the infra agent, so that the passphrase is not requested by every command.
+type encryptedFileStore struct {
+	filename string
+	// noPrompt fails with errCredentialStoreLocked instead of prompting for
+	// the passphrase when the store is locked.
+	noPrompt bool
+}
+
+type encryptedFile struct {
+	Version int    `json:"version"`
+	Salt    []byte `json:"salt"`
+	Nonce   []byte `json:"nonce"`
+	Data    []byte `json:"data"`
+}
+
+func encryptedCredentialsFilename() (string, error) {
+	infraDir, err := infraHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(infraDir, "credentials"), nil
+}
+
+func (s *encryptedFileStore) Get(host string) (string, error) {
+	keys, err := s.read()
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return "", errCredentialNotFound
+	case err != nil:
+		return "", err
+	}
+
+	accessKey, ok := keys[host]
+	if !ok {
+		return "", errCredentialNotFound
+	}
+	return accessKey, nil
+}
+
+func (s *encryptedFileStore) Store(host, accessKey string) error {
+	return s.update(func(keys map[string]string) {
+		keys[host] = accessKey
+	})
+}
+
+func (s *encryptedFileStore) Erase(host string) error {
+	if _, err := os.Stat(s.filename); errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return s.update(func(keys map[string]string) {
+		delete(keys, host)
+	})
+}
+
+// read returns the access keys in the file, by host.
+func (s *encryptedFileStore) read() (map[string]string, error) {
+	file, err := s.open()
+	if err != nil {
+		return nil, err
+	}
+	key, err := unlockKey(file, !s.noPrompt)
+	if err != nil {
+		return nil, err
+	}
+	return file.decrypt(key)
+}
+
+// update calls fn with the access keys in the file, and writes the result
+// back to the file. The file is created when it does not exist.
+func (s *encryptedFileStore) update(fn func(keys map[string]string)) error {
+	lock, err := os.OpenFile(s.filename+".lock", os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return err
+	}
+	defer lock.Close()
+	if err := lockFile(lock); err != nil {
+		return fmt.Errorf("lock credentials file: %w", err)
+	}
+	defer func() {
+		if err := unlockFile(lock); err != nil {
+			logging.Debugf("unlock credentials file: %v", err)
+		}
+	}()
+
+	var key []byte
+	var keys map[string]string
+	file, err := s.open()
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		file = &encryptedFile{Version: encryptedFileVersion, Salt: make([]byte, 16)}
+		if _, err := io.ReadFull(rand.Reader, file.Salt); err != nil {
+			return err
+		}
+		passphrase, err := readNewPassphrase()
+		if err != nil {
+			return err
+		}
+		if key, err = deriveKey(passphrase, file.Salt); err != nil {
+			return err
+		}
+		cacheUnlockedKey(file.Salt, key, defaultUnlockTimeout)
+		keys = map[string]string{}
+	case err != nil:
+		return err
+	default:
+		if key, err = unlockKey(file, !s.noPrompt); err != nil {
+			return err
+		}
+		if keys, err = file.decrypt(key); err != nil {
+			return err
+		}
+	}
+
+	fn(keys)
+	if err := file.encrypt(key, keys); err != nil {
+		return err
+	}
+
+	content, err := json.Marshal(file)
+	if err != nil {
+		return err
+	}
+	// write to a temporary file first, so that a failed write does not lose
+	// the existing access keys
+	tmp := s.filename + ".tmp"
+	if err := os.WriteFile(tmp, content, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.filename)
+}
+
+func (s *encryptedFileStore) open() (*encryptedFile, error) {
+	content, err := os.ReadFile(s.filename)
+	if err != nil {
+		return nil, err
+	}
+
+	file := &encryptedFile{}
+	if err := json.Unmarshal(content, file); err != nil {
+		return nil, fmt.Errorf("read %v: %w", s.filename, err)
+	}
+	if file.Version != encryptedFileVersion {
+		return nil, fmt.Errorf("credentials file %v has version %v which is not supported",
+			s.filename, file.Version)
+	}
+	return file, nil
+}
+
+// unlock prompts for the passphrase, and caches the derived key in the infra
+// agent until timeout.
+func (s *encryptedFileStore) unlock(timeout time.Duration) error {
+	file, err := s.open()
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return Error{Message: "the credential store is empty, it is created the next time you 'infra login'"}
+	case err != nil:
+		return err
+	}
+
+	passphrase, err := readPassphrase("Passphrase for the credential store: ")
+	if err != nil {
+		return err
+	}
+	key, err := deriveKey(passphrase, file.Salt)
+	if err != nil {
+		return err
+	}
+	if _, err := file.decrypt(key); err != nil {
+		return err
+	}
+
+	cacheUnlockedKey(file.Salt, key, timeout)
+	if err := agentUnlockCredentials(file.Salt, key, timeout); err != nil {
+		return fmt.Errorf("the infra agent is not running, the passphrase will be requested by the next command: %w", err)
+	}
+	return nil
+}
+
+var errIncorrectPassphrase = Error{Message: "incorrect passphrase for the credential store"}
+
+func (f *encryptedFile) decrypt(key []byte) (map[string]string, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := aead.Open(nil, f.Nonce, f.Data, nil)
+	if err != nil {
+		return nil, errIncorrectPassphrase
+	}
+
+	keys := map[string]string{}
+	if err := json.Unmarshal(plaintext, &keys); err != nil {
+		return nil, fmt.Errorf("decode credentials: %w", err)
+	}
+	return keys, nil
+}
+
+func (f *encryptedFile) encrypt(key []byte, keys map[string]string) error {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return err
+	}
+	plaintext, err := json.Marshal(keys)
+	if err != nil {
+		return err
+	}
+
+	f.Nonce = make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, f.Nonce); err != nil {
+		return err
+	}
+	f.Data = aead.Seal(nil, f.Nonce, plaintext, nil)
+	return nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func deriveKey(passphrase string, salt []byte) ([]byte, error) {
+	return scrypt.Key([]byte(passphrase), salt, 1<<15, 8, 1, 32)
+}
+
+// unlockKey returns the key that decrypts file. The key is read from the
+// first of:
+//
+//  1. the keys unlocked by this process
+//  2. the keys unlocked by the infra agent
+//  3. a key derived from INFRA_CREDENTIAL_PASSPHRASE, or from a passphrase
+//     entered at a prompt when prompt is true
+func unlockKey(file *encryptedFile, prompt bool) ([]byte, error) {
+	if key := unlockedKeys.get(file.Salt); key != nil {
+		return key, nil
+	}
+
+	key, err := agentUnlockedKey(file.Salt)
+	switch {
+	case err != nil:
+		logging.Debugf("unlocked key from agent: %v", err)
+	case key != nil:
+		if _, err := file.decrypt(key); err == nil {
+			unlockedKeys.put(file.Salt, key, defaultUnlockTimeout)
+			return key, nil
+		}
+	}
+
+	if _, ok := os.LookupEnv("INFRA_CREDENTIAL_PASSPHRASE"); !ok && !prompt {
+		return nil, errCredentialStoreLocked
+	}
+	passphrase, err := readPassphrase("Passphrase for the credential store: ")
+	if err != nil {
+		return nil, err
+	}
+	key, err = deriveKey(passphrase, file.Salt)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := file.decrypt(key); err != nil {
+		return nil, err
+	}
+	cacheUnlockedKey(file.Salt, key, defaultUnlockTimeout)
+	return key, nil
+}
+
+// cacheUnlockedKey caches the key in this process, and in the infra agent when
+// it is running.
+func cacheUnlockedKey(salt, key []byte, timeout time.Duration) {
+	unlockedKeys.put(salt, key, timeout)
+	if err := agentUnlockCredentials(salt, key, timeout); err != nil {
+		logging.Debugf("unlock credentials in agent: %v", err)
+	}
+}
+
+var errCredentialStoreLocked = Error{
+	Message: "the credential store is locked, run 'infra credentials unlock' or set INFRA_CREDENTIAL_PASSPHRASE",
+}
+
+// readPassphrase returns INFRA_CREDENTIAL_PASSPHRASE, or prompts for the
+// passphrase when stdin is a terminal.
+func readPassphrase(prompt string) (string, error) {
+	if passphrase, ok := os.LookupEnv("INFRA_CREDENTIAL_PASSPHRASE"); ok {
+		return passphrase, nil
+	}
+
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return "", errCredentialStoreLocked
+	}
+	fmt.Fprint(os.Stderr, prompt)
+	passphrase, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", err
+	}
+	return string(passphrase), nil
+}
+
+// readNewPassphrase returns the passphrase for a new credentials file.
+func readNewPassphrase() (string, error) {
+	if passphrase, ok := os.LookupEnv("INFRA_CREDENTIAL_PASSPHRASE"); ok {
+		return passphrase, nil
+	}
+
+	passphrase, err := readPassphrase("New passphrase for the credential store: ")
+	if err != nil {
+		return "", err
+	}
+	if passphrase == "" {
+		return "", Error{Message: "the passphrase for the credential store must not be empty"}
+	}
+	confirm, err := readPassphrase("Confirm passphrase: ")
+	if err != nil {
+		return "", err
+	}
+	if confirm != passphrase {
+		return "", Error{Message: "passphrases do not match"}
+	}
+	return passphrase, nil
+}
@@ -0,0 +1,349 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/poll"
+
+	"github.com/infrahq/infra/api"
+	"github.com/infrahq/infra/uid"
+)
+
+func TestCredentialsCmd_EncryptedFile(t *testing.T) {
+	home := setupEnv(t)
+	t.Setenv("INFRA_CREDENTIAL_PASSPHRASE", "correct horse battery staple")
+	t.Cleanup(unlockedKeys.clear)
+
+	expires := api.Time(time.Now().Add(time.Hour))
+	cfg := ClientConfig{
+		ClientConfigVersion: clientConfigVersion,
+		Hosts: []ClientHostConfig{
+			{Host: "prod.example.com", UserID: 1234, Name: "alice@example.com", AccessKey: "aaaaaaaaaa.bbbbbbbbbbbbbbbbbbbbbbbb", Expires: expires, Current: true},
+			{Host: "dev.example.com", UserID: 1234, Name: "alice@example.com", AccessKey: "cccccccccc.dddddddddddddddddddddddd", Expires: expires},
+		},
+	}
+	assert.NilError(t, writeConfig(&cfg))
+
+	ctx, bufs := PatchCLI(context.Background())
+	err := Run(ctx, "credentials", "use", "encrypted-file")
+	assert.NilError(t, err)
+	assert.Equal(t, bufs.Stderr.String(), "Access keys are stored in \"encrypted-file\".\n")
+
+	raw, err := os.ReadFile(filepath.Join(home, ".infra", "config"))
+	assert.NilError(t, err)
+	assert.Assert(t, !strings.Contains(string(raw), "aaaaaaaaaa") && !strings.Contains(string(raw), "cccccccccc"), string(raw))
+
+	raw, err = os.ReadFile(filepath.Join(home, ".infra", "credentials"))
+	assert.NilError(t, err)
+	assert.Assert(t, !strings.Contains(string(raw), "aaaaaaaaaa") && !strings.Contains(string(raw), "cccccccccc"), string(raw))
+
+	t.Run("read access keys", func(t *testing.T) {
+		unlockedKeys.clear()
+		host, err := currentHostConfig()
+		assert.NilError(t, err)
+		assert.Equal(t, host.AccessKey, "aaaaaaaaaa.bbbbbbbbbbbbbbbbbbbbbbbb")
+	})
+
+	t.Run("incorrect passphrase", func(t *testing.T) {
+		unlockedKeys.clear()
+		t.Setenv("INFRA_CREDENTIAL_PASSPHRASE", "wrong")
+		_, err := readConfig()
+		assert.ErrorContains(t, err, "incorrect passphrase")
+	})
+
+	t.Run("locked", func(t *testing.T) {
+		unlockedKeys.clear()
+		t.Setenv("INFRA_CREDENTIAL_PASSPHRASE", "")
+		os.Unsetenv("INFRA_CREDENTIAL_PASSPHRASE")
+		_, err := readConfig()
+		assert.ErrorIs(t, err, errCredentialStoreLocked)
+	})
+
+	t.Run("logout erases the access key", func(t *testing.T) {
+		config, err := readConfig()
+		assert.NilError(t, err)
+		config.Hosts = config.Hosts[:1]
+		assert.NilError(t, writeConfig(config))
+
+		store := &encryptedFileStore{filename: filepath.Join(home, ".infra", "credentials")}
+		_, err = store.Get("dev.example.com")
+		assert.ErrorIs(t, err, errCredentialNotFound)
+	})
+
+	t.Run("migrate plaintext access keys", func(t *testing.T) {
+		// an access key written to the config file by an older version
+		cfg := ClientConfig{
+			ClientConfigVersion: clientConfigVersion,
+			Hosts: []ClientHostConfig{
+				{Host: "prod.example.com", UserID: 1234, Name: "alice@example.com", AccessKey: "eeeeeeeeee.ffffffffffffffffffffffff", Current: true},
+			},
+		}
+		raw, err := json.Marshal(cfg)
+		assert.NilError(t, err)
+		raw = bytes.Replace(raw, []byte(`"version":"0.4"`), []byte(`"version":"0.4","credential-store":"encrypted-file"`), 1)
+		filename := filepath.Join(home, ".infra", "config")
+		assert.NilError(t, os.WriteFile(filename, raw, 0o600))
+
+		config, err := readConfig()
+		assert.NilError(t, err)
+		assert.Equal(t, config.Hosts[0].AccessKey, "eeeeeeeeee.ffffffffffffffffffffffff")
+
+		content, err := os.ReadFile(filename)
+		assert.NilError(t, err)
+		assert.Assert(t, !strings.Contains(string(content), "eeeeeeeeee"), string(content))
+
+		store := &encryptedFileStore{filename: filepath.Join(home, ".infra", "credentials")}
+		key, err := store.Get("prod.example.com")
+		assert.NilError(t, err)
+		assert.Equal(t, key, "eeeeeeeeee.ffffffffffffffffffffffff")
+	})
+
+	t.Run("migrate does not overwrite a newer config", func(t *testing.T) {
+		filename := filepath.Join(home, ".infra", "config")
+		cfg := ClientConfig{
+			ClientConfigVersion: clientConfigVersion,
+			CredentialStore:     credentialStoreEncryptedFile,
+			Hosts: []ClientHostConfig{
+				{Host: "prod.example.com", UserID: 1234, Name: "alice@example.com", AccessKey: "eeeeeeeeee.ffffffffffffffffffffffff", Current: true},
+			},
+		}
+		raw, err := json.Marshal(cfg)
+		assert.NilError(t, err)
+		assert.NilError(t, os.WriteFile(filename, raw, 0o600))
+
+		config, err := readConfigWithoutAccessKeys()
+		assert.NilError(t, err)
+
+		// another process adds a host after this process read the config
+		newer := cfg
+		newer.Hosts = append(newer.Hosts, ClientHostConfig{Host: "dev.example.com", UserID: 1234, Name: "alice@example.com"})
+		raw, err = json.Marshal(newer)
+		assert.NilError(t, err)
+		assert.NilError(t, os.WriteFile(filename, raw, 0o600))
+
+		assert.NilError(t, loadAccessKeys(config))
+		assert.Equal(t, config.Hosts[0].AccessKey, "eeeeeeeeee.ffffffffffffffffffffffff")
+
+		config, err = readConfigWithoutAccessKeys()
+		assert.NilError(t, err)
+		assert.Equal(t, len(config.Hosts), 2)
+		assert.Equal(t, config.Hosts[0].AccessKey, "")
+
+		store := &encryptedFileStore{filename: filepath.Join(home, ".infra", "credentials")}
+		key, err := store.Get("prod.example.com")
+		assert.NilError(t, err)
+		assert.Equal(t, key, "eeeeeeeeee.ffffffffffffffffffffffff")
+	})
+
+	t.Run("use plaintext", func(t *testing.T) {
+		ctx, _ := PatchCLI(context.Background())
+		err := Run(ctx, "credentials", "use", "plaintext")
+		assert.NilError(t, err)
+
+		raw, err := os.ReadFile(filepath.Join(home, ".infra", "config"))
+		assert.NilError(t, err)
+		assert.Assert(t, strings.Contains(string(raw), "eeeeeeeeee"), string(raw))
+
+		store := &encryptedFileStore{filename: filepath.Join(home, ".infra", "credentials")}
+		_, err = store.Get("prod.example.com")
+		assert.ErrorIs(t, err, errCredentialNotFound)
+	})
+}
+
+func TestCredentialsCmd_LockedStore(t *testing.T) {
+	home := setupEnv(t)
+	t.Cleanup(unlockedKeys.clear)
+	credentialsFile := filepath.Join(home, ".infra", "credentials")
+
+	expires := api.Time(time.Now().Add(time.Hour))
+	setup := func(t *testing.T) {
+		t.Helper()
+		t.Setenv("INFRA_CREDENTIAL_PASSPHRASE", "correct horse battery staple")
+		cfg := ClientConfig{
+			ClientConfigVersion: clientConfigVersion,
+			CredentialStore:     credentialStoreEncryptedFile,
+			Hosts: []ClientHostConfig{
+				{Host: "prod.example.com", UserID: 1234, Name: "alice@example.com", AccessKey: "aaaaaaaaaa.bbbbbbbbbbbbbbbbbbbbbbbb", Expires: expires, Current: true},
+				{Host: "dev.example.com", UserID: 1234, Name: "alice@example.com", AccessKey: "cccccccccc.dddddddddddddddddddddddd", Expires: expires},
+			},
+		}
+		assert.NilError(t, writeConfig(&cfg))
+
+		// lock the store
+		unlockedKeys.clear()
+		os.Unsetenv("INFRA_CREDENTIAL_PASSPHRASE")
+		_, err := readConfig()
+		assert.ErrorIs(t, err, errCredentialStoreLocked)
+	}
+
+	t.Run("context list", func(t *testing.T) {
+		setup(t)
+		ctx, bufs := PatchCLI(context.Background())
+		err := Run(ctx, "context", "list")
+		assert.NilError(t, err)
+		assert.Assert(t, strings.Contains(bufs.Stdout.String(), "prod.example.com"), bufs.Stdout.String())
+	})
+
+	t.Run("logout", func(t *testing.T) {
+		setup(t)
+		ctx, _ := PatchCLI(context.Background())
+		err := Run(ctx, "logout")
+		assert.NilError(t, err)
+
+		config, err := readConfigWithoutAccessKeys()
+		assert.NilError(t, err)
+		assert.Equal(t, config.Hosts[0].UserID, uid.ID(0))
+		assert.Equal(t, config.Hosts[1].UserID, uid.ID(1234))
+	})
+
+	t.Run("use plaintext", func(t *testing.T) {
+		setup(t)
+		ctx, bufs := PatchCLI(context.Background())
+		err := Run(ctx, "credentials", "use", "plaintext")
+		assert.NilError(t, err)
+		assert.Assert(t, strings.Contains(bufs.Stderr.String(), "log in again"), bufs.Stderr.String())
+
+		config, err := readConfig()
+		assert.NilError(t, err)
+		assert.Equal(t, config.CredentialStore, credentialStorePlaintext)
+		assert.Equal(t, config.Hosts[0].UserID, uid.ID(0))
+		assert.Equal(t, config.Hosts[0].AccessKey, "")
+	})
+
+	t.Run("reset", func(t *testing.T) {
+		setup(t)
+		ctx, _ := PatchCLI(context.Background())
+		err := Run(ctx, "credentials", "reset")
+		assert.NilError(t, err)
+
+		_, err = os.Stat(credentialsFile)
+		assert.Assert(t, os.IsNotExist(err), err)
+
+		config, err := readConfig()
+		assert.NilError(t, err)
+		assert.Equal(t, config.CredentialStore, credentialStoreEncryptedFile)
+		for _, host := range config.Hosts {
+			assert.Equal(t, host.UserID, uid.ID(0))
+			assert.Equal(t, host.AccessKey, "")
+		}
+	})
+}
+
+func TestCredentialsCmd_Helper(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("credential helper script requires a unix shell")
+	}
+	home := setupEnv(t)
+
+	bin := t.TempDir()
+	helper := `#!/bin/sh
+dir="$(dirname "$0")/store"
+mkdir -p "$dir"
+input="$(cat)"
+case "$1" in
+get)
+	if [ ! -f "$dir/$input" ]; then echo "credentials not found in store"; exit 1; fi
+	printf '{"ServerURL":"%s","Username":"access-key","Secret":"%s"}' "$input" "$(cat "$dir/$input")";;
+store)
+	host="$(echo "$input" | sed 's/.*"ServerURL":"\([^"]*\)".*/\1/')"
+	echo "$input" | sed 's/.*"Secret":"\([^"]*\)".*/\1/' > "$dir/$host";;
+erase)
+	rm -f "$dir/$input";;
+esac
+`
+	err := os.WriteFile(filepath.Join(bin, "infra-credential-test"), []byte(helper), 0o700)
+	assert.NilError(t, err)
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	cfg := ClientConfig{
+		ClientConfigVersion: clientConfigVersion,
+		Hosts: []ClientHostConfig{
+			{Host: "prod.example.com", UserID: 1234, Name: "alice@example.com", AccessKey: "aaaaaaaaaa.bbbbbbbbbbbbbbbbbbbbbbbb", Current: true},
+		},
+	}
+	assert.NilError(t, writeConfig(&cfg))
+
+	t.Run("helper not found", func(t *testing.T) {
+		ctx, _ := PatchCLI(context.Background())
+		err := Run(ctx, "credentials", "use", "missing")
+		assert.ErrorContains(t, err, "credential helper infra-credential-missing was not found")
+	})
+
+	ctx, _ := PatchCLI(context.Background())
+	err = Run(ctx, "credentials", "use", "test")
+	assert.NilError(t, err)
+
+	stored, err := os.ReadFile(filepath.Join(bin, "store", "prod.example.com"))
+	assert.NilError(t, err)
+	assert.Equal(t, string(stored), "aaaaaaaaaa.bbbbbbbbbbbbbbbbbbbbbbbb\n")
+
+	raw, err := os.ReadFile(filepath.Join(home, ".infra", "config"))
+	assert.NilError(t, err)
+	assert.Assert(t, !strings.Contains(string(raw), "aaaaaaaaaa"), string(raw))
+
+	host, err := currentHostConfig()
+	assert.NilError(t, err)
+	assert.Equal(t, host.AccessKey, "aaaaaaaaaa.bbbbbbbbbbbbbbbbbbbbbbbb")
+
+	config, err := readConfig()
+	assert.NilError(t, err)
+	config.Hosts[0].AccessKey = ""
+	assert.NilError(t, writeConfig(config))
+	_, err = os.Stat(filepath.Join(bin, "store", "prod.example.com"))
+	assert.Assert(t, os.IsNotExist(err))
+}
+
+func TestServeUnlockCache(t *testing.T) {
+	setupEnv(t)
+	infraDir, err := initInfraHomeDir()
+	assert.NilError(t, err)
+	filename := filepath.Join(infraDir, "agent.sock")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cache := &unlockCache{}
+	done := make(chan error, 1)
+	go func() {
+		done <- serveUnlockCache(ctx, filename, cache)
+	}()
+	poll.WaitOn(t, func(t poll.LogT) poll.Result {
+		if _, err := agentUnlockedKey([]byte("salt")); err != nil {
+			return poll.Continue("agent not ready: %v", err)
+		}
+		return poll.Success()
+	})
+
+	err = agentUnlockCredentials([]byte("salt"), []byte("key"), time.Minute)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, cache.get([]byte("salt")), []byte("key"))
+
+	key, err := agentUnlockedKey([]byte("salt"))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, key, []byte("key"))
+
+	key, err = agentUnlockedKey([]byte("other"))
+	assert.NilError(t, err)
+	assert.Assert(t, key == nil)
+
+	t.Run("expired", func(t *testing.T) {
+		err = agentUnlockCredentials([]byte("expired"), []byte("key"), -time.Second)
+		assert.NilError(t, err)
+		key, err := agentUnlockedKey([]byte("expired"))
+		assert.NilError(t, err)
+		assert.Assert(t, key == nil)
+	})
+
+	lockCredentials()
+	assert.Assert(t, cache.get([]byte("salt")) == nil)
+
+	cancel()
+	assert.NilError(t, <-done)
+}
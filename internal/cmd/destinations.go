@@ -2,11 +2,9 @@ package cmd
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 
 	"github.com/spf13/cobra"
-	"sigs.k8s.io/yaml"
 
 	"github.com/infrahq/infra/api"
 	humanfmt "github.com/infrahq/infra/internal/format"
@@ -34,7 +32,7 @@ func newDestinationsCmd(cli *CLI) *cobra.Command {
 }
 
 func newDestinationsListCmd(cli *CLI) *cobra.Command {
-	var format string
+	var output outputOptions
 	cmd := &cobra.Command{
 		Use:     "list",
 		Aliases: []string{"ls"},
@@ -54,59 +52,44 @@ func newDestinationsListCmd(cli *CLI) *cobra.Command {
 				return err
 			}
 
-			switch format {
-			case "json":
-				jsonOutput, err := json.Marshal(destinations)
-				if err != nil {
-					return err
-				}
-				cli.Output(string(jsonOutput))
-			case "yaml":
-				yamlOutput, err := yaml.Marshal(destinations)
-				if err != nil {
-					return err
-				}
-				cli.Output(string(yamlOutput))
-			default:
-				type row struct {
-					Name     string `header:"NAME"`
-					Kind     string `header:"KIND"`
-					URL      string `header:"URL"`
-					Status   string `header:"STATUS"`
-					LastSeen string `header:"LAST SEEN"`
+			type row struct {
+				Name     string `header:"NAME"`
+				Kind     string `header:"KIND"`
+				URL      string `header:"URL"`
+				Status   string `header:"STATUS"`
+				LastSeen string `header:"LAST SEEN"`
+			}
+
+			var rows []row
+			for _, d := range destinations {
+				var status string
+				switch {
+				case !d.Connected:
+					status = DestinationStatusDisconnected
+				case d.Connection.URL == "":
+					status = DestinationStatusPending
+				default:
+					status = DestinationStatusConnected
 				}
 
-				var rows []row
-				for _, d := range destinations {
-					var status string
-					switch {
-					case !d.Connected:
-						status = DestinationStatusDisconnected
-					case d.Connection.URL == "":
-						status = DestinationStatusPending
-					default:
-						status = DestinationStatusConnected
-					}
+				rows = append(rows, row{
+					Name:     d.Name,
+					Kind:     d.Kind,
+					URL:      d.Connection.URL,
+					Status:   status,
+					LastSeen: humanfmt.HumanTime(d.LastSeen.Time(), "never"),
+				})
+			}
 
-					rows = append(rows, row{
-						Name:     d.Name,
-						Kind:     d.Kind,
-						URL:      d.Connection.URL,
-						Status:   status,
-						LastSeen: humanfmt.HumanTime(d.LastSeen.Time(), "never"),
-					})
-				}
-				if len(rows) > 0 {
-					printTable(rows, cli.Stdout)
-				} else {
-					cli.Output("No destinations connected")
-				}
+			if output.isTable() && len(rows) == 0 {
+				cli.Output("No destinations connected")
+				return nil
 			}
-			return nil
+			return writeOutput(cli, output, destinations, rows)
 		},
 	}
 
-	addFormatFlag(cmd.Flags(), &format)
+	addFormatFlag(cmd.Flags(), &output)
 	return cmd
 }
 
@@ -114,11 +97,12 @@ func newDestinationsRemoveCmd(cli *CLI) *cobra.Command {
 	var force bool
 
 	cmd := &cobra.Command{
-		Use:     "remove DESTINATION",
-		Aliases: []string{"rm"},
-		Short:   "Disconnect a destination",
-		Example: "$ infra destinations remove docker-desktop",
-		Args:    ExactArgs(1),
+		Use:               "remove DESTINATION",
+		Aliases:           []string{"rm"},
+		Short:             "Disconnect a destination",
+		Example:           "$ infra destinations remove docker-desktop",
+		Args:              ExactArgs(1),
+		ValidArgsFunction: completeArgs([]completionSource{completeDestinations}),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			name := args[0]
 			client, err := cli.apiClient()
@@ -73,6 +73,40 @@ func TestDestinationsListCmd(t *testing.T) {
 		golden.Assert(t, bufs.Stdout.String(), t.Name())
 	})
 
+	t.Run("list with csv", func(t *testing.T) {
+		setup(t)
+		ctx, bufs := PatchCLI(context.Background())
+
+		err := Run(ctx, "destinations", "list", "--format=csv")
+		assert.NilError(t, err)
+		golden.Assert(t, bufs.Stdout.String(), t.Name())
+	})
+
+	t.Run("list with template", func(t *testing.T) {
+		setup(t)
+		ctx, bufs := PatchCLI(context.Background())
+
+		err := Run(ctx, "destinations", "list", `--template={{range .}}{{.name}} {{.connection.url}}{{"\n"}}{{end}}`)
+		assert.NilError(t, err)
+		assert.Equal(t, bufs.Stdout.String(), "destinationName 10.0.0.1\n")
+	})
+
+	t.Run("list with jsonpath", func(t *testing.T) {
+		setup(t)
+		ctx, bufs := PatchCLI(context.Background())
+
+		err := Run(ctx, "destinations", "list", "--template=jsonpath={[*].kind}")
+		assert.NilError(t, err)
+		assert.Equal(t, bufs.Stdout.String(), "kubernetes")
+	})
+
+	t.Run("list with unknown format", func(t *testing.T) {
+		ctx, _ := PatchCLI(context.Background())
+
+		err := Run(ctx, "destinations", "list", "--format=xml")
+		assert.ErrorContains(t, err, "must be one of table, json, yaml, or csv")
+	})
+
 	t.Run("list default table format", func(t *testing.T) {
 		setup(t)
 		ctx, bufs := PatchCLI(context.Background())
@@ -0,0 +1,600 @@
+package cmd
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/goware/urlx"
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/infrahq/infra/api"
+	"github.com/infrahq/infra/internal/format"
+	"github.com/infrahq/infra/internal/logging"
+)
+
+const (
+	doctorOK      = "ok"
+	doctorWarning = "warning"
+	doctorError   = "error"
+	doctorSkipped = "skipped"
+)
+
+// doctorCheck is the result of one check run by 'infra doctor'.
+type doctorCheck struct {
+	Name   string `json:"name" header:"NAME"`
+	Status string `json:"status" header:"STATUS"`
+	// Message describes the result of the check.
+	Message string `json:"message" header:"MESSAGE"`
+	// Remediation describes how to fix the problem found by the check.
+	Remediation string `json:"remediation,omitempty" header:"REMEDIATION"`
+}
+
+// maxClockSkew is the largest difference between the local clock and the
+// clock of the server that does not cause problems with token expiry.
+const maxClockSkew = 30 * time.Second
+
+// doctorDialTimeout is the time to wait for a connection to a destination.
+var doctorDialTimeout = 5 * time.Second
+
+func newDoctorCmd(cli *CLI) *cobra.Command {
+	var output outputOptions
+
+	cmd := &cobra.Command{
+		Use:   "doctor",
+		Short: "Diagnose problems with your Infra setup",
+		Long: `Diagnose problems with your Infra setup.
+
+Checks the connection to the server, the local clock, your session, the
+Infra agent, your kubeconfig, the connection to your destinations, and your
+ssh config, and describes how to fix any problems that are found.`,
+		Args:    NoArgs,
+		GroupID: groupOther,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			checks := runDoctor(cmd.Context())
+
+			if output.isTable() {
+				printDoctorChecks(cli, checks)
+			} else if err := writeOutput(cli, output, checks, checks); err != nil {
+				return err
+			}
+
+			var failed int
+			for _, check := range checks {
+				if check.Status == doctorError {
+					failed++
+				}
+			}
+			if failed > 0 {
+				return Error{Message: fmt.Sprintf("infra doctor found %d problem(s)", failed)}
+			}
+			return nil
+		},
+	}
+
+	addFormatFlag(cmd.Flags(), &output)
+	return cmd
+}
+
+func printDoctorChecks(cli *CLI, checks []doctorCheck) {
+	w := tabwriter.NewWriter(cli.Stdout, 0, 0, 2, ' ', 0)
+	defer w.Flush()
+
+	for _, check := range checks {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", check.Name, check.Status, check.Message)
+		if check.Remediation != "" {
+			fmt.Fprintf(w, "\t\t→ %s\n", check.Remediation)
+		}
+	}
+}
+
+// doctor holds the state shared by the checks run by 'infra doctor'. Each check
+// may depend on the state set by earlier checks.
+type doctor struct {
+	hostConfig      *ClientHostConfig
+	serverReachable bool
+	client          *api.Client
+	destinations    []api.Destination
+	grants          []api.Grant
+}
+
+// runDoctor runs every check, in order, and returns the results.
+func runDoctor(ctx context.Context) []doctorCheck {
+	d := &doctor{}
+
+	checks := []struct {
+		name string
+		run  func(ctx context.Context) doctorCheck
+	}{
+		{name: "config", run: d.checkConfig},
+		{name: "server", run: d.checkServer},
+		{name: "clock", run: d.checkClock},
+		{name: "login", run: d.checkLogin},
+		{name: "agent", run: d.checkAgent},
+		{name: "kubeconfig", run: d.checkKubeconfig},
+		{name: "destinations", run: d.checkDestinations},
+		{name: "ssh", run: d.checkSSHConfig},
+	}
+
+	results := make([]doctorCheck, 0, len(checks))
+	for _, check := range checks {
+		result := check.run(ctx)
+		result.Name = check.name
+		logging.Debugf("doctor: %v %v: %v", result.Name, result.Status, result.Message)
+		results = append(results, result)
+	}
+	return results
+}
+
+func (d *doctor) checkConfig(context.Context) doctorCheck {
+	hostConfig, err := currentHostConfig()
+	switch {
+	case errors.Is(err, ErrConfigNotFound):
+		return doctorCheck{
+			Status:      doctorError,
+			Message:     err.Error(),
+			Remediation: "run 'infra login' to log in to a server",
+		}
+	case err != nil:
+		return doctorCheck{
+			Status:      doctorError,
+			Message:     fmt.Sprintf("failed to read the Infra config: %v", err),
+			Remediation: "fix or remove ~/.infra/config, and run 'infra login'",
+		}
+	}
+
+	d.hostConfig = hostConfig
+	return doctorCheck{
+		Status:  doctorOK,
+		Message: fmt.Sprintf("using context %q", hostConfig.contextName()),
+	}
+}
+
+func (d *doctor) checkServer(ctx context.Context) doctorCheck {
+	if d.hostConfig == nil {
+		return doctorCheck{Status: doctorSkipped, Message: "no server is configured"}
+	}
+
+	host := d.hostConfig.Host
+	if d.hostConfig.SkipTLSVerify {
+		// the certificate is not verified, but the server must still respond
+		if _, err := requestServerVersion(ctx, d.hostConfig); err != nil {
+			return doctorCheck{
+				Status:      doctorError,
+				Message:     fmt.Sprintf("failed to connect to %v: %v", host, err),
+				Remediation: "check your network connection, VPN, and proxy settings, and that the server address is correct",
+			}
+		}
+
+		d.serverReachable = true
+		return doctorCheck{
+			Status:      doctorWarning,
+			Message:     fmt.Sprintf("%v is reachable, but TLS verification is disabled", host),
+			Remediation: fmt.Sprintf("run 'infra login %v' without --skip-tls-verify to trust the certificate of the server", host),
+		}
+	}
+
+	err := attemptTLSRequest(loginCmdOptions{
+		Server:             host,
+		TrustedCertificate: d.hostConfig.TrustedCertificate,
+	})
+	var uaErr x509.UnknownAuthorityError
+	var hostErr x509.HostnameError
+	var certErr x509.CertificateInvalidError
+	switch {
+	case err == nil:
+	case errors.As(err, &uaErr):
+		return doctorCheck{
+			Status:      doctorError,
+			Message:     fmt.Sprintf("the certificate of %v is not trusted", host),
+			Remediation: fmt.Sprintf("run 'infra login %v' to verify and trust the certificate of the server", host),
+		}
+	case errors.As(err, &hostErr), errors.As(err, &certErr):
+		return doctorCheck{
+			Status:      doctorError,
+			Message:     fmt.Sprintf("the certificate of %v is not valid: %v", host, err),
+			Remediation: "ask the administrator of the server to renew its certificate, or check that the server address is correct",
+		}
+	default:
+		return doctorCheck{
+			Status:      doctorError,
+			Message:     fmt.Sprintf("failed to connect to %v: %v", host, err),
+			Remediation: "check your network connection, VPN, and proxy settings, and that the server address is correct",
+		}
+	}
+
+	d.serverReachable = true
+	return doctorCheck{
+		Status:  doctorOK,
+		Message: fmt.Sprintf("%v is reachable and its certificate is trusted", host),
+	}
+}
+
+func (d *doctor) checkClock(ctx context.Context) doctorCheck {
+	if !d.serverReachable {
+		return doctorCheck{Status: doctorSkipped, Message: "the server is not reachable"}
+	}
+
+	serverTime, err := serverClock(ctx, d.hostConfig)
+	if err != nil {
+		return doctorCheck{
+			Status:  doctorWarning,
+			Message: fmt.Sprintf("failed to read the time from the server: %v", err),
+		}
+	}
+
+	skew := time.Since(serverTime)
+	direction := "ahead of"
+	if skew < 0 {
+		skew, direction = -skew, "behind"
+	}
+	if skew > maxClockSkew {
+		return doctorCheck{
+			Status:      doctorError,
+			Message:     fmt.Sprintf("the local clock is %v %v the server", format.ExactDuration(skew.Round(time.Second)), direction),
+			Remediation: "synchronize your clock, for example by enabling automatic date and time (NTP) in your system settings",
+		}
+	}
+	return doctorCheck{Status: doctorOK, Message: "the local clock is in sync with the server"}
+}
+
+// serverClock returns the time reported by the Date header of a response from
+// the server.
+func serverClock(ctx context.Context, hostConfig *ClientHostConfig) (time.Time, error) {
+	header, err := requestServerVersion(ctx, hostConfig)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	date := header.Get("Date")
+	if date == "" {
+		return time.Time{}, fmt.Errorf("the response has no Date header")
+	}
+	return http.ParseTime(date)
+}
+
+// requestServerVersion sends a request to the version endpoint of the server,
+// with the TLS settings of hostConfig, and returns the headers of the response.
+func requestServerVersion(ctx context.Context, hostConfig *ClientHostConfig) (http.Header, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://"+hostConfig.Host+"/api/version", nil)
+	if err != nil {
+		return nil, err
+	}
+	httpClient := http.Client{Transport: httpTransportForHostConfig(hostConfig)}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	return resp.Header, nil
+}
+
+func (d *doctor) checkLogin(ctx context.Context) doctorCheck {
+	switch {
+	case d.hostConfig == nil:
+		return doctorCheck{Status: doctorSkipped, Message: "no server is configured"}
+	case d.hostConfig.AccessKey != "" && d.hostConfig.isExpired():
+		return doctorCheck{
+			Status:      doctorError,
+			Message:     fmt.Sprintf("your session expired %v", format.HumanTimeLower(time.Time(d.hostConfig.Expires), "")),
+			Remediation: "run 'infra login' to start a new session",
+		}
+	case !d.hostConfig.isLoggedIn():
+		return doctorCheck{
+			Status:      doctorError,
+			Message:     fmt.Sprintf("you are not logged in to %v", d.hostConfig.Host),
+			Remediation: "run 'infra login' to start a new session",
+		}
+	case !d.serverReachable:
+		return doctorCheck{Status: doctorSkipped, Message: "the server is not reachable"}
+	}
+
+	opts, err := apiClientFromHostConfig(d.hostConfig)
+	if err != nil {
+		return doctorCheck{Status: doctorError, Message: err.Error(), Remediation: "run 'infra login' to start a new session"}
+	}
+	// a failed check should not log the user out
+	opts.SkipLogoutOnUnauthorized = true
+	client, err := NewAPIClient(opts)
+	if err != nil {
+		return doctorCheck{Status: doctorError, Message: err.Error()}
+	}
+
+	user, err := client.GetUser(ctx, d.hostConfig.UserID)
+	switch {
+	case api.ErrorStatusCode(err) == http.StatusUnauthorized:
+		return doctorCheck{
+			Status:      doctorError,
+			Message:     "your session is not valid for this server",
+			Remediation: "run 'infra login' to start a new session",
+		}
+	case err != nil:
+		return doctorCheck{
+			Status:  doctorError,
+			Message: fmt.Sprintf("failed to get your user from the server: %v", err),
+		}
+	}
+
+	d.client = client
+	return doctorCheck{
+		Status: doctorOK,
+		Message: fmt.Sprintf("logged in as %v, the session expires %v",
+			user.Name, format.HumanTimeLower(time.Time(d.hostConfig.Expires), "never")),
+	}
+}
+
+func (d *doctor) checkAgent(context.Context) doctorCheck {
+	running, err := configAgentRunning()
+	switch {
+	case err != nil:
+		return doctorCheck{
+			Status:  doctorWarning,
+			Message: fmt.Sprintf("failed to check if the agent is running: %v", err),
+		}
+	case !running:
+		return doctorCheck{
+			Status:      doctorWarning,
+			Message:     "the Infra agent is not running, your kubeconfig and ssh config are not updated when your grants change",
+			Remediation: "run 'infra login' to start the agent",
+		}
+	}
+
+	infraDir, err := infraHomeDir()
+	if err != nil {
+		return doctorCheck{Status: doctorWarning, Message: err.Error()}
+	}
+	status, err := readAgentStatus(filepath.Join(infraDir, "agent.json"))
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return doctorCheck{Status: doctorOK, Message: "the Infra agent is running"}
+	case err != nil:
+		return doctorCheck{Status: doctorWarning, Message: err.Error()}
+	case status.LastError != "":
+		return doctorCheck{
+			Status: doctorWarning,
+			Message: fmt.Sprintf("the Infra agent failed to sync %v: %v",
+				format.HumanTimeLower(status.LastErrorAt, "recently"), status.LastError),
+			Remediation: "see ~/.infra/agent.log for details, or run 'infra login' to restart the agent",
+		}
+	}
+	return doctorCheck{
+		Status:  doctorOK,
+		Message: fmt.Sprintf("the Infra agent is running, last sync %v", format.HumanTimeLower(status.LastSync, "never")),
+	}
+}
+
+// loadGrants lists the destinations and grants of the user the first time it
+// is called.
+func (d *doctor) loadGrants() error {
+	if d.grants != nil {
+		return nil
+	}
+	_, destinations, grants, err := getUserDestinationGrants(d.client, "")
+	if err != nil {
+		return err
+	}
+	d.destinations, d.grants = destinations, grants
+	if d.grants == nil {
+		d.grants = []api.Grant{}
+	}
+	return nil
+}
+
+func (d *doctor) destinationsOfKind(kind string) []api.Destination {
+	var result []api.Destination
+	for _, dest := range d.destinations {
+		if dest.Kind == kind {
+			result = append(result, dest)
+		}
+	}
+	return result
+}
+
+func (d *doctor) checkKubeconfig(context.Context) doctorCheck {
+	if d.client == nil {
+		return doctorCheck{Status: doctorSkipped, Message: "not logged in"}
+	}
+	if err := d.loadGrants(); err != nil {
+		return doctorCheck{Status: doctorError, Message: fmt.Sprintf("failed to list your grants: %v", err)}
+	}
+
+	expected := kubeconfigContexts(d.destinationsOfKind("kubernetes"), d.grants)
+
+	kubeConfig, err := rawKubeconfig()
+	if err != nil {
+		return doctorCheck{
+			Status:      doctorError,
+			Message:     fmt.Sprintf("failed to read your kubeconfig: %v", err),
+			Remediation: "fix the kubeconfig file, or set KUBECONFIG to a valid file",
+		}
+	}
+
+	var problems []string
+	var missing, stale []string
+	for name := range expected {
+		kubeContext, ok := kubeConfig.Contexts[name]
+		if !ok {
+			missing = append(missing, name)
+			continue
+		}
+		authInfo, ok := kubeConfig.AuthInfos[kubeContext.AuthInfo]
+		if !ok || authInfo.Exec == nil {
+			problems = append(problems, fmt.Sprintf("context %v has no Infra credentials", name))
+			continue
+		}
+		if !fileExists(authInfo.Exec.Command) {
+			problems = append(problems, fmt.Sprintf("context %v runs %v, which does not exist", name, authInfo.Exec.Command))
+		}
+	}
+	for name := range kubeConfig.Contexts {
+		if _, ok := expected[name]; !ok && strings.HasPrefix(name, "infra:") {
+			stale = append(stale, name)
+		}
+	}
+	sort.Strings(missing)
+	sort.Strings(stale)
+	if len(missing) > 0 {
+		problems = append(problems, "missing contexts for your grants: "+strings.Join(missing, ", "))
+	}
+	if len(stale) > 0 {
+		problems = append(problems, "contexts without a grant: "+strings.Join(stale, ", "))
+	}
+
+	if len(problems) > 0 {
+		return doctorCheck{
+			Status:      doctorError,
+			Message:     strings.Join(problems, "; "),
+			Remediation: "run 'infra login' to update your kubeconfig",
+		}
+	}
+	filename, err := managedKubeconfigFilename()
+	if err != nil {
+		return doctorCheck{Status: doctorError, Message: fmt.Sprintf("failed to read your infra config: %v", err)}
+	}
+	if filename != "" {
+		kubeconfigEnv := filepath.SplitList(os.Getenv(clientcmd.RecommendedConfigPathEnvVar))
+		if !containsFilename(kubeconfigEnv, filename) {
+			return doctorCheck{
+				Status:      doctorWarning,
+				Message:     fmt.Sprintf("Infra contexts are written to %v, which is not in KUBECONFIG", filename),
+				Remediation: fmt.Sprintf("add the file to KUBECONFIG in your shell profile: %v", kubeconfigEnvInstructions(filename)),
+			}
+		}
+	}
+
+	return doctorCheck{
+		Status:  doctorOK,
+		Message: fmt.Sprintf("your kubeconfig has each of your %d Infra context(s)", len(expected)),
+	}
+}
+
+func (d *doctor) checkDestinations(ctx context.Context) doctorCheck {
+	if d.client == nil {
+		return doctorCheck{Status: doctorSkipped, Message: "not logged in"}
+	}
+	if err := d.loadGrants(); err != nil {
+		return doctorCheck{Status: doctorError, Message: fmt.Sprintf("failed to list your grants: %v", err)}
+	}
+
+	var checked int
+	var disconnected, unreachable []string
+	for _, dest := range d.destinations {
+		if !hasGrantForDestination(d.grants, dest) {
+			continue
+		}
+		checked++
+		if !isDestinationAvailable(dest) {
+			disconnected = append(disconnected, dest.Name)
+			continue
+		}
+		if err := dialDestination(ctx, dest); err != nil {
+			unreachable = append(unreachable, fmt.Sprintf("%v (%v)", dest.Name, err))
+		}
+	}
+
+	switch {
+	case len(unreachable) > 0:
+		return doctorCheck{
+			Status:      doctorError,
+			Message:     "failed to connect to " + strings.Join(unreachable, ", "),
+			Remediation: "check your network connection, VPN, and firewall settings",
+		}
+	case len(disconnected) > 0:
+		return doctorCheck{
+			Status:      doctorWarning,
+			Message:     "the connector is not connected for " + strings.Join(disconnected, ", "),
+			Remediation: "ask your administrator to check the Infra connector of these destinations",
+		}
+	}
+	return doctorCheck{
+		Status:  doctorOK,
+		Message: fmt.Sprintf("connected to %d destination(s)", checked),
+	}
+}
+
+func hasGrantForDestination(grants []api.Grant, dest api.Destination) bool {
+	for _, g := range grants {
+		if isResourceForDestination(g.Resource, dest.Name) {
+			return true
+		}
+	}
+	return false
+}
+
+// dialDestination opens, and closes, a TCP connection to the destination.
+func dialDestination(ctx context.Context, dest api.Destination) error {
+	u, err := urlx.Parse(dest.Connection.URL)
+	if err != nil {
+		return err
+	}
+	addr := u.Host
+	if u.Port() == "" {
+		addr = net.JoinHostPort(u.Hostname(), "443")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, doctorDialTimeout)
+	defer cancel()
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+func (d *doctor) checkSSHConfig(context.Context) doctorCheck {
+	if d.client == nil {
+		return doctorCheck{Status: doctorSkipped, Message: "not logged in"}
+	}
+	if err := d.loadGrants(); err != nil {
+		return doctorCheck{Status: doctorError, Message: fmt.Sprintf("failed to list your grants: %v", err)}
+	}
+
+	var granted bool
+	for _, dest := range d.destinationsOfKind("ssh") {
+		if hasGrantForDestination(d.grants, dest) {
+			granted = true
+			break
+		}
+	}
+	if !granted {
+		return doctorCheck{Status: doctorSkipped, Message: "you do not have access to any ssh destinations"}
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return doctorCheck{Status: doctorError, Message: err.Error()}
+	}
+	fh, err := os.Open(filepath.Join(homeDir, ".ssh", "config"))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return doctorCheck{Status: doctorError, Message: fmt.Sprintf("failed to read ~/.ssh/config: %v", err)}
+	}
+	var found bool
+	if fh != nil {
+		found = hasInfraMatchLine(fh)
+		_ = fh.Close()
+	}
+
+	if !found {
+		return doctorCheck{
+			Status:      doctorError,
+			Message:     "~/.ssh/config does not have the Infra Match line, ssh does not use Infra for your destinations",
+			Remediation: "run 'infra login --enable-ssh' to add the Infra Match line to ~/.ssh/config",
+		}
+	}
+	return doctorCheck{Status: doctorOK, Message: "~/.ssh/config includes the Infra ssh config"}
+}
@@ -0,0 +1,217 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+
+	"github.com/infrahq/infra/api"
+	"github.com/infrahq/infra/uid"
+)
+
+func TestDoctorCmd(t *testing.T) {
+	home := setupEnv(t)
+
+	runDoctorJSON := func(t *testing.T) (map[string]doctorCheck, error) {
+		t.Helper()
+		ctx, bufs := PatchCLI(context.Background())
+		err := Run(ctx, "doctor", "--format=json")
+
+		var checks []doctorCheck
+		assert.NilError(t, json.Unmarshal(bufs.Stdout.Bytes(), &checks))
+		result := make(map[string]doctorCheck, len(checks))
+		for _, check := range checks {
+			result[check.Name] = check
+		}
+		return result, err
+	}
+
+	statuses := func(checks map[string]doctorCheck) map[string]string {
+		result := make(map[string]string, len(checks))
+		for name, check := range checks {
+			result[name] = check.Status
+		}
+		return result
+	}
+
+	t.Run("not logged in", func(t *testing.T) {
+		checks, err := runDoctorJSON(t)
+		assert.ErrorContains(t, err, "infra doctor found 1 problem(s)")
+
+		expected := map[string]string{
+			"config":       doctorError,
+			"server":       doctorSkipped,
+			"clock":        doctorSkipped,
+			"login":        doctorSkipped,
+			"agent":        doctorWarning,
+			"kubeconfig":   doctorSkipped,
+			"destinations": doctorSkipped,
+			"ssh":          doctorSkipped,
+		}
+		assert.DeepEqual(t, statuses(checks), expected)
+		assert.Equal(t, checks["config"].Remediation, "run 'infra login' to log in to a server")
+	})
+
+	userID := uid.New()
+	var serverClockOffset time.Duration
+	var destinationURL string
+	handler := func(resp http.ResponseWriter, req *http.Request) {
+		resp.Header().Set("Date", time.Now().Add(serverClockOffset).UTC().Format(http.TimeFormat))
+
+		var body any
+		switch req.URL.Path {
+		case "/api/version":
+			body = api.Version{Version: "0.0.0"}
+		case "/api/users/" + userID.String():
+			body = api.User{ID: userID, Name: "testuser@example.com"}
+		case "/api/grants":
+			body = api.ListResponse[api.Grant]{
+				Items: []api.Grant{
+					{User: userID, Privilege: "view", Resource: "prod"},
+					{User: userID, Privilege: "connect", Resource: "bastion"},
+				},
+				Count: 2,
+			}
+		case "/api/destinations":
+			destinations := api.ListResponse[api.Destination]{
+				Items: []api.Destination{
+					{
+						Name:       "prod",
+						Kind:       "kubernetes",
+						Connected:  true,
+						Connection: api.DestinationConnection{URL: destinationURL, CA: destinationCA},
+					},
+					{
+						Name:       "bastion",
+						Kind:       "ssh",
+						Connected:  true,
+						Connection: api.DestinationConnection{URL: destinationURL},
+					},
+				},
+				Count: 2,
+			}
+			if kind := req.URL.Query().Get("kind"); kind != "" {
+				destinations.Items = []api.Destination{destinations.Items[0]}
+				destinations.Count = 1
+				assert.Equal(t, kind, "kubernetes")
+			}
+			body = destinations
+		default:
+			resp.WriteHeader(http.StatusNotFound)
+			return
+		}
+		assert.Check(t, json.NewEncoder(resp).Encode(body))
+	}
+	srv := httptest.NewTLSServer(http.HandlerFunc(handler))
+	t.Cleanup(srv.Close)
+	destinationURL = srv.Listener.Addr().String()
+
+	cfg := newTestClientConfig(srv, api.User{ID: userID})
+	assert.NilError(t, writeConfig(&cfg))
+
+	t.Run("kubeconfig and ssh config not updated", func(t *testing.T) {
+		checks, err := runDoctorJSON(t)
+		assert.ErrorContains(t, err, "infra doctor found 2 problem(s)")
+
+		expected := map[string]string{
+			"config":       doctorOK,
+			"server":       doctorOK,
+			"clock":        doctorOK,
+			"login":        doctorOK,
+			"agent":        doctorWarning,
+			"kubeconfig":   doctorError,
+			"destinations": doctorOK,
+			"ssh":          doctorError,
+		}
+		assert.DeepEqual(t, statuses(checks), expected)
+		assert.Equal(t, checks["kubeconfig"].Message, "missing contexts for your grants: infra:prod")
+		assert.Equal(t, checks["kubeconfig"].Remediation, "run 'infra login' to update your kubeconfig")
+	})
+
+	t.Run("healthy", func(t *testing.T) {
+		client, err := NewAPIClient(&APIClientOpts{
+			Host:      cfg.Hosts[0].Host,
+			AccessKey: cfg.Hosts[0].AccessKey,
+			Transport: httpTransportForHostConfig(&cfg.Hosts[0]),
+		})
+		assert.NilError(t, err)
+		assert.NilError(t, updateKubeconfig(client))
+
+		sshConfig := "Match exec \"infra ssh hosts %h %p\"\n    Include ~/.ssh/infra/config\n"
+		assert.NilError(t, os.MkdirAll(filepath.Join(home, ".ssh"), 0o700))
+		assert.NilError(t, os.WriteFile(filepath.Join(home, ".ssh", "config"), []byte(sshConfig), 0o600))
+
+		checks, err := runDoctorJSON(t)
+		assert.NilError(t, err)
+		for name, check := range checks {
+			if name == "agent" {
+				continue
+			}
+			assert.Equal(t, check.Status, doctorOK, "%v: %v", name, check.Message)
+		}
+	})
+
+	t.Run("clock skew", func(t *testing.T) {
+		serverClockOffset = 10 * time.Minute
+		t.Cleanup(func() {
+			serverClockOffset = 0
+		})
+
+		checks, err := runDoctorJSON(t)
+		assert.ErrorContains(t, err, "infra doctor found 1 problem(s)")
+		assert.Equal(t, checks["clock"].Status, doctorError)
+		assert.Assert(t, strings.HasSuffix(checks["clock"].Message, "behind the server"), checks["clock"].Message)
+	})
+
+	t.Run("destination not reachable", func(t *testing.T) {
+		orig := destinationURL
+		destinationURL = "127.0.0.1:1"
+		t.Cleanup(func() {
+			destinationURL = orig
+		})
+
+		checks, err := runDoctorJSON(t)
+		assert.ErrorContains(t, err, "problem(s)")
+		assert.Equal(t, checks["destinations"].Status, doctorError)
+		assert.Equal(t, checks["destinations"].Remediation, "check your network connection, VPN, and firewall settings")
+	})
+
+	t.Run("skip TLS verify", func(t *testing.T) {
+		cfg := newTestClientConfig(srv, api.User{ID: userID})
+		cfg.Hosts[0].SkipTLSVerify = true
+		cfg.Hosts[0].TrustedCertificate = ""
+		assert.NilError(t, writeConfig(&cfg))
+
+		checks, err := runDoctorJSON(t)
+		assert.NilError(t, err)
+		assert.Equal(t, checks["server"].Status, doctorWarning)
+		assert.Equal(t, checks["server"].Message, cfg.Hosts[0].Host+" is reachable, but TLS verification is disabled")
+		assert.Equal(t, checks["clock"].Status, doctorOK)
+
+		cfg.Hosts[0].Host = "127.0.0.1:1"
+		assert.NilError(t, writeConfig(&cfg))
+
+		checks, err = runDoctorJSON(t)
+		assert.ErrorContains(t, err, "problem(s)")
+		assert.Equal(t, checks["server"].Status, doctorError)
+		assert.Equal(t, checks["clock"].Status, doctorSkipped)
+	})
+
+	t.Run("session not valid", func(t *testing.T) {
+		cfg := newTestClientConfig(srv, api.User{ID: uid.New()})
+		assert.NilError(t, writeConfig(&cfg))
+
+		checks, err := runDoctorJSON(t)
+		assert.ErrorContains(t, err, "problem(s)")
+		assert.Equal(t, checks["login"].Status, doctorError)
+		assert.Equal(t, checks["kubeconfig"].Status, doctorSkipped)
+	})
+}
@@ -41,6 +41,7 @@ func newGrantsCmd(cli *CLI) *cobra.Command {
 
 func newGrantsListCmd(cli *CLI) *cobra.Command {
 	var options grantsCmdOptions
+	var output outputOptions
 
 	cmd := &cobra.Command{
 		Use:     "list",
@@ -77,7 +78,7 @@ func newGrantsListCmd(cli *CLI) *cobra.Command {
 
 			if options.GroupName != "" {
 				if options.Inherited {
-					cli.Output("Warning: using --inherited with a group does nothing")
+					fmt.Fprintln(cli.Stderr, "Warning: using --inherited with a group does nothing")
 				}
 				group, err := getGroupByNameOrID(client, options.GroupName)
 				if err != nil {
@@ -92,6 +93,19 @@ func newGrantsListCmd(cli *CLI) *cobra.Command {
 				return err
 			}
 
+			if !output.isTable() {
+				// only csv uses the rows, which require the names of all
+				// users and groups
+				var rows []grantRow
+				if output.Format == outputCSV && output.Template == "" {
+					rows, err = grantRows(ctx, client, grants)
+					if err != nil {
+						return err
+					}
+				}
+				return writeOutput(cli, output, grants, rows)
+			}
+
 			numUserGrants, err := userGrants(ctx, cli, client, &grants)
 			if err != nil {
 				return err
@@ -119,9 +133,57 @@ func newGrantsListCmd(cli *CLI) *cobra.Command {
 	cmd.Flags().StringVar(&options.UserName, "user", "", "Filter by user name or id")
 	cmd.Flags().BoolVar(&options.Inherited, "inherited", false, "Include grants a user inherited through a group")
 	cmd.Flags().StringVar(&options.Role, "role", "", "Filter by user role")
+	addFormatFlag(cmd.Flags(), &output)
+	completeFlag(cmd, "destination", completeNamesFrom(completeDestinations))
+	completeFlag(cmd, "resource", completeNamesFrom(completeResources))
+	completeFlag(cmd, "group", completeNamesFrom(completeGroups))
+	completeFlag(cmd, "user", completeNamesFrom(completeUsers))
+	completeFlag(cmd, "role", completeGrantRole)
 	return cmd
 }
 
+type grantRow struct {
+	User     string `header:"USER"`
+	Group    string `header:"GROUP"`
+	Role     string `header:"ROLE"`
+	Resource string `header:"DESTINATION"`
+}
+
+// grantRows returns a row for each grant, with the name of the user or group,
+// for the csv format of 'infra grants list'.
+func grantRows(ctx context.Context, client *api.Client, grants []api.Grant) ([]grantRow, error) {
+	users, err := listAll(ctx, client.ListUsers, api.ListUsersRequest{})
+	if err != nil {
+		return nil, err
+	}
+	userNames := make(map[uid.ID]string, len(users))
+	for _, u := range users {
+		userNames[u.ID] = u.Name
+	}
+
+	groups, err := listAll(ctx, client.ListGroups, api.ListGroupsRequest{})
+	if err != nil {
+		return nil, err
+	}
+	groupNames := make(map[uid.ID]string, len(groups))
+	for _, g := range groups {
+		groupNames[g.ID] = g.Name
+	}
+
+	rows := make([]grantRow, 0, len(grants))
+	for _, g := range grants {
+		row := grantRow{Role: g.Privilege, Resource: g.Resource}
+		switch {
+		case g.User != 0:
+			row.User = userNames[g.User]
+		case g.Group != 0:
+			row.Group = groupNames[g.Group]
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
 func userGrants(ctx context.Context, cli *CLI, client *api.Client, grants *[]api.Grant) (int, error) {
 	users, err := listAll(ctx, client.ListUsers, api.ListUsersRequest{})
 	if err != nil {
@@ -228,7 +290,8 @@ $ infra grants remove janedoe@example.com staging --role viewer
 # Remove adminaccess to infra
 $ infra grants remove janedoe@example.com infra --role admin
 `,
-		Args: ExactArgs(2),
+		Args:              ExactArgs(2),
+		ValidArgsFunction: completeGrantArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			if isGroup {
 				options.GroupName = args[0]
@@ -243,6 +306,7 @@ $ infra grants remove janedoe@example.com infra --role admin
 	cmd.Flags().BoolVarP(&isGroup, "group", "g", false, "Group to revoke access from")
 	cmd.Flags().StringVar(&options.Role, "role", "", "Role to revoke")
 	cmd.Flags().BoolVar(&options.Force, "force", false, "Exit successfully even if grant does not exist")
+	completeFlag(cmd, "role", completeGrantRole)
 
 	return cmd
 }
@@ -337,7 +401,8 @@ $ infra grants add johndoe@example.com staging --role viewer
 # Assign a user a role within Infra
 $ infra grants add johndoe@example.com infra --role admin
 `,
-		Args: ExactArgs(2),
+		Args:              ExactArgs(2),
+		ValidArgsFunction: completeGrantArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			if isGroup {
 				options.GroupName = args[0]
@@ -352,6 +417,7 @@ $ infra grants add johndoe@example.com infra --role admin
 	cmd.Flags().BoolVarP(&isGroup, "group", "g", false, "When set, creates a grant for a group instead of a user")
 	cmd.Flags().StringVar(&options.Role, "role", models.BasePermissionConnect, "Type of access that the user or group will be given")
 	cmd.Flags().BoolVar(&options.Force, "force", false, "Create grant even if requested user, destination, or role are unknown")
+	completeFlag(cmd, "role", completeGrantRole)
 	return cmd
 }
 
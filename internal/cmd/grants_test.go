@@ -13,6 +13,7 @@ import (
 	"gotest.tools/v3/assert"
 
 	"github.com/infrahq/infra/api"
+	"github.com/infrahq/infra/uid"
 )
 
 func TestGrantsAddCmd(t *testing.T) {
@@ -394,3 +395,85 @@ func readChan(ch chan string) []string {
 func requestMatchesPrefix(req *http.Request, method string, path string) bool {
 	return req.Method == method && strings.HasPrefix(req.URL.Path, path)
 }
+
+func TestGrantsListCmd(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home) // for windows
+
+	var listedNames int
+	setup := func(t *testing.T) {
+		listedNames = 0
+		handler := func(resp http.ResponseWriter, req *http.Request) {
+			switch {
+			case requestMatches(req, http.MethodGet, "/api/grants"):
+				writeResponse(t, resp, api.ListResponse[api.Grant]{
+					Count: 2,
+					Items: []api.Grant{
+						{ID: 1, User: 3000, Privilege: "admin", Resource: "prod"},
+						{ID: 2, Group: 4000, Privilege: "view", Resource: "dev.web"},
+					},
+				})
+			case requestMatches(req, http.MethodGet, "/api/users"):
+				listedNames++
+				writeResponse(t, resp, api.ListResponse[api.User]{
+					Count: 1,
+					Items: []api.User{{ID: 3000, Name: "alice@example.com"}},
+				})
+			case requestMatches(req, http.MethodGet, "/api/groups"):
+				listedNames++
+				writeResponse(t, resp, api.ListResponse[api.Group]{
+					Count: 1,
+					Items: []api.Group{{ID: 4000, Name: "developers"}},
+				})
+			default:
+				resp.WriteHeader(http.StatusBadRequest)
+			}
+		}
+
+		srv := httptest.NewTLSServer(http.HandlerFunc(handler))
+		t.Cleanup(srv.Close)
+
+		cfg := newTestClientConfig(srv, api.User{})
+		assert.NilError(t, writeConfig(&cfg))
+	}
+
+	t.Run("json", func(t *testing.T) {
+		setup(t)
+		ctx, bufs := PatchCLI(context.Background())
+		err := Run(ctx, "grants", "list", "--format=json")
+		assert.NilError(t, err)
+
+		var grants []api.Grant
+		assert.NilError(t, json.Unmarshal(bufs.Stdout.Bytes(), &grants))
+		assert.Equal(t, len(grants), 2)
+		assert.Equal(t, grants[0].User, uid.ID(3000))
+		assert.Equal(t, grants[1].Group, uid.ID(4000))
+		// the names of users and groups are only needed for csv
+		assert.Equal(t, listedNames, 0)
+	})
+
+	t.Run("csv", func(t *testing.T) {
+		setup(t)
+		ctx, bufs := PatchCLI(context.Background())
+		err := Run(ctx, "grants", "list", "--format=csv")
+		assert.NilError(t, err)
+
+		expected := `USER,GROUP,ROLE,DESTINATION
+alice@example.com,,admin,prod
+,developers,view,dev.web
+`
+		assert.Equal(t, bufs.Stdout.String(), expected)
+	})
+
+	t.Run("inherited with a group warns on stderr", func(t *testing.T) {
+		setup(t)
+		ctx, bufs := PatchCLI(context.Background())
+		err := Run(ctx, "grants", "list", "--group", "developers", "--inherited", "--format=json")
+		assert.NilError(t, err)
+
+		assert.Equal(t, bufs.Stderr.String(), "Warning: using --inherited with a group does nothing\n")
+		var grants []api.Grant
+		assert.NilError(t, json.Unmarshal(bufs.Stdout.Bytes(), &grants))
+	})
+}
@@ -59,6 +59,7 @@ func newGroupsCmd(cli *CLI) *cobra.Command {
 func newGroupsListCmd(cli *CLI) *cobra.Command {
 	var noTruncate bool
 	var numUsers int
+	var output outputOptions
 	cmd := &cobra.Command{
 		Use:     "list",
 		Aliases: []string{"ls"},
@@ -118,17 +119,16 @@ func newGroupsListCmd(cli *CLI) *cobra.Command {
 				})
 			}
 
-			if len(rows) > 0 {
-				printTable(rows, cli.Stdout)
-			} else {
+			if output.isTable() && len(rows) == 0 {
 				cli.Output("No groups found")
+				return nil
 			}
-
-			return nil
+			return writeOutput(cli, output, groups, rows)
 		},
 	}
 	cmd.Flags().BoolVar(&noTruncate, "no-truncate", false, "Do not truncate the list of users for each group")
 	cmd.Flags().IntVar(&numUsers, "num-users", 8, "The number of users to display in each group")
+	addFormatFlag(cmd.Flags(), &output)
 	return cmd
 }
 
@@ -161,10 +161,11 @@ func newGroupsRemoveCmd(cli *CLI) *cobra.Command {
 	var force bool
 
 	cmd := &cobra.Command{
-		Use:     "remove GROUP",
-		Aliases: []string{"rm"},
-		Short:   "Delete a group",
-		Args:    ExactArgs(1),
+		Use:               "remove GROUP",
+		Aliases:           []string{"rm"},
+		Short:             "Delete a group",
+		Args:              ExactArgs(1),
+		ValidArgsFunction: completeArgs([]completionSource{completeGroups}),
 		Example: `# Delete a group
 $ infra groups remove Engineering`,
 		RunE: func(cmd *cobra.Command, args []string) error {
@@ -204,9 +205,10 @@ $ infra groups remove Engineering`,
 
 func newGroupsAddUserCmd(cli *CLI) *cobra.Command {
 	return &cobra.Command{
-		Use:   "adduser USER GROUP",
-		Short: "Add a user to a group",
-		Args:  ExactArgs(2),
+		Use:               "adduser USER GROUP",
+		Short:             "Add a user to a group",
+		Args:              ExactArgs(2),
+		ValidArgsFunction: completeArgs([]completionSource{completeUsers}, []completionSource{completeGroups}),
 		Example: `# Add a user to a group
 $ infra groups adduser johndoe@example.com Engineering
 `,
@@ -256,10 +258,11 @@ $ infra groups adduser johndoe@example.com Engineering
 func newGroupsRemoveUserCmd(cli *CLI) *cobra.Command {
 	var force bool
 	cmd := &cobra.Command{
-		Use:     "removeuser USER GROUP",
-		Short:   "Remove a user from a group",
-		Aliases: []string{"rmuser"},
-		Args:    ExactArgs(2),
+		Use:               "removeuser USER GROUP",
+		Short:             "Remove a user from a group",
+		Aliases:           []string{"rmuser"},
+		Args:              ExactArgs(2),
+		ValidArgsFunction: completeArgs([]completionSource{completeUsers}, []completionSource{completeGroups}),
 		Example: `# Remove a user from a group
 $ infra groups removeuser johndoe@example.com Engineering
 `,
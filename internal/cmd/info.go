@@ -12,18 +12,30 @@ import (
 )
 
 func newInfoCmd(cli *CLI) *cobra.Command {
-	return &cobra.Command{
+	var output outputOptions
+	cmd := &cobra.Command{
 		Use:     "info",
 		Short:   "Display the info about the current session",
 		Args:    NoArgs,
 		GroupID: groupOther,
 		RunE: func(cmd *cobra.Command, _ []string) error {
-			return info(cli)
+			return info(cli, output)
 		},
 	}
+
+	addFormatFlag(cmd.Flags(), &output)
+	return cmd
+}
+
+// sessionInfo is the output of 'infra info' in the json and yaml formats.
+type sessionInfo struct {
+	Server   string        `json:"server"`
+	User     *api.User     `json:"user"`
+	Provider *api.Provider `json:"provider,omitempty"`
+	Groups   []api.Group   `json:"groups"`
 }
 
-func info(cli *CLI) error {
+func info(cli *CLI, output outputOptions) error {
 	config, err := currentHostConfig()
 	if err != nil {
 		return err
@@ -36,6 +48,10 @@ func info(cli *CLI) error {
 
 	ctx := context.Background()
 
+	if config.UserID == 0 {
+		return fmt.Errorf("no active user")
+	}
+
 	user, err := client.GetUser(ctx, config.UserID)
 	if err != nil {
 		if api.ErrorStatusCode(err) == 401 {
@@ -44,44 +60,54 @@ func info(cli *CLI) error {
 		return err
 	}
 
-	w := tabwriter.NewWriter(cli.Stdout, 0, 0, 1, ' ', tabwriter.AlignRight)
-	defer w.Flush()
-
-	fmt.Fprintln(w)
-	fmt.Fprintf(w, "Server:\t %s\n", config.Host)
-
-	if config.UserID == 0 {
-		return fmt.Errorf("no active user")
-	}
-
-	fmt.Fprintf(w, "User:\t %s (%s)\n", user.Name, user.ID)
+	result := sessionInfo{Server: config.Host, User: user}
 
 	if config.ProviderID != 0 {
-		provider, err := client.GetProvider(ctx, config.ProviderID)
+		result.Provider, err = client.GetProvider(ctx, config.ProviderID)
 		if err != nil {
 			return err
 		}
-
-		fmt.Fprintf(w, "Identity Provider:\t %s (%s)\n", provider.Name, provider.URL)
 	}
 
-	userGroups, err := listAll(ctx, client.ListGroups, api.ListGroupsRequest{UserID: config.UserID})
+	result.Groups, err = listAll(ctx, client.ListGroups, api.ListGroupsRequest{UserID: config.UserID})
 	if err != nil {
 		return err
 	}
 
-	groups := "(none)"
+	groupNames := make([]string, 0, len(result.Groups))
+	for _, userGroup := range result.Groups {
+		groupNames = append(groupNames, userGroup.Name)
+	}
 
-	if len(userGroups) > 0 {
-		g := make([]string, 0, len(userGroups))
-		for _, userGroup := range userGroups {
-			g = append(g, userGroup.Name)
+	if !output.isTable() {
+		type row struct {
+			Server   string `header:"SERVER"`
+			User     string `header:"USER"`
+			Provider string `header:"PROVIDER"`
+			Groups   string `header:"GROUPS"`
+		}
+		r := row{Server: result.Server, User: user.Name, Groups: strings.Join(groupNames, ", ")}
+		if result.Provider != nil {
+			r.Provider = result.Provider.Name
 		}
+		return writeOutput(cli, output, result, []row{r})
+	}
 
-		groups = strings.Join(g, ", ")
+	w := tabwriter.NewWriter(cli.Stdout, 0, 0, 1, ' ', tabwriter.AlignRight)
+	defer w.Flush()
 
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "Server:\t %s\n", config.Host)
+	fmt.Fprintf(w, "User:\t %s (%s)\n", user.Name, user.ID)
+
+	if result.Provider != nil {
+		fmt.Fprintf(w, "Identity Provider:\t %s (%s)\n", result.Provider.Name, result.Provider.URL)
 	}
 
+	groups := "(none)"
+	if len(groupNames) > 0 {
+		groups = strings.Join(groupNames, ", ")
+	}
 	fmt.Fprintf(w, "Groups:\t %s\n", groups)
 
 	fmt.Fprintln(w)
@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gotest.tools/v3/assert"
+
+	"github.com/infrahq/infra/api"
+	"github.com/infrahq/infra/uid"
+)
+
+func TestInfoCmd(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home) // for windows
+
+	userID := uid.New()
+	var host string
+	setup := func(t *testing.T) {
+		handler := func(resp http.ResponseWriter, req *http.Request) {
+			switch {
+			case requestMatches(req, http.MethodGet, fmt.Sprintf("/api/users/%s", userID)):
+				writeResponse(t, resp, api.User{ID: userID, Name: "alice@example.com"})
+			case requestMatches(req, http.MethodGet, "/api/groups") && req.URL.Query().Get("userID") == userID.String():
+				writeResponse(t, resp, api.ListResponse[api.Group]{
+					Count: 2,
+					Items: []api.Group{{ID: 4000, Name: "developers"}, {ID: 4001, Name: "admins"}},
+				})
+			default:
+				resp.WriteHeader(http.StatusBadRequest)
+			}
+		}
+
+		srv := httptest.NewTLSServer(http.HandlerFunc(handler))
+		t.Cleanup(srv.Close)
+
+		cfg := newTestClientConfig(srv, api.User{ID: userID, Name: "alice@example.com"})
+		assert.NilError(t, writeConfig(&cfg))
+		host = cfg.Hosts[0].Host
+	}
+
+	t.Run("json", func(t *testing.T) {
+		setup(t)
+		ctx, bufs := PatchCLI(context.Background())
+		err := Run(ctx, "info", "--format=json")
+		assert.NilError(t, err)
+
+		var actual sessionInfo
+		assert.NilError(t, json.Unmarshal(bufs.Stdout.Bytes(), &actual))
+		assert.Equal(t, actual.Server, host)
+		assert.Equal(t, actual.User.ID, userID)
+		assert.Equal(t, actual.User.Name, "alice@example.com")
+		assert.Assert(t, actual.Provider == nil)
+		assert.Equal(t, len(actual.Groups), 2)
+	})
+
+	t.Run("csv", func(t *testing.T) {
+		setup(t)
+		ctx, bufs := PatchCLI(context.Background())
+		err := Run(ctx, "info", "--format=csv")
+		assert.NilError(t, err)
+
+		expected := fmt.Sprintf("SERVER,USER,PROVIDER,GROUPS\n%s,alice@example.com,,\"developers, admins\"\n", host)
+		assert.Equal(t, bufs.Stdout.String(), expected)
+	})
+}
@@ -39,7 +39,9 @@ type keyCreateOptions struct {
 	Expiry            time.Duration
 	InactivityTimeout time.Duration
 	Connector         bool
+	Destination       string
 	Quiet             bool
+	RateLimit         int
 }
 
 func newKeysAddCmd(cli *CLI) *cobra.Command {
@@ -57,6 +59,11 @@ $ infra keys add --expiry=12h --name example-key
 # Create an access key to add a Kubernetes connection to Infra
 $ infra keys add --connector
 
+# Create a connector access key that can only send activity for 'production'.
+# Connector keys created without --destination can send activity for any
+# registered destination.
+$ infra keys add --connector --destination production
+
 # Set an environment variable with the newly created access key
 $ MY_ACCESS_KEY=$(infra keys add -q --name my-key)
 `,
@@ -69,6 +76,10 @@ $ MY_ACCESS_KEY=$(infra keys add -q --name my-key)
 				}
 			}
 
+			if options.Destination != "" && !options.Connector {
+				return Error{Message: "--destination can only be used with --connector"}
+			}
+
 			client, err := cli.apiClient()
 			if err != nil {
 				return err
@@ -106,6 +117,8 @@ $ MY_ACCESS_KEY=$(infra keys add -q --name my-key)
 				Name:              options.Name,
 				Expiry:            api.Duration(options.Expiry),
 				InactivityTimeout: api.Duration(options.InactivityTimeout),
+				RateLimit:         options.RateLimit,
+				Destination:       options.Destination,
 			})
 			if err != nil {
 				if api.ErrorStatusCode(err) == 403 {
@@ -153,10 +166,14 @@ $ MY_ACCESS_KEY=$(infra keys add -q --name my-key)
 
 	cmd.Flags().StringVar(&options.Name, "name", "", "The name of the access key")
 	cmd.Flags().StringVar(&options.UserName, "user", "", "The name of the user who will own the key")
+	completeFlag(cmd, "user", completeNamesFrom(completeUsers))
 	cmd.Flags().BoolVar(&options.Connector, "connector", false, "Create the key for the connector")
+	cmd.Flags().StringVar(&options.Destination, "destination", "", "Bind the connector key to a destination")
+	completeFlag(cmd, "destination", completeNamesFrom(completeDestinations))
 	cmd.Flags().BoolVarP(&options.Quiet, "quiet", "q", false, "Only display the access key")
 	cmd.Flags().DurationVar(&options.Expiry, "expiry", oneYear, "The total time that the access key will be valid for")
 	cmd.Flags().DurationVar(&options.InactivityTimeout, "inactivity-timeout", thirtyDays, "A specified deadline that the access key must be used within to remain valid")
+	cmd.Flags().IntVar(&options.RateLimit, "rate-limit", 0, "The number of requests per minute allowed for the key. 0 means only the organization limit applies")
 
 	return cmd
 }
@@ -171,10 +188,11 @@ func newKeysRemoveCmd(cli *CLI) *cobra.Command {
 	var options keyRemoveOptions
 
 	cmd := &cobra.Command{
-		Use:     "remove KEY",
-		Aliases: []string{"rm"},
-		Short:   "Delete an access key",
-		Args:    ExactArgs(1),
+		Use:               "remove KEY",
+		Aliases:           []string{"rm"},
+		Short:             "Delete an access key",
+		Args:              ExactArgs(1),
+		ValidArgsFunction: completeArgs([]completionSource{completeKeys}),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			ctx := context.Background()
 			client, err := cli.apiClient()
@@ -249,6 +267,7 @@ func newKeysRemoveCmd(cli *CLI) *cobra.Command {
 
 	cmd.Flags().BoolVar(&options.Force, "force", false, "Exit successfully even if access key does not exist")
 	cmd.Flags().StringVar(&options.UserName, "user", "", "The name of the user who owns the key")
+	completeFlag(cmd, "user", completeNamesFrom(completeUsers))
 	cmd.Flags().BoolVar(&options.Connector, "connector", false, "Remove a key for the connector")
 
 	return cmd
@@ -262,6 +281,7 @@ type keyListOptions struct {
 
 func newKeysListCmd(cli *CLI) *cobra.Command {
 	var options keyListOptions
+	var output outputOptions
 
 	cmd := &cobra.Command{
 		Use:     "list",
@@ -338,18 +358,18 @@ func newKeysListCmd(cli *CLI) *cobra.Command {
 				})
 			}
 
-			if len(rows) > 0 {
-				printTable(rows, cli.Stdout)
-			} else {
+			if output.isTable() && len(rows) == 0 {
 				cli.Output("No access keys found")
+				return nil
 			}
-
-			return nil
+			return writeOutput(cli, output, keys, rows)
 		},
 	}
 
 	cmd.Flags().BoolVar(&options.AllUsers, "all", false, "Show keys for all users")
 	cmd.Flags().StringVar(&options.UserName, "user", "", "The name of a user to list access keys for")
 	cmd.Flags().BoolVar(&options.ShowExpired, "show-expired", false, "Show expired access keys")
+	addFormatFlag(cmd.Flags(), &output)
+	completeFlag(cmd, "user", completeNamesFrom(completeUsers))
 	return cmd
 }
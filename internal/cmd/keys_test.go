@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"context"
+	"encoding/csv"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
@@ -231,4 +232,32 @@ func TestKeysListCmd(t *testing.T) {
 
 		golden.Assert(t, bufs.Stdout.String(), t.Name())
 	})
+
+	t.Run("list json", func(t *testing.T) {
+		setup(t)
+		ctx, bufs := PatchCLI(context.Background())
+
+		err := Run(ctx, "keys", "list", "--format=json")
+		assert.NilError(t, err)
+
+		var keys []api.AccessKey
+		assert.NilError(t, json.Unmarshal(bufs.Stdout.Bytes(), &keys))
+		assert.Equal(t, len(keys), 3)
+		assert.Equal(t, keys[1].Name, "side-door")
+		assert.Equal(t, keys[1].IssuedForName, "admin")
+	})
+
+	t.Run("list csv", func(t *testing.T) {
+		setup(t)
+		ctx, bufs := PatchCLI(context.Background())
+
+		err := Run(ctx, "keys", "list", "--format=csv")
+		assert.NilError(t, err)
+
+		records, err := csv.NewReader(bufs.Stdout).ReadAll()
+		assert.NilError(t, err)
+		assert.Equal(t, len(records), 4)
+		assert.DeepEqual(t, records[0], []string{"NAME", "ISSUED FOR", "CREATED", "LAST USED", "EXPIRES", "INACTIVITY TIMEOUT"})
+		assert.DeepEqual(t, records[3][:2], []string{"storage", "clerk"})
+	})
 }
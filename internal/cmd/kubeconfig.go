@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"runtime"
+
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/infrahq/infra/internal/logging"
+)
+
+func newKubeconfigCmd(cli *CLI) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "kubeconfig",
+		Short: "Manage where Infra writes Kubernetes contexts",
+		Long: `Manage where Infra writes Kubernetes contexts.
+
+By default Infra writes a context for each cluster you have access to in your
+default kubeconfig. Use 'infra kubeconfig use-file' to write the contexts to a
+separate file instead, and add the file to KUBECONFIG so that kubectl reads
+both files.
+
+Each cluster has a context named infra:CLUSTER, and each namespace you have
+been granted access to has a context named infra:CLUSTER:NAMESPACE.`,
+		GroupID: groupOther,
+	}
+
+	cmd.AddCommand(newKubeconfigUseFileCmd(cli))
+	cmd.AddCommand(newKubeconfigUseDefaultCmd(cli))
+	return cmd
+}
+
+func newKubeconfigUseFileCmd(cli *CLI) *cobra.Command {
+	return &cobra.Command{
+		Use:   "use-file [FILE]",
+		Short: "Write Infra contexts to a separate kubeconfig file",
+		Long: `Write Infra contexts to a separate kubeconfig file.
+
+FILE defaults to ~/.kube/infra. The Infra contexts are removed from the
+kubeconfig they were written to before.`,
+		Example: `# Write Infra contexts to ~/.kube/infra
+$ infra kubeconfig use-file`,
+		Args: MaxArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			filename, err := defaultManagedKubeconfigFilename()
+			if err != nil {
+				return err
+			}
+			if len(args) > 0 {
+				filename = args[0]
+			}
+			filename, err = filepath.Abs(filename)
+			if err != nil {
+				return err
+			}
+			if filepath.Clean(clientcmd.NewDefaultClientConfigLoadingRules().GetDefaultFilename()) == filename {
+				return Error{Message: fmt.Sprintf("%v is your default kubeconfig, use 'infra kubeconfig use-default' to write Infra contexts to it", filename)}
+			}
+
+			config, err := readConfig()
+			if err != nil {
+				return err
+			}
+			if config.KubeconfigFile == filename {
+				fmt.Fprintf(cli.Stderr, "Infra contexts are already written to %v.\n", filename)
+				return nil
+			}
+
+			if err := setKubeconfigFile(cli, config, filename); err != nil {
+				return err
+			}
+
+			fmt.Fprintf(cli.Stderr, "Infra contexts are written to %v.\n", filename)
+			fmt.Fprintf(cli.Stderr, "To use them with kubectl, add the file to KUBECONFIG in your shell profile:\n\n")
+			fmt.Fprintf(cli.Stderr, "  %v\n\n", kubeconfigEnvInstructions(filename))
+			return nil
+		},
+	}
+}
+
+func newKubeconfigUseDefaultCmd(cli *CLI) *cobra.Command {
+	return &cobra.Command{
+		Use:   "use-default",
+		Short: "Write Infra contexts to your default kubeconfig",
+		Args:  NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			config, err := readConfig()
+			if err != nil {
+				return err
+			}
+			previous := config.KubeconfigFile
+			if previous == "" {
+				fmt.Fprintln(cli.Stderr, "Infra contexts are already written to your default kubeconfig.")
+				return nil
+			}
+
+			if err := setKubeconfigFile(cli, config, ""); err != nil {
+				return err
+			}
+
+			fmt.Fprintln(cli.Stderr, "Infra contexts are written to your default kubeconfig.")
+			fmt.Fprintf(cli.Stderr, "You can remove %v from KUBECONFIG.\n", previous)
+			return nil
+		},
+	}
+}
+
+// setKubeconfigFile removes the Infra contexts from the kubeconfig they are
+// written to now, and writes them to filename. An empty filename is the
+// default kubeconfig.
+func setKubeconfigFile(cli *CLI, config *ClientConfig, filename string) error {
+	if err := clearKubeconfig(); err != nil {
+		logging.Warnf("failed to remove Infra contexts from the previous kubeconfig: %v", err)
+	}
+
+	config.KubeconfigFile = filename
+	if err := writeConfig(config); err != nil {
+		return err
+	}
+
+	if _, err := currentHostConfig(); err != nil {
+		// not logged in, the contexts are written by the next 'infra login'
+		return nil
+	}
+	client, err := cli.apiClient()
+	if err != nil {
+		return err
+	}
+	if err := updateKubeconfig(client); err != nil {
+		logging.Warnf("failed to write Infra contexts, run 'infra login' to write them: %v", err)
+	}
+	return nil
+}
+
+// kubeconfigEnvInstructions returns the shell command that adds filename to
+// KUBECONFIG, after the default kubeconfig.
+func kubeconfigEnvInstructions(filename string) string {
+	if runtime.GOOS == "windows" {
+		return fmt.Sprintf(`$env:KUBECONFIG = "$HOME\.kube\config;%v"`, filename)
+	}
+	return fmt.Sprintf(`export KUBECONFIG="$HOME/.kube/config:%v"`, filename)
+}
@@ -1,7 +1,9 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"strings"
@@ -14,15 +16,99 @@ import (
 	"github.com/infrahq/infra/internal/logging"
 )
 
-func clientConfig() clientcmd.ClientConfig {
+func clientConfig() (clientcmd.ClientConfig, error) {
 	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
 	loadingRules.WarnIfAllMissing = false
 
-	return clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, &clientcmd.ConfigOverrides{})
+	// include the managed kubeconfig, so that the Infra contexts are found
+	// even when KUBECONFIG does not include the file.
+	filename, err := managedKubeconfigFilename()
+	if err != nil {
+		return nil, err
+	}
+	if filename != "" && !containsFilename(loadingRules.Precedence, filename) {
+		loadingRules.Precedence = append(loadingRules.Precedence, filename)
+	}
+
+	return clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, &clientcmd.ConfigOverrides{}), nil
+}
+
+// rawKubeconfig returns the kubeconfig merged from all the files in the
+// loading precedence, and the managed kubeconfig.
+func rawKubeconfig() (clientcmdapi.Config, error) {
+	config, err := clientConfig()
+	if err != nil {
+		return clientcmdapi.Config{}, err
+	}
+	return config.RawConfig()
+}
+
+// managedKubeconfigFilename returns the file that Infra contexts are written
+// to, or an empty string when they are written to the default kubeconfig.
+// The access keys are not read, so that kubectl does not need the credential
+// store to find the kubeconfig.
+func managedKubeconfigFilename() (string, error) {
+	config, err := readConfigWithoutAccessKeys()
+	if err != nil {
+		return "", fmt.Errorf("read config: %w", err)
+	}
+	return config.KubeconfigFile, nil
+}
+
+// defaultManagedKubeconfigFilename returns the default file used by
+// 'infra kubeconfig use-file'.
+func defaultManagedKubeconfigFilename() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, clientcmd.RecommendedHomeDir, "infra"), nil
+}
+
+func containsFilename(filenames []string, filename string) bool {
+	for _, f := range filenames {
+		if filepath.Clean(f) == filepath.Clean(filename) {
+			return true
+		}
+	}
+	return false
+}
+
+// loadInfraKubeconfig returns the kubeconfig that Infra contexts are written
+// to, and its filename. The managed kubeconfig is empty when the file does
+// not exist yet.
+func loadInfraKubeconfig() (string, *clientcmdapi.Config, error) {
+	filename, err := managedKubeconfigFilename()
+	if err != nil {
+		return "", nil, err
+	}
+	if filename != "" {
+		kubeConfig, err := clientcmd.LoadFromFile(filename)
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			return filename, clientcmdapi.NewConfig(), nil
+		case err != nil:
+			return "", nil, err
+		}
+		return filename, kubeConfig, nil
+	}
+
+	defaultConfig, err := clientConfig()
+	if err != nil {
+		return "", nil, err
+	}
+	kubeConfig, err := defaultConfig.RawConfig()
+	if err != nil {
+		return "", nil, err
+	}
+	return defaultConfig.ConfigAccess().GetDefaultFilename(), &kubeConfig, nil
 }
 
 func kubernetesSetContext(cli *CLI, cluster, namespace string) error {
-	config := clientConfig()
+	config, err := clientConfig()
+	if err != nil {
+		return err
+	}
 
 	kubeConfig, err := config.RawConfig()
 	if err != nil {
@@ -31,6 +117,12 @@ func kubernetesSetContext(cli *CLI, cluster, namespace string) error {
 
 	name := strings.TrimPrefix(cluster, "infra:")
 
+	// use the context for the namespace, when there is a grant for the namespace
+	if _, ok := kubeConfig.Contexts[fmt.Sprintf("infra:%s:%s", name, namespace)]; ok && namespace != "" {
+		name = name + ":" + namespace
+		namespace = ""
+	}
+
 	// set friendly name based on user input rather than internal format
 	friendlyName := strings.ReplaceAll(name, ":", ".")
 
@@ -47,8 +139,9 @@ func kubernetesSetContext(cli *CLI, cluster, namespace string) error {
 	kubeConfig.CurrentContext = contextName
 	kubeConfig.Contexts[contextName] = kubeContext
 
-	configFile := config.ConfigAccess().GetDefaultFilename()
-	if err := safelyWriteConfigToFile(kubeConfig, configFile); err != nil {
+	// write each change to the file it came from, so that the Infra contexts
+	// stay in the managed kubeconfig
+	if err := clientcmd.ModifyConfig(config.ConfigAccess(), kubeConfig, false); err != nil {
 		return err
 	}
 
@@ -56,6 +149,46 @@ func kubernetesSetContext(cli *CLI, cluster, namespace string) error {
 	return nil
 }
 
+// printKubeconfig writes a kubeconfig with only the context for destination
+// to the stdout of the CLI. destination is a cluster, or CLUSTER.NAMESPACE.
+func printKubeconfig(cli *CLI, client *api.Client, destination string) error {
+	user, destinations, grants, err := getUserDestinationGrants(client, "kubernetes")
+	if err != nil {
+		return err
+	}
+
+	kubeConfig, err := newInfraKubeconfig(user, destinations, grants)
+	if err != nil {
+		return err
+	}
+
+	cluster, namespace, _ := strings.Cut(destination, ".")
+	contextName := "infra:" + cluster
+	if _, ok := kubeConfig.Contexts[contextName+":"+namespace]; ok && namespace != "" {
+		contextName = contextName + ":" + namespace
+	} else {
+		kubeContext, ok := kubeConfig.Contexts[contextName]
+		if !ok {
+			return fmt.Errorf("context not found: %v", destination)
+		}
+		if namespace != "" {
+			kubeContext.Namespace = namespace
+		}
+	}
+
+	kubeConfig.CurrentContext = contextName
+	if err := clientcmdapi.MinifyConfig(kubeConfig); err != nil {
+		return err
+	}
+
+	out, err := clientcmd.Write(*kubeConfig)
+	if err != nil {
+		return err
+	}
+	_, err = cli.Stdout.Write(out)
+	return err
+}
+
 func updateKubeconfig(client *api.Client) error {
 	user, destinations, grants, err := getUserDestinationGrants(client, "kubernetes")
 	if err != nil {
@@ -66,19 +199,116 @@ func updateKubeconfig(client *api.Client) error {
 }
 
 func writeKubeconfig(user *api.User, destinations []api.Destination, grants []api.Grant) error {
-	defaultConfig := clientConfig()
+	filename, kubeConfig, err := loadInfraKubeconfig()
+	if err != nil {
+		return err
+	}
 
-	kubeConfig, err := defaultConfig.RawConfig()
+	infraConfig, err := newInfraKubeconfig(user, destinations, grants)
 	if err != nil {
 		return err
 	}
 
-	type clusterContext struct {
-		Namespace string
-		URL       string
-		CA        []byte
+	// cleanup others, before adding the current contexts, because the
+	// contexts share clusters and users
+	for id, ctx := range kubeConfig.Contexts {
+		if !strings.HasPrefix(id, "infra:") {
+			continue
+		}
+
+		if _, ok := infraConfig.Contexts[id]; !ok {
+			delete(kubeConfig.AuthInfos, ctx.AuthInfo)
+			delete(kubeConfig.Clusters, ctx.Cluster)
+			delete(kubeConfig.Contexts, id)
+		}
 	}
 
+	for name, cluster := range infraConfig.Clusters {
+		kubeConfig.Clusters[name] = cluster
+	}
+	for name, kubeContext := range infraConfig.Contexts {
+		// use existing kubeContext if possible which may contain
+		// user-defined overrides. preserve them if possible
+		if _, ok := kubeConfig.Contexts[name]; !ok {
+			kubeConfig.Contexts[name] = kubeContext
+		}
+	}
+	for name, authInfo := range infraConfig.AuthInfos {
+		kubeConfig.AuthInfos[name] = authInfo
+	}
+
+	return safelyWriteConfigToFile(*kubeConfig, filename)
+}
+
+// newInfraKubeconfig returns a kubeconfig with only the Infra contexts for
+// the destinations that the grants give access to.
+func newInfraKubeconfig(user *api.User, destinations []api.Destination, grants []api.Grant) (*clientcmdapi.Config, error) {
+	kubeConfig := clientcmdapi.NewConfig()
+
+	infraContexts := kubeconfigContexts(destinations, grants)
+	if len(infraContexts) == 0 {
+		return kubeConfig, nil
+	}
+
+	// pin the exec credential to the infra context used to write the
+	// kubeconfig, so that kubectl uses the same server from any directory.
+	var execEnv []clientcmdapi.ExecEnvVar
+	if hostConfig, err := currentHostConfig(); err == nil {
+		execEnv = []clientcmdapi.ExecEnvVar{{Name: "INFRA_CONTEXT", Value: hostConfig.contextName()}}
+	}
+
+	for contextName, infraContext := range infraContexts {
+		logging.Debugf("creating kubeconfig for %s", contextName)
+
+		u, err := urlx.Parse(infraContext.URL)
+		if err != nil {
+			return nil, err
+		}
+
+		u.Scheme = "https"
+
+		kubeConfig.Clusters[infraContext.Cluster] = &clientcmdapi.Cluster{
+			Server:                   u.String(),
+			CertificateAuthorityData: infraContext.CA,
+		}
+		kubeConfig.Contexts[contextName] = &clientcmdapi.Context{
+			Cluster:   infraContext.Cluster,
+			AuthInfo:  user.Name,
+			Namespace: infraContext.Namespace,
+		}
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+
+	kubeConfig.AuthInfos[user.Name] = &clientcmdapi.AuthInfo{
+		Exec: &clientcmdapi.ExecConfig{
+			Command:         executable,
+			Args:            []string{"tokens", "add"},
+			Env:             execEnv,
+			APIVersion:      "client.authentication.k8s.io/v1beta1",
+			InteractiveMode: clientcmdapi.IfAvailableExecInteractiveMode,
+		},
+	}
+	return kubeConfig, nil
+}
+
+// clusterContext is a kubeconfig context for a cluster the user has access to.
+type clusterContext struct {
+	// Cluster is the name of the kubeconfig cluster used by the context.
+	Cluster   string
+	Namespace string
+	URL       string
+	CA        []byte
+}
+
+// kubeconfigContexts returns the kubeconfig contexts, by name, for the
+// destinations that the grants give access to. Each cluster has a context
+// named infra:CLUSTER, and each namespace grant has a context named
+// infra:CLUSTER:NAMESPACE.
+func kubeconfigContexts(destinations []api.Destination, grants []api.Grant) map[string]clusterContext {
 	infraContexts := make(map[string]clusterContext)
 
 	for _, g := range grants {
@@ -94,11 +324,6 @@ func writeKubeconfig(user *api.User, destinations []api.Destination, grants []ap
 			namespace = ""
 		}
 
-		contextName := "infra:" + cluster
-		if _, ok := infraContexts[contextName]; ok && namespace != "" {
-			continue
-		}
-
 		var infraContext clusterContext
 		for _, d := range destinations {
 			if !isResourceForDestination(g.Resource, d.Name) {
@@ -118,75 +343,20 @@ func writeKubeconfig(user *api.User, destinations []api.Destination, grants []ap
 			continue
 		}
 
+		contextName := "infra:" + cluster
+		infraContext.Cluster = contextName
 		infraContext.Namespace = namespace
-		infraContexts[contextName] = infraContext
-	}
-
-	for contextName, infraContext := range infraContexts {
-		logging.Debugf("creating kubeconfig for %s", contextName)
 
-		u, err := urlx.Parse(infraContext.URL)
-		if err != nil {
-			return err
-		}
-
-		u.Scheme = "https"
-
-		kubeConfig.Clusters[contextName] = &clientcmdapi.Cluster{
-			Server:                   u.String(),
-			CertificateAuthorityData: infraContext.CA,
-		}
-
-		// use existing kubeContext if possible which may contain
-		// user-defined overrides. preserve them if possible
-		kubeContext, ok := kubeConfig.Contexts[contextName]
-		if !ok {
-			kubeContext = &clientcmdapi.Context{
-				Cluster:   contextName,
-				AuthInfo:  user.Name,
-				Namespace: infraContext.Namespace,
-			}
-		}
-
-		kubeConfig.Contexts[contextName] = kubeContext
-
-		executable, err := os.Executable()
-		if err != nil {
-			return err
-		}
-
-		kubeConfig.AuthInfos[user.Name] = &clientcmdapi.AuthInfo{
-			Exec: &clientcmdapi.ExecConfig{
-				Command:         executable,
-				Args:            []string{"tokens", "add"},
-				APIVersion:      "client.authentication.k8s.io/v1beta1",
-				InteractiveMode: clientcmdapi.IfAvailableExecInteractiveMode,
-			},
+		if namespace != "" {
+			infraContexts[contextName+":"+namespace] = infraContext
 		}
-	}
-
-	// cleanup others
-	for id, ctx := range kubeConfig.Contexts {
-		parts := strings.Split(id, ":")
 
-		if len(parts) < 1 {
-			continue
-		}
-
-		if parts[0] != "infra" {
+		if _, ok := infraContexts[contextName]; ok && namespace != "" {
 			continue
 		}
-
-		if _, ok := infraContexts[id]; !ok {
-			delete(kubeConfig.AuthInfos, ctx.AuthInfo)
-			delete(kubeConfig.Clusters, ctx.Cluster)
-			delete(kubeConfig.Contexts, id)
-		}
+		infraContexts[contextName] = infraContext
 	}
-
-	configFile := defaultConfig.ConfigAccess().GetDefaultFilename()
-
-	return safelyWriteConfigToFile(kubeConfig, configFile)
+	return infraContexts
 }
 
 // safelyWriteConfigToFile creates a temp file, then overwrites the target
@@ -228,21 +398,13 @@ func safelyWriteConfigToFile(kubeConfig clientcmdapi.Config, fileToWrite string)
 }
 
 func clearKubeconfig() error {
-	defaultConfig := clientConfig()
-
-	kubeConfig, err := defaultConfig.RawConfig()
+	filename, kubeConfig, err := loadInfraKubeconfig()
 	if err != nil {
 		return err
 	}
 
 	for id, ctx := range kubeConfig.Contexts {
-		parts := strings.Split(id, ":")
-
-		if len(parts) < 1 {
-			continue
-		}
-
-		if parts[0] != "infra" {
+		if !strings.HasPrefix(id, "infra:") {
 			continue
 		}
 
@@ -255,9 +417,67 @@ func clearKubeconfig() error {
 		kubeConfig.CurrentContext = ""
 	}
 
-	kubeConfigFilename := defaultConfig.ConfigAccess().GetDefaultFilename()
-	if err := clientcmd.WriteToFile(kubeConfig, kubeConfigFilename); err != nil {
+	if err := clientcmd.WriteToFile(*kubeConfig, filename); err != nil {
 		return err
 	}
+
+	// 'infra use' sets the current context in the first file of the loading
+	// precedence, which is not the managed kubeconfig.
+	return clearInfraCurrentContext(filename)
+}
+
+// clearInfraCurrentContext removes an Infra current-context from each file in
+// the kubeconfig loading precedence, other than skip.
+func clearInfraCurrentContext(skip string) error {
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	for _, filename := range loadingRules.Precedence {
+		if filepath.Clean(filename) == filepath.Clean(skip) {
+			continue
+		}
+
+		kubeConfig, err := clientcmd.LoadFromFile(filename)
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			continue
+		case err != nil:
+			return err
+		}
+		if !strings.HasPrefix(kubeConfig.CurrentContext, "infra:") {
+			continue
+		}
+
+		logging.Debugf("clearing current context %v in %v", kubeConfig.CurrentContext, filename)
+		kubeConfig.CurrentContext = ""
+		if err := clientcmd.WriteToFile(*kubeConfig, filename); err != nil {
+			return err
+		}
+	}
 	return nil
 }
+
+// renameKubeconfigInfraContext updates the exec credentials in the kubeconfig
+// that use the infra context oldName to use newName.
+func renameKubeconfigInfraContext(oldName, newName string) error {
+	filename, kubeConfig, err := loadInfraKubeconfig()
+	if err != nil {
+		return err
+	}
+
+	var changed bool
+	for _, authInfo := range kubeConfig.AuthInfos {
+		if authInfo.Exec == nil {
+			continue
+		}
+		for i, env := range authInfo.Exec.Env {
+			if env.Name == "INFRA_CONTEXT" && env.Value == oldName {
+				authInfo.Exec.Env[i].Value = newName
+				changed = true
+			}
+		}
+	}
+	if !changed {
+		return nil
+	}
+
+	return safelyWriteConfigToFile(*kubeConfig, filename)
+}
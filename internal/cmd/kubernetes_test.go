@@ -88,7 +88,7 @@ func TestUpdateKubeconfig(t *testing.T) {
 		err := updateKubeconfig(client)
 		assert.NilError(t, err)
 
-		actualKubeconfig, err := clientConfig().RawConfig()
+		actualKubeconfig, err := rawKubeconfig()
 		assert.NilError(t, err)
 
 		assert.DeepEqual(t, actualKubeconfig.Contexts, map[string]*clientcmdapi.Context{
@@ -151,7 +151,7 @@ func TestWriteKubeconfig(t *testing.T) {
 		assert.NilError(t, err)
 		assert.Equal(t, int(configFileStat.Mode().Perm()), 0o600)
 
-		kubeConfig, err := clientConfig().RawConfig()
+		kubeConfig, err := rawKubeconfig()
 		assert.NilError(t, err)
 
 		return kubeConfig
@@ -175,6 +175,11 @@ func TestWriteKubeconfig(t *testing.T) {
 				Cluster:   "infra:connected",
 				Namespace: "namespace",
 			},
+			"infra:connected:namespace": {
+				AuthInfo:  "user",
+				Cluster:   "infra:connected",
+				Namespace: "namespace",
+			},
 		}
 
 		actual := run(t, api.Grant{Resource: "connected.namespace"})
@@ -191,6 +196,21 @@ func TestWriteKubeconfig(t *testing.T) {
 				Cluster:   "infra:connected",
 				Namespace: "namespace",
 			},
+			"infra:connected:namespace": {
+				AuthInfo:  "user",
+				Cluster:   "infra:connected",
+				Namespace: "namespace",
+			},
+			"infra:connected:namespace2": {
+				AuthInfo:  "user",
+				Cluster:   "infra:connected",
+				Namespace: "namespace2",
+			},
+			"infra:connected:namespace3": {
+				AuthInfo:  "user",
+				Cluster:   "infra:connected",
+				Namespace: "namespace3",
+			},
 		}
 
 		grants := []api.Grant{
@@ -231,6 +251,21 @@ func TestWriteKubeconfig(t *testing.T) {
 				AuthInfo: "user",
 				Cluster:  "infra:connected",
 			},
+			"infra:connected:namespace": {
+				AuthInfo:  "user",
+				Cluster:   "infra:connected",
+				Namespace: "namespace",
+			},
+			"infra:connected:namespace2": {
+				AuthInfo:  "user",
+				Cluster:   "infra:connected",
+				Namespace: "namespace2",
+			},
+			"infra:connected:namespace3": {
+				AuthInfo:  "user",
+				Cluster:   "infra:connected",
+				Namespace: "namespace3",
+			},
 		}
 
 		grants := []api.Grant{
@@ -288,6 +323,21 @@ func TestWriteKubeconfig(t *testing.T) {
 				AuthInfo: "user",
 				Cluster:  "infra:connected",
 			},
+			"infra:connected:namespace": {
+				AuthInfo:  "user",
+				Cluster:   "infra:connected",
+				Namespace: "namespace",
+			},
+			"infra:connected:namespace2": {
+				AuthInfo:  "user",
+				Cluster:   "infra:connected",
+				Namespace: "namespace2",
+			},
+			"infra:connected:namespace3": {
+				AuthInfo:  "user",
+				Cluster:   "infra:connected",
+				Namespace: "namespace3",
+			},
 		}
 
 		grants := []api.Grant{
@@ -376,7 +426,7 @@ func TestWriteKubeconfig_UserNamespaceOverride(t *testing.T) {
 	err = writeKubeconfig(&user, destinations, grants)
 	assert.NilError(t, err)
 
-	actual, err := clientConfig().RawConfig()
+	actual, err := rawKubeconfig()
 	assert.NilError(t, err)
 	assert.Equal(t, actual.Contexts["infra:cluster"].Namespace, "override")
 }
@@ -412,7 +462,7 @@ func TestSafelyWriteConfigToFile(t *testing.T) {
 	assert.NilError(t, err)
 
 	// check that the file is written to
-	actual, err := clientConfig().RawConfig()
+	actual, err := rawKubeconfig()
 	assert.NilError(t, err)
 	assert.Equal(t, actual.Contexts["infra:cluster:default"].Namespace, "default")
 
@@ -12,19 +12,23 @@ import (
 )
 
 func newListCmd(cli *CLI) *cobra.Command {
-	return &cobra.Command{
+	var output outputOptions
+	cmd := &cobra.Command{
 		Use:     "list",
 		Aliases: []string{"ls"},
 		Short:   "List accessible destinations",
 		Args:    NoArgs,
 		GroupID: groupCore,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return list(cli)
+			return list(cli, output)
 		},
 	}
+
+	addFormatFlag(cmd.Flags(), &output)
+	return cmd
 }
 
-func list(cli *CLI) error {
+func list(cli *CLI, output outputOptions) error {
 	client, err := cli.apiClient()
 	if err != nil {
 		return err
@@ -37,6 +41,7 @@ func list(cli *CLI) error {
 
 	grantsByResource := make(map[string]map[string]struct{})
 	resources := []string{}
+	accessible := []api.Grant{}
 	for _, g := range grants {
 		if isResourceForDestination(g.Resource, "infra") {
 			continue
@@ -46,6 +51,7 @@ func list(cli *CLI) error {
 			continue
 		}
 
+		accessible = append(accessible, g)
 		if grantsByResource[g.Resource] == nil {
 			grantsByResource[g.Resource] = make(map[string]struct{})
 			resources = append(resources, g.Resource)
@@ -79,10 +85,10 @@ func list(cli *CLI) error {
 		})
 	}
 
-	if len(rows) > 0 {
-		printTable(rows, cli.Stdout)
-	} else {
+	if output.isTable() && len(rows) == 0 {
 		cli.Output("You have not been granted access to any active destinations")
+	} else if err := writeOutput(cli, output, accessible, rows); err != nil {
+		return err
 	}
 
 	return updateKubeconfig(client)
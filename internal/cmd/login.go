@@ -102,7 +102,7 @@ infra login`,
 
 func login(cli *CLI, options loginCmdOptions) error {
 	ctx := context.Background()
-	config, err := readConfig()
+	config, err := readConfigWithoutAccessKeys()
 	if err != nil {
 		return err
 	}
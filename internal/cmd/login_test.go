@@ -103,7 +103,7 @@ func TestLoginCmd_Options(t *testing.T) {
 	})
 
 	runStep(t, "login updated kube config", func(t *testing.T) {
-		kubeCfg, err := clientConfig().RawConfig()
+		kubeCfg, err := rawKubeconfig()
 		assert.NilError(t, err)
 
 		// config is empty because there are no grants yet
@@ -75,12 +75,7 @@ func logoutOfServer(hostConfig *ClientHostConfig) (success bool) {
 		return false
 	}
 
-	defer func() {
-		hostConfig.AccessKey = ""
-		hostConfig.UserID = 0
-		hostConfig.Name = ""
-		hostConfig.Expires = api.Time{}
-	}()
+	defer clearSession(hostConfig)
 
 	if hostConfig.isLoggedIn() {
 		err := client.Logout(ctx)
@@ -98,6 +93,31 @@ func logoutOfServer(hostConfig *ClientHostConfig) (success bool) {
 	return true
 }
 
+// clearSession removes the session of hostConfig from the config and the
+// local caches. It does not log out of the server.
+func clearSession(hostConfig *ClientHostConfig) {
+	clearTokenCache(hostConfig)
+	clearCompletionCache(hostConfig)
+	hostConfig.AccessKey = ""
+	hostConfig.UserID = 0
+	hostConfig.Name = ""
+	hostConfig.Expires = api.Time{}
+}
+
+// readConfigForLogout reads the config, and the access keys used to log out of
+// the servers. When the access keys can not be read from the credential store
+// the sessions are only removed locally.
+func readConfigForLogout() (*ClientConfig, error) {
+	config, err := readConfigWithoutAccessKeys()
+	if err != nil {
+		return nil, err
+	}
+	if err := loadAccessKeys(config); err != nil {
+		logging.Warnf("%v, the session is removed without logging out of the server", err)
+	}
+	return config, nil
+}
+
 func logout(cli *CLI, clear bool, server string, all bool) error {
 	switch {
 	case all:
@@ -116,7 +136,7 @@ func logout(cli *CLI, clear bool, server string, all bool) error {
 }
 
 func logoutAll(cli *CLI, clear bool) error {
-	config, err := readConfig()
+	config, err := readConfigForLogout()
 	if err != nil {
 		if errors.Is(err, ErrConfigNotFound) {
 			return nil
@@ -139,7 +159,7 @@ func logoutAll(cli *CLI, clear bool) error {
 		return err
 	}
 
-	if err := writeConfig(config); err != nil {
+	if err := writeConfigWithoutAccessKeys(config); err != nil {
 		return err
 	}
 
@@ -147,7 +167,7 @@ func logoutAll(cli *CLI, clear bool) error {
 }
 
 func logoutOne(cli *CLI, clear bool, server string) error {
-	config, err := readConfig()
+	config, err := readConfigForLogout()
 	if err != nil {
 		if errors.Is(err, ErrConfigNotFound) {
 			return nil
@@ -178,16 +198,26 @@ func logoutOne(cli *CLI, clear bool, server string) error {
 		return err
 	}
 
-	if err := writeConfig(config); err != nil {
+	if err := writeConfigWithoutAccessKeys(config); err != nil {
 		return err
 	}
 
 	return nil
 }
 
+// findClientConfigHost returns the host with the server or context name, or
+// the selected context when server is empty.
 func findClientConfigHost(config *ClientConfig, server string) (*ClientHostConfig, int) {
+	if server == "" {
+		i, err := config.selectedHostIndex()
+		if err != nil {
+			logging.Debugf("selected context: %v", err)
+			return nil, -1
+		}
+		return &config.Hosts[i], i
+	}
 	for i := range config.Hosts {
-		if (server == "" && config.Hosts[i].Current) || (server == config.Hosts[i].Host) {
+		if server == config.Hosts[i].Host || server == config.Hosts[i].contextName() {
 			return &config.Hosts[i], i
 		}
 	}
@@ -191,7 +191,7 @@ func TestLogout(t *testing.T) {
 		expected.Hosts[0].Expires = api.Time{}
 		assert.DeepEqual(t, &expected, updatedCfg)
 
-		updatedKubeCfg, err := clientConfig().RawConfig()
+		updatedKubeCfg, err := rawKubeconfig()
 		assert.NilError(t, err)
 		assert.DeepEqual(t, expectedKubeCfg, updatedKubeCfg,
 			cmpopts.EquateEmpty(),
@@ -217,7 +217,7 @@ func TestLogout(t *testing.T) {
 		expected.Hosts[0].Expires = api.Time{}
 		assert.DeepEqual(t, &expected, updatedCfg)
 
-		updatedKubeCfg, err := clientConfig().RawConfig()
+		updatedKubeCfg, err := rawKubeconfig()
 		assert.NilError(t, err)
 		assert.DeepEqual(t, expectedKubeCfg, updatedKubeCfg,
 			cmpopts.EquateEmpty(),
@@ -246,7 +246,7 @@ func TestLogout(t *testing.T) {
 		kubeconfig := expectedKubeCfg
 		kubeconfig.CurrentContext = "keep:non-infra"
 
-		updatedKubeCfg, err := clientConfig().RawConfig()
+		updatedKubeCfg, err := rawKubeconfig()
 		assert.NilError(t, err)
 		assert.DeepEqual(t, kubeconfig, updatedKubeCfg,
 			cmpopts.EquateEmpty(),
@@ -268,7 +268,7 @@ func TestLogout(t *testing.T) {
 		assert.Equal(t, int32(1), int32(len(updatedCfg.Hosts)))
 		assert.DeepEqual(t, testFields.config.Hosts[1], updatedCfg.Hosts[0])
 
-		updatedKubeCfg, err := clientConfig().RawConfig()
+		updatedKubeCfg, err := rawKubeconfig()
 		assert.NilError(t, err)
 		assert.DeepEqual(t, expectedKubeCfg, updatedKubeCfg,
 			cmpopts.EquateEmpty(),
@@ -298,7 +298,7 @@ func TestLogout(t *testing.T) {
 		expected.Hosts[1].Expires = api.Time{}
 		assert.DeepEqual(t, &expected, updatedCfg)
 
-		updatedKubeCfg, err := clientConfig().RawConfig()
+		updatedKubeCfg, err := rawKubeconfig()
 		assert.NilError(t, err)
 		assert.DeepEqual(t, expectedKubeCfg, updatedKubeCfg,
 			cmpopts.EquateEmpty(),
@@ -320,7 +320,7 @@ func TestLogout(t *testing.T) {
 		expected := ClientConfig{ClientConfigVersion: clientConfigVersion}
 		assert.DeepEqual(t, &expected, updatedCfg)
 
-		updatedKubeCfg, err := clientConfig().RawConfig()
+		updatedKubeCfg, err := rawKubeconfig()
 		assert.NilError(t, err)
 		assert.DeepEqual(t, expectedKubeCfg, updatedKubeCfg,
 			cmpopts.EquateEmpty(),
@@ -368,7 +368,7 @@ func TestLogout(t *testing.T) {
 		assert.NilError(t, err)
 		assert.Equal(t, int32(1), atomic.LoadInt32(testFields.count), "calls to API")
 
-		updatedKubeCfg, err := clientConfig().RawConfig()
+		updatedKubeCfg, err := rawKubeconfig()
 		expectedKubeCfg.CurrentContext = "keep:non-infra"
 		assert.NilError(t, err)
 		assert.DeepEqual(t, expectedKubeCfg, updatedKubeCfg,
@@ -0,0 +1,167 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"text/template"
+
+	"github.com/spf13/pflag"
+	"k8s.io/client-go/util/jsonpath"
+	"sigs.k8s.io/yaml"
+)
+
+// outputFormat is the value of the --format flag. It implements pflag.Value so
+// that an unknown format is rejected before the command runs.
+type outputFormat string
+
+const (
+	outputTable outputFormat = "table"
+	outputJSON  outputFormat = "json"
+	outputYAML  outputFormat = "yaml"
+	outputCSV   outputFormat = "csv"
+)
+
+func (f *outputFormat) String() string {
+	return string(*f)
+}
+
+func (f *outputFormat) Set(value string) error {
+	switch format := outputFormat(value); format {
+	case outputTable, outputJSON, outputYAML, outputCSV:
+		*f = format
+		return nil
+	default:
+		return fmt.Errorf("must be one of table, json, yaml, or csv")
+	}
+}
+
+func (f *outputFormat) Type() string {
+	return "string"
+}
+
+// outputOptions are the flags that select the output format of a read command.
+type outputOptions struct {
+	Format   outputFormat
+	Template string
+}
+
+func addFormatFlag(flags *pflag.FlagSet, bind *outputOptions) {
+	bind.Format = outputTable
+	flags.Var(&bind.Format, "format", "Output format [table|json|yaml|csv]")
+	flags.StringVar(&bind.Template, "template", "", "Format the output with a Go template, or a JSONPath expression prefixed with 'jsonpath='")
+}
+
+// isTable returns true when the output is a table for people to read, and not
+// a format for scripts.
+func (o outputOptions) isTable() bool {
+	return o.Template == "" && (o.Format == "" || o.Format == outputTable)
+}
+
+// writeOutput writes the output of a read command in the format selected by
+// options. value is the API object, or the list of API objects, written by the
+// json and yaml formats, and by templates. rows is a slice of structs with
+// header tags, written by the table and csv formats.
+func writeOutput(cli *CLI, options outputOptions, value any, rows any) error {
+	if options.Template != "" {
+		return writeTemplate(cli.Stdout, options.Template, value)
+	}
+
+	switch options.Format {
+	case outputJSON:
+		jsonOutput, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(cli.Stdout, string(jsonOutput))
+	case outputYAML:
+		yamlOutput, err := yaml.Marshal(value)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(cli.Stdout, string(yamlOutput))
+	case outputCSV:
+		return writeCSV(cli.Stdout, rows)
+	default:
+		printTable(rows, cli.Stdout)
+	}
+	return nil
+}
+
+// writeTemplate executes tmpl with value. Templates use the json field names of
+// the API objects, the same names used by the json and yaml formats.
+func writeTemplate(w io.Writer, tmpl string, value any) error {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	var data any
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	if err := decoder.Decode(&data); err != nil {
+		return err
+	}
+
+	if strings.HasPrefix(tmpl, "jsonpath=") {
+		jp := jsonpath.New("template")
+		if err := jp.Parse(strings.TrimPrefix(tmpl, "jsonpath=")); err != nil {
+			return Error{Message: fmt.Sprintf("invalid JSONPath template: %v", err)}
+		}
+		return jp.Execute(w, data)
+	}
+
+	t, err := template.New("template").Funcs(templateFuncs).Parse(tmpl)
+	if err != nil {
+		return Error{Message: fmt.Sprintf("invalid template: %v", err)}
+	}
+	return t.Execute(w, data)
+}
+
+var templateFuncs = template.FuncMap{
+	"json": func(v any) (string, error) {
+		out, err := json.Marshal(v)
+		return string(out), err
+	},
+}
+
+// writeCSV writes rows, a slice of structs, with a header line from the header
+// tags of the struct fields.
+func writeCSV(w io.Writer, rows any) error {
+	v := reflect.ValueOf(rows)
+	if v.Kind() != reflect.Slice || v.Type().Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("csv output requires a slice of structs, not %T", rows)
+	}
+
+	elem := v.Type().Elem()
+	var fields []int
+	var header []string
+	for i := 0; i < elem.NumField(); i++ {
+		field := elem.Field(i)
+		name, ok := field.Tag.Lookup("header")
+		if !ok || !field.IsExported() {
+			continue
+		}
+		fields = append(fields, i)
+		header = append(header, name)
+	}
+
+	out := csv.NewWriter(w)
+	if err := out.Write(header); err != nil {
+		return err
+	}
+	for i := 0; i < v.Len(); i++ {
+		record := make([]string, 0, len(fields))
+		for _, field := range fields {
+			record = append(record, fmt.Sprint(v.Index(i).Field(field).Interface()))
+		}
+		if err := out.Write(record); err != nil {
+			return err
+		}
+	}
+	out.Flush()
+	return out.Error()
+}
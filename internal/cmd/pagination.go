@@ -10,6 +10,10 @@ import (
 // listAll is a helper function that handles pagination and calls the given list request function.
 // listItems is the corresponding function in the API client that handles the Request "req".
 // handleError is a function that handles the error returned by the API client.
+//
+// listAll follows NextCursor when the server returns one, and falls back to
+// requesting each page by number for endpoints and servers that do not
+// support cursor pagination.
 func listAll[Item any, Req api.Paginatable](
 	ctx context.Context,
 	listItems func(context.Context, Req) (*api.ListResponse[Item], error),
@@ -29,6 +33,10 @@ func listAll[Item any, Req api.Paginatable](
 	users := make([]Item, 0, res.TotalCount)
 	users = append(users, res.Items...)
 
+	if res.NextCursor != "" {
+		return listAllByCursor(ctx, listItems, req, res.NextCursor, users)
+	}
+
 	for page := 2; page <= res.TotalPages; page++ {
 		req, ok := req.SetPage(page).(Req)
 		if !ok {
@@ -45,3 +53,34 @@ func listAll[Item any, Req api.Paginatable](
 
 	return users, nil
 }
+
+func listAllByCursor[Item any, Req api.Paginatable](
+	ctx context.Context,
+	listItems func(context.Context, Req) (*api.ListResponse[Item], error),
+	req Req,
+	cursor string,
+	items []Item,
+) ([]Item, error) {
+	req, ok := req.SetPage(0).SetCursor(cursor).(Req)
+	if !ok {
+		panic("SetCursor returned a different request type than expected")
+	}
+
+	for {
+		logging.Debugf("call server: cursor %v", cursor)
+		res, err := listItems(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, res.Items...)
+
+		cursor = res.NextCursor
+		if cursor == "" {
+			return items, nil
+		}
+		req, ok = req.SetCursor(cursor).(Req)
+		if !ok {
+			panic("SetCursor returned a different request type than expected")
+		}
+	}
+}
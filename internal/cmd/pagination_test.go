@@ -47,6 +47,15 @@ func TestListAll(t *testing.T) {
 		})
 	})
 
+	t.Run("cursor", func(t *testing.T) {
+		users, err := listAll(ctx, mockListUsers, api.ListUsersRequest{Name: "cursor"})
+		assert.NilError(t, err)
+
+		assert.DeepEqual(t, users, []api.User{
+			{Name: "1@test.com"}, {Name: "2@test.com"}, {Name: "3@test.com"},
+		})
+	})
+
 	t.Run("error", func(t *testing.T) {
 		_, err := listAll(ctx, mockListUsers, api.ListUsersRequest{Name: "error"})
 		assert.Error(t, err, "default error")
@@ -76,6 +85,19 @@ func mockListUsers(_ context.Context, req api.ListUsersRequest) (*api.ListRespon
 			Items:              []api.User{{Name: fmt.Sprintf("%d@test.com", req.Page)}, {Name: fmt.Sprintf("%d@test.org", req.Page)}},
 			PaginationResponse: api.PaginationResponse{TotalPages: 5, TotalCount: 5, Page: req.Page},
 		}, nil
+	case "cursor":
+		if req.Page != 0 && req.Cursor != "" {
+			return nil, Error{Message: "page and cursor are mutually exclusive"}
+		}
+		next := map[string]string{"": "2", "2": "3"}
+		n := req.Cursor
+		if n == "" {
+			n = "1"
+		}
+		return &api.ListResponse[api.User]{
+			Items:              []api.User{{Name: fmt.Sprintf("%s@test.com", n)}},
+			PaginationResponse: api.PaginationResponse{Limit: 1, NextCursor: next[req.Cursor]},
+		}, nil
 	case "403":
 		return nil, api.Error{Code: 403}
 	default:
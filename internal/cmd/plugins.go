@@ -0,0 +1,349 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/infrahq/infra/api"
+	"github.com/infrahq/infra/internal/generate"
+	"github.com/infrahq/infra/internal/logging"
+	"github.com/infrahq/infra/uid"
+)
+
+const (
+	// pluginPrefix is the prefix of the name of the program that implements a
+	// plugin. 'infra NAME' runs the program infra-NAME when NAME is not a
+	// built-in command.
+	pluginPrefix = "infra-"
+
+	// pluginAccessKeyExpiry is how long the access key created for a plugin
+	// is valid. Plugins are expected to be short-lived commands, and the key
+	// is deleted when the plugin exits, so the expiry only limits how long a
+	// key remains valid if the CLI is killed before it can delete the key.
+	pluginAccessKeyExpiry            = time.Hour
+	pluginAccessKeyInactivityTimeout = 15 * time.Minute
+)
+
+func newPluginsCmd(cli *CLI) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "plugins",
+		Short: "Manage CLI plugins",
+		Long: `Manage CLI plugins.
+
+A plugin is a program named infra-NAME in a directory in PATH. 'infra NAME'
+runs the plugin when NAME is not a built-in command, and passes the remaining
+arguments to it.
+
+Plugins receive the current session in environment variables:
+
+  INFRA_SERVER              the hostname of the current server
+  INFRA_SERVER_URL          the URL of the current server
+  INFRA_ACCESS_KEY          an access key for the current user, deleted when
+                            the plugin exits
+  INFRA_ACCESS_KEY_EXPIRES  when the access key expires, in RFC 3339 format
+  INFRA_CONTEXT             the name of the current context
+  INFRA_USER_NAME           the name of the current user
+  INFRA_TRUSTED_CERTIFICATE the PEM encoded certificate of the server, when
+                            it was trusted at login
+  INFRA_SKIP_TLS_VERIFY     true when TLS verification is disabled
+
+The session variables are not set when you are not logged in. Plugins always
+receive the preferences of the CLI:
+
+  INFRA_LOG_LEVEL           the log level, from --log-level
+  INFRA_NON_INTERACTIVE     true when prompts are disabled by
+                            --non-interactive, or when stdin is not a terminal
+  INFRA_FORMAT              the output format, one of table, json, yaml, or
+                            csv, from --format
+
+The preferences are set by flags before the name of the plugin, for example
+'infra --format=json NAME', or by the environment variables.`,
+		GroupID: groupOther,
+	}
+
+	cmd.AddCommand(newPluginsListCmd(cli))
+	return cmd
+}
+
+// pluginInfo is a plugin found in PATH.
+type pluginInfo struct {
+	Name string `json:"name" header:"NAME"`
+	Path string `json:"path" header:"PATH"`
+	// ShadowedBy is the built-in command, or the path of the plugin found
+	// earlier in PATH, that runs instead of this plugin.
+	ShadowedBy string `json:"shadowedBy,omitempty" header:"SHADOWED BY"`
+}
+
+func newPluginsListCmd(cli *CLI) *cobra.Command {
+	var output outputOptions
+	cmd := &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List installed plugins",
+		Args:    NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			plugins := findPlugins(cmd.Root())
+
+			if output.isTable() && len(plugins) == 0 {
+				cli.Output("No plugins found in PATH")
+				return nil
+			}
+			return writeOutput(cli, output, plugins, plugins)
+		},
+	}
+
+	addFormatFlag(cmd.Flags(), &output)
+	return cmd
+}
+
+// findPlugins returns the plugins in each directory in PATH, in the order they
+// are found by 'infra NAME'.
+func findPlugins(root *cobra.Command) []pluginInfo {
+	plugins := []pluginInfo{}
+	seen := make(map[string]string)
+
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		if dir == "" {
+			continue
+		}
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			logging.Debugf("read plugin directory %v: %v", dir, err)
+			continue
+		}
+
+		for _, entry := range entries {
+			name, ok := pluginName(entry)
+			if !ok {
+				continue
+			}
+
+			plugin := pluginInfo{Name: name, Path: filepath.Join(dir, entry.Name())}
+			switch path, found := seen[name]; {
+			case isBuiltinCommand(root, name):
+				plugin.ShadowedBy = "built-in command"
+			case found:
+				plugin.ShadowedBy = path
+			default:
+				seen[name] = plugin.Path
+			}
+			plugins = append(plugins, plugin)
+		}
+	}
+	return plugins
+}
+
+// pluginName returns the name of the plugin implemented by the program in
+// entry, or false if entry is not a plugin. Credential helpers use the same
+// prefix, but they are not plugins.
+func pluginName(entry os.DirEntry) (string, bool) {
+	filename := entry.Name()
+	if !strings.HasPrefix(filename, pluginPrefix) || strings.HasPrefix(filename, credentialHelperPrefix) {
+		return "", false
+	}
+
+	info, err := entry.Info()
+	if err != nil || info.IsDir() {
+		return "", false
+	}
+
+	name := strings.TrimPrefix(filename, pluginPrefix)
+	if runtime.GOOS == "windows" {
+		ext := strings.ToLower(filepath.Ext(name))
+		switch ext {
+		case ".exe", ".bat", ".cmd", ".com":
+		default:
+			return "", false
+		}
+		name = strings.TrimSuffix(name, filepath.Ext(name))
+	} else if info.Mode().Perm()&0o111 == 0 {
+		return "", false
+	}
+	return name, name != ""
+}
+
+func isBuiltinCommand(root *cobra.Command, name string) bool {
+	for _, cmd := range root.Commands() {
+		if cmd.Name() == name || cmd.HasAlias(name) {
+			return true
+		}
+	}
+	return name == "help"
+}
+
+// completePlugins completes the names of the installed plugins as the first
+// argument of the root command.
+func completePlugins(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	var names []string
+	for _, plugin := range findPlugins(cmd.Root()) {
+		if plugin.ShadowedBy == "" && strings.HasPrefix(plugin.Name, toComplete) {
+			names = append(names, plugin.Name)
+		}
+	}
+	sort.Strings(names)
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+// runPlugin runs the plugin named by args[0], with the remaining args. It
+// returns the unknown command error when no plugin has that name.
+func runPlugin(cli *CLI, cmd *cobra.Command, args []string) error {
+	name := args[0]
+	unknown := fmt.Errorf("unknown command %q for %q%s", name, cmd.CommandPath(), suggestions(cmd, name))
+	if strings.ContainsAny(name, `/\`) || strings.HasPrefix(pluginPrefix+name, credentialHelperPrefix) {
+		return unknown
+	}
+
+	program, err := exec.LookPath(pluginPrefix + name)
+	if err != nil {
+		logging.Debugf("plugin %v: %v", name, err)
+		return unknown
+	}
+
+	env, cleanup := pluginEnv(cmd.Context(), cli, name)
+	defer cleanup()
+
+	logging.Debugf("run plugin %v", program)
+	plugin := exec.Command(program, args[1:]...)
+	plugin.Env = append(os.Environ(), env...)
+	plugin.Stdin = cli.Stdin
+	plugin.Stdout = cli.Stdout
+	plugin.Stderr = cli.Stderr
+	// Wait for the plugin so that the access key is deleted.
+	return runForwardingSignals(plugin)
+}
+
+// runForwardingSignals runs cmd, and returns the error from exitErrorFromCmd.
+// The child receives interrupts from the terminal, and decides when to exit,
+// so the CLI ignores interrupts while it waits for the child and can clean up
+// after it exits. Termination and hangup signals are forwarded to the child.
+func runForwardingSignals(cmd *exec.Cmd) error {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+	defer signal.Stop(signals)
+
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			select {
+			case sig := <-signals:
+				if sig == os.Interrupt {
+					// the terminal sends interrupts to the child
+					continue
+				}
+				_ = cmd.Process.Signal(sig)
+			case <-done:
+				return
+			}
+		}
+	}()
+	return exitErrorFromCmd(cmd.Wait())
+}
+
+func suggestions(cmd *cobra.Command, name string) string {
+	if cmd.SuggestionsMinimumDistance <= 0 {
+		cmd.SuggestionsMinimumDistance = 2
+	}
+	var out string
+	if suggestions := cmd.SuggestionsFor(name); len(suggestions) > 0 {
+		out = "\n\nDid you mean this?\n"
+		for _, s := range suggestions {
+			out += fmt.Sprintf("\t%v\n", s)
+		}
+	}
+	return out
+}
+
+// pluginEnv returns the environment variables that pass the current session,
+// log level, and output preferences to a plugin. The returned func deletes the
+// access key created for the plugin.
+func pluginEnv(ctx context.Context, cli *CLI, name string) ([]string, func()) {
+	env := []string{
+		"INFRA_LOG_LEVEL=" + cli.RootOptions.LogLevel,
+		"INFRA_NON_INTERACTIVE=" + strconv.FormatBool(cli.RootOptions.NonInteractive),
+		"INFRA_FORMAT=" + string(cli.RootOptions.Format),
+	}
+	cleanup := func() {}
+
+	config, err := currentHostConfig()
+	if err != nil || !config.isLoggedIn() {
+		logging.Debugf("not logged in, running plugin %v without a session", name)
+		return env, cleanup
+	}
+
+	client, err := cli.apiClient()
+	if err != nil {
+		logging.Debugf("running plugin %v without a session: %v", name, err)
+		return env, cleanup
+	}
+
+	key, err := createPluginAccessKey(ctx, client, config.UserID, name)
+	if err != nil {
+		logging.Warnf("failed to create an access key for plugin %v: %v", name, err)
+		return env, cleanup
+	}
+
+	env = append(env,
+		"INFRA_SERVER="+config.Host,
+		"INFRA_SERVER_URL="+client.URL,
+		"INFRA_ACCESS_KEY="+key.AccessKey,
+		"INFRA_ACCESS_KEY_EXPIRES="+key.Expires.Time().Format(time.RFC3339),
+		"INFRA_CONTEXT="+config.contextName(),
+		"INFRA_USER_NAME="+config.Name,
+		"INFRA_SKIP_TLS_VERIFY="+strconv.FormatBool(config.SkipTLSVerify),
+	)
+	if config.TrustedCertificate != "" {
+		env = append(env, "INFRA_TRUSTED_CERTIFICATE="+config.TrustedCertificate)
+	}
+
+	cleanup = func() {
+		logging.Debugf("call server: delete access key %v", key.Name)
+		if err := client.DeleteAccessKey(context.Background(), key.ID); err != nil {
+			logging.Warnf("failed to delete the access key %v created for plugin %v: %v", key.Name, name, err)
+		}
+	}
+	return env, cleanup
+}
+
+func createPluginAccessKey(ctx context.Context, client *api.Client, userID uid.ID, name string) (*api.CreateAccessKeyResponse, error) {
+	suffix, err := generate.CryptoRandom(8, generate.CharsetAlphaNumeric)
+	if err != nil {
+		return nil, err
+	}
+
+	keyName := "plugin-" + strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+			return r
+		default:
+			return '_'
+		}
+	}, name) + "-" + suffix
+
+	logging.Debugf("call server: create access key named %q", keyName)
+	return client.CreateAccessKey(ctx, &api.CreateAccessKeyRequest{
+		UserID:            userID,
+		Name:              keyName,
+		Expiry:            api.Duration(pluginAccessKeyExpiry),
+		InactivityTimeout: api.Duration(pluginAccessKeyInactivityTimeout),
+	})
+}
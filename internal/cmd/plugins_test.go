@@ -0,0 +1,164 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+
+	"github.com/infrahq/infra/api"
+	"github.com/infrahq/infra/uid"
+)
+
+func TestPlugins(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("plugin scripts require a unix shell")
+	}
+	setupEnv(t)
+
+	bin := t.TempDir()
+	plugin := `#!/bin/sh
+env | grep '^INFRA_' | sort > "$(dirname "$0")/env"
+echo "args: $*"
+exit ${EXIT_CODE:-0}
+`
+	writePlugin := func(dir, name string) string {
+		filename := filepath.Join(dir, name)
+		assert.NilError(t, os.WriteFile(filename, []byte(plugin), 0o700))
+		return filename
+	}
+	hello := writePlugin(bin, "infra-hello")
+	writePlugin(bin, "infra-version")
+	writePlugin(bin, "infra-credential-test")
+	assert.NilError(t, os.WriteFile(filepath.Join(bin, "infra-notexec"), []byte(plugin), 0o600))
+
+	shadowed := t.TempDir()
+	shadowedHello := writePlugin(shadowed, "infra-hello")
+	t.Setenv("PATH", strings.Join([]string{bin, shadowed, os.Getenv("PATH")}, string(os.PathListSeparator)))
+
+	readEnv := func(t *testing.T) map[string]string {
+		t.Helper()
+		raw, err := os.ReadFile(filepath.Join(bin, "env"))
+		assert.NilError(t, err)
+		env := make(map[string]string)
+		for _, line := range strings.Split(strings.TrimSpace(string(raw)), "\n") {
+			key, value, _ := strings.Cut(line, "=")
+			env[key] = value
+		}
+		return env
+	}
+
+	userID := uid.New()
+	keyID := uid.New()
+	var created *api.CreateAccessKeyRequest
+	var deleted []string
+	handler := func(resp http.ResponseWriter, req *http.Request) {
+		switch {
+		case req.Method == http.MethodPost && req.URL.Path == "/api/access-keys":
+			created = &api.CreateAccessKeyRequest{}
+			assert.Check(t, json.NewDecoder(req.Body).Decode(created))
+			assert.Check(t, json.NewEncoder(resp).Encode(api.CreateAccessKeyResponse{
+				ID:        keyID,
+				Name:      created.Name,
+				IssuedFor: created.UserID,
+				Expires:   api.Time(time.Now().Add(time.Duration(created.Expiry))),
+				AccessKey: "pluginkey.abcdefghijklmnopqrstuvwx",
+			}))
+		case req.Method == http.MethodDelete && strings.HasPrefix(req.URL.Path, "/api/access-keys/"):
+			deleted = append(deleted, strings.TrimPrefix(req.URL.Path, "/api/access-keys/"))
+		default:
+			resp.WriteHeader(http.StatusNotFound)
+		}
+	}
+	srv := httptest.NewTLSServer(http.HandlerFunc(handler))
+	t.Cleanup(srv.Close)
+
+	cfg := newTestClientConfig(srv, api.User{ID: userID})
+	assert.NilError(t, writeConfig(&cfg))
+
+	t.Run("list", func(t *testing.T) {
+		ctx, bufs := PatchCLI(context.Background())
+		err := Run(ctx, "plugins", "list", "--format=json")
+		assert.NilError(t, err)
+
+		var plugins []pluginInfo
+		assert.NilError(t, json.Unmarshal(bufs.Stdout.Bytes(), &plugins))
+		expected := []pluginInfo{
+			{Name: "hello", Path: hello},
+			{Name: "version", Path: filepath.Join(bin, "infra-version"), ShadowedBy: "built-in command"},
+			{Name: "hello", Path: shadowedHello, ShadowedBy: hello},
+		}
+		assert.DeepEqual(t, plugins, expected)
+	})
+
+	t.Run("run with session", func(t *testing.T) {
+		ctx, bufs := PatchCLI(context.Background())
+		err := Run(ctx, "--log-level=debug", "--format=json", "--non-interactive", "hello", "world", "--name", "alice")
+		assert.NilError(t, err)
+		assert.Equal(t, bufs.Stdout.String(), "args: world --name alice\n")
+
+		assert.Equal(t, created.UserID, userID)
+		assert.Equal(t, time.Duration(created.Expiry), pluginAccessKeyExpiry)
+		assert.Assert(t, strings.HasPrefix(created.Name, "plugin-hello-"), created.Name)
+		assert.DeepEqual(t, deleted, []string{keyID.String()})
+
+		env := readEnv(t)
+		assert.Equal(t, env["INFRA_SERVER"], cfg.Hosts[0].Host)
+		assert.Equal(t, env["INFRA_SERVER_URL"], srv.URL)
+		assert.Equal(t, env["INFRA_ACCESS_KEY"], "pluginkey.abcdefghijklmnopqrstuvwx")
+		assert.Equal(t, env["INFRA_CONTEXT"], cfg.Hosts[0].contextName())
+		assert.Equal(t, env["INFRA_USER_NAME"], cfg.Hosts[0].Name)
+		assert.Equal(t, env["INFRA_LOG_LEVEL"], "debug")
+		assert.Equal(t, env["INFRA_NON_INTERACTIVE"], "true")
+		assert.Equal(t, env["INFRA_FORMAT"], "json")
+		_, err = time.Parse(time.RFC3339, env["INFRA_ACCESS_KEY_EXPIRES"])
+		assert.NilError(t, err)
+	})
+
+	t.Run("exit code", func(t *testing.T) {
+		t.Setenv("EXIT_CODE", "3")
+		ctx, _ := PatchCLI(context.Background())
+		err := Run(ctx, "hello")
+		var exitErr exitError
+		assert.Assert(t, errors.As(err, &exitErr), err)
+		assert.Equal(t, exitErr.ExitCode(), 3)
+	})
+
+	t.Run("not logged in", func(t *testing.T) {
+		t.Setenv("HOME", t.TempDir())
+		t.Setenv("USERPROFILE", os.Getenv("HOME"))
+		created = nil
+
+		ctx, _ := PatchCLI(context.Background())
+		err := Run(ctx, "hello")
+		assert.NilError(t, err)
+		assert.Assert(t, created == nil)
+
+		env := readEnv(t)
+		assert.Equal(t, env["INFRA_LOG_LEVEL"], "info")
+		assert.Equal(t, env["INFRA_FORMAT"], "table")
+		_, ok := env["INFRA_ACCESS_KEY"]
+		assert.Assert(t, !ok)
+	})
+
+	t.Run("unknown command", func(t *testing.T) {
+		ctx, _ := PatchCLI(context.Background())
+		err := Run(ctx, "logn")
+		assert.ErrorContains(t, err, `unknown command "logn" for "infra"`)
+		assert.ErrorContains(t, err, "Did you mean this?\n\tlogin")
+
+		for _, name := range []string{"notexec", "credential-test", "../bin/infra-hello"} {
+			err = Run(ctx, name)
+			assert.ErrorContains(t, err, "unknown command")
+		}
+	})
+}
@@ -8,7 +8,6 @@ import (
 	"time"
 
 	"github.com/spf13/cobra"
-	"sigs.k8s.io/yaml"
 
 	"github.com/infrahq/infra/api"
 	"github.com/infrahq/infra/internal/cmd/cliopts"
@@ -99,7 +98,8 @@ $ infra providers edit okta --client-secret VT_oXtkEDaT7UFY-C3DSRWYb00qyKZ1K1VCq
 # Connect Google to Infra with group sync
 $ infra providers edit google --client-secret VT_oXtkEDaT7UFY-C3DSRWYb00qyKZ1K1VCq7YzN --service-account-key ~/client-123.json --service-account-email hello@example.com --workspace-domain-admin admin@example.com
 `,
-		Args: ExactArgs(1),
+		Args:              ExactArgs(1),
+		ValidArgsFunction: completeArgs([]completionSource{completeProviders}),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return updateProvider(cli, args[0], opts)
 		},
@@ -114,7 +114,7 @@ $ infra providers edit google --client-secret VT_oXtkEDaT7UFY-C3DSRWYb00qyKZ1K1V
 }
 
 func newProvidersListCmd(cli *CLI) *cobra.Command {
-	var format string
+	var output outputOptions
 	cmd := &cobra.Command{
 		Use:     "list",
 		Aliases: []string{"ls"},
@@ -134,42 +134,26 @@ func newProvidersListCmd(cli *CLI) *cobra.Command {
 				return err
 			}
 
-			switch format {
-			case "json":
-				jsonOutput, err := json.Marshal(providers)
-				if err != nil {
-					return err
-				}
-				cli.Output(string(jsonOutput))
-			case "yaml":
-				yamlOutput, err := yaml.Marshal(providers)
-				if err != nil {
-					return err
-				}
-				cli.Output(string(yamlOutput))
-			default:
-				type row struct {
-					Name string `header:"NAME"`
-					Kind string `header:"KIND"`
-					URL  string `header:"URL"`
-				}
+			type row struct {
+				Name string `header:"NAME"`
+				Kind string `header:"KIND"`
+				URL  string `header:"URL"`
+			}
 
-				var rows []row
-				for _, p := range providers {
-					rows = append(rows, row{Name: p.Name, URL: p.URL, Kind: p.Kind})
-				}
+			var rows []row
+			for _, p := range providers {
+				rows = append(rows, row{Name: p.Name, URL: p.URL, Kind: p.Kind})
+			}
 
-				if len(rows) > 0 {
-					printTable(rows, cli.Stdout)
-				} else {
-					cli.Output("No providers found")
-				}
+			if output.isTable() && len(rows) == 0 {
+				cli.Output("No providers found")
+				return nil
 			}
-			return nil
+			return writeOutput(cli, output, providers, rows)
 		},
 	}
 
-	addFormatFlag(cmd.Flags(), &format)
+	addFormatFlag(cmd.Flags(), &output)
 	return cmd
 }
 
@@ -381,11 +365,12 @@ func newProvidersRemoveCmd(cli *CLI) *cobra.Command {
 	var force bool
 
 	cmd := &cobra.Command{
-		Use:     "remove PROVIDER",
-		Aliases: []string{"rm"},
-		Short:   "Disconnect an identity provider",
-		Example: "$ infra providers remove okta",
-		Args:    ExactArgs(1),
+		Use:               "remove PROVIDER",
+		Aliases:           []string{"rm"},
+		Short:             "Disconnect an identity provider",
+		Example:           "$ infra providers remove okta",
+		Args:              ExactArgs(1),
+		ValidArgsFunction: completeArgs([]completionSource{completeProviders}),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			client, err := cli.apiClient()
 			if err != nil {
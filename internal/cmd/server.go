@@ -2,13 +2,17 @@ package cmd
 
 import (
 	"context"
+	"crypto/sha256"
 	"fmt"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 
 	"github.com/infrahq/infra/internal/cmd/types"
 	"github.com/infrahq/infra/internal/logging"
@@ -32,35 +36,25 @@ func newServerCmd() *cobra.Command {
 				configFilename = os.Getenv("INFRA_SERVER_CONFIG_FILE")
 			}
 
-			infraDir, err := infraHomeDir()
-			if err != nil {
-				return err
-			}
-			options := defaultServerOptions(infraDir)
-
-			if err := server.ApplyOptions(&options, configFilename, cmd.Flags()); err != nil {
-				return err
+			loadOptions := func() (server.Options, error) {
+				return loadServerOptions(configFilename, cmd.Flags())
 			}
-
-			tlsCache, err := canonicalPath(options.TLSCache)
-			if err != nil {
-				return err
-			}
-
-			options.TLSCache = tlsCache
-
-			dbEncryptionKey, err := canonicalPath(options.DBEncryptionKey)
+			options, err := loadOptions()
 			if err != nil {
 				return err
 			}
 
-			options.DBEncryptionKey = dbEncryptionKey
-
 			srv, err := newServer(options)
 			if err != nil {
 				return fmt.Errorf("creating server: %w", err)
 			}
-			return runServer(cmd.Context(), srv)
+
+			ctx, cancel := context.WithCancel(cmd.Context())
+			defer cancel()
+			go watchServerConfig(ctx, configFilename, func() {
+				reloadServer(ctx, srv, loadOptions)
+			})
+			return runServer(ctx, srv)
 		},
 	}
 
@@ -122,6 +116,101 @@ func defaultServerOptions(infraDir string) server.Options {
 	}
 }
 
+func loadServerOptions(configFilename string, flags *pflag.FlagSet) (server.Options, error) {
+	infraDir, err := infraHomeDir()
+	if err != nil {
+		return server.Options{}, err
+	}
+	options := defaultServerOptions(infraDir)
+
+	if err := server.ApplyOptions(&options, configFilename, flags); err != nil {
+		return server.Options{}, err
+	}
+
+	options.TLSCache, err = canonicalPath(options.TLSCache)
+	if err != nil {
+		return server.Options{}, err
+	}
+
+	options.DBEncryptionKey, err = canonicalPath(options.DBEncryptionKey)
+	if err != nil {
+		return server.Options{}, err
+	}
+	return options, nil
+}
+
+// configPollInterval is how often the server config file is checked for
+// changes.
+var configPollInterval = 5 * time.Second
+
+// watchServerConfig calls reload when the process receives a SIGHUP, or when
+// the content of the config file changes. watchServerConfig returns when ctx
+// is cancelled.
+func watchServerConfig(ctx context.Context, configFilename string, reload func()) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	ticker := time.NewTicker(configPollInterval)
+	defer ticker.Stop()
+
+	// the checksum is used instead of the modified time because config files
+	// mounted from a kubernetes ConfigMap are replaced by changing a symlink.
+	checksum, _ := fileChecksum(configFilename)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			logging.Infof("received SIGHUP, reloading server configuration")
+			reload()
+		case <-ticker.C:
+			if configFilename == "" {
+				continue
+			}
+			current, err := fileChecksum(configFilename)
+			if err != nil {
+				// the file may be missing while it is being replaced
+				logging.L.Debug().Err(err).Msg("failed to read server config file")
+				continue
+			}
+			if current == checksum {
+				continue
+			}
+			checksum = current
+			logging.Infof("server config file %v changed, reloading server configuration", configFilename)
+			reload()
+		}
+	}
+}
+
+func fileChecksum(filename string) ([sha256.Size]byte, error) {
+	if filename == "" {
+		return [sha256.Size]byte{}, nil
+	}
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return [sha256.Size]byte{}, err
+	}
+	return sha256.Sum256(content), nil
+}
+
+// reloadServer loads the server options and applies them to srv. If the
+// options can not be loaded, or are not valid, the error is logged and srv
+// continues to use the previous options.
+func reloadServer(ctx context.Context, srv *server.Server, loadOptions func() (server.Options, error)) {
+	options, err := loadOptions()
+	if err != nil {
+		logging.L.Error().Err(err).Msg("failed to load server configuration, the previous configuration is still in use")
+		return
+	}
+	if _, err := srv.Reload(ctx, options); err != nil {
+		logging.L.Error().Err(err).Msg("failed to reload server configuration")
+		return
+	}
+	logging.Infof("reloaded server configuration")
+}
+
 // runServer is a shim for testing.
 var runServer = func(ctx context.Context, srv *server.Server) error {
 	return srv.Run(ctx)
@@ -4,8 +4,11 @@ import (
 	"context"
 	"fmt"
 	"net/url"
+	"os"
 	"path/filepath"
 	"reflect"
+	"runtime"
+	"syscall"
 	"testing"
 	"time"
 
@@ -204,11 +207,38 @@ users:
     password: the-password
     infraRole: admin
 
+groups:
+  - name: developers
+    members:
+      - username
+      - other@example.com
+
+grants:
+  - user: username
+    role: admin
+    resource: production
+  - group: developers
+    role: view
+    resource: production.default
+
+providers:
+  - name: okta
+    kind: okta
+    url: example.okta.com
+    clientID: the-client-id
+    clientSecret: the-client-secret
+
+destinations:
+  - name: production
+    kind: kubernetes
+
 redis:
   host: myredis
   username: myuser
   password: mypassword
 
+rateLimiter: memory
+
 api:
   requestTimeout: 2m
   blockingRequestTimeout: 4m
@@ -269,6 +299,25 @@ api:
 								InfraRole: "admin",
 							},
 						},
+						Groups: []server.Group{
+							{Name: "developers", Members: []string{"username", "other@example.com"}},
+						},
+						Grants: []server.Grant{
+							{User: "username", Role: "admin", Resource: "production"},
+							{Group: "developers", Role: "view", Resource: "production.default"},
+						},
+						Providers: []server.Provider{
+							{
+								Name:         "okta",
+								Kind:         "okta",
+								URL:          "example.okta.com",
+								ClientID:     "the-client-id",
+								ClientSecret: "the-client-secret",
+							},
+						},
+						Destinations: []server.Destination{
+							{Name: "production", Kind: "kubernetes"},
+						},
 					},
 
 					DB: data.NewDBOptions{
@@ -283,6 +332,7 @@ api:
 						Username: "myuser",
 						Password: "mypassword",
 					},
+					RateLimiter: "memory",
 
 					API: server.APIOptions{
 						RequestTimeout:         2 * time.Minute,
@@ -502,30 +552,6 @@ func TestServerCmd_DeprecatedConfig(t *testing.T) {
 			},
 			expectedErr: "dbEncryptionKeyProvider is no longer supported",
 		},
-		{
-			name: "grants",
-			setup: func(t *testing.T, cmd *cobra.Command) {
-				content := `grants: []`
-
-				dir := fs.NewDir(t, t.Name(),
-					fs.WithFile("cfg.yaml", content))
-
-				t.Setenv("INFRA_SERVER_CONFIG_FILE", dir.Join("cfg.yaml"))
-			},
-			expectedErr: "grants can no longer be defined from config",
-		},
-		{
-			name: "providers",
-			setup: func(t *testing.T, cmd *cobra.Command) {
-				content := `providers: []`
-
-				dir := fs.NewDir(t, t.Name(),
-					fs.WithFile("cfg.yaml", content))
-
-				t.Setenv("INFRA_SERVER_CONFIG_FILE", dir.Join("cfg.yaml"))
-			},
-			expectedErr: "providers can no longer be defined from config",
-		},
 	}
 
 	for _, tc := range testCases {
@@ -534,3 +560,54 @@ func TestServerCmd_DeprecatedConfig(t *testing.T) {
 		})
 	}
 }
+
+func TestWatchServerConfig(t *testing.T) {
+	origInterval := configPollInterval
+	configPollInterval = 10 * time.Millisecond
+	t.Cleanup(func() {
+		configPollInterval = origInterval
+	})
+
+	dir := fs.NewDir(t, t.Name(), fs.WithFile("config.yaml", "addr:\n  http: :80\n"))
+	filename := dir.Join("config.yaml")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	reloads := make(chan struct{}, 10)
+	go watchServerConfig(ctx, filename, func() {
+		reloads <- struct{}{}
+	})
+
+	// give the watcher time to read the original file
+	time.Sleep(50 * time.Millisecond)
+	select {
+	case <-reloads:
+		t.Fatal("unexpected reload before the config file changed")
+	default:
+	}
+
+	err := os.WriteFile(filename, []byte("addr:\n  http: :8080\n"), 0o600)
+	assert.NilError(t, err)
+
+	select {
+	case <-reloads:
+	case <-time.After(2 * time.Second):
+		t.Fatal("timeout waiting for reload")
+	}
+
+	t.Run("SIGHUP", func(t *testing.T) {
+		if runtime.GOOS == "windows" {
+			t.Skip("SIGHUP is not supported on windows")
+		}
+		proc, err := os.FindProcess(os.Getpid())
+		assert.NilError(t, err)
+		assert.NilError(t, proc.Signal(syscall.SIGHUP))
+
+		select {
+		case <-reloads:
+		case <-time.After(2 * time.Second):
+			t.Fatal("timeout waiting for reload")
+		}
+	})
+}
@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"bufio"
+	"bytes"
 	"context"
 	"crypto/rand"
 	"crypto/rsa"
@@ -13,6 +14,7 @@ import (
 	"io/fs"
 	"net"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"strings"
 	"syscall"
@@ -26,22 +28,12 @@ import (
 	"github.com/infrahq/infra/internal/logging"
 )
 
-func newSSHCmd(cli *CLI) *cobra.Command {
-	cmd := &cobra.Command{
-		Use:    "ssh",
-		Short:  "Commands for integrating with ssh",
-		Hidden: true,
-	}
-
-	cmd.AddCommand(newSSHHostsCmd(cli))
-	return cmd
-}
-
 func newSSHHostsCmd(cli *CLI) *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "hosts HOSTNAME PORT",
-		Short: "Check if the host is known to infra",
-		Args:  ExactArgs(2),
+		Use:    "hosts HOSTNAME PORT",
+		Short:  "Check if the host is known to infra",
+		Args:   ExactArgs(2),
+		Hidden: true,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			host, port := args[0], args[1]
 
@@ -82,6 +74,16 @@ func (e exitError) Error() string {
 	return fmt.Sprintf("exit code %v", e.code)
 }
 
+// exitErrorFromCmd returns an exitError with the exit code of a command that
+// exited with a non-zero code, so that the CLI exits with the same code.
+func exitErrorFromCmd(err error) error {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitError{code: exitErr.ExitCode()}
+	}
+	return err
+}
+
 var errNotInfraDestination = fmt.Errorf("no destination matching that address or hostname")
 
 var errBeforeDestinationMatch = fmt.Errorf("failed to lookup infra destinations")
@@ -137,35 +139,67 @@ func splitHostPortSSH(hostname string) (host, port string) {
 	return host, port
 }
 
-func writeInfraKnownHosts(infraSSHDir string, dest *api.Destination) error {
-	filename := filepath.Join(infraSSHDir, "known_hosts")
-	fh, err := os.OpenFile(filename, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o600)
-	if err != nil {
-		return err
-	}
+func writeInfraKnownHosts(infraSSHDir string, destinations []api.Destination) error {
+	buf := new(bytes.Buffer)
+	for _, dest := range destinations {
+		hostname := dest.Connection.URL
+		if host, _, err := net.SplitHostPort(hostname); err == nil {
+			hostname = host
+		}
 
-	hostname := dest.Connection.URL
-	if host, _, err := net.SplitHostPort(hostname); err == nil {
-		hostname = host
-	}
+		for _, key := range strings.Split(string(dest.Connection.CA), "\n") {
+			if key == "" {
+				continue
+			}
 
-	for _, key := range strings.Split(string(dest.Connection.CA), "\n") {
-		if key == "" {
-			continue
+			fmt.Fprintf(buf, "%v %v\n", hostname, key)
 		}
+	}
+	return writeInfraSSHFile(filepath.Join(infraSSHDir, "known_hosts"), buf.Bytes())
+}
 
-		line := fmt.Sprintf("%v %v\n", hostname, key)
-		if _, err := fh.WriteString(line); err != nil {
-			return err
-		}
+// writeInfraSSHFile replaces the contents of a file in the infra ssh directory.
+// The content is written to a temporary file that is renamed, so that ssh
+// never reads a partial file.
+func writeInfraSSHFile(filename string, content []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(filename), filepath.Base(filename)+".tmp")
+	if err != nil {
+		return err
 	}
-	if err := fh.Sync(); err != nil {
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(content); err != nil {
+		_ = tmp.Close()
 		return err
 	}
-	if err := fh.Close(); err != nil {
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
 		return err
 	}
-	return nil
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filename)
+}
+
+// lockInfraSSHDir blocks until it acquires the lock shared by 'infra ssh hosts'
+// and the agent, which both write the files in the infra ssh directory. The
+// returned func releases the lock.
+func lockInfraSSHDir(infraSSHDir string) (func(), error) {
+	lock, err := os.OpenFile(filepath.Join(infraSSHDir, "lock"), os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	if err := lockFile(lock); err != nil {
+		_ = lock.Close()
+		return nil, fmt.Errorf("lock infra ssh directory: %w", err)
+	}
+	return func() {
+		if err := unlockFile(lock); err != nil {
+			logging.Debugf("unlock infra ssh directory: %v", err)
+		}
+		_ = lock.Close()
+	}, nil
 }
 
 func setupDestinationSSHConfig(ctx context.Context, cli *CLI, destination *api.Destination) error {
@@ -207,11 +241,83 @@ func setupDestinationSSHConfig(ctx context.Context, cli *CLI, destination *api.D
 		return fmt.Errorf("create ssh keypair: %w", err)
 	}
 
-	if err := writeInfraKnownHosts(infraSSHDir, destination); err != nil {
+	unlock, err := lockInfraSSHDir(infraSSHDir)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	destinations := []api.Destination{*destination}
+	if err := writeInfraKnownHosts(infraSSHDir, destinations); err != nil {
 		return fmt.Errorf("write known hosts: %w", err)
 	}
 
-	if err := writeDestinationSSHConfig(infraSSHDir, destination, user, keyFilename); err != nil {
+	if err := writeDestinationSSHConfig(infraSSHDir, destinations, user, keyFilename); err != nil {
+		return fmt.Errorf("write infra ssh config: %w", err)
+	}
+	return nil
+}
+
+// syncSSHConfig writes the infra ssh config and known hosts for every ssh
+// destination the user has a grant for. The key pair is only created by
+// 'infra ssh hosts', so syncSSHConfig does nothing until the user has used
+// infra ssh at least once.
+func syncSSHConfig(
+	ctx context.Context,
+	client *api.Client,
+	hostCfg *ClientHostConfig,
+	user *api.User,
+	destinations []api.Destination,
+	grants []api.Grant,
+) error {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return fmt.Errorf("user home directory: %w", err)
+	}
+	infraSSHDir := filepath.Join(homeDir, ".ssh/infra")
+
+	keysCfg, err := readKeysConfig(infraSSHDir)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return nil
+	case err != nil:
+		return err
+	}
+
+	org, err := client.GetOrganizationSelf(ctx)
+	if err != nil {
+		return err
+	}
+
+	keyFilename := findSSHKey(infraSSHDir, keysCfg, hostCfg, org.ID, user)
+	if keyFilename == "" {
+		logging.Debugf("no ssh key for %v, skipping ssh config", hostCfg.Host)
+		return nil
+	}
+
+	var granted []api.Destination
+	for _, destination := range destinations {
+		if !isDestinationAvailable(destination) {
+			continue
+		}
+		for _, grant := range grants {
+			if isResourceForDestination(grant.Resource, destination.Name) {
+				granted = append(granted, destination)
+				break
+			}
+		}
+	}
+
+	unlock, err := lockInfraSSHDir(infraSSHDir)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if err := writeInfraKnownHosts(infraSSHDir, granted); err != nil {
+		return fmt.Errorf("write known hosts: %w", err)
+	}
+	if err := writeDestinationSSHConfig(infraSSHDir, granted, user, keyFilename); err != nil {
 		return fmt.Errorf("write infra ssh config: %w", err)
 	}
 	return nil
@@ -432,14 +538,14 @@ var infraDestinationSSHConfigTemplate = template.Must(template.New("ssh-config")
 const infraDestinationSSHConfig = `
 
 # This file is managed by Infra. Do not edit!
-
+{{ range .Hosts }}
 Host {{ .Hostname }}
-    IdentityFile {{ .KeyFilename }}
+    IdentityFile {{ $.KeyFilename }}
     IdentitiesOnly yes
-    UserKnownHostsFile {{ .InfraSSHDir }}/known_hosts
-    User {{ .Username }}
+    UserKnownHostsFile {{ $.InfraSSHDir }}/known_hosts
+    User {{ $.Username }}
     Port {{ .Port }}
-
+{{ end }}
 `
 
 // hasInfraMatchLine does a minimal parse of the ssh client config file and
@@ -477,32 +583,24 @@ func hasInfraMatchLine(sshConfig io.Reader) bool {
 
 func writeDestinationSSHConfig(
 	infraSSHDir string,
-	destination *api.Destination,
+	destinations []api.Destination,
 	user *api.User,
 	keyFilename string,
 ) error {
-	filename := filepath.Join(infraSSHDir, "config")
-	fh, err := os.OpenFile(filename, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o600)
-	if err != nil {
-		return err
+	hosts := make([]map[string]string, 0, len(destinations))
+	for _, destination := range destinations {
+		host, port := splitHostPortSSH(destination.Connection.URL)
+		hosts = append(hosts, map[string]string{"Hostname": host, "Port": port})
 	}
-
-	host, port := splitHostPortSSH(destination.Connection.URL)
 	data := map[string]any{
 		"Username":    user.SSHLoginName,
-		"Hostname":    host,
-		"Port":        port,
+		"Hosts":       hosts,
 		"KeyFilename": keyFilename,
 		"InfraSSHDir": infraSSHDir,
 	}
-	if err := infraDestinationSSHConfigTemplate.Execute(fh, data); err != nil {
+	buf := new(bytes.Buffer)
+	if err := infraDestinationSSHConfigTemplate.Execute(buf, data); err != nil {
 		return err
 	}
-	if err := fh.Sync(); err != nil {
-		return err
-	}
-	if err := fh.Close(); err != nil {
-		return err
-	}
-	return nil
+	return writeInfraSSHFile(filepath.Join(infraSSHDir, "config"), buf.Bytes())
 }
@@ -0,0 +1,340 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/infrahq/infra/api"
+)
+
+// sshExecutable and scpExecutable are the programs run by 'infra ssh' and
+// 'infra scp'.
+var (
+	sshExecutable = "ssh"
+	scpExecutable = "scp"
+)
+
+type sshCmdOptions struct {
+	LocalForward   []string
+	RemoteForward  []string
+	DynamicForward []string
+	NoCommand      bool
+	Options        []string
+}
+
+func newSSHCmd(cli *CLI) *cobra.Command {
+	var options sshCmdOptions
+
+	cmd := &cobra.Command{
+		Use:   "ssh [USER@]DESTINATION [-- COMMAND...]",
+		Short: "Connect to a destination with ssh",
+		Long: `Connect to a destination with ssh.
+
+DESTINATION is the name of an ssh destination, or its hostname. The ssh key
+pair for Infra is created when it does not exist. The system ssh is run with
+a temporary ssh config for the destination, so that your ~/.ssh/config is
+not changed.`,
+		Example: `# Connect to the bastion destination
+$ infra ssh bastion
+
+# Run a command as the root user
+$ infra ssh root@bastion -- systemctl status sshd
+
+# Forward local port 8080 to port 80 on the destination
+$ infra ssh -N -L 8080:localhost:80 bastion`,
+		Args:              MinArgs(1),
+		ValidArgsFunction: completeArgs([]completionSource{completeSSHDestinations}),
+		GroupID:           groupCore,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runSSHConnect(cmd.Context(), cli, args[0], args[1:], options)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringArrayVarP(&options.LocalForward, "local-forward", "L", nil, "Forward a local port to the destination, see 'man ssh'")
+	flags.StringArrayVarP(&options.RemoteForward, "remote-forward", "R", nil, "Forward a port on the destination to the local host, see 'man ssh'")
+	flags.StringArrayVarP(&options.DynamicForward, "dynamic-forward", "D", nil, "Forward a local port using the SOCKS protocol, see 'man ssh'")
+	flags.BoolVarP(&options.NoCommand, "no-command", "N", false, "Do not run a command, only forward ports")
+	flags.StringArrayVarP(&options.Options, "option", "o", nil, "Options passed to ssh, in the format of the ssh config file")
+
+	cmd.AddCommand(newSSHHostsCmd(cli))
+	return cmd
+}
+
+func runSSHConnect(ctx context.Context, cli *CLI, target string, command []string, options sshCmdOptions) error {
+	session, err := newSSHSession(ctx, cli)
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+
+	login, name := splitSSHLogin(target)
+	hostname, err := session.addDestination(name)
+	if err != nil {
+		return err
+	}
+	if err := session.writeConfig(ctx, cli); err != nil {
+		return err
+	}
+
+	args := []string{"-F", session.configFilename()}
+	if login != "" {
+		args = append(args, "-l", login)
+	}
+	for _, forward := range options.LocalForward {
+		args = append(args, "-L", forward)
+	}
+	for _, forward := range options.RemoteForward {
+		args = append(args, "-R", forward)
+	}
+	for _, forward := range options.DynamicForward {
+		args = append(args, "-D", forward)
+	}
+	if options.NoCommand {
+		args = append(args, "-N")
+	}
+	for _, option := range options.Options {
+		args = append(args, "-o", option)
+	}
+	args = append(args, hostname)
+	if len(command) > 0 {
+		args = append(args, "--")
+		args = append(args, command...)
+	}
+	return session.run(cli, sshExecutable, args)
+}
+
+type scpCmdOptions struct {
+	Recursive bool
+	Options   []string
+}
+
+func newSCPCmd(cli *CLI) *cobra.Command {
+	var options scpCmdOptions
+
+	cmd := &cobra.Command{
+		Use:   "scp SOURCE... TARGET",
+		Short: "Copy files to or from a destination with scp",
+		Long: `Copy files to or from a destination with scp.
+
+Remote files are written as [USER@]DESTINATION:PATH, where DESTINATION is the
+name of an ssh destination, or its hostname.`,
+		Example: `# Copy a file to the home directory on the bastion destination
+$ infra scp ./notes.txt bastion:
+
+# Copy a directory from the destination
+$ infra scp -r root@bastion:/var/log/app ./logs`,
+		Args:    MinArgs(2),
+		GroupID: groupCore,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runSCP(cmd.Context(), cli, args, options)
+		},
+	}
+
+	cmd.Flags().BoolVarP(&options.Recursive, "recursive", "r", false, "Copy directories recursively")
+	cmd.Flags().StringArrayVarP(&options.Options, "option", "o", nil, "Options passed to scp, in the format of the ssh config file")
+	return cmd
+}
+
+func runSCP(ctx context.Context, cli *CLI, paths []string, options scpCmdOptions) error {
+	session, err := newSSHSession(ctx, cli)
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+
+	args := []string{"-F", session.configFilename()}
+	if options.Recursive {
+		args = append(args, "-r")
+	}
+	for _, option := range options.Options {
+		args = append(args, "-o", option)
+	}
+
+	var remote bool
+	for _, path := range paths {
+		target, file, ok := splitSCPPath(path)
+		if !ok {
+			args = append(args, path)
+			continue
+		}
+
+		remote = true
+		login, name := splitSSHLogin(target)
+		hostname, err := session.addDestination(name)
+		if err != nil {
+			return err
+		}
+		if login != "" {
+			hostname = login + "@" + hostname
+		}
+		args = append(args, hostname+":"+file)
+	}
+	if !remote {
+		return Error{Message: "at least one path must be on a destination, in the format DESTINATION:PATH"}
+	}
+
+	if err := session.writeConfig(ctx, cli); err != nil {
+		return err
+	}
+	return session.run(cli, scpExecutable, args)
+}
+
+// splitSSHLogin splits a [USER@]DESTINATION argument into the user and the
+// destination.
+func splitSSHLogin(target string) (login, destination string) {
+	if i := strings.LastIndex(target, "@"); i >= 0 {
+		return target[:i], target[i+1:]
+	}
+	return "", target
+}
+
+// splitSCPPath splits a [USER@]DESTINATION:PATH argument. ok is false when
+// path is a local path.
+func splitSCPPath(path string) (target, file string, ok bool) {
+	i := strings.Index(path, ":")
+	if i <= 0 {
+		return "", "", false
+	}
+	// a path separator before the colon means a local path, as it does for scp
+	if strings.ContainsAny(path[:i], `/\`) {
+		return "", "", false
+	}
+	// a drive letter, like C:\Users, is a local path
+	if i == 1 && len(path) > 2 && (path[2] == '\\' || path[2] == '/') {
+		return "", "", false
+	}
+	return path[:i], path[i+1:], true
+}
+
+// sshSession is the temporary ssh config and known hosts used by 'infra ssh'
+// and 'infra scp' to connect to destinations.
+type sshSession struct {
+	client       *api.Client
+	hostConfig   *ClientHostConfig
+	destinations []api.Destination
+	selected     []api.Destination
+	// dir is the temporary directory for the ssh config and known hosts.
+	dir string
+}
+
+func newSSHSession(ctx context.Context, cli *CLI) (*sshSession, error) {
+	if _, err := exec.LookPath(sshExecutable); err != nil {
+		return nil, Error{Message: "ssh was not found in PATH, install OpenSSH to connect to destinations"}
+	}
+
+	hostConfig, err := currentHostConfig()
+	if err != nil {
+		return nil, err
+	}
+	client, err := cli.apiClient()
+	if err != nil {
+		return nil, err
+	}
+
+	destinations, err := listAll(ctx, client.ListDestinations, api.ListDestinationsRequest{Kind: "ssh"})
+	if err != nil {
+		return nil, err
+	}
+
+	dir, err := os.MkdirTemp("", "infra-ssh-")
+	if err != nil {
+		return nil, err
+	}
+	return &sshSession{
+		client:       client,
+		hostConfig:   hostConfig,
+		destinations: destinations,
+		dir:          dir,
+	}, nil
+}
+
+// addDestination adds the destination with name, or hostname, to the session,
+// and returns the hostname used to connect to it.
+func (s *sshSession) addDestination(name string) (string, error) {
+	var destination *api.Destination
+	for i, dest := range s.destinations {
+		if dest.Name == name {
+			destination = &s.destinations[i]
+			break
+		}
+		if host, _ := splitHostPortSSH(dest.Connection.URL); host == name && destination == nil {
+			destination = &s.destinations[i]
+		}
+	}
+
+	switch {
+	case destination == nil:
+		return "", Error{Message: fmt.Sprintf("no ssh destination named %q, use 'infra list' to see the destinations you have access to", name)}
+	case !isDestinationAvailable(*destination):
+		return "", Error{Message: fmt.Sprintf("destination %q is not connected to Infra", destination.Name)}
+	}
+
+	s.selected = append(s.selected, *destination)
+	host, _ := splitHostPortSSH(destination.Connection.URL)
+	return host, nil
+}
+
+// writeConfig provisions the ssh key, and writes the ssh config and known
+// hosts for the selected destinations to the temporary directory.
+func (s *sshSession) writeConfig(ctx context.Context, cli *CLI) error {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return fmt.Errorf("user home directory: %w", err)
+	}
+	infraSSHDir := filepath.Join(homeDir, ".ssh/infra")
+	if err := mkdirAll(infraSSHDir); err != nil {
+		return err
+	}
+
+	user, err := s.client.GetUserSelf(ctx)
+	if err != nil {
+		return err
+	}
+
+	keyFilename, err := provisionSSHKey(ctx, provisionSSHKeyOptions{
+		cli:         cli,
+		client:      s.client,
+		hostConfig:  s.hostConfig,
+		infraSSHDir: infraSSHDir,
+		user:        user,
+	})
+	if err != nil {
+		return fmt.Errorf("create ssh keypair: %w", err)
+	}
+
+	if err := writeInfraKnownHosts(s.dir, s.selected); err != nil {
+		return fmt.Errorf("write known hosts: %w", err)
+	}
+	if err := writeDestinationSSHConfig(s.dir, s.selected, user, keyFilename); err != nil {
+		return fmt.Errorf("write ssh config: %w", err)
+	}
+	return nil
+}
+
+func (s *sshSession) configFilename() string {
+	return filepath.Join(s.dir, "config")
+}
+
+// run runs program with args, connected to the stdin, stdout, and stderr of
+// the CLI. The CLI exits with the exit code of program. Signals are handled
+// while program runs, so that the CLI exits after program, and Close removes
+// the temporary directory.
+func (s *sshSession) run(cli *CLI, program string, args []string) error {
+	cmd := exec.Command(program, args...)
+	cmd.Stdin = cli.Stdin
+	cmd.Stdout = cli.Stdout
+	cmd.Stderr = cli.Stderr
+	return runForwardingSignals(cmd)
+}
+
+// Close removes the temporary directory.
+func (s *sshSession) Close() {
+	_ = os.RemoveAll(s.dir)
+}
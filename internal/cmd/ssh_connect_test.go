@@ -0,0 +1,218 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"gotest.tools/v3/assert"
+
+	"github.com/infrahq/infra/api"
+	"github.com/infrahq/infra/uid"
+)
+
+func TestSSHCmd(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake ssh script requires a unix shell")
+	}
+	home := setupEnv(t)
+
+	const hostKey = "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIHostKeyForTesting"
+	userID := uid.New()
+	orgID := uid.New()
+	keyID := uid.New()
+	handler := func(resp http.ResponseWriter, req *http.Request) {
+		var body any
+		switch req.URL.Path {
+		case "/api/destinations":
+			assert.Check(t, req.URL.Query().Get("kind") == "ssh")
+			body = api.ListResponse[api.Destination]{
+				Items: []api.Destination{
+					{
+						Name:       "bastion",
+						Kind:       "ssh",
+						Connected:  true,
+						Connection: api.DestinationConnection{URL: "bastion.example.com:2222", CA: hostKey},
+					},
+					{
+						Name:       "offline",
+						Kind:       "ssh",
+						Connection: api.DestinationConnection{URL: "offline.example.com"},
+					},
+				},
+				Count: 2,
+			}
+		case "/api/users/self":
+			body = api.User{
+				ID:           userID,
+				Name:         "testuser@example.com",
+				SSHLoginName: "testuser",
+				PublicKeys:   []api.UserPublicKey{{ID: keyID}},
+			}
+		case "/api/organizations/self":
+			body = api.Organization{ID: orgID}
+		default:
+			resp.WriteHeader(http.StatusNotFound)
+			return
+		}
+		assert.Check(t, json.NewEncoder(resp).Encode(body))
+	}
+	srv := httptest.NewTLSServer(http.HandlerFunc(handler))
+	t.Cleanup(srv.Close)
+
+	cfg := newTestClientConfig(srv, api.User{ID: userID})
+	assert.NilError(t, writeConfig(&cfg))
+
+	// use an existing key, so that the test does not generate a new key
+	infraSSHDir := filepath.Join(home, ".ssh", "infra")
+	assert.NilError(t, os.MkdirAll(filepath.Join(infraSSHDir, "keys"), 0o700))
+	keyFilename := filepath.Join(infraSSHDir, "keys", keyID.String())
+	assert.NilError(t, os.WriteFile(keyFilename, []byte("private"), 0o600))
+	assert.NilError(t, os.WriteFile(keyFilename+".pub", []byte("public"), 0o600))
+	assert.NilError(t, writeKeysConfig(infraSSHDir, &keysConfig{
+		Keys: []localPublicKey{{
+			Server:         cfg.Hosts[0].Host,
+			OrganizationID: orgID.String(),
+			UserID:         userID.String(),
+			PublicKeyID:    keyID.String(),
+		}},
+	}))
+
+	// the fake ssh and scp print their arguments, the ssh config, and the
+	// known hosts passed with -F
+	bin := t.TempDir()
+	fake := `#!/bin/sh
+echo "args: $*"
+cat "$2"
+cat "$(dirname "$2")/known_hosts"
+exit 3
+`
+	for _, name := range []string{"fake-ssh", "fake-scp"} {
+		assert.NilError(t, os.WriteFile(filepath.Join(bin, name), []byte(fake), 0o700))
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	origSSH, origSCP := sshExecutable, scpExecutable
+	sshExecutable, scpExecutable = "fake-ssh", "fake-scp"
+	t.Cleanup(func() {
+		sshExecutable, scpExecutable = origSSH, origSCP
+	})
+
+	expectedConfig := func(configFilename string) string {
+		dir := filepath.Dir(configFilename)
+		return fmt.Sprintf(`
+
+# This file is managed by Infra. Do not edit!
+
+Host bastion.example.com
+    IdentityFile %v
+    IdentitiesOnly yes
+    UserKnownHostsFile %v/known_hosts
+    User testuser
+    Port 2222
+
+bastion.example.com %v
+`, keyFilename, dir, hostKey)
+	}
+
+	t.Run("ssh with command", func(t *testing.T) {
+		ctx, bufs := PatchCLI(context.Background())
+		err := Run(ctx, "ssh", "-L", "8080:localhost:80", "root@bastion", "--", "uptime", "-p")
+		var exitErr exitError
+		assert.Assert(t, errors.As(err, &exitErr), err)
+		assert.Equal(t, exitErr.ExitCode(), 3)
+
+		out := bufs.Stdout.String()
+		firstLine, rest, _ := strings.Cut(out, "\n")
+		fields := strings.Fields(firstLine)
+		assert.Equal(t, len(fields), 11, firstLine)
+		configFilename := fields[2]
+		assert.DeepEqual(t, fields, []string{
+			"args:", "-F", configFilename, "-l", "root",
+			"-L", "8080:localhost:80", "bastion.example.com", "--", "uptime", "-p",
+		})
+		assert.Equal(t, rest, expectedConfig(configFilename))
+
+		// the temporary config is removed
+		_, err = os.Stat(configFilename)
+		assert.Assert(t, os.IsNotExist(err))
+		// the global ssh config is not changed
+		_, err = os.Stat(filepath.Join(home, ".ssh", "config"))
+		assert.Assert(t, os.IsNotExist(err))
+	})
+
+	t.Run("ssh by hostname", func(t *testing.T) {
+		ctx, bufs := PatchCLI(context.Background())
+		err := Run(ctx, "ssh", "-N", "bastion.example.com")
+		assert.ErrorContains(t, err, "exit code 3")
+
+		firstLine, _, _ := strings.Cut(bufs.Stdout.String(), "\n")
+		fields := strings.Fields(firstLine)
+		assert.DeepEqual(t, fields[3:], []string{"-N", "bastion.example.com"})
+	})
+
+	t.Run("unknown destination", func(t *testing.T) {
+		ctx, _ := PatchCLI(context.Background())
+		err := Run(ctx, "ssh", "unknown")
+		assert.ErrorContains(t, err, `no ssh destination named "unknown"`)
+	})
+
+	t.Run("destination not connected", func(t *testing.T) {
+		ctx, _ := PatchCLI(context.Background())
+		err := Run(ctx, "ssh", "offline")
+		assert.ErrorContains(t, err, `destination "offline" is not connected to Infra`)
+	})
+
+	t.Run("scp", func(t *testing.T) {
+		ctx, bufs := PatchCLI(context.Background())
+		err := Run(ctx, "scp", "-r", "./local/dir", "root@bastion:/tmp/")
+		assert.ErrorContains(t, err, "exit code 3")
+
+		out := bufs.Stdout.String()
+		firstLine, rest, _ := strings.Cut(out, "\n")
+		fields := strings.Fields(firstLine)
+		assert.Equal(t, len(fields), 6, firstLine)
+		configFilename := fields[2]
+		assert.DeepEqual(t, fields, []string{
+			"args:", "-F", configFilename, "-r", "./local/dir", "root@bastion.example.com:/tmp/",
+		})
+		assert.Equal(t, rest, expectedConfig(configFilename))
+	})
+
+	t.Run("scp without a destination", func(t *testing.T) {
+		ctx, _ := PatchCLI(context.Background())
+		err := Run(ctx, "scp", "a.txt", "b.txt")
+		assert.ErrorContains(t, err, "at least one path must be on a destination")
+	})
+}
+
+func TestSplitSCPPath(t *testing.T) {
+	type testCase struct {
+		path   string
+		target string
+		file   string
+		remote bool
+	}
+	testCases := []testCase{
+		{path: "bastion:/tmp/file", target: "bastion", file: "/tmp/file", remote: true},
+		{path: "root@bastion:", target: "root@bastion", file: "", remote: true},
+		{path: "./local:file", remote: false},
+		{path: "local.txt", remote: false},
+		{path: `C:\Users\file`, remote: false},
+		{path: ":file", remote: false},
+	}
+	for _, tc := range testCases {
+		target, file, ok := splitSCPPath(tc.path)
+		assert.Equal(t, ok, tc.remote, tc.path)
+		assert.Equal(t, target, tc.target, tc.path)
+		assert.Equal(t, file, tc.file, tc.path)
+	}
+}
@@ -62,6 +62,25 @@ func publicKeyMatches(localKey localPublicKey, hostCfg *ClientHostConfig, orgID
 		localKey.OrganizationID == orgID.String()
 }
 
+// findSSHKey returns the filename of the private key that matches the host,
+// org, and user, and exists both locally and in the API. findSSHKey returns an
+// empty string if there is no matching key.
+func findSSHKey(infraSSHDir string, keysCfg *keysConfig, hostCfg *ClientHostConfig, orgID uid.ID, user *api.User) string {
+	for _, key := range keysCfg.Keys {
+		if !publicKeyMatches(key, hostCfg, orgID) {
+			continue
+		}
+		if !userPublicKeyContains(user.PublicKeys, key.PublicKeyID) {
+			continue
+		}
+		filename := filepath.Join(infraSSHDir, "keys", key.PublicKeyID)
+		if fileExists(filename) && fileExists(filename+".pub") {
+			return filename
+		}
+	}
+	return ""
+}
+
 func userPublicKeyContains(keys []api.UserPublicKey, id string) bool {
 	for _, key := range keys {
 		if key.ID.String() == id {
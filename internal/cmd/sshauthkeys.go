@@ -22,6 +22,7 @@ func newSSHDCmd(cli *CLI) *cobra.Command {
 	}
 
 	cmd.AddCommand(newSSHDAuthKeysCmd(cli))
+	cmd.AddCommand(newSSHDRecordCmd(cli))
 	return cmd
 }
 
@@ -0,0 +1,191 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/spf13/cobra"
+)
+
+type sshdRecordOptions struct {
+	socketPath string
+}
+
+func newSSHDRecordCmd(cli *CLI) *cobra.Command {
+	var opts sshdRecordOptions
+	cmd := &cobra.Command{
+		Use:    "record",
+		Hidden: true,
+		Args:   NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// sshd_config: ForceCommand sudo -n infra sshd record --socket <path>
+			return runSSHDRecord(cli, opts)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.socketPath, "socket",
+		"/run/infra/session-recording.sock", "Path to the connector session recording socket")
+	return cmd
+}
+
+// sftpServerPaths are the locations of the sftp-server binary on common
+// distributions. sftp-server is used to run the internal-sftp subsystem.
+var sftpServerPaths = []string{
+	"/usr/lib/openssh/sftp-server",
+	"/usr/libexec/openssh/sftp-server",
+	"/usr/lib/ssh/sftp-server",
+	"/usr/libexec/sftp-server",
+}
+
+// sftpSubsystemArgs returns the arguments to run the sftp subsystem when
+// command is the sftp subsystem configured in sshd_config. sshd runs the
+// ForceCommand for subsystem requests as well, with SSH_ORIGINAL_COMMAND set
+// to the command of the subsystem. internal-sftp is built into sshd, so it is
+// run with sftp-server instead, using the same arguments. The returned args
+// are empty when command is internal-sftp and sftp-server is not installed.
+func sftpSubsystemArgs(command string, lookPath func(string) bool) ([]string, bool) {
+	args := strings.Fields(command)
+	if len(args) == 0 {
+		return nil, false
+	}
+
+	switch {
+	case args[0] == "internal-sftp":
+		for _, path := range sftpServerPaths {
+			if lookPath(path) {
+				return append([]string{path}, args[1:]...), true
+			}
+		}
+		return nil, true
+	case filepath.Base(args[0]) == "sftp-server":
+		return args, true
+	}
+	return nil, false
+}
+
+// asciicastHeader is the first line of a recording in asciicast v2 format.
+// See https://github.com/asciinema/asciinema/blob/develop/doc/asciicast-v2.md.
+type asciicastHeader struct {
+	Version   int               `json:"version"`
+	Width     int               `json:"width"`
+	Height    int               `json:"height"`
+	Timestamp int64             `json:"timestamp"`
+	Command   string            `json:"command,omitempty"`
+	Env       map[string]string `json:"env,omitempty"`
+}
+
+// asciicastWriter writes the events of a terminal session in asciicast v2
+// format. Write records output events, so the asciicastWriter can be used as
+// the destination of an io.Copy from a pty.
+type asciicastWriter struct {
+	mu     sync.Mutex
+	out    io.Writer
+	start  time.Time
+	output *asciicastStream
+	input  *asciicastStream
+	err    error
+}
+
+// asciicastStream records the data written to it as events of one kind.
+type asciicastStream struct {
+	w    *asciicastWriter
+	kind string
+	// partial holds the bytes of a multi-byte UTF-8 character that was split
+	// across calls to Write.
+	partial []byte
+}
+
+func newAsciicastWriter(out io.Writer, header asciicastHeader, start time.Time) (*asciicastWriter, error) {
+	header.Version = 2
+	header.Timestamp = start.Unix()
+	raw, err := json.Marshal(header)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := out.Write(append(raw, '\n')); err != nil {
+		return nil, err
+	}
+	w := &asciicastWriter{out: out, start: start}
+	w.output = &asciicastStream{w: w, kind: "o"}
+	w.input = &asciicastStream{w: w, kind: "i"}
+	return w, nil
+}
+
+func (w *asciicastWriter) Write(p []byte) (int, error) {
+	return w.output.Write(p)
+}
+
+// Input returns a writer that records input events.
+func (w *asciicastWriter) Input() io.Writer {
+	return w.input
+}
+
+func (s *asciicastStream) Write(p []byte) (int, error) {
+	s.w.mu.Lock()
+	defer s.w.mu.Unlock()
+
+	data := append(s.partial, p...)
+	cut := incompleteRuneSuffix(data)
+	s.partial = append([]byte(nil), data[len(data)-cut:]...)
+	data = data[:len(data)-cut]
+	if len(data) == 0 {
+		return len(p), s.w.err
+	}
+
+	if err := s.w.event(s.kind, string(data)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Resize records a change to the size of the terminal.
+func (w *asciicastWriter) Resize(width, height int) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.event("r", fmt.Sprintf("%dx%d", width, height))
+}
+
+// Err returns the first error returned when writing an event.
+func (w *asciicastWriter) Err() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.err
+}
+
+func (w *asciicastWriter) event(kind string, data string) error {
+	if w.err != nil {
+		return w.err
+	}
+	elapsed := float64(time.Since(w.start).Microseconds()) / 1e6
+	raw, err := json.Marshal([]any{elapsed, kind, data})
+	if err != nil {
+		return err
+	}
+	if _, err := w.out.Write(append(raw, '\n')); err != nil {
+		w.err = err
+		return err
+	}
+	return nil
+}
+
+// incompleteRuneSuffix returns the number of bytes at the end of p that are
+// the start of a UTF-8 encoded character that is not yet complete.
+func incompleteRuneSuffix(p []byte) int {
+	for i := 1; i < utf8.UTFMax && i <= len(p); i++ {
+		start := len(p) - i
+		if !utf8.RuneStart(p[start]) {
+			continue
+		}
+		if utf8.FullRune(p[start:]) {
+			return 0
+		}
+		return i
+	}
+	return 0
+}
@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestAsciicastWriter(t *testing.T) {
+	out := new(strings.Builder)
+	start := time.Date(2023, 1, 30, 10, 0, 0, 0, time.UTC)
+	w, err := newAsciicastWriter(out, asciicastHeader{
+		Width:  80,
+		Height: 24,
+		Env:    map[string]string{"SHELL": "/bin/bash"},
+	}, start)
+	assert.NilError(t, err)
+
+	_, err = w.Write([]byte("$ ls\r\n"))
+	assert.NilError(t, err)
+	assert.NilError(t, w.Resize(120, 40))
+
+	// a multi-byte character split across writes is recorded as one event
+	euro := []byte("€")
+	_, err = w.Write(append([]byte("cost: "), euro[:2]...))
+	assert.NilError(t, err)
+	_, err = w.Write(euro[2:])
+	assert.NilError(t, err)
+
+	// input and output are buffered separately
+	_, err = w.Input().Write(append([]byte("pwd\n"), euro[:1]...))
+	assert.NilError(t, err)
+	_, err = w.Write([]byte("done"))
+	assert.NilError(t, err)
+	_, err = w.Input().Write(euro[1:])
+	assert.NilError(t, err)
+
+	scanner := bufio.NewScanner(strings.NewReader(out.String()))
+	assert.Assert(t, scanner.Scan())
+	var header asciicastHeader
+	assert.NilError(t, json.Unmarshal(scanner.Bytes(), &header))
+	expectedHeader := asciicastHeader{
+		Version:   2,
+		Width:     80,
+		Height:    24,
+		Timestamp: start.Unix(),
+		Env:       map[string]string{"SHELL": "/bin/bash"},
+	}
+	assert.DeepEqual(t, header, expectedHeader)
+
+	type event struct {
+		Kind string
+		Data string
+	}
+	var events []event
+	for scanner.Scan() {
+		var raw []any
+		assert.NilError(t, json.Unmarshal(scanner.Bytes(), &raw))
+		assert.Equal(t, len(raw), 3)
+		_, ok := raw[0].(float64)
+		assert.Assert(t, ok, "elapsed time should be a number")
+		events = append(events, event{Kind: raw[1].(string), Data: raw[2].(string)})
+	}
+	expected := []event{
+		{Kind: "o", Data: "$ ls\r\n"},
+		{Kind: "r", Data: "120x40"},
+		{Kind: "o", Data: "cost: "},
+		{Kind: "o", Data: "€"},
+		{Kind: "i", Data: "pwd\n"},
+		{Kind: "o", Data: "done"},
+		{Kind: "i", Data: "€"},
+	}
+	assert.DeepEqual(t, events, expected)
+}
+
+func TestSFTPSubsystemArgs(t *testing.T) {
+	installed := func(path string) bool {
+		return path == "/usr/libexec/openssh/sftp-server"
+	}
+
+	type testCase struct {
+		command  string
+		expected []string
+	}
+	testCases := []testCase{
+		{command: ""},
+		{command: "ls -la"},
+		{command: "internal-sftp", expected: []string{"/usr/libexec/openssh/sftp-server"}},
+		{
+			command:  "internal-sftp -f AUTH -l INFO",
+			expected: []string{"/usr/libexec/openssh/sftp-server", "-f", "AUTH", "-l", "INFO"},
+		},
+		{command: "/usr/lib/openssh/sftp-server", expected: []string{"/usr/lib/openssh/sftp-server"}},
+		{command: "/usr/lib/openssh/sftp-server -e", expected: []string{"/usr/lib/openssh/sftp-server", "-e"}},
+	}
+	for _, tc := range testCases {
+		actual, ok := sftpSubsystemArgs(tc.command, installed)
+		assert.Equal(t, ok, tc.expected != nil, tc.command)
+		assert.DeepEqual(t, actual, tc.expected)
+	}
+
+	t.Run("internal-sftp without sftp-server", func(t *testing.T) {
+		args, ok := sftpSubsystemArgs("internal-sftp", func(string) bool { return false })
+		assert.Assert(t, ok)
+		assert.Equal(t, len(args), 0)
+	})
+}
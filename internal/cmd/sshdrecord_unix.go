@@ -0,0 +1,306 @@
+//go:build !windows
+
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"os/signal"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/creack/pty"
+	"golang.org/x/term"
+
+	"github.com/infrahq/infra/internal/connector"
+	"github.com/infrahq/infra/internal/linux"
+)
+
+// runSSHDRecord runs the command requested by the ssh client, or a login
+// shell, as the user that started the ssh session, and sends a recording of
+// the session to the connector.
+//
+// runSSHDRecord runs as root, started by sudo from the sshd ForceCommand, so
+// that the user can not stop or modify the recording. Sessions with a
+// terminal record the output of the terminal. Sessions without a terminal,
+// like scp or remote commands, record both input and output. The sftp
+// subsystem is run without a recording.
+func runSSHDRecord(cli *CLI, opts sshdRecordOptions) error {
+	if os.Geteuid() != 0 {
+		return errors.New("infra sshd record must run as root, from the ForceCommand written by the infra connector")
+	}
+	// sudo sets SUDO_USER to the name of the user that ran sudo
+	username := os.Getenv("SUDO_USER")
+	if username == "" {
+		return errors.New("infra sshd record must run with sudo, SUDO_USER is not set")
+	}
+	localUser, credential, err := sessionUser(username)
+	if err != nil {
+		return err
+	}
+
+	shell := localUser.Shell
+	if shell == "" {
+		shell = "/bin/sh"
+	}
+	command := os.Getenv("SSH_ORIGINAL_COMMAND")
+
+	if args, ok := sftpSubsystemArgs(command, isExecutable); ok {
+		if len(args) == 0 {
+			return errors.New("the internal-sftp subsystem requires sftp-server, which is not installed")
+		}
+		return runSFTPSubsystem(cli, args, localUser, credential, shell)
+	}
+
+	cmd := exec.Command(shell)
+	if command != "" {
+		cmd.Args = []string{shell, "-c", command}
+	} else {
+		// a leading dash in argv[0] starts a login shell
+		cmd.Args = []string{"-" + filepath.Base(shell)}
+	}
+	cmd.Env = sessionEnv(localUser, shell)
+	cmd.Dir = localUser.HomeDir
+	if _, err := os.Stat(cmd.Dir); err != nil {
+		cmd.Dir = "/"
+	}
+
+	// fail closed, the session must not start if it can't be recorded
+	conn, err := net.Dial("unix", opts.socketPath)
+	if err != nil {
+		return fmt.Errorf("session recording is not available: %w", err)
+	}
+	defer conn.Close()
+
+	preamble, err := json.Marshal(connector.SessionRecordingPreamble{LoginName: localUser.Username})
+	if err != nil {
+		return err
+	}
+	if _, err := conn.Write(append(preamble, '\n')); err != nil {
+		return fmt.Errorf("session recording is not available: %w", err)
+	}
+
+	header := asciicastHeader{
+		Command: command,
+		Env:     map[string]string{"SHELL": shell, "TERM": os.Getenv("TERM")},
+	}
+
+	stdin := int(cli.Stdin.Fd())
+	if !term.IsTerminal(stdin) {
+		cmd.SysProcAttr = &syscall.SysProcAttr{Credential: credential}
+		return runSSHDRecordWithoutTerminal(cli, cmd, conn, header)
+	}
+
+	width, height, err := term.GetSize(stdin)
+	if err != nil {
+		return fmt.Errorf("get terminal size: %w", err)
+	}
+	header.Width, header.Height = width, height
+	recorder, err := newAsciicastWriter(conn, header, time.Now())
+	if err != nil {
+		return fmt.Errorf("session recording is not available: %w", err)
+	}
+
+	ptmx, err := startWithTerminal(cmd, credential, width, height)
+	if err != nil {
+		return err
+	}
+	defer ptmx.Close()
+
+	resize := make(chan os.Signal, 1)
+	signal.Notify(resize, syscall.SIGWINCH)
+	defer signal.Stop(resize)
+	go func() {
+		for range resize {
+			width, height, err := term.GetSize(stdin)
+			if err != nil {
+				continue
+			}
+			if err := pty.Setsize(ptmx, &pty.Winsize{Rows: uint16(height), Cols: uint16(width)}); err != nil {
+				continue
+			}
+			_ = recorder.Resize(width, height)
+		}
+	}()
+
+	state, err := term.MakeRaw(stdin)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = term.Restore(stdin, state)
+	}()
+
+	go func() {
+		_, _ = io.Copy(ptmx, cli.Stdin)
+	}()
+	// io.Copy returns when the command exits and the pty is closed, or when
+	// writing the recording fails.
+	_, _ = io.Copy(io.MultiWriter(cli.Stdout, recorder), ptmx)
+
+	if err := recorder.Err(); err != nil {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+		_ = term.Restore(stdin, state)
+		return fmt.Errorf("session ended because recording failed: %w", err)
+	}
+	return exitErrorFromCmd(cmd.Wait())
+}
+
+// runSFTPSubsystem runs the sftp subsystem as the user, connected directly to
+// the ssh session. sftp, and scp with OpenSSH 9 and later, use the sftp
+// subsystem. The sftp protocol is binary, so the file transfer is not
+// recorded.
+func runSFTPSubsystem(cli *CLI, args []string, localUser linux.LocalUser, credential *syscall.Credential, shell string) error {
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Env = sessionEnv(localUser, shell)
+	cmd.Dir = localUser.HomeDir
+	if _, err := os.Stat(cmd.Dir); err != nil {
+		cmd.Dir = "/"
+	}
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = cli.Stdin, cli.Stdout, cli.Stderr
+	cmd.SysProcAttr = &syscall.SysProcAttr{Credential: credential}
+	return exitErrorFromCmd(cmd.Run())
+}
+
+// isExecutable returns true if path is a regular file that can be executed.
+func isExecutable(path string) bool {
+	fi, err := os.Stat(path)
+	return err == nil && fi.Mode().IsRegular() && fi.Mode().Perm()&0o111 != 0
+}
+
+// startWithTerminal starts cmd as the user in a new session, with a new pty as
+// its controlling terminal. The pty is owned by the user, like the pty created
+// by sshd, so that the user can open /dev/tty.
+func startWithTerminal(cmd *exec.Cmd, credential *syscall.Credential, width, height int) (*os.File, error) {
+	ptmx, tty, err := pty.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer tty.Close()
+
+	if err := tty.Chown(int(credential.Uid), -1); err != nil {
+		_ = ptmx.Close()
+		return nil, fmt.Errorf("set owner of terminal: %w", err)
+	}
+	if err := pty.Setsize(ptmx, &pty.Winsize{Rows: uint16(height), Cols: uint16(width)}); err != nil {
+		_ = ptmx.Close()
+		return nil, err
+	}
+
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = tty, tty, tty
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		Setsid:     true,
+		Setctty:    true,
+		Credential: credential,
+	}
+	if err := cmd.Start(); err != nil {
+		_ = ptmx.Close()
+		return nil, err
+	}
+	return ptmx, nil
+}
+
+// runSSHDRecordWithoutTerminal runs cmd and records its input and output. The
+// session ends if the recording fails.
+func runSSHDRecordWithoutTerminal(cli *CLI, cmd *exec.Cmd, conn net.Conn, header asciicastHeader) error {
+	// asciicast requires a terminal size, use the default size of a terminal.
+	header.Width, header.Height = 80, 24
+	recorder, err := newAsciicastWriter(conn, header, time.Now())
+	if err != nil {
+		return fmt.Errorf("session recording is not available: %w", err)
+	}
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	cmd.Stdout = io.MultiWriter(cli.Stdout, recorder)
+	cmd.Stderr = io.MultiWriter(cli.Stderr, recorder)
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	// Wait closes stdin once the command exits, which stops the copy. The copy
+	// is not waited for, because reading from the client may never return.
+	go func() {
+		_, _ = io.Copy(stdin, io.TeeReader(cli.Stdin, recorder.Input()))
+		_ = stdin.Close()
+	}()
+
+	err = cmd.Wait()
+	if recordErr := recorder.Err(); recordErr != nil {
+		return fmt.Errorf("session ended because recording failed: %w", recordErr)
+	}
+	return exitErrorFromCmd(err)
+}
+
+// sessionUser returns the local user that started the ssh session, and the
+// credentials used to run the session as that user.
+func sessionUser(username string) (linux.LocalUser, *syscall.Credential, error) {
+	localUsers, err := linux.ReadLocalUsers("/etc/passwd")
+	if err != nil {
+		return linux.LocalUser{}, nil, fmt.Errorf("read local users: %w", err)
+	}
+
+	for _, localUser := range localUsers {
+		if localUser.Username != username {
+			continue
+		}
+
+		uid, err := strconv.ParseUint(localUser.UID, 10, 32)
+		if err != nil {
+			return localUser, nil, fmt.Errorf("invalid uid for user %v: %w", username, err)
+		}
+		gid, err := strconv.ParseUint(localUser.GID, 10, 32)
+		if err != nil {
+			return localUser, nil, fmt.Errorf("invalid gid for user %v: %w", username, err)
+		}
+		credential := &syscall.Credential{Uid: uint32(uid), Gid: uint32(gid)}
+
+		u, err := user.LookupId(localUser.UID)
+		if err != nil {
+			return localUser, nil, fmt.Errorf("lookup user %v: %w", username, err)
+		}
+		groupIDs, err := u.GroupIds()
+		if err != nil {
+			return localUser, nil, fmt.Errorf("lookup groups of user %v: %w", username, err)
+		}
+		for _, groupID := range groupIDs {
+			id, err := strconv.ParseUint(groupID, 10, 32)
+			if err != nil {
+				continue
+			}
+			credential.Groups = append(credential.Groups, uint32(id))
+		}
+		return localUser, credential, nil
+	}
+	return linux.LocalUser{}, nil, fmt.Errorf("no local user named %q", username)
+}
+
+// sessionEnv returns the environment for the session. sudo removes most of the
+// environment set by sshd, so the environment is created from the user, and
+// the variables that sudo keeps.
+func sessionEnv(localUser linux.LocalUser, shell string) []string {
+	env := []string{
+		"HOME=" + localUser.HomeDir,
+		"USER=" + localUser.Username,
+		"LOGNAME=" + localUser.Username,
+		"SHELL=" + shell,
+		"PATH=/usr/local/bin:/usr/bin:/bin",
+	}
+	for _, name := range []string{"TERM", "LANG", "SSH_CLIENT", "SSH_CONNECTION", "SSH_ORIGINAL_COMMAND"} {
+		if value, ok := os.LookupEnv(name); ok {
+			env = append(env, name+"="+value)
+		}
+	}
+	return env
+}
@@ -0,0 +1,7 @@
+package cmd
+
+import "errors"
+
+func runSSHDRecord(*CLI, sshdRecordOptions) error {
+	return errors.New("session recording is not supported on windows")
+}
@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/infrahq/infra/api"
+	"github.com/infrahq/infra/internal/logging"
+)
+
+// tokenExpiryMargin is the time before a cached token expires when the token
+// is no longer used. The margin prevents kubectl from sending a token that
+// expires before the request reaches the destination.
+const tokenExpiryMargin = time.Minute
+
+// tokenCache stores the token created by 'infra tokens add' for a host and
+// user, so that kubectl can use the same token for many commands. The cache is
+// locked while it is open, so that concurrent kubectl commands wait for the
+// first one to create a token instead of each creating a new token.
+type tokenCache struct {
+	filename string
+	lock     *os.File
+}
+
+// tokenCacheDir returns the directory used to store cached tokens.
+func tokenCacheDir() (string, error) {
+	infraDir, err := infraHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(infraDir, "tokens"), nil
+}
+
+// tokenCacheFilename returns the filename used to cache tokens for the host
+// and user. The name is a hash, because the host may contain characters that
+// are not valid in a filename.
+func tokenCacheFilename(dir string, hostConfig *ClientHostConfig) string {
+	key := sha256.Sum256([]byte(hostConfig.Host + "\x00" + hostConfig.UserID.String()))
+	return filepath.Join(dir, fmt.Sprintf("%x.json", key[:16]))
+}
+
+// openTokenCache opens and locks the token cache for the host and user. The
+// caller must call Close to release the lock.
+func openTokenCache(hostConfig *ClientHostConfig) (*tokenCache, error) {
+	dir, err := tokenCacheDir()
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+
+	filename := tokenCacheFilename(dir, hostConfig)
+	lock, err := os.OpenFile(filename+".lock", os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	if err := lockFile(lock); err != nil {
+		_ = lock.Close()
+		return nil, fmt.Errorf("lock token cache: %w", err)
+	}
+	return &tokenCache{filename: filename, lock: lock}, nil
+}
+
+// Read returns the cached token, or nil if there is no cached token that is
+// valid for at least tokenExpiryMargin.
+func (c *tokenCache) Read() (*api.CreateTokenResponse, error) {
+	content, err := os.ReadFile(c.filename)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return nil, nil
+	case err != nil:
+		return nil, err
+	}
+
+	token := &api.CreateTokenResponse{}
+	if err := json.Unmarshal(content, token); err != nil {
+		return nil, fmt.Errorf("decode cached token: %w", err)
+	}
+	if time.Until(time.Time(token.Expires)) < tokenExpiryMargin {
+		return nil, nil
+	}
+	return token, nil
+}
+
+// Write stores the token in the cache.
+func (c *tokenCache) Write(token *api.CreateTokenResponse) error {
+	content, err := json.Marshal(token)
+	if err != nil {
+		return err
+	}
+	// the cache is locked, so the file can be written in place
+	return os.WriteFile(c.filename, content, 0o600)
+}
+
+// Close releases the lock on the cache.
+func (c *tokenCache) Close() error {
+	if err := unlockFile(c.lock); err != nil {
+		_ = c.lock.Close()
+		return err
+	}
+	return c.lock.Close()
+}
+
+// clearTokenCache removes the cached token for the host and user. It is
+// called on logout so that a token is not used after the session has ended.
+func clearTokenCache(hostConfig *ClientHostConfig) {
+	if hostConfig.UserID == 0 {
+		return
+	}
+	dir, err := tokenCacheDir()
+	if err != nil {
+		logging.Debugf("token cache directory: %s", err)
+		return
+	}
+	filename := tokenCacheFilename(dir, hostConfig)
+	if err := os.Remove(filename); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		logging.Debugf("remove cached token: %s", err)
+	}
+}
@@ -3,11 +3,15 @@ package cmd
 import (
 	"context"
 	"encoding/json"
+	"os"
 	"time"
 
 	"github.com/spf13/cobra"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	clientauthenticationv1beta1 "k8s.io/client-go/pkg/apis/clientauthentication/v1beta1"
+
+	"github.com/infrahq/infra/api"
+	"github.com/infrahq/infra/internal/logging"
 )
 
 func newTokensCmd(cli *CLI) *cobra.Command {
@@ -34,13 +38,7 @@ func newTokensAddCmd(cli *CLI) *cobra.Command {
 }
 
 func tokensCreate(cli *CLI) error {
-	ctx := context.Background()
-	client, err := cli.apiClient()
-	if err != nil {
-		return err
-	}
-
-	token, err := client.CreateToken(ctx)
+	token, err := cachedToken(cli)
 	if err != nil {
 		return err
 	}
@@ -66,3 +64,58 @@ func tokensCreate(cli *CLI) error {
 
 	return nil
 }
+
+// cachedToken returns the cached token for the current host and user, or
+// creates a new token and adds it to the cache. The cache is not used when the
+// access key or server is set from the environment, because the user is not
+// known.
+func cachedToken(cli *CLI) (*api.CreateTokenResponse, error) {
+	_, hasEnvAccessKey := os.LookupEnv("INFRA_ACCESS_KEY")
+	_, hasEnvServer := os.LookupEnv("INFRA_SERVER")
+	if hasEnvAccessKey || hasEnvServer {
+		return createToken(cli)
+	}
+
+	hostConfig, err := currentHostConfig()
+	if err != nil {
+		return nil, err
+	}
+	if hostConfig.UserID == 0 {
+		return createToken(cli)
+	}
+
+	cache, err := openTokenCache(hostConfig)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := cache.Close(); err != nil {
+			logging.Debugf("close token cache: %s", err)
+		}
+	}()
+
+	token, err := cache.Read()
+	switch {
+	case err != nil:
+		logging.Debugf("read cached token: %s", err)
+	case token != nil:
+		return token, nil
+	}
+
+	token, err = createToken(cli)
+	if err != nil {
+		return nil, err
+	}
+	if err := cache.Write(token); err != nil {
+		logging.Debugf("write cached token: %s", err)
+	}
+	return token, nil
+}
+
+func createToken(cli *CLI) (*api.CreateTokenResponse, error) {
+	client, err := cli.apiClient()
+	if err != nil {
+		return nil, err
+	}
+	return client.CreateToken(context.Background())
+}
@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+	clientauthenticationv1beta1 "k8s.io/client-go/pkg/apis/clientauthentication/v1beta1"
+
+	"github.com/infrahq/infra/api"
+)
+
+func TestTokensAddCmd(t *testing.T) {
+	setupEnv(t)
+
+	var count int32
+	var mu sync.Mutex
+	var expires time.Time
+	setExpires := func(t time.Time) {
+		mu.Lock()
+		defer mu.Unlock()
+		expires = t
+	}
+	handler := func(resp http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/api/tokens":
+			n := atomic.AddInt32(&count, 1)
+			mu.Lock()
+			token := api.CreateTokenResponse{
+				Token:   "token-" + string(rune('0'+n)),
+				Expires: api.Time(expires),
+			}
+			mu.Unlock()
+			assert.Check(t, json.NewEncoder(resp).Encode(token))
+		case "/api/logout":
+			_, _ = resp.Write([]byte(`{}`))
+		case "/api/version":
+			assert.Check(t, json.NewEncoder(resp).Encode(api.Version{Version: "0.0.0"}))
+		default:
+			resp.WriteHeader(http.StatusInternalServerError)
+		}
+	}
+	srv := httptest.NewTLSServer(http.HandlerFunc(handler))
+	t.Cleanup(srv.Close)
+
+	cfg := newTestClientConfig(srv, api.User{})
+	assert.NilError(t, writeConfig(&cfg))
+
+	tokensAdd := func(t *testing.T) string {
+		t.Helper()
+		ctx, bufs := PatchCLI(context.Background())
+		err := Run(ctx, "tokens", "add", "--skip-version-check")
+		assert.NilError(t, err)
+
+		var cred clientauthenticationv1beta1.ExecCredential
+		assert.NilError(t, json.Unmarshal(bufs.Stdout.Bytes(), &cred))
+		return cred.Status.Token
+	}
+
+	setExpires(time.Now().Add(5 * time.Minute))
+	assert.Equal(t, tokensAdd(t), "token-1")
+
+	t.Run("cached token is reused", func(t *testing.T) {
+		assert.Equal(t, tokensAdd(t), "token-1")
+		assert.Equal(t, atomic.LoadInt32(&count), int32(1))
+	})
+
+	t.Run("concurrent commands create one token", func(t *testing.T) {
+		clearTokenCache(&cfg.Hosts[0])
+
+		cli := &CLI{}
+		cli.RootOptions.SkipAPIVersionCheck = true
+
+		var wg sync.WaitGroup
+		for i := 0; i < 5; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				token, err := cachedToken(cli)
+				if assert.Check(t, err) {
+					assert.Check(t, token.Token == "token-2", token.Token)
+				}
+			}()
+		}
+		wg.Wait()
+		assert.Equal(t, atomic.LoadInt32(&count), int32(2))
+	})
+
+	t.Run("token near expiry is replaced", func(t *testing.T) {
+		clearTokenCache(&cfg.Hosts[0])
+
+		setExpires(time.Now().Add(30 * time.Second))
+		assert.Equal(t, tokensAdd(t), "token-3")
+
+		setExpires(time.Now().Add(5 * time.Minute))
+		assert.Equal(t, tokensAdd(t), "token-4")
+		assert.Equal(t, tokensAdd(t), "token-4")
+	})
+
+	t.Run("logout clears the cache", func(t *testing.T) {
+		ctx, _ := PatchCLI(context.Background())
+		assert.NilError(t, Run(ctx, "logout"))
+
+		cache, err := openTokenCache(&cfg.Hosts[0])
+		assert.NilError(t, err)
+		defer cache.Close()
+		token, err := cache.Read()
+		assert.NilError(t, err)
+		assert.Assert(t, token == nil)
+	})
+}
@@ -7,7 +7,8 @@ import (
 )
 
 func newUseCmd(cli *CLI) *cobra.Command {
-	return &cobra.Command{
+	var printOnly bool
+	cmd := &cobra.Command{
 		Use:   "use DESTINATION",
 		Short: "Access a destination",
 		Example: `
@@ -15,7 +16,10 @@ func newUseCmd(cli *CLI) *cobra.Command {
 $ infra use development
 
 # Use a Kubernetes namespace context
-$ infra use development.kube-system`,
+$ infra use development.kube-system
+
+# Write a kubeconfig for the namespace context, for use in CI
+$ infra use development.kube-system --print > kubeconfig`,
 		Args:              ExactArgs(1),
 		GroupID:           groupCore,
 		ValidArgsFunction: getUseCompletion,
@@ -27,6 +31,10 @@ $ infra use development.kube-system`,
 				return err
 			}
 
+			if printOnly {
+				return printKubeconfig(cli, client, destination)
+			}
+
 			err = updateKubeconfig(client)
 			if err != nil {
 				return err
@@ -41,44 +49,18 @@ $ infra use development.kube-system`,
 			return kubernetesSetContext(cli, parts[0], parts[1])
 		},
 	}
+
+	cmd.Flags().BoolVar(&printOnly, "print", false, "Print a kubeconfig with only the context for the destination, instead of changing your kubeconfig")
+	return cmd
 }
 
 func getUseCompletion(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
-	opts, err := defaultClientOpts()
-	if err != nil {
-		return nil, cobra.ShellCompDirectiveError
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
 	}
-	client, err := NewAPIClient(opts)
-	if err != nil {
-		return nil, cobra.ShellCompDirectiveError
-	}
-	_, destinations, grants, err := getUserDestinationGrants(client, "")
-	if err != nil {
-		return nil, cobra.ShellCompDirectiveError
-	}
-
-	resources := make(map[string]struct{}, len(grants))
-
-	for _, g := range grants {
-		resources[g.Resource] = struct{}{}
+	validArgs, directive := completeFrom(completeUseResources)
+	if directive == cobra.ShellCompDirectiveError {
+		return nil, directive
 	}
-
-	validArgs := make([]string, 0, len(resources))
-
-	for r := range resources {
-		var exists bool
-		for _, d := range destinations {
-			if strings.HasPrefix(r, d.Name) {
-				exists = true
-				break
-			}
-		}
-
-		if exists {
-			validArgs = append(validArgs, r)
-		}
-
-	}
-
 	return validArgs, cobra.ShellCompDirectiveNoSpace
 }
@@ -11,6 +11,7 @@ import (
 
 	"gotest.tools/v3/assert"
 	is "gotest.tools/v3/assert/cmp"
+	"k8s.io/client-go/tools/clientcmd"
 
 	"github.com/infrahq/infra/api"
 	"github.com/infrahq/infra/uid"
@@ -117,11 +118,11 @@ func TestUse(t *testing.T) {
 		err := Run(context.Background(), "use", "cluster")
 		assert.NilError(t, err)
 
-		kubeconfig, err := clientConfig().RawConfig()
+		kubeconfig, err := rawKubeconfig()
 		assert.NilError(t, err)
 
 		assert.Equal(t, len(kubeconfig.Clusters), 1)
-		assert.Equal(t, len(kubeconfig.Contexts), 1)
+		assert.Equal(t, len(kubeconfig.Contexts), 2)
 		assert.Equal(t, len(kubeconfig.AuthInfos), 1)
 		assert.Equal(t, kubeconfig.CurrentContext, "infra:cluster")
 		assert.Assert(t, is.Contains(kubeconfig.AuthInfos, "testuser@example.com"))
@@ -133,17 +134,31 @@ func TestUse(t *testing.T) {
 		err := Run(context.Background(), "use", "cluster.namespace")
 		assert.NilError(t, err)
 
-		kubeconfig, err := clientConfig().RawConfig()
+		kubeconfig, err := rawKubeconfig()
 		assert.NilError(t, err)
 
 		assert.Equal(t, len(kubeconfig.Clusters), 1)
-		assert.Equal(t, len(kubeconfig.Contexts), 1)
+		assert.Equal(t, len(kubeconfig.Contexts), 2)
 		assert.Equal(t, len(kubeconfig.AuthInfos), 1)
-		assert.Equal(t, kubeconfig.CurrentContext, "infra:cluster")
+		assert.Equal(t, kubeconfig.CurrentContext, "infra:cluster:namespace")
+		assert.Equal(t, kubeconfig.Contexts[kubeconfig.CurrentContext].Cluster, "infra:cluster")
 		assert.Equal(t, kubeconfig.Contexts[kubeconfig.CurrentContext].Namespace, "namespace")
 		assert.Assert(t, is.Contains(kubeconfig.AuthInfos, "testuser@example.com"))
 	})
 
+	t.Run("UseNamespaceWithoutGrant", func(t *testing.T) {
+		setup(t)
+
+		err := Run(context.Background(), "use", "cluster.other")
+		assert.NilError(t, err)
+
+		kubeconfig, err := rawKubeconfig()
+		assert.NilError(t, err)
+
+		assert.Equal(t, kubeconfig.CurrentContext, "infra:cluster")
+		assert.Equal(t, kubeconfig.Contexts[kubeconfig.CurrentContext].Namespace, "other")
+	})
+
 	t.Run("UseUnknown", func(t *testing.T) {
 		setup(t)
 
@@ -163,27 +178,121 @@ func TestUse(t *testing.T) {
 		err := Run(context.Background(), "use", "cluster.namespace")
 		assert.NilError(t, err)
 
-		kubeconfig, err := clientConfig().RawConfig()
+		err = Run(context.Background(), "use", "cluster")
 		assert.NilError(t, err)
 
-		assert.Equal(t, len(kubeconfig.Clusters), 1)
-		assert.Equal(t, len(kubeconfig.Contexts), 1)
-		assert.Equal(t, len(kubeconfig.AuthInfos), 1)
+		kubeconfig, err := rawKubeconfig()
+		assert.NilError(t, err)
+
+		assert.Equal(t, len(kubeconfig.Contexts), 2)
 		assert.Equal(t, kubeconfig.CurrentContext, "infra:cluster")
-		assert.Equal(t, kubeconfig.Contexts[kubeconfig.CurrentContext].Namespace, "namespace")
-		assert.Assert(t, is.Contains(kubeconfig.AuthInfos, "testuser@example.com"))
+		assert.Equal(t, kubeconfig.Contexts["infra:cluster"].Namespace, "")
+		assert.Equal(t, kubeconfig.Contexts["infra:cluster:namespace"].Namespace, "namespace")
+	})
 
-		err = Run(context.Background(), "use", "cluster")
+	t.Run("print", func(t *testing.T) {
+		setup(t)
+
+		ctx, bufs := PatchCLI(context.Background())
+		err := Run(ctx, "use", "cluster.namespace", "--print")
 		assert.NilError(t, err)
 
-		kubeconfig, err = clientConfig().RawConfig()
+		printed, err := clientcmd.Load(bufs.Stdout.Bytes())
+		assert.NilError(t, err)
+		assert.Equal(t, printed.CurrentContext, "infra:cluster:namespace")
+		assert.Equal(t, len(printed.Contexts), 1)
+		assert.Equal(t, printed.Contexts["infra:cluster:namespace"].Namespace, "namespace")
+		assert.Equal(t, len(printed.Clusters), 1)
+		assert.Equal(t, printed.Clusters["infra:cluster"].Server, "https://kubernetes.docker.local")
+		assert.Equal(t, len(printed.AuthInfos), 1)
+		assert.DeepEqual(t, printed.AuthInfos["testuser@example.com"].Exec.Args, []string{"tokens", "add"})
+
+		// the kubeconfig is not changed
+		kubeconfig, err := rawKubeconfig()
 		assert.NilError(t, err)
+		assert.Equal(t, len(kubeconfig.Contexts), 0)
 
-		assert.Equal(t, len(kubeconfig.Clusters), 1)
-		assert.Equal(t, len(kubeconfig.Contexts), 1)
-		assert.Equal(t, len(kubeconfig.AuthInfos), 1)
+		err = Run(ctx, "use", "unknown", "--print")
+		assert.ErrorContains(t, err, "context not found: unknown")
+	})
+
+	t.Run("managed kubeconfig", func(t *testing.T) {
+		setup(t)
+
+		err := Run(context.Background(), "use", "cluster")
+		assert.NilError(t, err)
+
+		ctx, bufs := PatchCLI(context.Background())
+		err = Run(ctx, "kubeconfig", "use-file")
+		assert.NilError(t, err)
+
+		managed := filepath.Join(home, ".kube", "infra")
+		assert.Assert(t, is.Contains(bufs.Stderr.String(), "Infra contexts are written to "+managed))
+
+		// the contexts are moved to the managed kubeconfig
+		defaultConfig, err := clientcmd.LoadFromFile(filepath.Join(home, "config"))
+		assert.NilError(t, err)
+		assert.Equal(t, len(defaultConfig.Contexts), 0)
+		assert.Equal(t, defaultConfig.CurrentContext, "")
+
+		managedConfig, err := clientcmd.LoadFromFile(managed)
+		assert.NilError(t, err)
+		assert.Equal(t, len(managedConfig.Contexts), 2)
+
+		// use finds the contexts in the managed kubeconfig
+		err = Run(context.Background(), "use", "cluster.namespace")
+		assert.NilError(t, err)
+
+		kubeconfig, err := rawKubeconfig()
+		assert.NilError(t, err)
+		assert.Equal(t, kubeconfig.CurrentContext, "infra:cluster:namespace")
+		assert.Equal(t, len(kubeconfig.Contexts), 2)
+
+		managedConfig, err = clientcmd.LoadFromFile(managed)
+		assert.NilError(t, err)
+		assert.Equal(t, len(managedConfig.Contexts), 2)
+
+		err = Run(context.Background(), "kubeconfig", "use-default")
+		assert.NilError(t, err)
+
+		managedConfig, err = clientcmd.LoadFromFile(managed)
+		assert.NilError(t, err)
+		assert.Equal(t, len(managedConfig.Contexts), 0)
+
+		defaultConfig, err = clientcmd.LoadFromFile(filepath.Join(home, "config"))
+		assert.NilError(t, err)
+		assert.Equal(t, len(defaultConfig.Contexts), 2)
+	})
+	t.Run("logout with managed kubeconfig", func(t *testing.T) {
+		setup(t)
+
+		err := Run(context.Background(), "kubeconfig", "use-file")
+		assert.NilError(t, err)
+		err = Run(context.Background(), "use", "cluster")
+		assert.NilError(t, err)
+
+		kubeconfig, err := rawKubeconfig()
+		assert.NilError(t, err)
 		assert.Equal(t, kubeconfig.CurrentContext, "infra:cluster")
-		assert.Equal(t, kubeconfig.Contexts[kubeconfig.CurrentContext].Namespace, "namespace")
-		assert.Assert(t, is.Contains(kubeconfig.AuthInfos, "testuser@example.com"))
+
+		// kubectl config use-context writes the current context to the
+		// default kubeconfig
+		defaultFilename := filepath.Join(home, "config")
+		defaultConfig, err := clientcmd.LoadFromFile(defaultFilename)
+		assert.NilError(t, err)
+		defaultConfig.CurrentContext = "infra:cluster"
+		assert.NilError(t, clientcmd.WriteToFile(*defaultConfig, defaultFilename))
+
+		err = Run(context.Background(), "logout")
+		assert.NilError(t, err)
+
+		defaultConfig, err = clientcmd.LoadFromFile(defaultFilename)
+		assert.NilError(t, err)
+		assert.Equal(t, defaultConfig.CurrentContext, "")
+
+		managedConfig, err := clientcmd.LoadFromFile(filepath.Join(home, ".kube", "infra"))
+		assert.NilError(t, err)
+		assert.Equal(t, len(managedConfig.Contexts), 0)
+		assert.Equal(t, managedConfig.CurrentContext, "")
 	})
 }
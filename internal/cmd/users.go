@@ -2,7 +2,6 @@ package cmd
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"net/mail"
@@ -10,7 +9,6 @@ import (
 
 	survey "github.com/AlecAivazis/survey/v2"
 	"github.com/spf13/cobra"
-	"sigs.k8s.io/yaml"
 
 	"github.com/infrahq/infra/api"
 	humanfmt "github.com/infrahq/infra/internal/format"
@@ -92,7 +90,8 @@ func newUsersEditCmd(cli *CLI) *cobra.Command {
 		Short: "Update a user",
 		Example: `# Set a new password for a user
 $ infra users edit janedoe@example.com --password`,
-		Args: ExactArgs(1),
+		Args:              ExactArgs(1),
+		ValidArgsFunction: completeArgs([]completionSource{completeUsers}),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			if !editPassword {
 				return errors.New("Please specify a field to update. For options, run 'infra users edit --help'")
@@ -127,7 +126,7 @@ func passwordError(cli *CLI, err error) bool {
 }
 
 func newUsersListCmd(cli *CLI) *cobra.Command {
-	var format string
+	var output outputOptions
 
 	cmd := &cobra.Command{
 		Use:     "list",
@@ -156,40 +155,23 @@ func newUsersListCmd(cli *CLI) *cobra.Command {
 				return err
 			}
 
-			switch format {
-			case "json":
-				jsonOutput, err := json.Marshal(users)
-				if err != nil {
-					return err
-				}
-				cli.Output(string(jsonOutput))
-			case "yaml":
-				yamlOutput, err := yaml.Marshal(users)
-				if err != nil {
-					return err
-				}
-				cli.Output(string(yamlOutput))
-			default:
-				for _, user := range users {
-					rows = append(rows, row{
-						Name:       user.Name,
-						LastSeenAt: humanfmt.HumanTime(user.LastSeenAt.Time(), "never"),
-						Providers:  strings.Join(user.ProviderNames, ", "),
-					})
-				}
-
-				if len(rows) > 0 {
-					printTable(rows, cli.Stdout)
-				} else {
-					cli.Output("No users found")
-				}
+			for _, user := range users {
+				rows = append(rows, row{
+					Name:       user.Name,
+					LastSeenAt: humanfmt.HumanTime(user.LastSeenAt.Time(), "never"),
+					Providers:  strings.Join(user.ProviderNames, ", "),
+				})
 			}
 
-			return nil
+			if output.isTable() && len(rows) == 0 {
+				cli.Output("No users found")
+				return nil
+			}
+			return writeOutput(cli, output, users, rows)
 		},
 	}
 
-	addFormatFlag(cmd.Flags(), &format)
+	addFormatFlag(cmd.Flags(), &output)
 	return cmd
 }
 
@@ -202,7 +184,8 @@ func newUsersRemoveCmd(cli *CLI) *cobra.Command {
 		Short:   "Delete a user",
 		Example: `# Delete a user
 $ infra users remove janedoe@example.com`,
-		Args: ExactArgs(1),
+		Args:              ExactArgs(1),
+		ValidArgsFunction: completeArgs([]completionSource{completeUsers}),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			name := args[0]
 
@@ -13,58 +13,85 @@ import (
 )
 
 func newVersionCmd(cli *CLI) *cobra.Command {
-	return &cobra.Command{
+	var output outputOptions
+	cmd := &cobra.Command{
 		Use:     "version",
 		Short:   "Display the Infra version",
 		GroupID: groupOther,
 		Args:    NoArgs,
 		RunE: func(cmd *cobra.Command, _ []string) error {
-			return version(cli)
+			return version(cli, output)
 		},
 	}
+
+	addFormatFlag(cmd.Flags(), &output)
+	return cmd
 }
 
-func version(cli *CLI) error {
-	ctx := context.Background()
+// versionInfo is the output of 'infra version'. Server is empty when the
+// server is disconnected, or is not shown.
+type versionInfo struct {
+	Client string `json:"client" header:"CLIENT"`
+	Server string `json:"server,omitempty" header:"SERVER"`
+	// hideServer is true when the server version is not shown, because the
+	// server is a SaaS server.
+	hideServer bool
+}
+
+func version(cli *CLI, output outputOptions) error {
+	result, err := getVersionInfo(cli)
+	if err != nil {
+		return err
+	}
+
+	if !output.isTable() {
+		return writeOutput(cli, output, result, []versionInfo{*result})
+	}
 
 	w := tabwriter.NewWriter(cli.Stdout, 0, 0, 1, ' ', tabwriter.AlignRight)
 	defer w.Flush()
 
 	fmt.Fprintln(w)
-	fmt.Fprintln(w, "Client:\t", strings.TrimPrefix(internal.FullVersion(), "v"))
+	fmt.Fprintln(w, "Client:\t", result.Client)
+	switch {
+	case result.Server != "":
+		fmt.Fprintln(w, "Server:\t", result.Server)
+	case !result.hideServer:
+		fmt.Fprintln(w, "Server:\t", "disconnected")
+	}
+	fmt.Fprintln(w)
+
+	return nil
+}
+
+func getVersionInfo(cli *CLI) (*versionInfo, error) {
+	ctx := context.Background()
+	result := &versionInfo{Client: strings.TrimPrefix(internal.FullVersion(), "v")}
 
 	// Note that we use the client to get this version, but it is in fact the server version
 	client, err := cli.apiClient()
 	if err != nil {
-		fmt.Fprintln(w, "Server:\t", "disconnected")
 		logging.Debugf("%s", err.Error())
-		fmt.Fprintln(w)
-
-		return nil
+		return result, nil
 	}
 
 	config, err := currentHostConfig()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// Don't bother printing the server version for SaaS
 	if strings.HasSuffix(config.Host, ".infrahq.com") {
-		fmt.Fprintln(w)
-		return nil
+		result.hideServer = true
+		return result, nil
 	}
 
 	version, err := client.GetServerVersion(ctx)
 	if err != nil {
-		fmt.Fprintln(w, "Server:\t", "disconnected")
 		logging.Debugf("%s", err.Error())
-		fmt.Fprintln(w)
-
-		return nil
+		return result, nil
 	}
 
-	fmt.Fprintln(w, "Server:\t", strings.TrimPrefix(version.Version, "v"))
-	fmt.Fprintln(w)
-
-	return nil
+	result.Server = strings.TrimPrefix(version.Version, "v")
+	return result, nil
 }
@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -56,11 +57,9 @@ func TestVersionCmd(t *testing.T) {
 		assert.Equal(t, bufs.Stdout.String(), fmt.Sprintf(expectedSaasServerOutput, intVersion))
 	})
 
-	t.Run("version local server", func(t *testing.T) {
-		ctx, bufs := PatchCLI(context.Background())
-
-		intVersion := strings.TrimPrefix(internal.FullVersion(), "v")
+	intVersion := strings.TrimPrefix(internal.FullVersion(), "v")
 
+	setupLocalServer := func(t *testing.T) {
 		handler := func(resp http.ResponseWriter, req *http.Request) {
 			if req.URL.Path != "/api/version" {
 				resp.WriteHeader(http.StatusBadRequest)
@@ -90,12 +89,39 @@ func TestVersionCmd(t *testing.T) {
 
 		err := writeConfig(&cfg)
 		assert.NilError(t, err)
+	}
 
-		err = Run(ctx, "version")
+	t.Run("version local server", func(t *testing.T) {
+		setupLocalServer(t)
+		ctx, bufs := PatchCLI(context.Background())
+
+		err := Run(ctx, "version")
 		assert.NilError(t, err)
 
 		assert.Equal(t, bufs.Stdout.String(), fmt.Sprintf(expectedLocalServerOutput, intVersion, intVersion))
 	})
+
+	t.Run("version json", func(t *testing.T) {
+		setupLocalServer(t)
+		ctx, bufs := PatchCLI(context.Background())
+
+		err := Run(ctx, "version", "--format=json")
+		assert.NilError(t, err)
+
+		var actual map[string]string
+		assert.NilError(t, json.Unmarshal(bufs.Stdout.Bytes(), &actual))
+		assert.DeepEqual(t, actual, map[string]string{"client": intVersion, "server": intVersion})
+	})
+
+	t.Run("version csv", func(t *testing.T) {
+		setupLocalServer(t)
+		ctx, bufs := PatchCLI(context.Background())
+
+		err := Run(ctx, "version", "--format=csv")
+		assert.NilError(t, err)
+
+		assert.Equal(t, bufs.Stdout.String(), fmt.Sprintf("CLIENT,SERVER\n%s,%s\n", intVersion, intVersion))
+	})
 }
 
 var expectedDisconnectedOutput = `
@@ -0,0 +1,253 @@
+package connector
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/infrahq/infra/api"
+	"github.com/infrahq/infra/internal/claims"
+	"github.com/infrahq/infra/internal/logging"
+)
+
+type ActivityOptions struct {
+	// Enabled turns on recording of every request to the proxy.
+	Enabled bool
+	// BatchSize is the maximum number of records sent to the server in a
+	// single request.
+	BatchSize int
+	// FlushInterval is the maximum time a record is buffered before it is
+	// sent to the server.
+	FlushInterval time.Duration
+	// FallbackFile is the path to a file where records are written, as JSON
+	// lines, when they can not be sent to the server. When empty, records
+	// that can not be sent are dropped.
+	FallbackFile string
+}
+
+type activitySink interface {
+	Write(ctx context.Context, records []api.DestinationActivity) error
+}
+
+// serverActivitySink sends activity records to the infra API server.
+type serverActivitySink struct {
+	client      apiClient
+	destination string
+}
+
+func (s *serverActivitySink) Write(ctx context.Context, records []api.DestinationActivity) error {
+	return s.client.CreateDestinationActivity(ctx, &api.CreateDestinationActivityRequest{
+		Destination: s.destination,
+		Records:     records,
+	})
+}
+
+// fileActivitySink appends activity records to a local file as JSON lines.
+type fileActivitySink struct {
+	mu   sync.Mutex
+	path string
+}
+
+func (s *fileActivitySink) Write(_ context.Context, records []api.DestinationActivity) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	fh, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(fh)
+	for _, record := range records {
+		if err := enc.Encode(record); err != nil {
+			_ = fh.Close()
+			return err
+		}
+	}
+	return fh.Close()
+}
+
+// activityRecorder buffers activity records and sends them to the sink in
+// batches. A nil activityRecorder does not record anything.
+type activityRecorder struct {
+	records       chan api.DestinationActivity
+	batchSize     int
+	flushInterval time.Duration
+	sink          activitySink
+	fallback      activitySink
+
+	count *prometheus.CounterVec
+}
+
+func newActivityRecorder(opts ActivityOptions, sink activitySink) *activityRecorder {
+	r := &activityRecorder{
+		records:       make(chan api.DestinationActivity, opts.BatchSize*10),
+		batchSize:     opts.BatchSize,
+		flushInterval: opts.FlushInterval,
+		sink:          sink,
+		count: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "infra",
+			Subsystem: "connector",
+			Name:      "activity_records_total",
+			Help:      "The number of activity records, by the result of sending them to the server.",
+		}, []string{"result"}),
+	}
+	if opts.FallbackFile != "" {
+		r.fallback = &fileActivitySink{path: opts.FallbackFile}
+	}
+	return r
+}
+
+// Record adds the record to the buffer. Record never blocks, if the buffer is
+// full the record is dropped.
+func (r *activityRecorder) Record(record api.DestinationActivity) {
+	if r == nil {
+		return
+	}
+	select {
+	case r.records <- record:
+	default:
+		r.count.WithLabelValues("dropped").Inc()
+	}
+}
+
+// Run sends batches of records to the sink until ctx is cancelled. Any
+// buffered records are sent before Run returns.
+func (r *activityRecorder) Run(ctx context.Context) error {
+	ticker := time.NewTicker(r.flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]api.DestinationActivity, 0, r.batchSize)
+	flush := func(ctx context.Context) {
+		if len(batch) == 0 {
+			return
+		}
+		r.flush(ctx, batch)
+		batch = make([]api.DestinationActivity, 0, r.batchSize)
+	}
+
+	for {
+		select {
+		case record := <-r.records:
+			batch = append(batch, record)
+			if len(batch) >= r.batchSize {
+				flush(ctx)
+			}
+		case <-ticker.C:
+			flush(ctx)
+		case <-ctx.Done():
+			// drain the buffer, using a new context because ctx is already done
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			for {
+				select {
+				case record := <-r.records:
+					batch = append(batch, record)
+					if len(batch) >= r.batchSize {
+						flush(shutdownCtx)
+					}
+				default:
+					flush(shutdownCtx)
+					return ctx.Err()
+				}
+			}
+		}
+	}
+}
+
+func (r *activityRecorder) flush(ctx context.Context, batch []api.DestinationActivity) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	err := r.sink.Write(ctx, batch)
+	if err == nil {
+		r.count.WithLabelValues("sent").Add(float64(len(batch)))
+		return
+	}
+	logging.L.Warn().Err(err).Int("count", len(batch)).Msg("failed to send activity records")
+
+	if r.fallback == nil {
+		r.count.WithLabelValues("dropped").Add(float64(len(batch)))
+		return
+	}
+	if err := r.fallback.Write(ctx, batch); err != nil {
+		logging.L.Error().Err(err).Int("count", len(batch)).Msg("failed to write activity records to fallback file")
+		r.count.WithLabelValues("dropped").Add(float64(len(batch)))
+		return
+	}
+	r.count.WithLabelValues("fallback").Add(float64(len(batch)))
+}
+
+// statusRecorder records the status code of the response. It supports
+// connection upgrades, which are used by exec, attach, and port-forward.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(code int) {
+	if r.status == 0 {
+		r.status = code
+	}
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	return r.ResponseWriter.Write(b)
+}
+
+func (r *statusRecorder) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (r *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := r.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("response does not support hijacking")
+	}
+	if r.status == 0 {
+		r.status = http.StatusSwitchingProtocols
+	}
+	return hijacker.Hijack()
+}
+
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+func (r *statusRecorder) Status() int {
+	if r.status == 0 {
+		return http.StatusOK
+	}
+	return r.status
+}
+
+func newActivityRecord(attrs requestAttributes, claim claims.Custom, status int, start time.Time) api.DestinationActivity {
+	return api.DestinationActivity{
+		Time:        api.Time(start),
+		User:        claim.Name,
+		Groups:      claim.Groups,
+		Verb:        attrs.Verb,
+		APIGroup:    attrs.APIGroup,
+		Resource:    attrs.Resource,
+		Subresource: attrs.Subresource,
+		Namespace:   attrs.Namespace,
+		Name:        attrs.Name,
+		Path:        attrs.Path,
+		StatusCode:  status,
+		LatencyMS:   time.Since(start).Milliseconds(),
+	}
+}
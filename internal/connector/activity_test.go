@@ -0,0 +1,138 @@
+package connector
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/poll"
+
+	"github.com/infrahq/infra/api"
+)
+
+type fakeActivitySink struct {
+	mu      sync.Mutex
+	batches [][]api.DestinationActivity
+	err     error
+}
+
+func (f *fakeActivitySink) Write(_ context.Context, records []api.DestinationActivity) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return f.err
+	}
+	f.batches = append(f.batches, records)
+	return nil
+}
+
+func (f *fakeActivitySink) Batches() [][]api.DestinationActivity {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.batches
+}
+
+func TestActivityRecorder(t *testing.T) {
+	t.Run("sends batches", func(t *testing.T) {
+		sink := &fakeActivitySink{}
+		opts := ActivityOptions{BatchSize: 2, FlushInterval: time.Hour}
+		recorder := newActivityRecorder(opts, sink)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error)
+		go func() {
+			done <- recorder.Run(ctx)
+		}()
+
+		recorder.Record(api.DestinationActivity{User: "one"})
+		recorder.Record(api.DestinationActivity{User: "two"})
+		recorder.Record(api.DestinationActivity{User: "three"})
+
+		// the first batch is sent when it is full
+		poll.WaitOn(t, func(t poll.LogT) poll.Result {
+			if len(sink.Batches()) == 0 {
+				return poll.Continue("waiting for the first batch")
+			}
+			return poll.Success()
+		}, poll.WithDelay(time.Millisecond))
+
+		// the remaining record is sent on shutdown
+		cancel()
+		assert.ErrorIs(t, <-done, context.Canceled)
+
+		expected := [][]api.DestinationActivity{
+			{{User: "one"}, {User: "two"}},
+			{{User: "three"}},
+		}
+		assert.DeepEqual(t, sink.Batches(), expected)
+		assert.Equal(t, testutil.ToFloat64(recorder.count.WithLabelValues("sent")), float64(3))
+	})
+
+	t.Run("writes to fallback file", func(t *testing.T) {
+		sink := &fakeActivitySink{err: errors.New("server unavailable")}
+		filename := filepath.Join(t.TempDir(), "activity.log")
+		opts := ActivityOptions{BatchSize: 10, FlushInterval: time.Hour, FallbackFile: filename}
+		recorder := newActivityRecorder(opts, sink)
+
+		batch := []api.DestinationActivity{
+			{User: "one", Verb: "get", Resource: "pods", StatusCode: 200},
+			{User: "two", Verb: "list", Resource: "secrets", StatusCode: 403},
+		}
+		recorder.flush(context.Background(), batch)
+
+		fh, err := os.Open(filename)
+		assert.NilError(t, err)
+		defer fh.Close()
+
+		var actual []api.DestinationActivity
+		scanner := bufio.NewScanner(fh)
+		for scanner.Scan() {
+			var record api.DestinationActivity
+			assert.NilError(t, json.Unmarshal(scanner.Bytes(), &record))
+			actual = append(actual, record)
+		}
+		assert.NilError(t, scanner.Err())
+		assert.DeepEqual(t, actual, batch)
+		assert.Equal(t, testutil.ToFloat64(recorder.count.WithLabelValues("fallback")), float64(2))
+	})
+
+	t.Run("drops records when buffer is full", func(t *testing.T) {
+		opts := ActivityOptions{BatchSize: 1, FlushInterval: time.Hour}
+		recorder := newActivityRecorder(opts, &fakeActivitySink{})
+
+		for i := 0; i < 15; i++ {
+			recorder.Record(api.DestinationActivity{})
+		}
+		assert.Equal(t, testutil.ToFloat64(recorder.count.WithLabelValues("dropped")), float64(5))
+	})
+
+	t.Run("nil recorder", func(t *testing.T) {
+		var recorder *activityRecorder
+		recorder.Record(api.DestinationActivity{})
+	})
+}
+
+func TestStatusRecorder(t *testing.T) {
+	t.Run("explicit status", func(t *testing.T) {
+		rec := &statusRecorder{ResponseWriter: httptest.NewRecorder()}
+		rec.WriteHeader(http.StatusNotFound)
+		rec.WriteHeader(http.StatusOK)
+		assert.Equal(t, rec.Status(), http.StatusNotFound)
+	})
+	t.Run("implicit status", func(t *testing.T) {
+		rec := &statusRecorder{ResponseWriter: httptest.NewRecorder()}
+		_, err := rec.Write([]byte("ok"))
+		assert.NilError(t, err)
+		assert.Equal(t, rec.Status(), http.StatusOK)
+	})
+}
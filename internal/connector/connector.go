@@ -27,7 +27,6 @@ import (
 	"github.com/infrahq/infra/internal/logging"
 	"github.com/infrahq/infra/internal/repeat"
 	"github.com/infrahq/infra/metrics"
-	"github.com/infrahq/infra/uid"
 )
 
 func Run(ctx context.Context, options Options) error {
@@ -62,6 +61,10 @@ type Options struct {
 	// Kubernetes specific options below here
 	CACert types.StringOrFile
 	CAKey  types.StringOrFile
+
+	LeaderElection LeaderElectionOptions
+	Policy         PolicyOptions
+	Activity       ActivityOptions
 }
 
 type ServerOptions struct {
@@ -100,6 +103,9 @@ type connector struct {
 	destination *api.Destination
 	certCache   *CertCache
 	options     Options
+
+	// leader is nil when leader election is disabled.
+	leader *leaderState
 }
 
 type apiClient interface {
@@ -107,12 +113,10 @@ type apiClient interface {
 	ListDestinations(ctx context.Context, req api.ListDestinationsRequest) (*api.ListResponse[api.Destination], error)
 	CreateDestination(ctx context.Context, req *api.CreateDestinationRequest) (*api.Destination, error)
 	UpdateDestination(ctx context.Context, req api.UpdateDestinationRequest) (*api.Destination, error)
-
-	// GetGroup and GetUser are used to retrieve the name of the group or user.
-	// TODO: we can remove these calls to GetGroup and GetUser by including
-	// the name of the group or user in the ListGrants response.
-	GetGroup(ctx context.Context, id uid.ID) (*api.Group, error)
-	GetUser(ctx context.Context, id uid.ID) (*api.User, error)
+	ListRoleTemplates(ctx context.Context, req api.ListRoleTemplatesRequest) (*api.ListResponse[api.RoleTemplate], error)
+	ListProxyPolicies(ctx context.Context, req api.ListProxyPoliciesRequest) (*api.ListResponse[api.ProxyPolicy], error)
+	CreateDestinationActivity(ctx context.Context, req *api.CreateDestinationActivityRequest) error
+	CreateSessionRecording(ctx context.Context, req *api.CreateSessionRecordingRequest) (*api.SessionRecording, error)
 }
 
 type kubeClient interface {
@@ -123,6 +127,7 @@ type kubeClient interface {
 
 	UpdateClusterRoleBindings(subjects map[string][]rbacv1.Subject) error
 	UpdateRoleBindings(subjects map[kubernetes.ClusterRoleNamespace][]rbacv1.Subject) error
+	UpdateRoleTemplates(templates map[string][]rbacv1.PolicyRule) error
 }
 
 func runKubernetesConnector(ctx context.Context, options Options) error {
@@ -209,7 +214,7 @@ func runKubernetesConnector(ctx context.Context, options Options) error {
 		certCache:   certCache,
 		options:     options,
 	}
-	group.Go(func() error {
+	syncGrants := func(ctx context.Context) error {
 		backOff := &backoff.ExponentialBackOff{
 			InitialInterval:     2 * time.Second,
 			MaxInterval:         time.Minute,
@@ -218,10 +223,36 @@ func runKubernetesConnector(ctx context.Context, options Options) error {
 		}
 		waiter := repeat.NewWaiter(backOff)
 		fn := func(ctx context.Context, grants []api.Grant) error {
-			return updateRoles(ctx, con.client, con.k8s, grants)
+			// role templates must exist before grants can be bound to them
+			if err := syncRoleTemplates(ctx, con.client, con.k8s); err != nil {
+				logging.L.Warn().Err(err).Msg("failed to sync role templates")
+			}
+			return updateRoles(con.k8s, grants)
 		}
 		return syncGrantsToDestination(ctx, con, waiter, fn)
-	})
+	}
+
+	if options.LeaderElection.Enabled {
+		identity, err := leaderElectionIdentity(options.LeaderElection)
+		if err != nil {
+			return err
+		}
+		lock, err := k8s.LeaseLock(options.LeaderElection.LeaseName, identity)
+		if err != nil {
+			return fmt.Errorf("create leader election lock: %w", err)
+		}
+
+		con.leader = newLeaderState()
+		promRegistry.MustRegister(con.leader.gauge)
+
+		group.Go(func() error {
+			return runWithLeaderElection(ctx, options.LeaderElection, lock, con.leader, syncGrants)
+		})
+	} else {
+		group.Go(func() error {
+			return syncGrants(ctx)
+		})
+	}
 	group.Go(func() error {
 		// TODO: how long should this wait? Use exponential backoff on error?
 		waiter := repeat.NewWaiter(backoff.NewConstantBackOff(30 * time.Second))
@@ -237,6 +268,37 @@ func runKubernetesConnector(ctx context.Context, options Options) error {
 		}
 	})
 
+	var policies *policySet
+	if options.Policy.Enabled {
+		policies = newPolicySet()
+		promRegistry.MustRegister(policies.denied)
+
+		group.Go(func() error {
+			waiter := repeat.NewWaiter(backoff.NewConstantBackOff(options.Policy.SyncInterval))
+			for {
+				if err := syncPolicies(ctx, con.client, options.Name, policies); err != nil {
+					logging.L.Warn().Err(err).Msg("failed to sync proxy policies")
+				} else {
+					waiter.Reset()
+				}
+				if err := waiter.Wait(ctx); err != nil {
+					return err
+				}
+			}
+		})
+	}
+
+	var activity *activityRecorder
+	if options.Activity.Enabled {
+		sink := &serverActivitySink{client: con.client, destination: options.Name}
+		activity = newActivityRecorder(options.Activity, sink)
+		promRegistry.MustRegister(activity.count)
+
+		group.Go(func() error {
+			return activity.Run(ctx)
+		})
+	}
+
 	router := http.NewServeMux()
 	router.HandleFunc("/healthz", healthHandler)
 
@@ -299,7 +361,7 @@ func runKubernetesConnector(ctx context.Context, options Options) error {
 	})
 
 	authn := newAuthenticator(options)
-	router.HandleFunc("/", proxyMiddleware(proxy, authn, k8s.Config.BearerToken))
+	router.HandleFunc("/", proxyMiddleware(proxy, authn, policies, activity, k8s.Config.BearerToken))
 	tlsServer := &http.Server{
 		ReadHeaderTimeout: 30 * time.Second,
 		ReadTimeout:       60 * time.Second,
@@ -400,11 +462,21 @@ func syncDestination(ctx context.Context, con connector) error {
 		logging.L.Debug().Str("addr", endpoint.String()).Msg("connector endpoint address")
 	}
 
+	// Every replica serves the proxy, so the certificate must be updated on
+	// all of them, but only the leader updates the destination.
+	if !con.leader.IsLeader() {
+		return nil
+	}
+
 	namespaces, err := con.k8s.Namespaces()
 	if err != nil {
 		return fmt.Errorf("could not get kubernetes namespaces: %w", err)
 	}
 
+	if err := syncRoleTemplates(ctx, con.client, con.k8s); err != nil {
+		logging.L.Warn().Err(err).Msg("failed to sync role templates")
+	}
+
 	clusterRoles, err := con.k8s.ClusterRoles()
 	if err != nil {
 		return fmt.Errorf("could not get kubernetes cluster-roles: %w", err)
@@ -476,10 +548,9 @@ func syncGrantsToDestination(
 		ctx, cancel := context.WithTimeout(ctx, 7*time.Minute)
 		defer cancel()
 
-		grants, err := con.client.ListGrants(ctx, api.ListGrantsRequest{
-			Destination:     con.destination.Name, // TODO: use options.Name when that is required
-			BlockingRequest: api.BlockingRequest{LastUpdateIndex: latestIndex},
-		})
+		// only the ssh connector creates local users for the members of groups
+		showGroupMembers := con.options.Kind == "ssh"
+		grants, err := listGrantsForDestination(ctx, con.client, con.destination.Name, latestIndex, showGroupMembers)
 		var apiError api.Error
 		switch {
 		case errors.As(err, &apiError) && apiError.Code == http.StatusNotModified:
@@ -522,8 +593,47 @@ func syncGrantsToDestination(
 	}
 }
 
+// grantsPageLimit is the number of grants requested in each page by
+// listGrantsForDestination.
+const grantsPageLimit = 1000
+
+// listGrantsForDestination blocks until there are grants for the destination
+// with an update index greater than lastUpdateIndex, then returns all of them.
+// The grants include the details of the subject, and the members of groups
+// when showGroupMembers is true, so that a connector can reconcile without
+// looking up each user and group. The result is a single ListResponse with the
+// items from every page.
+func listGrantsForDestination(ctx context.Context, client apiClient, destination string, lastUpdateIndex int64, showGroupMembers bool) (*api.ListResponse[api.Grant], error) {
+	req := api.ListGrantsRequest{
+		Destination:        destination, // TODO: use options.Name when that is required
+		ShowSubjectDetails: true,
+		ShowGroupMembers:   showGroupMembers,
+		BlockingRequest:    api.BlockingRequest{LastUpdateIndex: lastUpdateIndex},
+		PaginationRequest:  api.PaginationRequest{Page: 1, Limit: grantsPageLimit},
+	}
+	result, err := client.ListGrants(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Later pages are not blocking requests. If a grant changes while the pages
+	// are being read the update index of the first page will be lower than the
+	// change, so the next blocking request returns immediately.
+	req.LastUpdateIndex = 0
+	for page := result.Page; page < result.TotalPages; page++ {
+		req.Page = page + 1
+		resp, err := client.ListGrants(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		result.Items = append(result.Items, resp.Items...)
+	}
+	result.Count = len(result.Items)
+	return result, nil
+}
+
 // UpdateRoles converts infra grants to role-bindings in the current cluster
-func updateRoles(ctx context.Context, c apiClient, k kubeClient, grants []api.Grant) error {
+func updateRoles(k kubeClient, grants []api.Grant) error {
 	logging.Debugf("syncing local grants from infra configuration")
 
 	crSubjects := make(map[string][]rbacv1.Subject)                           // cluster-role: subject
@@ -538,22 +648,16 @@ func updateRoles(ctx context.Context, c apiClient, k kubeClient, grants []api.Gr
 
 		switch {
 		case g.Group != 0:
-			group, err := c.GetGroup(ctx, g.Group)
-			if err != nil {
-				return err
-			}
-
-			name = group.Name
+			name = g.GroupName
 			kind = rbacv1.GroupKind
 		case g.User != 0:
-			user, err := c.GetUser(ctx, g.User)
-			if err != nil {
-				return err
-			}
-
-			name = user.Name
+			name = g.UserName
 			kind = rbacv1.UserKind
 		}
+		if name == "" {
+			logging.L.Warn().Str("grant", g.ID.String()).Msg("grant is missing the name of the subject")
+			continue
+		}
 
 		subj := rbacv1.Subject{
 			APIGroup: "rbac.authorization.k8s.io",
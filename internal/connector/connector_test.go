@@ -276,7 +276,7 @@ func TestSyncGrantsToDestination_KubeBindings(t *testing.T) {
 		}
 
 		fn := func(ctx context.Context, grants []api.Grant) error {
-			return updateRoles(ctx, con.client, con.k8s, grants)
+			return updateRoles(con.k8s, grants)
 		}
 		err := syncGrantsToDestination(ctx, con, waiter, fn)
 		assert.ErrorIs(t, err, errDone)
@@ -291,8 +291,8 @@ func TestSyncGrantsToDestination_KubeBindings(t *testing.T) {
 			fakeAPI: &fakeAPIClient{
 				listGrantsResult: &api.ListResponse[api.Grant]{
 					Items: []api.Grant{
-						{User: uid.ID(123), Resource: "the-test", Privilege: "view"},
-						{User: uid.ID(124), Resource: "the-test.ns1", Privilege: "logs"},
+						{User: uid.ID(123), UserName: "one@example.com", Resource: "the-test", Privilege: "view"},
+						{User: uid.ID(124), UserName: "two@example.com", Resource: "the-test.ns1", Privilege: "logs"},
 					},
 					LastUpdateIndex: api.LastUpdateIndex{Index: 42},
 				},
@@ -320,7 +320,7 @@ func TestSyncGrantsToDestination_KubeBindings(t *testing.T) {
 			fakeAPI: &fakeAPIClient{
 				listGrantsResult: &api.ListResponse[api.Grant]{
 					Items: []api.Grant{
-						{User: uid.ID(123), Resource: "the-test", Privilege: "view"},
+						{User: uid.ID(123), UserName: "one@example.com", Resource: "the-test", Privilege: "view"},
 					},
 					LastUpdateIndex: api.LastUpdateIndex{Index: 42},
 				},
@@ -339,6 +339,32 @@ func TestSyncGrantsToDestination_KubeBindings(t *testing.T) {
 	}
 }
 
+func TestListGrantsForDestination(t *testing.T) {
+	ctx := context.Background()
+	fakeAPI := &fakeAPIClient{
+		listGrantsPages: [][]api.Grant{
+			{{ID: 1, User: 123, UserName: "one@example.com"}, {ID: 2, Group: 124, GroupName: "the-group"}},
+			{{ID: 3, User: 125, UserName: "three@example.com"}},
+			{{ID: 4, User: 126, UserName: "four@example.com"}},
+		},
+	}
+
+	actual, err := listGrantsForDestination(ctx, fakeAPI, "the-dest", 12, false)
+	assert.NilError(t, err)
+
+	var ids []uid.ID
+	for _, grant := range actual.Items {
+		ids = append(ids, grant.ID)
+	}
+	assert.DeepEqual(t, ids, []uid.ID{1, 2, 3, 4})
+	assert.Equal(t, actual.Count, 4)
+	assert.Equal(t, actual.LastUpdateIndex.Index, int64(42))
+
+	// only the first page is a blocking request
+	assert.DeepEqual(t, fakeAPI.listGrantsIndexes, []int64{12, 0, 0})
+	assert.DeepEqual(t, fakeAPI.listGrantsPageRequests, []int{1, 2, 3})
+}
+
 type fakeWaiter struct {
 	index      int
 	resets     []int
@@ -368,24 +394,65 @@ type fakeAPIClient struct {
 	listGrantsResult  *api.ListResponse[api.Grant]
 	listGrantsError   error
 	listGrantsIndexes []int64
+	// listGrantsPages is used instead of listGrantsResult to return a page of
+	// grants for each request.
+	listGrantsPages        [][]api.Grant
+	listGrantsPageRequests []int
 
-	users map[uid.ID]api.User
+	roleTemplates []api.RoleTemplate
+
+	sessionRecordings           []api.CreateSessionRecordingRequest
+	createSessionRecordingError error
 }
 
 func (f *fakeAPIClient) ListGrants(ctx context.Context, req api.ListGrantsRequest) (*api.ListResponse[api.Grant], error) {
 	f.listGrantsIndexes = append(f.listGrantsIndexes, req.LastUpdateIndex)
-	return f.listGrantsResult, f.listGrantsError
+	if f.listGrantsPages == nil {
+		return f.listGrantsResult, f.listGrantsError
+	}
+
+	f.listGrantsPageRequests = append(f.listGrantsPageRequests, req.Page)
+	resp := &api.ListResponse[api.Grant]{
+		PaginationResponse: api.PaginationResponse{
+			Page:       req.Page,
+			Limit:      req.Limit,
+			TotalPages: len(f.listGrantsPages),
+		},
+		LastUpdateIndex: api.LastUpdateIndex{Index: 42},
+	}
+	if req.Page <= len(f.listGrantsPages) {
+		resp.Items = f.listGrantsPages[req.Page-1]
+	}
+	resp.Count = len(resp.Items)
+	return resp, nil
 }
 
-func (f *fakeAPIClient) GetGroup(ctx context.Context, id uid.ID) (*api.Group, error) {
-	return &api.Group{Name: "the-group"}, nil
+func (f *fakeAPIClient) ListRoleTemplates(ctx context.Context, req api.ListRoleTemplatesRequest) (*api.ListResponse[api.RoleTemplate], error) {
+	// return one template per page, to exercise pagination
+	page := req.Page
+	if page == 0 {
+		page = 1
+	}
+	resp := &api.ListResponse[api.RoleTemplate]{
+		PaginationResponse: api.PaginationResponse{
+			Page:       page,
+			Limit:      1,
+			TotalPages: len(f.roleTemplates),
+			TotalCount: len(f.roleTemplates),
+		},
+	}
+	if page <= len(f.roleTemplates) {
+		resp.Items = f.roleTemplates[page-1 : page]
+	}
+	return resp, nil
 }
 
-func (f *fakeAPIClient) GetUser(ctx context.Context, id uid.ID) (*api.User, error) {
-	if user, ok := f.users[id]; ok {
-		return &user, nil
+func (f *fakeAPIClient) CreateSessionRecording(ctx context.Context, req *api.CreateSessionRecordingRequest) (*api.SessionRecording, error) {
+	if f.createSessionRecordingError != nil {
+		return nil, f.createSessionRecordingError
 	}
-	return &api.User{Name: "theuser@example.com"}, nil
+	f.sessionRecordings = append(f.sessionRecordings, *req)
+	return &api.SessionRecording{Destination: req.Destination}, nil
 }
 
 type fakeKubeClient struct {
@@ -393,6 +460,12 @@ type fakeKubeClient struct {
 	updateBindingsError           error
 	updateClusterRoleBindingsArgs []map[string][]rbacv1.Subject
 	updateRoleBindingsArgs        []map[kubernetes.ClusterRoleNamespace][]rbacv1.Subject
+	updateRoleTemplatesArgs       []map[string][]rbacv1.PolicyRule
+}
+
+func (f *fakeKubeClient) UpdateRoleTemplates(templates map[string][]rbacv1.PolicyRule) error {
+	f.updateRoleTemplatesArgs = append(f.updateRoleTemplatesArgs, templates)
+	return nil
 }
 
 func (f *fakeKubeClient) UpdateClusterRoleBindings(subjects map[string][]rbacv1.Subject) error {
@@ -0,0 +1,145 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+
+	"github.com/infrahq/infra/internal/logging"
+)
+
+type LeaderElectionOptions struct {
+	// Enabled turns on leader election using a Kubernetes Lease. When enabled
+	// only the replica holding the lease reconciles RBAC and updates the
+	// destination in Infra. Every replica continues to serve the proxy.
+	Enabled bool
+
+	// LeaseName is the name of the Lease object used for the election. It is
+	// created in the namespace of the connector pod.
+	LeaseName string
+
+	// Identity of this replica in the election. Defaults to the hostname,
+	// which is the pod name when running in Kubernetes.
+	Identity string
+
+	LeaseDuration time.Duration
+	RenewDeadline time.Duration
+	RetryPeriod   time.Duration
+}
+
+// leaderState tracks if this replica is currently the leader.
+type leaderState struct {
+	leader atomic.Bool
+	gauge  prometheus.Gauge
+}
+
+func newLeaderState() *leaderState {
+	return &leaderState{
+		gauge: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: "infra",
+			Subsystem: "connector",
+			Name:      "leader",
+			Help:      "A gauge that is 1 when this connector replica is the leader, and 0 otherwise.",
+		}),
+	}
+}
+
+// IsLeader returns true if the replica is the leader. A nil leaderState is
+// always the leader, which is the case when leader election is disabled.
+func (l *leaderState) IsLeader() bool {
+	if l == nil {
+		return true
+	}
+	return l.leader.Load()
+}
+
+func (l *leaderState) set(isLeader bool) {
+	l.leader.Store(isLeader)
+	if isLeader {
+		l.gauge.Set(1)
+		return
+	}
+	l.gauge.Set(0)
+}
+
+// runWithLeaderElection participates in leader election using lock until ctx
+// is cancelled. Every time this replica becomes the leader fn is called with a
+// context that is cancelled when leadership is lost. When fn returns the lease
+// is released, so that another replica, or this one, can take over the work.
+func runWithLeaderElection(
+	ctx context.Context,
+	opts LeaderElectionOptions,
+	lock resourcelock.Interface,
+	state *leaderState,
+	fn func(ctx context.Context) error,
+) error {
+	// release cancels the context of the current elector.Run, which releases
+	// the lease. It is set before each call to Run.
+	var release context.CancelFunc
+
+	config := leaderelection.LeaderElectionConfig{
+		Lock:            lock,
+		LeaseDuration:   opts.LeaseDuration,
+		RenewDeadline:   opts.RenewDeadline,
+		RetryPeriod:     opts.RetryPeriod,
+		ReleaseOnCancel: true,
+		Name:            opts.LeaseName,
+		Callbacks: leaderelection.LeaderCallbacks{
+			OnStartedLeading: func(ctx context.Context) {
+				logging.L.Info().Str("identity", lock.Identity()).Msg("started leading")
+				state.set(true)
+				err := fn(ctx)
+				switch {
+				case ctx.Err() != nil:
+					return
+				case err != nil:
+					logging.L.Error().Err(err).Msg("leader reconcile stopped")
+				default:
+					logging.L.Warn().Msg("leader reconcile returned, releasing the lease")
+				}
+				release()
+			},
+			OnStoppedLeading: func() {
+				logging.L.Info().Str("identity", lock.Identity()).Msg("stopped leading")
+				state.set(false)
+			},
+			OnNewLeader: func(identity string) {
+				logging.L.Debug().Str("leader", identity).Msg("observed new leader")
+			},
+		},
+	}
+
+	elector, err := leaderelection.NewLeaderElector(config)
+	if err != nil {
+		return fmt.Errorf("leader election: %w", err)
+	}
+
+	// Run returns when leadership is lost, so keep participating in the
+	// election until the connector shuts down.
+	for {
+		runCtx, cancel := context.WithCancel(ctx)
+		release = cancel
+		elector.Run(runCtx)
+		cancel()
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+	}
+}
+
+func leaderElectionIdentity(opts LeaderElectionOptions) (string, error) {
+	if opts.Identity != "" {
+		return opts.Identity, nil
+	}
+	hostname, err := os.Hostname()
+	if err != nil {
+		return "", fmt.Errorf("leader election identity: %w", err)
+	}
+	return hostname, nil
+}
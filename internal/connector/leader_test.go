@@ -0,0 +1,150 @@
+package connector
+
+import (
+	"context"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/poll"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+
+	"github.com/infrahq/infra/api"
+	"github.com/infrahq/infra/internal/cmd/types"
+	"github.com/infrahq/infra/uid"
+)
+
+func TestRunWithLeaderElection(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too slow for short run")
+	}
+
+	clientset := fake.NewSimpleClientset()
+	opts := LeaderElectionOptions{
+		LeaseName:     "infra-connector",
+		LeaseDuration: 2 * time.Second,
+		RenewDeadline: time.Second,
+		RetryPeriod:   200 * time.Millisecond,
+	}
+
+	newLock := func(identity string) resourcelock.Interface {
+		return &resourcelock.LeaseLock{
+			LeaseMeta:  metav1.ObjectMeta{Name: opts.LeaseName, Namespace: "infra"},
+			Client:     clientset.CoordinationV1(),
+			LockConfig: resourcelock.ResourceLockConfig{Identity: identity},
+		}
+	}
+
+	type replica struct {
+		state  *leaderState
+		cancel context.CancelFunc
+		done   chan error
+	}
+
+	start := func(identity string) *replica {
+		ctx, cancel := context.WithCancel(context.Background())
+		r := &replica{state: newLeaderState(), cancel: cancel, done: make(chan error, 1)}
+		go func() {
+			r.done <- runWithLeaderElection(ctx, opts, newLock(identity), r.state, func(ctx context.Context) error {
+				<-ctx.Done()
+				return ctx.Err()
+			})
+		}()
+		t.Cleanup(cancel)
+		return r
+	}
+
+	first := start("first")
+	poll.WaitOn(t, func(t poll.LogT) poll.Result {
+		if first.state.IsLeader() {
+			return poll.Success()
+		}
+		return poll.Continue("waiting for first replica to become leader")
+	}, poll.WithTimeout(5*time.Second))
+
+	second := start("second")
+	time.Sleep(2 * opts.RetryPeriod)
+	assert.Assert(t, !second.state.IsLeader())
+
+	first.cancel()
+	assert.ErrorIs(t, <-first.done, context.Canceled)
+	assert.Assert(t, !first.state.IsLeader())
+
+	poll.WaitOn(t, func(t poll.LogT) poll.Result {
+		if second.state.IsLeader() {
+			return poll.Success()
+		}
+		return poll.Continue("waiting for second replica to become leader")
+	}, poll.WithTimeout(5*time.Second))
+}
+
+func TestRunWithLeaderElection_ReleasesWhenFnReturns(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too slow for short run")
+	}
+
+	clientset := fake.NewSimpleClientset()
+	opts := LeaderElectionOptions{
+		LeaseName:     "infra-connector",
+		LeaseDuration: 2 * time.Second,
+		RenewDeadline: time.Second,
+		RetryPeriod:   200 * time.Millisecond,
+	}
+	lock := &resourcelock.LeaseLock{
+		LeaseMeta:  metav1.ObjectMeta{Name: opts.LeaseName, Namespace: "infra"},
+		Client:     clientset.CoordinationV1(),
+		LockConfig: resourcelock.ResourceLockConfig{Identity: "first"},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	var calls atomic.Int32
+	done := make(chan error, 1)
+	go func() {
+		done <- runWithLeaderElection(ctx, opts, lock, newLeaderState(), func(ctx context.Context) error {
+			calls.Add(1)
+			return nil
+		})
+	}()
+
+	// fn is called again only after the lease is released and acquired again
+	poll.WaitOn(t, func(t poll.LogT) poll.Result {
+		if calls.Load() >= 2 {
+			return poll.Success()
+		}
+		return poll.Continue("waiting for the lease to be released and acquired again")
+	}, poll.WithTimeout(5*time.Second))
+
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled)
+}
+
+func TestSyncDestination_NotLeader(t *testing.T) {
+	testCACertPEM, err := os.ReadFile("./_testdata/test-ca-cert.pem")
+	assert.NilError(t, err)
+	testCAKeyPEM, err := os.ReadFile("./_testdata/test-ca-key.pem")
+	assert.NilError(t, err)
+
+	con := connector{
+		k8s:         &fakeKubeClient{},
+		client:      &fakeAPIClient{},
+		destination: &api.Destination{Name: "the-dest"},
+		options:     Options{EndpointAddr: types.HostPort{Host: "127.0.0.1", Port: 443}},
+		certCache:   NewCertCache(testCACertPEM, testCAKeyPEM),
+		leader:      newLeaderState(),
+	}
+
+	err = syncDestination(context.Background(), con)
+	assert.NilError(t, err)
+
+	// the certificate is updated on every replica
+	assert.DeepEqual(t, con.certCache.hosts, []string{"127.0.0.1"})
+	// the destination is only registered by the leader
+	assert.Equal(t, con.destination.ID, uid.ID(0))
+	assert.Assert(t, con.destination.Resources == nil)
+}
@@ -0,0 +1,307 @@
+package connector
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/infrahq/infra/api"
+	"github.com/infrahq/infra/internal/logging"
+)
+
+type PolicyOptions struct {
+	// Enabled turns on evaluation of proxy policies for every request to
+	// the proxy. Requests are denied until the policies are retrieved from the
+	// infra API server.
+	Enabled bool
+	// SyncInterval is how often the policies are retrieved from the infra
+	// API server.
+	SyncInterval time.Duration
+}
+
+// requestAttributes describes a request to the Kubernetes API, using the same
+// terms as Kubernetes RBAC.
+type requestAttributes struct {
+	IsResourceRequest bool
+	Path              string
+	Verb              string
+	APIGroup          string
+	Namespace         string
+	Resource          string
+	Subresource       string
+	Name              string
+}
+
+// parseRequestAttributes returns the attributes of the request. It follows the
+// same rules as the RequestInfoFactory in the kubernetes apiserver.
+func parseRequestAttributes(req *http.Request) requestAttributes {
+	attrs := requestAttributes{
+		Path: req.URL.Path,
+		Verb: strings.ToLower(req.Method),
+	}
+
+	parts := strings.Split(strings.Trim(req.URL.Path, "/"), "/")
+	switch {
+	case len(parts) >= 3 && parts[0] == "api":
+		parts = parts[2:]
+	case len(parts) >= 4 && parts[0] == "apis":
+		attrs.APIGroup = parts[1]
+		parts = parts[3:]
+	default:
+		return attrs
+	}
+	attrs.IsResourceRequest = true
+
+	switch req.Method {
+	case http.MethodPost:
+		attrs.Verb = "create"
+	case http.MethodGet, http.MethodHead:
+		attrs.Verb = "get"
+	case http.MethodPut:
+		attrs.Verb = "update"
+	case http.MethodPatch:
+		attrs.Verb = "patch"
+	case http.MethodDelete:
+		attrs.Verb = "delete"
+	}
+
+	// the deprecated watch path, /api/v1/watch/namespaces/...
+	if parts[0] == "watch" {
+		attrs.Verb = "watch"
+		parts = parts[1:]
+	}
+
+	if len(parts) >= 2 && parts[0] == "namespaces" {
+		attrs.Namespace = parts[1]
+		// a request for the namespace itself
+		if len(parts) == 2 {
+			attrs.Resource = "namespaces"
+			attrs.Name = parts[1]
+		} else {
+			parts = parts[2:]
+		}
+	}
+
+	if attrs.Resource == "" {
+		switch len(parts) {
+		case 0:
+		case 1:
+			attrs.Resource = parts[0]
+		case 2:
+			attrs.Resource, attrs.Name = parts[0], parts[1]
+		default:
+			attrs.Resource, attrs.Name, attrs.Subresource = parts[0], parts[1], parts[2]
+		}
+	}
+
+	if attrs.Name == "" {
+		switch attrs.Verb {
+		case "get":
+			attrs.Verb = "list"
+			if req.URL.Query().Get("watch") == "true" {
+				attrs.Verb = "watch"
+			}
+		case "delete":
+			attrs.Verb = "deletecollection"
+		}
+	}
+	return attrs
+}
+
+// policiesNotLoaded denies every request until the proxy policies are loaded
+// from the infra API server, so that a connector which can not reach the
+// server does not ignore the deny policies.
+var policiesNotLoaded = api.ProxyPolicy{
+	Name:   "policies-not-loaded",
+	Reason: "the connector has not loaded the proxy policies from Infra",
+}
+
+// policySet holds the proxy policies for this destination. A nil policySet
+// allows every request. A policySet denies every request until the policies
+// are set for the first time.
+type policySet struct {
+	mu       sync.RWMutex
+	policies []api.ProxyPolicy
+	loaded   bool
+
+	denied *prometheus.CounterVec
+}
+
+func newPolicySet() *policySet {
+	return &policySet{
+		denied: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "infra",
+			Subsystem: "connector",
+			Name:      "policy_denied_requests_total",
+			Help:      "The number of proxied requests denied by a policy.",
+		}, []string{"policy", "verb", "resource"}),
+	}
+}
+
+func (p *policySet) set(policies []api.ProxyPolicy) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.policies = policies
+	p.loaded = true
+}
+
+// evaluate returns the first policy that denies the request, or nil if the
+// request is allowed.
+func (p *policySet) evaluate(attrs requestAttributes, user string, groups []string, now time.Time) *api.ProxyPolicy {
+	if p == nil {
+		return nil
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if !p.loaded {
+		p.denied.With(prometheus.Labels{
+			"policy":   policiesNotLoaded.Name,
+			"verb":     attrs.Verb,
+			"resource": attrs.Resource,
+		}).Inc()
+		return &policiesNotLoaded
+	}
+
+	for i := range p.policies {
+		policy := p.policies[i]
+		if policyRuleMatches(policy, attrs, user, groups, now) {
+			p.denied.With(prometheus.Labels{
+				"policy":   policy.Name,
+				"verb":     attrs.Verb,
+				"resource": attrs.Resource,
+			}).Inc()
+			return &policy
+		}
+	}
+	return nil
+}
+
+func policyRuleMatches(policy api.ProxyPolicy, attrs requestAttributes, user string, groups []string, now time.Time) bool {
+	rule := policy.Rule
+
+	if !attrs.IsResourceRequest {
+		// rules that select resources never match non-resource requests
+		if len(rule.Resources) > 0 || len(rule.Subresources) > 0 || len(rule.Namespaces) > 0 {
+			return false
+		}
+	}
+
+	switch {
+	case !matchesAny(rule.Verbs, attrs.Verb):
+		return false
+	case !matchesAny(rule.Resources, attrs.Resource):
+		return false
+	case !matchesAny(rule.Subresources, attrs.Subresource):
+		return false
+	case !matchesAny(rule.Namespaces, attrs.Namespace):
+		return false
+	case !matchesAny(rule.Users, user):
+		return false
+	case len(rule.Groups) > 0 && !matchesAnyOf(rule.Groups, groups):
+		return false
+	}
+
+	if rule.Schedule != nil {
+		matches, err := rule.Schedule.Matches(now)
+		if err != nil {
+			// deny the request if the schedule can not be evaluated, the
+			// server validates schedules, so this should never happen
+			logging.L.Warn().Err(err).Str("policy", policy.Name).Msg("invalid policy schedule")
+			return true
+		}
+		return matches
+	}
+	return true
+}
+
+// matchesAny returns true if values is empty, or if value is in values.
+func matchesAny(values []string, value string) bool {
+	if len(values) == 0 {
+		return true
+	}
+	for _, v := range values {
+		if v == "*" || v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesAnyOf(values []string, candidates []string) bool {
+	for _, c := range candidates {
+		if matchesAny(values, c) {
+			return true
+		}
+	}
+	return false
+}
+
+// writePolicyDenied writes a Kubernetes Status response so that kubectl and
+// other clients display the reason for the denial.
+func writePolicyDenied(resp http.ResponseWriter, attrs requestAttributes, policy *api.ProxyPolicy) {
+	target := fmt.Sprintf("%s %q", attrs.Verb, attrs.Path)
+	if attrs.IsResourceRequest {
+		target = attrs.Resource
+		if attrs.Subresource != "" {
+			target += "/" + attrs.Subresource
+		}
+		if attrs.Name != "" {
+			target = fmt.Sprintf("%s %q", target, attrs.Name)
+		}
+	}
+
+	message := fmt.Sprintf("%s is forbidden: denied by policy %q", target, policy.Name)
+	if policy.Reason != "" {
+		message += ": " + policy.Reason
+	}
+
+	status := metav1.Status{
+		TypeMeta: metav1.TypeMeta{Kind: "Status", APIVersion: "v1"},
+		Status:   metav1.StatusFailure,
+		Message:  message,
+		Reason:   metav1.StatusReasonForbidden,
+		Details: &metav1.StatusDetails{
+			Name:  attrs.Name,
+			Group: attrs.APIGroup,
+			Kind:  attrs.Resource,
+		},
+		Code: http.StatusForbidden,
+	}
+
+	resp.Header().Set("Content-Type", "application/json")
+	resp.WriteHeader(http.StatusForbidden)
+	if err := json.NewEncoder(resp).Encode(status); err != nil {
+		logging.L.Warn().Err(err).Msg("failed to write policy denied response")
+	}
+}
+
+// syncPolicies retrieves the proxy policies for the destination from the
+// infra API server.
+func syncPolicies(ctx context.Context, c apiClient, destination string, policies *policySet) error {
+	var result []api.ProxyPolicy
+	req := api.ListProxyPoliciesRequest{Destination: destination}
+	for {
+		resp, err := c.ListProxyPolicies(ctx, req)
+		if err != nil {
+			return fmt.Errorf("list proxy policies: %w", err)
+		}
+		result = append(result, resp.Items...)
+
+		if resp.Page >= resp.TotalPages {
+			break
+		}
+		req.Page = resp.Page + 1
+	}
+
+	policies.set(result)
+	return nil
+}
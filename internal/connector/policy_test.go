@@ -0,0 +1,294 @@
+package connector
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"gotest.tools/v3/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/infrahq/infra/api"
+)
+
+func TestParseRequestAttributes(t *testing.T) {
+	type testCase struct {
+		method   string
+		url      string
+		expected requestAttributes
+	}
+
+	run := func(t *testing.T, tc testCase) {
+		req := httptest.NewRequest(tc.method, tc.url, nil)
+		actual := parseRequestAttributes(req)
+		assert.DeepEqual(t, actual, tc.expected)
+	}
+
+	testCases := []testCase{
+		{
+			method: http.MethodGet,
+			url:    "/api/v1/namespaces/default/pods",
+			expected: requestAttributes{
+				IsResourceRequest: true,
+				Path:              "/api/v1/namespaces/default/pods",
+				Verb:              "list",
+				Namespace:         "default",
+				Resource:          "pods",
+			},
+		},
+		{
+			method: http.MethodGet,
+			url:    "/api/v1/namespaces/default/pods?watch=true",
+			expected: requestAttributes{
+				IsResourceRequest: true,
+				Path:              "/api/v1/namespaces/default/pods",
+				Verb:              "watch",
+				Namespace:         "default",
+				Resource:          "pods",
+			},
+		},
+		{
+			method: http.MethodPost,
+			url:    "/api/v1/namespaces/kube-system/pods/coredns/exec?command=sh",
+			expected: requestAttributes{
+				IsResourceRequest: true,
+				Path:              "/api/v1/namespaces/kube-system/pods/coredns/exec",
+				Verb:              "create",
+				Namespace:         "kube-system",
+				Resource:          "pods",
+				Name:              "coredns",
+				Subresource:       "exec",
+			},
+		},
+		{
+			method: http.MethodDelete,
+			url:    "/apis/apps/v1/namespaces/web/deployments",
+			expected: requestAttributes{
+				IsResourceRequest: true,
+				Path:              "/apis/apps/v1/namespaces/web/deployments",
+				Verb:              "deletecollection",
+				APIGroup:          "apps",
+				Namespace:         "web",
+				Resource:          "deployments",
+			},
+		},
+		{
+			method: http.MethodGet,
+			url:    "/api/v1/namespaces/web",
+			expected: requestAttributes{
+				IsResourceRequest: true,
+				Path:              "/api/v1/namespaces/web",
+				Verb:              "get",
+				Namespace:         "web",
+				Resource:          "namespaces",
+				Name:              "web",
+			},
+		},
+		{
+			method: http.MethodPatch,
+			url:    "/api/v1/nodes/node-1",
+			expected: requestAttributes{
+				IsResourceRequest: true,
+				Path:              "/api/v1/nodes/node-1",
+				Verb:              "patch",
+				Resource:          "nodes",
+				Name:              "node-1",
+			},
+		},
+		{
+			method: http.MethodGet,
+			url:    "/api/v1/watch/namespaces/default/secrets",
+			expected: requestAttributes{
+				IsResourceRequest: true,
+				Path:              "/api/v1/watch/namespaces/default/secrets",
+				Verb:              "watch",
+				Namespace:         "default",
+				Resource:          "secrets",
+			},
+		},
+		{
+			method: http.MethodGet,
+			url:    "/healthz",
+			expected: requestAttributes{
+				Path: "/healthz",
+				Verb: "get",
+			},
+		},
+		{
+			method: http.MethodGet,
+			url:    "/apis/apps/v1",
+			expected: requestAttributes{
+				Path: "/apis/apps/v1",
+				Verb: "get",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.method+" "+tc.url, func(t *testing.T) {
+			run(t, tc)
+		})
+	}
+}
+
+func TestPolicySet_Evaluate(t *testing.T) {
+	noExec := api.ProxyPolicy{
+		Name:   "no-exec",
+		Reason: "exec is not allowed in kube-system",
+		Rule: api.ProxyPolicyRule{
+			Resources:    []string{"pods"},
+			Subresources: []string{"exec", "attach"},
+			Namespaces:   []string{"kube-system"},
+		},
+	}
+	noSecretsAfterHours := api.ProxyPolicy{
+		Name: "no-secrets-after-hours",
+		Rule: api.ProxyPolicyRule{
+			Verbs:     []string{"get", "list", "watch"},
+			Resources: []string{"secrets"},
+			Groups:    []string{"contractors"},
+			Schedule: &api.PolicySchedule{
+				Days:    []string{"mon", "tue", "wed", "thu", "fri"},
+				Start:   "09:00",
+				End:     "17:00",
+				Outside: true,
+			},
+		},
+	}
+
+	policies := newPolicySet()
+	policies.set([]api.ProxyPolicy{noExec, noSecretsAfterHours})
+
+	// a wednesday
+	businessHours := time.Date(2023, 1, 25, 10, 0, 0, 0, time.UTC)
+	evening := time.Date(2023, 1, 25, 20, 0, 0, 0, time.UTC)
+
+	type testCase struct {
+		name     string
+		attrs    requestAttributes
+		user     string
+		groups   []string
+		now      time.Time
+		expected *api.ProxyPolicy
+	}
+
+	testCases := []testCase{
+		{
+			name: "exec in kube-system",
+			attrs: requestAttributes{
+				IsResourceRequest: true, Verb: "create",
+				Namespace: "kube-system", Resource: "pods", Subresource: "exec", Name: "coredns",
+			},
+			user:     "admin@example.com",
+			now:      businessHours,
+			expected: &noExec,
+		},
+		{
+			name: "exec in another namespace",
+			attrs: requestAttributes{
+				IsResourceRequest: true, Verb: "create",
+				Namespace: "default", Resource: "pods", Subresource: "exec", Name: "web",
+			},
+			user: "admin@example.com",
+			now:  businessHours,
+		},
+		{
+			name: "get pod in kube-system",
+			attrs: requestAttributes{
+				IsResourceRequest: true, Verb: "get",
+				Namespace: "kube-system", Resource: "pods", Name: "coredns",
+			},
+			user: "admin@example.com",
+			now:  businessHours,
+		},
+		{
+			name: "secrets during business hours",
+			attrs: requestAttributes{
+				IsResourceRequest: true, Verb: "list", Namespace: "default", Resource: "secrets",
+			},
+			user:   "contractor@example.com",
+			groups: []string{"everyone", "contractors"},
+			now:    businessHours,
+		},
+		{
+			name: "secrets after hours",
+			attrs: requestAttributes{
+				IsResourceRequest: true, Verb: "list", Namespace: "default", Resource: "secrets",
+			},
+			user:     "contractor@example.com",
+			groups:   []string{"everyone", "contractors"},
+			now:      evening,
+			expected: &noSecretsAfterHours,
+		},
+		{
+			name: "secrets after hours not in group",
+			attrs: requestAttributes{
+				IsResourceRequest: true, Verb: "list", Namespace: "default", Resource: "secrets",
+			},
+			user:   "employee@example.com",
+			groups: []string{"everyone"},
+			now:    evening,
+		},
+		{
+			name:  "non-resource request",
+			attrs: requestAttributes{Verb: "get", Path: "/healthz"},
+			user:  "admin@example.com",
+			now:   businessHours,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := policies.evaluate(tc.attrs, tc.user, tc.groups, tc.now)
+			assert.DeepEqual(t, actual, tc.expected)
+		})
+	}
+
+	t.Run("denials are counted", func(t *testing.T) {
+		counter := policies.denied.WithLabelValues("no-exec", "create", "pods")
+		assert.Equal(t, testutil.ToFloat64(counter), float64(1))
+	})
+
+	t.Run("policies not loaded", func(t *testing.T) {
+		policies := newPolicySet()
+		attrs := requestAttributes{IsResourceRequest: true, Verb: "get", Resource: "pods"}
+		assert.DeepEqual(t, policies.evaluate(attrs, "user", nil, businessHours), &policiesNotLoaded)
+
+		policies.set(nil)
+		assert.Assert(t, policies.evaluate(attrs, "user", nil, businessHours) == nil)
+	})
+
+	t.Run("nil policy set", func(t *testing.T) {
+		var policies *policySet
+		attrs := requestAttributes{IsResourceRequest: true, Verb: "get", Resource: "secrets"}
+		assert.Assert(t, policies.evaluate(attrs, "user", nil, businessHours) == nil)
+	})
+}
+
+func TestWritePolicyDenied(t *testing.T) {
+	resp := httptest.NewRecorder()
+	attrs := requestAttributes{
+		IsResourceRequest: true, Verb: "create",
+		Namespace: "kube-system", Resource: "pods", Subresource: "exec", Name: "coredns",
+	}
+	policy := &api.ProxyPolicy{Name: "no-exec", Reason: "exec is not allowed in kube-system"}
+
+	writePolicyDenied(resp, attrs, policy)
+	assert.Equal(t, resp.Code, http.StatusForbidden)
+	assert.Equal(t, resp.Header().Get("Content-Type"), "application/json")
+
+	var status metav1.Status
+	assert.NilError(t, json.NewDecoder(resp.Body).Decode(&status))
+	expected := metav1.Status{
+		TypeMeta: metav1.TypeMeta{Kind: "Status", APIVersion: "v1"},
+		Status:   metav1.StatusFailure,
+		Message:  `pods/exec "coredns" is forbidden: denied by policy "no-exec": exec is not allowed in kube-system`,
+		Reason:   metav1.StatusReasonForbidden,
+		Details:  &metav1.StatusDetails{Name: "coredns", Kind: "pods"},
+		Code:     http.StatusForbidden,
+	}
+	assert.DeepEqual(t, status, expected)
+}
@@ -20,6 +20,8 @@ import (
 func proxyMiddleware(
 	proxy *httputil.ReverseProxy,
 	authn *authenticator,
+	policies *policySet,
+	activity *activityRecorder,
 	bearerToken string,
 ) func(resp http.ResponseWriter, req *http.Request) {
 	return func(resp http.ResponseWriter, req *http.Request) {
@@ -43,13 +45,32 @@ func proxyMiddleware(
 			return
 		}
 
+		attrs := parseRequestAttributes(req)
+		defer func() {
+			activity.Record(newActivityRecord(attrs, claim, status, start))
+		}()
+
+		if policy := policies.evaluate(attrs, claim.Name, claim.Groups, time.Now()); policy != nil {
+			logging.L.Info().
+				Str("user", claim.Name).
+				Str("verb", attrs.Verb).
+				Str("path", attrs.Path).
+				Str("policy", policy.Name).
+				Msg("request denied by policy")
+			writePolicyDenied(resp, attrs, policy)
+			status = http.StatusForbidden
+			return
+		}
+
 		req.Header.Set("Impersonate-User", claim.Name)
 		for _, g := range claim.Groups {
 			req.Header.Add("Impersonate-Group", g)
 		}
 
 		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", bearerToken))
-		proxy.ServeHTTP(resp, req)
+		recorder := &statusRecorder{ResponseWriter: resp}
+		proxy.ServeHTTP(recorder, req)
+		status = recorder.Status()
 	}
 }
 
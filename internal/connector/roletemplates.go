@@ -0,0 +1,52 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+
+	"github.com/infrahq/infra/api"
+	"github.com/infrahq/infra/internal/logging"
+)
+
+// syncRoleTemplates creates a ClusterRole for every role template defined in
+// the infra API, so that grants using the template name as the privilege can
+// be bound on this destination.
+func syncRoleTemplates(ctx context.Context, c apiClient, k kubeClient) error {
+	logging.Debugf("syncing role templates from infra configuration")
+
+	templates := make(map[string][]rbacv1.PolicyRule)
+	req := api.ListRoleTemplatesRequest{}
+	for {
+		resp, err := c.ListRoleTemplates(ctx, req)
+		if err != nil {
+			return fmt.Errorf("list role templates: %w", err)
+		}
+
+		for _, template := range resp.Items {
+			templates[template.Name] = policyRulesFromRoleRules(template.Rules)
+		}
+
+		if resp.Page >= resp.TotalPages {
+			break
+		}
+		req.Page = resp.Page + 1
+	}
+
+	return k.UpdateRoleTemplates(templates)
+}
+
+func policyRulesFromRoleRules(rules []api.RoleRule) []rbacv1.PolicyRule {
+	result := make([]rbacv1.PolicyRule, 0, len(rules))
+	for _, rule := range rules {
+		result = append(result, rbacv1.PolicyRule{
+			APIGroups:       rule.APIGroups,
+			Resources:       rule.Resources,
+			ResourceNames:   rule.ResourceNames,
+			NonResourceURLs: rule.NonResourceURLs,
+			Verbs:           rule.Verbs,
+		})
+	}
+	return result
+}
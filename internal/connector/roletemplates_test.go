@@ -0,0 +1,57 @@
+package connector
+
+import (
+	"context"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	rbacv1 "k8s.io/api/rbac/v1"
+
+	"github.com/infrahq/infra/api"
+)
+
+func TestSyncRoleTemplates(t *testing.T) {
+	fakeAPI := &fakeAPIClient{
+		roleTemplates: []api.RoleTemplate{
+			{
+				Name: "debug",
+				Rules: []api.RoleRule{
+					{APIGroups: []string{""}, Resources: []string{"pods/exec"}, Verbs: []string{"create"}},
+					{NonResourceURLs: []string{"/healthz"}, Verbs: []string{"get"}},
+				},
+			},
+			{
+				Name: "logs",
+				Rules: []api.RoleRule{
+					{APIGroups: []string{""}, Resources: []string{"pods/log"}, ResourceNames: []string{"web"}, Verbs: []string{"get"}},
+				},
+			},
+		},
+	}
+	fakeKube := &fakeKubeClient{}
+
+	err := syncRoleTemplates(context.Background(), fakeAPI, fakeKube)
+	assert.NilError(t, err)
+
+	expected := []map[string][]rbacv1.PolicyRule{
+		{
+			"debug": {
+				{APIGroups: []string{""}, Resources: []string{"pods/exec"}, Verbs: []string{"create"}},
+				{NonResourceURLs: []string{"/healthz"}, Verbs: []string{"get"}},
+			},
+			"logs": {
+				{APIGroups: []string{""}, Resources: []string{"pods/log"}, ResourceNames: []string{"web"}, Verbs: []string{"get"}},
+			},
+		},
+	}
+	assert.DeepEqual(t, fakeKube.updateRoleTemplatesArgs, expected)
+
+	t.Run("no templates", func(t *testing.T) {
+		fakeKube := &fakeKubeClient{}
+		err := syncRoleTemplates(context.Background(), &fakeAPIClient{}, fakeKube)
+		assert.NilError(t, err)
+
+		expected := []map[string][]rbacv1.PolicyRule{{}}
+		assert.DeepEqual(t, fakeKube.updateRoleTemplatesArgs, expected)
+	})
+}
@@ -0,0 +1,479 @@
+package connector
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/infrahq/infra/api"
+	"github.com/infrahq/infra/internal/linux"
+	"github.com/infrahq/infra/internal/logging"
+	"github.com/infrahq/infra/uid"
+)
+
+type SessionRecordingOptions struct {
+	// Enabled turns on recording of ssh sessions. When enabled the connector
+	// writes SSHOptions.ManagedConfigPath to force all sessions for users in
+	// SSHOptions.Group through 'infra sshd record', and SudoersPath to allow
+	// those users to run 'infra sshd record' as root.
+	Enabled bool
+	// SocketPath is the path to the unix socket used by 'infra sshd record'
+	// to send recordings to the connector. Only root can connect to the socket.
+	SocketPath string
+	// SudoersPath is the path to a sudoers file that is written by the infra
+	// connector. Defaults to /etc/sudoers.d/infra-session-recording.
+	SudoersPath string
+	// SpoolDir is the directory where recordings are stored until they are
+	// uploaded to the server.
+	SpoolDir string
+	// UploadInterval is the time between attempts to upload any recordings
+	// that failed to upload.
+	UploadInterval time.Duration
+}
+
+const managedSSHDConfigHeader = "# Managed by infra connector. Changes to this file will be overwritten."
+
+// renderManagedSSHDConfig returns the contents of the sshd_config file that
+// forces all sessions for users in group to run the command.
+func renderManagedSSHDConfig(group string, command string) string {
+	return fmt.Sprintf("%v\nMatch Group %v\n    ForceCommand %v\n", managedSSHDConfigHeader, group, command)
+}
+
+// renderManagedSudoers returns the contents of the sudoers file that allows
+// users in group to run the command as root, without a password. sudo sets
+// SUDO_USER, which 'infra sshd record' uses to run the session as the user.
+func renderManagedSudoers(group string, executable string, command string) string {
+	return fmt.Sprintf(`%[1]v
+Defaults!%[2]v env_keep += "SSH_ORIGINAL_COMMAND SSH_CLIENT SSH_CONNECTION TERM LANG"
+Defaults!%[2]v !requiretty
+%%%[3]v ALL=(root) NOPASSWD: %[4]v
+`, managedSSHDConfigHeader, executable, group, command)
+}
+
+// updateManagedSSHDConfig writes or removes the sshd_config file at
+// opts.ManagedConfigPath and the sudoers file at opts.Recording.SudoersPath,
+// and reloads sshd when the sshd_config file changed. The sshd_config file is
+// only included by sshd when the main sshd_config includes it, which is the
+// default for sshd_config.d/*.conf on most distributions.
+//
+// sshd runs the ForceCommand as the user, so 'infra sshd record' is started
+// with sudo. The recording is captured by a process that the user can not
+// modify or signal, and sent to a socket that only root can connect to.
+func updateManagedSSHDConfig(opts SSHOptions, executable string) error {
+	if !opts.Recording.Enabled {
+		removed, err := removeManagedFile(opts.ManagedConfigPath)
+		if err != nil {
+			return fmt.Errorf("remove managed sshd_config: %w", err)
+		}
+		if removed {
+			reloadSSHDWithWarning()
+		}
+		if _, err := removeManagedFile(opts.Recording.SudoersPath); err != nil {
+			return fmt.Errorf("remove managed sudoers: %w", err)
+		}
+		return nil
+	}
+
+	command := fmt.Sprintf("%v sshd record --socket %v", executable, opts.Recording.SocketPath)
+
+	// write the sudoers file first, so that sshd never runs a command that
+	// sudo does not allow.
+	sudoers := renderManagedSudoers(opts.Group, executable, command)
+	if _, err := writeManagedFile(opts.Recording.SudoersPath, sudoers, 0o440, validateSudoers); err != nil {
+		return fmt.Errorf("write managed sudoers: %w", err)
+	}
+
+	config := renderManagedSSHDConfig(opts.Group, "sudo -n "+command)
+	changed, err := writeManagedFile(opts.ManagedConfigPath, config, 0o644, nil)
+	if err != nil {
+		return fmt.Errorf("write managed sshd_config: %w", err)
+	}
+	if changed {
+		reloadSSHDWithWarning()
+	}
+	return nil
+}
+
+// writeManagedFile writes content to filename when the file does not already
+// have that content. The file is written to a temporary file, checked with
+// validate, and renamed, so that a partial or invalid file is never used.
+// Returns true when the file changed.
+func writeManagedFile(filename string, content string, mode fs.FileMode, validate func(string) error) (bool, error) {
+	current, err := os.ReadFile(filename)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		return false, err
+	case string(current) == content:
+		return false, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(filename), 0o755); err != nil {
+		return false, err
+	}
+	// sudo ignores files in sudoers.d that contain a dot, so the temporary
+	// file is never included.
+	tmp := filename + ".tmp"
+	if err := os.WriteFile(tmp, []byte(content), mode); err != nil {
+		return false, err
+	}
+	if err := os.Chmod(tmp, mode); err != nil {
+		_ = os.Remove(tmp)
+		return false, err
+	}
+	if validate != nil {
+		if err := validate(tmp); err != nil {
+			_ = os.Remove(tmp)
+			return false, err
+		}
+	}
+	if err := os.Rename(tmp, filename); err != nil {
+		_ = os.Remove(tmp)
+		return false, err
+	}
+	logging.L.Info().Str("filename", filename).Msg("updated managed file")
+	return true, nil
+}
+
+// removeManagedFile removes filename if it was written by writeManagedFile.
+// Returns true when the file was removed.
+func removeManagedFile(filename string) (bool, error) {
+	current, err := os.ReadFile(filename)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return false, nil
+	case err != nil:
+		return false, err
+	}
+
+	if !bytes.HasPrefix(current, []byte(managedSSHDConfigHeader)) {
+		logging.L.Warn().Str("filename", filename).
+			Msg("file was not created by infra, not removing it")
+		return false, nil
+	}
+	if err := os.Remove(filename); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// validateSudoers checks the syntax of a sudoers file with visudo. A sudoers
+// file with a syntax error prevents sudo from running any command.
+func validateSudoers(filename string) error {
+	visudo, err := exec.LookPath("visudo")
+	if err != nil {
+		logging.L.Debug().Err(err).Msg("visudo not found, not validating sudoers file")
+		return nil
+	}
+	out, err := exec.Command(visudo, "-c", "-q", "-f", filename).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("invalid sudoers file: %w: %s", err, bytes.TrimSpace(out))
+	}
+	return nil
+}
+
+// sshdPidFilename is a shim for testing.
+var sshdPidFilename = "/run/sshd.pid"
+
+func reloadSSHDWithWarning() {
+	if err := reloadSSHD(sshdPidFilename); err != nil {
+		logging.L.Warn().Err(err).Msg("failed to reload sshd, restart sshd to apply the new config")
+	}
+}
+
+// spooledRecording is the metadata for a recording in the spool directory. The
+// metadata file is written once the recording is complete, so a recording is
+// only uploaded once this file exists.
+type spooledRecording struct {
+	UserID    uid.ID    `json:"userID"`
+	LoginName string    `json:"loginName"`
+	StartedAt time.Time `json:"startedAt"`
+	EndedAt   time.Time `json:"endedAt"`
+}
+
+type sessionRecorder struct {
+	client      apiClient
+	destination string
+	opts        SessionRecordingOptions
+
+	// uploadNow is used to start an upload as soon as a recording is complete.
+	uploadNow chan struct{}
+}
+
+func newSessionRecorder(client apiClient, destination string, opts SessionRecordingOptions) *sessionRecorder {
+	return &sessionRecorder{
+		client:      client,
+		destination: destination,
+		opts:        opts,
+		uploadNow:   make(chan struct{}, 1),
+	}
+}
+
+// Listen creates the unix socket used by 'infra sshd record'. Only the user
+// that runs the connector can connect to the socket, which prevents a user
+// from uploading a recording that was not captured by 'infra sshd record'.
+func (r *sessionRecorder) Listen() (net.Listener, error) {
+	if err := os.MkdirAll(r.opts.SpoolDir, 0o700); err != nil {
+		return nil, fmt.Errorf("create spool directory: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(r.opts.SocketPath), 0o755); err != nil {
+		return nil, fmt.Errorf("create socket directory: %w", err)
+	}
+	// remove the socket left behind by a previous run
+	if err := os.Remove(r.opts.SocketPath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	listener, err := net.Listen("unix", r.opts.SocketPath)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(r.opts.SocketPath, 0o600); err != nil {
+		_ = listener.Close()
+		return nil, err
+	}
+	return listener, nil
+}
+
+// Serve accepts connections from 'infra sshd record' until ctx is cancelled.
+func (r *sessionRecorder) Serve(ctx context.Context, listener net.Listener) error {
+	go func() {
+		<-ctx.Done()
+		_ = listener.Close()
+	}()
+
+	for {
+		conn, err := listener.Accept()
+		switch {
+		case ctx.Err() != nil:
+			return ctx.Err()
+		case err != nil:
+			return fmt.Errorf("accept: %w", err)
+		}
+
+		go func() {
+			defer conn.Close()
+			if err := r.record(conn); err != nil {
+				logging.L.Error().Err(err).Msg("failed to record ssh session")
+			}
+		}()
+	}
+}
+
+// SessionRecordingPreamble is the first line sent by 'infra sshd record' to
+// the session recording socket, before the recording.
+type SessionRecordingPreamble struct {
+	// LoginName is the name of the local user that started the session.
+	LoginName string `json:"loginName"`
+}
+
+func (r *sessionRecorder) record(conn net.Conn) error {
+	peerUID, err := peerUID(conn)
+	if err != nil {
+		return fmt.Errorf("read peer credentials: %w", err)
+	}
+	// the socket permissions already prevent this, check again in case the
+	// socket was modified.
+	if peerUID != strconv.Itoa(os.Geteuid()) {
+		return fmt.Errorf("session recording from uid %v rejected, recordings must be sent by 'infra sshd record' running as root", peerUID)
+	}
+
+	reader := bufio.NewReader(conn)
+	line, err := reader.ReadBytes('\n')
+	if err != nil {
+		return fmt.Errorf("read session recording preamble: %w", err)
+	}
+	var preamble SessionRecordingPreamble
+	if err := json.Unmarshal(line, &preamble); err != nil {
+		return fmt.Errorf("read session recording preamble: %w", err)
+	}
+
+	user, err := localUserForName(preamble.LoginName)
+	if err != nil {
+		return err
+	}
+	userID, err := uid.Parse([]byte(user.Info[0]))
+	if err != nil {
+		return fmt.Errorf("invalid infra user ID for local user %v: %w", user.Username, err)
+	}
+
+	meta := spooledRecording{
+		UserID:    userID,
+		LoginName: user.Username,
+		StartedAt: time.Now().UTC(),
+	}
+	name := filepath.Join(r.opts.SpoolDir, uid.New().String())
+
+	fh, err := os.OpenFile(name+".cast", os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	if err := copyRecording(fh, reader, api.MaxSessionRecordingSize); err != nil {
+		logging.L.Warn().Err(err).Str("username", user.Username).Msg("session recording is incomplete")
+	}
+	if err := fh.Close(); err != nil {
+		return err
+	}
+
+	meta.EndedAt = time.Now().UTC()
+	raw, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(name+".json", raw); err != nil {
+		return err
+	}
+
+	select {
+	case r.uploadNow <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+var errRecordingTooLarge = errors.New("recording exceeds the maximum size")
+
+// copyRecording copies complete lines from src to dst, stopping once the next
+// line would exceed limit. The remainder of src is discarded so that the
+// session can continue.
+func copyRecording(dst io.Writer, src io.Reader, limit int) error {
+	reader := bufio.NewReader(src)
+	var written int
+	for {
+		line, err := reader.ReadBytes('\n')
+		switch {
+		case errors.Is(err, io.EOF):
+			// a partial line means the session ended before the line was
+			// written, so it is not included in the recording.
+			return nil
+		case err != nil:
+			return err
+		}
+
+		if written+len(line) > limit {
+			_, _ = io.Copy(io.Discard, reader)
+			return errRecordingTooLarge
+		}
+		n, err := dst.Write(line)
+		written += n
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func writeFileAtomic(filename string, content []byte) error {
+	tmp := filename + ".tmp"
+	if err := os.WriteFile(tmp, content, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filename)
+}
+
+func localUserForName(username string) (linux.LocalUser, error) {
+	localUsers, err := linux.ReadLocalUsers(etcPasswdFilename)
+	if err != nil {
+		return linux.LocalUser{}, fmt.Errorf("read local users: %w", err)
+	}
+	for _, user := range localUsers {
+		if user.Username != username {
+			continue
+		}
+		if !user.IsManagedByInfra() {
+			return linux.LocalUser{}, fmt.Errorf("local user %v is not managed by infra", user.Username)
+		}
+		return user, nil
+	}
+	return linux.LocalUser{}, fmt.Errorf("no local user named %q", username)
+}
+
+// RunUploader uploads completed recordings from the spool directory until ctx
+// is cancelled.
+func (r *sessionRecorder) RunUploader(ctx context.Context) error {
+	ticker := time.NewTicker(r.opts.UploadInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := r.upload(ctx); err != nil {
+			logging.L.Warn().Err(err).Msg("failed to upload session recordings")
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		case <-r.uploadNow:
+		}
+	}
+}
+
+// upload sends each completed recording in the spool directory to the server,
+// and removes it from the spool directory once it was accepted.
+func (r *sessionRecorder) upload(ctx context.Context) error {
+	entries, err := os.ReadDir(r.opts.SpoolDir)
+	if err != nil {
+		return err
+	}
+
+	var failed int
+	for _, entry := range entries {
+		if !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		name := filepath.Join(r.opts.SpoolDir, strings.TrimSuffix(entry.Name(), ".json"))
+		if err := r.uploadOne(ctx, name); err != nil {
+			logging.L.Debug().Err(err).Str("filename", name).Msg("upload session recording")
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d recordings failed to upload, will retry", failed)
+	}
+	return nil
+}
+
+func (r *sessionRecorder) uploadOne(ctx context.Context, name string) error {
+	raw, err := os.ReadFile(name + ".json")
+	if err != nil {
+		return err
+	}
+	var meta spooledRecording
+	if err := json.Unmarshal(raw, &meta); err != nil {
+		return fmt.Errorf("read recording metadata: %w", err)
+	}
+	content, err := os.ReadFile(name + ".cast")
+	if err != nil {
+		return err
+	}
+
+	if len(content) > 0 {
+		_, err = r.client.CreateSessionRecording(ctx, &api.CreateSessionRecordingRequest{
+			Destination: r.destination,
+			UserID:      meta.UserID,
+			LoginName:   meta.LoginName,
+			StartedAt:   api.Time(meta.StartedAt),
+			EndedAt:     api.Time(meta.EndedAt),
+			Content:     content,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := os.Remove(name + ".cast"); err != nil {
+		return err
+	}
+	return os.Remove(name + ".json")
+}
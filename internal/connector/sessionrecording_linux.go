@@ -0,0 +1,49 @@
+package connector
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// peerUID returns the uid of the process on the other end of the unix socket.
+func peerUID(conn net.Conn) (string, error) {
+	unixConn, ok := conn.(*net.UnixConn)
+	if !ok {
+		return "", fmt.Errorf("connection is not a unix socket")
+	}
+	raw, err := unixConn.SyscallConn()
+	if err != nil {
+		return "", err
+	}
+
+	var cred *syscall.Ucred
+	var credErr error
+	err = raw.Control(func(fd uintptr) {
+		cred, credErr = syscall.GetsockoptUcred(int(fd), syscall.SOL_SOCKET, syscall.SO_PEERCRED)
+	})
+	if err != nil {
+		return "", err
+	}
+	if credErr != nil {
+		return "", credErr
+	}
+	return strconv.FormatUint(uint64(cred.Uid), 10), nil
+}
+
+// reloadSSHD sends SIGHUP to the sshd process, which causes it to read the
+// config files again.
+func reloadSSHD(pidFilename string) error {
+	raw, err := os.ReadFile(pidFilename)
+	if err != nil {
+		return fmt.Errorf("read sshd pid: %w", err)
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(raw)))
+	if err != nil {
+		return fmt.Errorf("invalid sshd pid: %w", err)
+	}
+	return syscall.Kill(pid, syscall.SIGHUP)
+}
@@ -0,0 +1,18 @@
+//go:build !linux
+
+package connector
+
+import (
+	"errors"
+	"net"
+)
+
+var errSessionRecordingUnsupported = errors.New("session recording is only supported on linux")
+
+func peerUID(net.Conn) (string, error) {
+	return "", errSessionRecordingUnsupported
+}
+
+func reloadSSHD(string) error {
+	return errSessionRecordingUnsupported
+}
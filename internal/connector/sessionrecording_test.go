@@ -0,0 +1,182 @@
+package connector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/poll"
+
+	"github.com/infrahq/infra/uid"
+)
+
+func TestUpdateManagedSSHDConfig(t *testing.T) {
+	sshdPidFilename = filepath.Join(t.TempDir(), "missing.pid")
+	t.Cleanup(func() {
+		sshdPidFilename = "/run/sshd.pid"
+	})
+
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "sshd_config.d", "infra.conf")
+	sudoers := filepath.Join(dir, "sudoers.d", "infra-session-recording")
+	opts := SSHOptions{
+		Group:             "infra-users",
+		ManagedConfigPath: filename,
+		Recording: SessionRecordingOptions{
+			Enabled:     true,
+			SocketPath:  "/run/infra/session-recording.sock",
+			SudoersPath: sudoers,
+		},
+	}
+
+	t.Run("enabled", func(t *testing.T) {
+		assert.NilError(t, updateManagedSSHDConfig(opts, "/usr/local/bin/infra"))
+
+		raw, err := os.ReadFile(filename)
+		assert.NilError(t, err)
+		expected := `# Managed by infra connector. Changes to this file will be overwritten.
+Match Group infra-users
+    ForceCommand sudo -n /usr/local/bin/infra sshd record --socket /run/infra/session-recording.sock
+`
+		assert.Equal(t, string(raw), expected)
+
+		raw, err = os.ReadFile(sudoers)
+		assert.NilError(t, err)
+		expected = `# Managed by infra connector. Changes to this file will be overwritten.
+Defaults!/usr/local/bin/infra env_keep += "SSH_ORIGINAL_COMMAND SSH_CLIENT SSH_CONNECTION TERM LANG"
+Defaults!/usr/local/bin/infra !requiretty
+%infra-users ALL=(root) NOPASSWD: /usr/local/bin/infra sshd record --socket /run/infra/session-recording.sock
+`
+		assert.Equal(t, string(raw), expected)
+
+		info, err := os.Stat(sudoers)
+		assert.NilError(t, err)
+		assert.Equal(t, info.Mode().Perm(), os.FileMode(0o440))
+	})
+
+	t.Run("disabled removes the files", func(t *testing.T) {
+		opts := opts
+		opts.Recording.Enabled = false
+		assert.NilError(t, updateManagedSSHDConfig(opts, "/usr/local/bin/infra"))
+
+		_, err := os.Stat(filename)
+		assert.Assert(t, errors.Is(err, os.ErrNotExist))
+		_, err = os.Stat(sudoers)
+		assert.Assert(t, errors.Is(err, os.ErrNotExist))
+	})
+
+	t.Run("disabled does not remove unmanaged file", func(t *testing.T) {
+		assert.NilError(t, os.WriteFile(filename, []byte("Match User root\n"), 0o644))
+
+		opts := opts
+		opts.Recording.Enabled = false
+		assert.NilError(t, updateManagedSSHDConfig(opts, "/usr/local/bin/infra"))
+
+		_, err := os.Stat(filename)
+		assert.NilError(t, err)
+	})
+}
+
+func TestCopyRecording(t *testing.T) {
+	t.Run("partial line is discarded", func(t *testing.T) {
+		out := new(strings.Builder)
+		src := strings.NewReader("{\"version\":2}\n[0.1,\"o\",\"a\"]\n[0.2,\"o\"")
+		assert.NilError(t, copyRecording(out, src, 1024))
+		assert.Equal(t, out.String(), "{\"version\":2}\n[0.1,\"o\",\"a\"]\n")
+	})
+
+	t.Run("exceeds limit", func(t *testing.T) {
+		out := new(strings.Builder)
+		src := strings.NewReader("{\"version\":2}\n[0.1,\"o\",\"abcdefg\"]\n")
+		err := copyRecording(out, src, 20)
+		assert.ErrorIs(t, err, errRecordingTooLarge)
+		assert.Equal(t, out.String(), "{\"version\":2}\n")
+	})
+}
+
+func TestSessionRecorder(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("session recording requires linux")
+	}
+
+	dir := t.TempDir()
+	etcPasswdFilename = filepath.Join(dir, "passwd")
+	t.Cleanup(func() {
+		etcPasswdFilename = "/etc/passwd"
+	})
+	userID := uid.New()
+	passwd := fmt.Sprintf("alice:x:%d:%d:%v,managed by infra:/home/alice:/bin/bash\n",
+		os.Getuid(), os.Getgid(), userID)
+	assert.NilError(t, os.WriteFile(etcPasswdFilename, []byte(passwd), 0o600))
+
+	client := &fakeAPIClient{createSessionRecordingError: errors.New("server unavailable")}
+	opts := SessionRecordingOptions{
+		Enabled:        true,
+		SocketPath:     filepath.Join(dir, "run", "recording.sock"),
+		SpoolDir:       filepath.Join(dir, "spool"),
+		UploadInterval: time.Hour,
+	}
+	recorder := newSessionRecorder(client, "bastion", opts)
+
+	listener, err := recorder.Listen()
+	assert.NilError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go func() {
+		_ = recorder.Serve(ctx, listener)
+	}()
+
+	info, err := os.Stat(opts.SocketPath)
+	assert.NilError(t, err)
+	assert.Equal(t, info.Mode().Perm(), os.FileMode(0o600))
+
+	content := "{\"version\":2,\"width\":80,\"height\":24}\n[0.5,\"o\",\"$ \"]\n"
+	conn, err := net.Dial("unix", opts.SocketPath)
+	assert.NilError(t, err)
+	_, err = conn.Write([]byte("{\"loginName\":\"alice\"}\n" + content))
+	assert.NilError(t, err)
+	assert.NilError(t, conn.Close())
+
+	// wait for the recording to be spooled
+	spooled := func(t poll.LogT) poll.Result {
+		select {
+		case <-recorder.uploadNow:
+			return poll.Success()
+		default:
+			return poll.Continue("waiting for recording to complete")
+		}
+	}
+	poll.WaitOn(t, spooled, poll.WithTimeout(5*time.Second))
+
+	// the upload fails, the recording stays in the spool directory
+	err = recorder.upload(ctx)
+	assert.ErrorContains(t, err, "1 recordings failed to upload")
+	entries, err := os.ReadDir(opts.SpoolDir)
+	assert.NilError(t, err)
+	assert.Equal(t, len(entries), 2)
+
+	// the retry succeeds, and removes the recording from the spool directory
+	client.createSessionRecordingError = nil
+	assert.NilError(t, recorder.upload(ctx))
+
+	assert.Equal(t, len(client.sessionRecordings), 1)
+	actual := client.sessionRecordings[0]
+	assert.Equal(t, actual.Destination, "bastion")
+	assert.Equal(t, actual.UserID, userID)
+	assert.Equal(t, actual.LoginName, "alice")
+	assert.Equal(t, string(actual.Content), content)
+	assert.Assert(t, !time.Time(actual.StartedAt).IsZero())
+
+	entries, err = os.ReadDir(opts.SpoolDir)
+	assert.NilError(t, err)
+	assert.Equal(t, len(entries), 0)
+}
@@ -31,6 +31,14 @@ type SSHOptions struct {
 	// ssh server that will call infra to authenticate users. Defaults to
 	// /etc/ssh/sshd_config.
 	SSHDConfigPath string `config:"sshdConfigPath"`
+
+	// ManagedConfigPath is the path to an sshd_config file that is written by
+	// the infra connector. The file must be included by the sshd_config at
+	// SSHDConfigPath. Defaults to /etc/ssh/sshd_config.d/infra.conf.
+	ManagedConfigPath string
+
+	// Recording configures recording of ssh sessions.
+	Recording SessionRecordingOptions
 }
 
 func runSSHConnector(ctx context.Context, opts Options) error {
@@ -52,7 +60,28 @@ func runSSHConnector(ctx context.Context, opts Options) error {
 		options:     opts,
 	}
 
+	executable, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	if err := updateManagedSSHDConfig(opts.SSH, executable); err != nil {
+		return err
+	}
+
 	group, ctx := errgroup.WithContext(ctx)
+	if opts.SSH.Recording.Enabled {
+		recorder := newSessionRecorder(client, destination.Name, opts.SSH.Recording)
+		listener, err := recorder.Listen()
+		if err != nil {
+			return fmt.Errorf("session recording: %w", err)
+		}
+		group.Go(func() error {
+			return recorder.Serve(ctx, listener)
+		})
+		group.Go(func() error {
+			return recorder.RunUploader(ctx)
+		})
+	}
 	group.Go(func() error {
 		backOff := &backoff.ExponentialBackOff{
 			InitialInterval:     2 * time.Second,
@@ -62,7 +91,7 @@ func runSSHConnector(ctx context.Context, opts Options) error {
 		}
 		waiter := repeat.NewWaiter(backOff)
 		fn := func(ctx context.Context, grants []api.Grant) error {
-			return updateLocalUsers(ctx, client, opts.SSH, grants)
+			return updateLocalUsers(opts.SSH, grants)
 		}
 		return syncGrantsToDestination(ctx, con, waiter, fn)
 	})
@@ -89,6 +118,20 @@ func validateOptionsSSH(opts Options) error {
 		return fmt.Errorf("missing ssh.group")
 	case opts.SSH.SSHDConfigPath == "":
 		return fmt.Errorf("missing ssh.sshd_config_path")
+	case opts.SSH.ManagedConfigPath == "":
+		return fmt.Errorf("missing ssh.managedConfigPath")
+	}
+	if opts.SSH.Recording.Enabled {
+		switch {
+		case opts.SSH.Recording.SocketPath == "":
+			return fmt.Errorf("missing ssh.recording.socketPath")
+		case opts.SSH.Recording.SudoersPath == "":
+			return fmt.Errorf("missing ssh.recording.sudoersPath")
+		case opts.SSH.Recording.SpoolDir == "":
+			return fmt.Errorf("missing ssh.recording.spoolDir")
+		case opts.SSH.Recording.UploadInterval <= 0:
+			return fmt.Errorf("ssh.recording.uploadInterval must be greater than 0")
+		}
 	}
 	return nil
 }
@@ -183,9 +226,8 @@ func readHostKeyFile(filename string, out io.Writer) error {
 // etcPasswdFilename is a shim for testing.
 var etcPasswdFilename = "/etc/passwd"
 
-// TODO: grants for groups need to be resolved to a user somehow
-func updateLocalUsers(ctx context.Context, client apiClient, opts SSHOptions, grants []api.Grant) error {
-	byUserID := grantsByUserID(grants)
+func updateLocalUsers(opts SSHOptions, grants []api.Grant) error {
+	byUserID := usersFromGrants(grants)
 
 	localUsers, err := linux.ReadLocalUsers(etcPasswdFilename)
 	if err != nil {
@@ -224,12 +266,7 @@ func updateLocalUsers(ctx context.Context, client apiClient, opts SSHOptions, gr
 		logging.L.Info().Str("username", user.Username).Msg("removed user")
 	}
 
-	for _, grant := range byUserID {
-		user, err := client.GetUser(ctx, grant.User)
-		if err != nil {
-			return fmt.Errorf("get user: %w", err)
-		}
-
+	for _, user := range byUserID {
 		if user.SSHLoginName == "" {
 			logging.L.Error().Str("user", user.Name).Msg("missing SSHLoginName")
 			continue
@@ -248,10 +285,26 @@ func updateLocalUsers(ctx context.Context, client apiClient, opts SSHOptions, gr
 	return nil
 }
 
-func grantsByUserID(grants []api.Grant) map[string]api.Grant {
-	result := make(map[string]api.Grant, len(grants))
+// usersFromGrants returns the users with a grant, keyed by the ID of the user.
+// Grants to a group include a user for each member of the group.
+func usersFromGrants(grants []api.Grant) map[string]*api.User {
+	result := make(map[string]*api.User, len(grants))
 	for _, grant := range grants {
-		result[grant.User.String()] = grant
+		if grant.User != 0 {
+			result[grant.User.String()] = &api.User{
+				ID:           grant.User,
+				Name:         grant.UserName,
+				SSHLoginName: grant.UserSSHLoginName,
+			}
+			continue
+		}
+		for _, member := range grant.GroupMembers {
+			result[member.ID.String()] = &api.User{
+				ID:           member.ID,
+				Name:         member.Name,
+				SSHLoginName: member.SSHLoginName,
+			}
+		}
 	}
 	return result
 }
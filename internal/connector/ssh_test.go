@@ -1,7 +1,6 @@
 package connector
 
 import (
-	"context"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -12,7 +11,6 @@ import (
 
 	"github.com/infrahq/infra/api"
 	data "github.com/infrahq/infra/internal/linux"
-	"github.com/infrahq/infra/uid"
 )
 
 func TestUpdateLocalUsers(t *testing.T) {
@@ -27,20 +25,13 @@ func TestUpdateLocalUsers(t *testing.T) {
 		etcPasswdFilename = "/etc/passwd"
 	})
 
-	ctx := context.Background()
-	fakeClient := &fakeAPIClient{
-		users: map[uid.ID]api.User{
-			1111: {ID: 1111, Name: "one@example.com", SSHLoginName: "one111"},
-			2222: {ID: 2222, Name: "two@example.com", SSHLoginName: "two222"},
-		},
-	}
 	grants := []api.Grant{
-		{ID: 123, User: 1111, Privilege: "connect"},
-		{ID: 124, User: 2222, Privilege: "connect"},
+		{ID: 123, User: 1111, UserName: "one@example.com", UserSSHLoginName: "one111", Privilege: "connect"},
+		{ID: 124, User: 2222, UserName: "two@example.com", UserSSHLoginName: "two222", Privilege: "connect"},
 	}
 
 	opts := SSHOptions{Group: "infra-users"}
-	err := updateLocalUsers(ctx, fakeClient, opts, grants)
+	err := updateLocalUsers(opts, grants)
 	assert.NilError(t, err)
 
 	actual, err := os.ReadFile(logFile)
@@ -56,32 +47,65 @@ useradd --comment 'Ej,managed by infra' -m -p '*' -g infra-users two222
 	assert.Equal(t, expected, string(actual))
 }
 
-func TestUpdateLocalUsers_RemoveFailed(t *testing.T) {
+func TestUpdateLocalUsers_GroupGrant(t *testing.T) {
 	logDir := t.TempDir()
 	logFile := filepath.Join(logDir, "users.log")
 	cwd, _ := os.Getwd()
 	t.Setenv("PATH", filepath.Join(cwd, "testdata/bin")+":"+os.Getenv("PATH"))
 	t.Setenv("TEST_CONNECTOR_USER_LOG_FILE", logFile)
 
-	etcPasswdFilename = "testdata/localusers-etcpasswd-remove-failed"
+	etcPasswdFilename = "testdata/localusers-etcpasswd"
 	t.Cleanup(func() {
 		etcPasswdFilename = "/etc/passwd"
 	})
 
-	ctx := context.Background()
-	fakeClient := &fakeAPIClient{
-		users: map[uid.ID]api.User{
-			1111: {ID: 1111, Name: "one@example.com", SSHLoginName: "one111"},
-			2222: {ID: 2222, Name: "two@example.com", SSHLoginName: "two222"},
+	grants := []api.Grant{
+		{ID: 123, User: 1111, UserName: "one@example.com", UserSSHLoginName: "one111", Privilege: "connect"},
+		{
+			ID:        124,
+			Group:     77,
+			GroupName: "the-group",
+			GroupMembers: []api.GrantGroupMember{
+				{ID: 2222, Name: "two@example.com", SSHLoginName: "two222"},
+				{ID: 3333, Name: "three@example.com", SSHLoginName: "three333"},
+			},
+			Privilege: "connect",
 		},
 	}
+
+	opts := SSHOptions{Group: "infra-users"}
+	err := updateLocalUsers(opts, grants)
+	assert.NilError(t, err)
+
+	actual, err := os.ReadFile(logFile)
+	assert.NilError(t, err)
+
+	expected := `pkill --signal KILL --uid four444
+userdel --remove four444
+useradd --comment 'Ej,managed by infra' -m -p '*' -g infra-users two222
+`
+	assert.Equal(t, expected, string(actual))
+}
+
+func TestUpdateLocalUsers_RemoveFailed(t *testing.T) {
+	logDir := t.TempDir()
+	logFile := filepath.Join(logDir, "users.log")
+	cwd, _ := os.Getwd()
+	t.Setenv("PATH", filepath.Join(cwd, "testdata/bin")+":"+os.Getenv("PATH"))
+	t.Setenv("TEST_CONNECTOR_USER_LOG_FILE", logFile)
+
+	etcPasswdFilename = "testdata/localusers-etcpasswd-remove-failed"
+	t.Cleanup(func() {
+		etcPasswdFilename = "/etc/passwd"
+	})
+
 	grants := []api.Grant{
-		{ID: 123, User: 1111, Privilege: "connect"},
-		{ID: 124, User: 2222, Privilege: "connect"},
+		{ID: 123, User: 1111, UserName: "one@example.com", UserSSHLoginName: "one111", Privilege: "connect"},
+		{ID: 124, User: 2222, UserName: "two@example.com", UserSSHLoginName: "two222", Privilege: "connect"},
 	}
 
 	opts := SSHOptions{Group: "infra-users"}
-	err := updateLocalUsers(ctx, fakeClient, opts, grants)
+	err := updateLocalUsers(opts, grants)
 	assert.ErrorContains(t, err, "remove user failremove: userdel: exit status 8")
 
 	actual, err := os.ReadFile(logFile)
@@ -162,6 +186,7 @@ func TestReadLocalUsers(t *testing.T) {
 			UID:      "0",
 			GID:      "0",
 			HomeDir:  "/root",
+			Shell:    "/bin/ash",
 		},
 		{
 			Username: "adm",
@@ -169,6 +194,7 @@ func TestReadLocalUsers(t *testing.T) {
 			UID:      "3",
 			GID:      "4",
 			HomeDir:  "/var/adm",
+			Shell:    "/sbin/nologin",
 		},
 		{
 			Username: "example",
@@ -176,6 +202,7 @@ func TestReadLocalUsers(t *testing.T) {
 			UID:      "1001",
 			GID:      "1001",
 			HomeDir:  "/home/example",
+			Shell:    "/bin/bash",
 		},
 	}
 	assert.DeepEqual(t, actual, expected)
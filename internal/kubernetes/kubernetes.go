@@ -25,6 +25,7 @@ import (
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
 
 	"github.com/infrahq/infra/internal/logging"
 )
@@ -242,6 +243,114 @@ func (k *Kubernetes) UpdateRoleBindings(subjects map[ClusterRoleNamespace][]rbac
 	return nil
 }
 
+// UpdateRoleTemplates creates or updates a ClusterRole for each of the role
+// templates, and deletes ClusterRoles for templates that no longer exist,
+// along with any bindings to them.
+// A template with the same name as a ClusterRole that is not managed by infra
+// is skipped so that existing roles are never modified.
+func (k *Kubernetes) UpdateRoleTemplates(templates map[string][]rbacv1.PolicyRule) error {
+	clientset, err := kubernetes.NewForConfig(k.Config)
+	if err != nil {
+		return err
+	}
+
+	crs, err := clientset.RbacV1().ClusterRoles().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+
+	toDelete := make(map[string]bool)
+	unmanaged := make(map[string]bool)
+	for _, cr := range crs.Items {
+		if cr.Labels["app.infrahq.com/role-template"] == "true" {
+			toDelete[cr.Name] = true
+			continue
+		}
+		unmanaged[cr.Name] = true
+	}
+
+	for name, rules := range templates {
+		if unmanaged[name] {
+			logging.Warnf("skipping role template %s, a cluster role with that name already exists", name)
+			continue
+		}
+
+		cr := &rbacv1.ClusterRole{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: name,
+				Labels: map[string]string{
+					"app.kubernetes.io/managed-by":  "infra",
+					"app.infrahq.com/role-template": "true",
+					"app.infrahq.com/include-role":  "true",
+				},
+			},
+			Rules: rules,
+		}
+
+		_, err = clientset.RbacV1().ClusterRoles().Update(context.TODO(), cr, metav1.UpdateOptions{})
+		if err != nil {
+			if !k8sErrors.IsNotFound(err) {
+				return err
+			}
+			_, err = clientset.RbacV1().ClusterRoles().Create(context.TODO(), cr, metav1.CreateOptions{})
+			if err != nil {
+				return err
+			}
+		}
+
+		delete(toDelete, name)
+	}
+
+	for name := range toDelete {
+		err := clientset.RbacV1().ClusterRoles().Delete(context.TODO(), name, metav1.DeleteOptions{})
+		if err != nil && !k8sErrors.IsNotFound(err) {
+			return err
+		}
+	}
+
+	return deleteBindingsForClusterRoles(clientset, toDelete)
+}
+
+// deleteBindingsForClusterRoles deletes the ClusterRoleBindings and
+// RoleBindings managed by infra that refer to one of the cluster roles.
+func deleteBindingsForClusterRoles(clientset *kubernetes.Clientset, clusterRoles map[string]bool) error {
+	if len(clusterRoles) == 0 {
+		return nil
+	}
+
+	opts := metav1.ListOptions{LabelSelector: "app.kubernetes.io/managed-by=infra"}
+
+	crbs, err := clientset.RbacV1().ClusterRoleBindings().List(context.TODO(), opts)
+	if err != nil {
+		return err
+	}
+	for _, crb := range crbs.Items {
+		if crb.RoleRef.Kind != "ClusterRole" || !clusterRoles[crb.RoleRef.Name] {
+			continue
+		}
+		err := clientset.RbacV1().ClusterRoleBindings().Delete(context.TODO(), crb.Name, metav1.DeleteOptions{})
+		if err != nil && !k8sErrors.IsNotFound(err) {
+			return err
+		}
+	}
+
+	rbs, err := clientset.RbacV1().RoleBindings("").List(context.TODO(), opts)
+	if err != nil {
+		return err
+	}
+	for _, rb := range rbs.Items {
+		if rb.RoleRef.Kind != "ClusterRole" || !clusterRoles[rb.RoleRef.Name] {
+			continue
+		}
+		err := clientset.RbacV1().RoleBindings(rb.Namespace).Delete(context.TODO(), rb.Name, metav1.DeleteOptions{})
+		if err != nil && !k8sErrors.IsNotFound(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func (k *Kubernetes) Namespaces() ([]string, error) {
 	clientset, err := kubernetes.NewForConfig(k.Config)
 	if err != nil {
@@ -530,6 +639,29 @@ func readNamespaceFromInClusterFile() (string, error) {
 	return string(contents), nil
 }
 
+// LeaseLock returns a lock backed by a Lease named name in the namespace of the
+// connector pod. The lock is used for leader election between replicas.
+func (k *Kubernetes) LeaseLock(name, identity string) (resourcelock.Interface, error) {
+	clientset, err := kubernetes.NewForConfig(k.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	namespace, err := readNamespaceFromInClusterFile()
+	if err != nil {
+		return nil, err
+	}
+
+	return &resourcelock.LeaseLock{
+		LeaseMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Client:     clientset.CoordinationV1(),
+		LockConfig: resourcelock.ResourceLockConfig{Identity: identity},
+	}, nil
+}
+
 // Find the first suitable Service, filtering on app.infrahq.com/component
 func (k *Kubernetes) Service(labels ...string) (*corev1.Service, error) {
 	clientset, err := kubernetes.NewForConfig(k.Config)
@@ -18,6 +18,7 @@ type LocalUser struct {
 	GID      string
 	Info     []string
 	HomeDir  string
+	Shell    string
 }
 
 const sentinelManagedByInfra = "managed by infra"
@@ -53,7 +54,7 @@ func ReadLocalUsers(filename string) ([]LocalUser, error) {
 			GID:     fields[3],
 			Info:    strings.FieldsFunc(fields[4], isRuneComma),
 			HomeDir: fields[5],
-			// field 6 is login shell
+			Shell:   fields[6],
 		})
 	}
 	return result, scan.Err()
@@ -14,7 +14,10 @@ import (
 
 func (a *API) ListAccessKeys(c *gin.Context, r *api.ListAccessKeysRequest) (*api.ListResponse[api.AccessKey], error) {
 	rCtx := getRequestContext(c)
-	p := PaginationFromRequest(r.PaginationRequest)
+	p, err := PaginationFromRequest(r.PaginationRequest)
+	if err != nil {
+		return nil, err
+	}
 	accessKeys, err := access.ListAccessKeys(rCtx, r.UserID, r.Name, r.ShowExpired, &p)
 	if err != nil {
 		return nil, err
@@ -45,6 +48,10 @@ func (a *API) CreateAccessKey(c *gin.Context, r *api.CreateAccessKeyRequest) (*a
 		ExpiresAt:           time.Now().UTC().Add(time.Duration(r.Expiry)),
 		InactivityExtension: time.Duration(r.InactivityTimeout),
 		InactivityTimeout:   time.Now().UTC().Add(time.Duration(r.InactivityTimeout)),
+		RateLimit:           r.RateLimit,
+	}
+	if r.Destination != "" {
+		accessKey.Scopes = models.CommaSeparatedStrings{models.ScopeDestinationPrefix + r.Destination}
 	}
 
 	raw, err := access.CreateAccessKey(rCtx, accessKey)
@@ -13,6 +13,7 @@ import (
 
 	gocmp "github.com/google/go-cmp/cmp"
 	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
 
 	"github.com/infrahq/infra/api"
 	"github.com/infrahq/infra/internal/server/data"
@@ -32,6 +33,8 @@ func TestAPI_CreateAccessKey(t *testing.T) {
 	routes := srv.GenerateRoutes()
 
 	userResp := createUser(t, srv, routes, "usera@example.com")
+	connector := data.InfraConnectorIdentity(srv.DB())
+	prodKey := createConnectorAccessKey(t, srv.DB(), "prod")
 
 	run := func(t *testing.T, tc testCase) {
 		body := tc.setup(t)
@@ -114,6 +117,72 @@ func TestAPI_CreateAccessKey(t *testing.T) {
 				assert.DeepEqual(t, respBody.FieldErrors, expected)
 			},
 		},
+		{
+			name: "connector key bound to a destination",
+			setup: func(t *testing.T) io.Reader {
+				return jsonBody(t, &api.CreateAccessKeyRequest{
+					UserID:            connector.ID,
+					Expiry:            api.Duration(time.Minute),
+					InactivityTimeout: api.Duration(time.Minute),
+					Destination:       "prod",
+				})
+			},
+			expected: func(t *testing.T, resp *httptest.ResponseRecorder) {
+				assert.Equal(t, resp.Code, http.StatusCreated, resp.Body.String())
+			},
+		},
+		{
+			name: "user key bound to a destination",
+			setup: func(t *testing.T) io.Reader {
+				return jsonBody(t, &api.CreateAccessKeyRequest{
+					UserID:            userResp.ID,
+					Expiry:            api.Duration(time.Minute),
+					InactivityTimeout: api.Duration(time.Minute),
+					Destination:       "prod",
+				})
+			},
+			expected: func(t *testing.T, resp *httptest.ResponseRecorder) {
+				assert.Equal(t, resp.Code, http.StatusBadRequest, resp.Body.String())
+			},
+		},
+		{
+			name: "bound connector key creates a key for its destination",
+			setup: func(t *testing.T) io.Reader {
+				return jsonBody(t, &api.CreateAccessKeyRequest{
+					UserID:            connector.ID,
+					Expiry:            api.Duration(time.Minute),
+					InactivityTimeout: api.Duration(time.Minute),
+					Destination:       "prod",
+				})
+			},
+			expected: func(t *testing.T, resp *httptest.ResponseRecorder) {
+				assert.Equal(t, resp.Code, http.StatusCreated, resp.Body.String())
+			},
+			headers: map[string][]string{
+				"Authorization": {"Bearer " + prodKey},
+			},
+		},
+		{
+			name: "bound connector key creates a key for another destination",
+			setup: func(t *testing.T) io.Reader {
+				return jsonBody(t, &api.CreateAccessKeyRequest{
+					UserID:            connector.ID,
+					Expiry:            api.Duration(time.Minute),
+					InactivityTimeout: api.Duration(time.Minute),
+					Destination:       "staging",
+				})
+			},
+			expected: func(t *testing.T, resp *httptest.ResponseRecorder) {
+				assert.Equal(t, resp.Code, http.StatusForbidden, resp.Body.String())
+
+				respBody := &api.Error{}
+				assert.NilError(t, json.Unmarshal(resp.Body.Bytes(), respBody))
+				assert.Assert(t, is.Contains(respBody.Message, `the access key is bound to destination "prod"`))
+			},
+			headers: map[string][]string{
+				"Authorization": {"Bearer " + prodKey},
+			},
+		},
 		{
 			name: "migration from <= 0.18.0",
 			setup: func(t *testing.T) io.Reader {
@@ -2,9 +2,14 @@ package server
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"os"
+	"sort"
+	"sync"
 	"time"
 
+	"github.com/infrahq/infra/internal"
 	"github.com/infrahq/infra/internal/logging"
 	"github.com/infrahq/infra/internal/server/data"
 )
@@ -16,41 +21,87 @@ import (
 // transaction passed to this job will not have an OrganizationID.
 type BackgroundJobFunc func(tx data.WriteTxn) error
 
-func backgroundJob(ctx context.Context, db *data.DB, job BackgroundJobFunc, every time.Duration) func() error {
-	return func() error {
-		t := time.NewTicker(every)
-		funcName := getFuncName(job)
+// backgroundJobs is the registry of background jobs run by the server. Every
+// replica of the server runs the same jobs, so the replicas use a postgres
+// advisory lock, and the time of the last run stored in the background_jobs
+// table, to run each job on only one replica per interval.
+type backgroundJobs struct {
+	db       *data.DB
+	hostname string
 
-		jobWithRecover := func() error {
-			if ctx.Err() != nil {
-				return ctx.Err()
-			}
-			defer func() {
-				if err := recover(); err != nil {
-					logging.Errorf("background job %s panic: %s", funcName, err)
-				}
-			}()
+	mu   sync.Mutex
+	jobs map[string]*backgroundJobEntry
+}
 
-			tx, err := db.Begin(ctx, nil)
-			if err != nil {
-				return fmt.Errorf("failed to start transaction :%w", err)
-			}
-			if err := job(tx); err != nil {
-				_ = tx.Rollback()
-				return err
-			}
-			return tx.Commit()
-		}
+type backgroundJobEntry struct {
+	name  string
+	job   BackgroundJobFunc
+	every time.Duration
+}
+
+func newBackgroundJobs(db *data.DB) *backgroundJobs {
+	hostname, err := os.Hostname()
+	if err != nil {
+		logging.L.Warn().Err(err).Msg("failed to lookup hostname for background jobs")
+	}
+	return &backgroundJobs{
+		db:       db,
+		hostname: hostname,
+		jobs:     make(map[string]*backgroundJobEntry),
+	}
+}
+
+func (b *backgroundJobs) register(job BackgroundJobFunc, every time.Duration) *backgroundJobEntry {
+	entry := &backgroundJobEntry{name: getFuncName(job), job: job, every: every}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.jobs[entry.name] = entry
+	return entry
+}
+
+func (b *backgroundJobs) get(name string) (*backgroundJobEntry, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	entry, ok := b.jobs[name]
+	return entry, ok
+}
+
+// list returns all the registered jobs sorted by name.
+func (b *backgroundJobs) list() []*backgroundJobEntry {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	entries := make([]*backgroundJobEntry, 0, len(b.jobs))
+	for _, entry := range b.jobs {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].name < entries[j].name
+	})
+	return entries
+}
+
+// backgroundJob registers the job, and returns a function that attempts to
+// run the job every interval until ctx is cancelled.
+func (b *backgroundJobs) backgroundJob(ctx context.Context, job BackgroundJobFunc, every time.Duration) func() error {
+	entry := b.register(job, every)
+
+	return func() error {
+		t := time.NewTicker(every)
 
 		for {
 			select {
 			case <-t.C:
-				startAt := time.Now().UTC()
-				logging.Debugf("background job %s starting", funcName)
-				if err := jobWithRecover(); err != nil {
-					logging.Errorf("background job %s error: %s", funcName, err.Error())
-				} else {
-					logging.Infof("background job %s successful, elapsed: %s", funcName, time.Since(startAt))
+				logging.Debugf("background job %s starting", entry.name)
+				run, err := b.run(ctx, entry, false)
+				switch {
+				case err != nil:
+					logging.Errorf("background job %s error: %s", entry.name, err.Error())
+				case run == nil:
+					logging.Debugf("background job %s skipped, it was run by another server", entry.name)
+				default:
+					logging.Infof("background job %s successful, elapsed: %s", entry.name, run.Duration)
 				}
 			case <-ctx.Done():
 				t.Stop()
@@ -59,3 +110,80 @@ func backgroundJob(ctx context.Context, db *data.DB, job BackgroundJobFunc, ever
 		}
 	}
 }
+
+// run the job, and record the run in the background_jobs table. run returns
+// a nil BackgroundJob if the job did not run because another server is
+// running the job, or because another server ran the job within the current
+// interval. When force is true the job is run even if it ran within the
+// current interval.
+func (b *backgroundJobs) run(ctx context.Context, entry *backgroundJobEntry, force bool) (*data.BackgroundJob, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	// the lock is held by this transaction until the run is recorded, so that
+	// no other server can run the job at the same time.
+	lockTx, err := b.db.Begin(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer logError(lockTx.Rollback, "failed to rollback background job lock transaction")
+
+	locked, err := data.TryLockBackgroundJob(lockTx, entry.name)
+	if err != nil || !locked {
+		return nil, err
+	}
+
+	if !force {
+		last, err := data.GetBackgroundJob(lockTx, entry.name)
+		switch {
+		case errors.Is(err, internal.ErrNotFound):
+		case err != nil:
+			return nil, err
+		// allow some drift between the tickers on different servers
+		case time.Since(last.StartedAt) < entry.every-entry.every/10:
+			return nil, nil
+		}
+	}
+
+	run := &data.BackgroundJob{
+		Name:      entry.name,
+		StartedAt: time.Now().UTC(),
+		Hostname:  b.hostname,
+	}
+	jobErr := runBackgroundJobFunc(ctx, b.db, entry.job)
+	run.Duration = time.Since(run.StartedAt)
+	if jobErr != nil {
+		run.Error = jobErr.Error()
+	}
+
+	if err := data.UpdateBackgroundJob(lockTx, run); err != nil {
+		return nil, err
+	}
+	if err := lockTx.Commit(); err != nil {
+		return nil, err
+	}
+	return run, jobErr
+}
+
+// runBackgroundJobFunc runs the job in a transaction. The transaction is
+// committed if the job returns no error. A panic from the job is returned as
+// an error.
+func runBackgroundJobFunc(ctx context.Context, db *data.DB, job BackgroundJobFunc) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+
+	tx, err := db.Begin(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction :%w", err)
+	}
+	defer logError(tx.Rollback, "failed to rollback background job transaction")
+
+	if err := job(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -63,7 +63,7 @@ func TestJobWrapper(t *testing.T) {
 	}
 
 	g := errgroup.Group{}
-	fn := backgroundJob(ctx, db, job, time.Millisecond)
+	fn := newBackgroundJobs(db).backgroundJob(ctx, job, time.Millisecond)
 	g.Go(fn)
 	<-chReady
 
@@ -94,3 +94,64 @@ func runStep(t *testing.T, name string, fn func(t *testing.T)) {
 		t.FailNow()
 	}
 }
+
+func TestBackgroundJobs_Run(t *testing.T) {
+	ctx := context.Background()
+	db := setupDB(t)
+
+	var calls int
+	job := func(tx data.WriteTxn) error {
+		calls++
+		if calls == 3 {
+			return fmt.Errorf("the job failed")
+		}
+		return nil
+	}
+
+	// two registries simulate two servers
+	first := newBackgroundJobs(db)
+	second := newBackgroundJobs(db)
+	entry := first.register(job, time.Hour)
+	other := second.register(job, time.Hour)
+
+	runStep(t, "first run", func(t *testing.T) {
+		run, err := first.run(ctx, entry, false)
+		assert.NilError(t, err)
+		assert.Assert(t, run != nil)
+		assert.Equal(t, calls, 1)
+	})
+	runStep(t, "skipped when run within the interval", func(t *testing.T) {
+		run, err := second.run(ctx, other, false)
+		assert.NilError(t, err)
+		assert.Assert(t, run == nil)
+		assert.Equal(t, calls, 1)
+	})
+	runStep(t, "force runs within the interval", func(t *testing.T) {
+		run, err := second.run(ctx, other, true)
+		assert.NilError(t, err)
+		assert.Assert(t, run != nil)
+		assert.Equal(t, calls, 2)
+	})
+	runStep(t, "error is recorded", func(t *testing.T) {
+		_, err := first.run(ctx, entry, true)
+		assert.ErrorContains(t, err, "the job failed")
+
+		last, err := data.GetBackgroundJob(db, entry.name)
+		assert.NilError(t, err)
+		assert.Equal(t, last.Error, "the job failed")
+	})
+	runStep(t, "skipped when locked by another server", func(t *testing.T) {
+		tx, err := db.Begin(ctx, nil)
+		assert.NilError(t, err)
+		t.Cleanup(func() { _ = tx.Rollback() })
+
+		locked, err := data.TryLockBackgroundJob(tx, entry.name)
+		assert.NilError(t, err)
+		assert.Assert(t, locked)
+
+		run, err := first.run(ctx, entry, true)
+		assert.NilError(t, err)
+		assert.Assert(t, run == nil)
+		assert.Equal(t, calls, 3)
+	})
+}
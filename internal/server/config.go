@@ -16,13 +16,21 @@ import (
 	"github.com/infrahq/infra/internal/logging"
 	"github.com/infrahq/infra/internal/server/data"
 	"github.com/infrahq/infra/internal/server/models"
+	"github.com/infrahq/infra/internal/server/providers"
 	"github.com/infrahq/infra/internal/validate"
 	"github.com/infrahq/infra/uid"
 )
 
+// BootstrapConfig declares the state of the default organization. The state
+// is reconciled by loadConfig every time the server starts, so the same
+// config can be applied many times.
 type BootstrapConfig struct {
 	DefaultOrganizationDomain string
 	Users                     []User
+	Groups                    []Group
+	Grants                    []Grant
+	Providers                 []Provider
+	Destinations              []Destination
 }
 
 type User struct {
@@ -38,6 +46,81 @@ func (u User) ValidationRules() []validate.ValidationRule {
 	}
 }
 
+// Group is a group of users. The members of the group are replaced by Members
+// every time the config is loaded. Members that do not exist are created.
+type Group struct {
+	Name    string
+	Members []string
+}
+
+func (g Group) ValidationRules() []validate.ValidationRule {
+	return []validate.ValidationRule{
+		validate.Required("name", g.Name),
+	}
+}
+
+// Grant gives a user or group a role on a resource. Grants that are removed
+// from the config are not deleted.
+type Grant struct {
+	User     string
+	Group    string
+	Role     string
+	Resource string
+}
+
+func (g Grant) ValidationRules() []validate.ValidationRule {
+	return []validate.ValidationRule{
+		validate.RequireOneOf(
+			validate.Field{Name: "user", Value: g.User},
+			validate.Field{Name: "group", Value: g.Group},
+		),
+		validate.Required("role", g.Role),
+		validate.Required("resource", g.Resource),
+	}
+}
+
+// Provider is an OIDC identity provider that users can login with.
+type Provider struct {
+	Name string
+	// Kind is one of oidc, okta, azure, or google. Defaults to oidc.
+	Kind         string
+	URL          string
+	ClientID     string
+	ClientSecret Secret
+}
+
+func (p Provider) ValidationRules() []validate.ValidationRule {
+	return []validate.ValidationRule{
+		validate.Required("name", p.Name),
+		validate.Required("url", p.URL),
+		validate.Required("clientID", p.ClientID),
+		validate.Required("clientSecret", p.ClientSecret),
+		validate.Enum("kind", p.Kind, []string{
+			models.ProviderKindOIDC.String(),
+			models.ProviderKindOkta.String(),
+			models.ProviderKindAzure.String(),
+			models.ProviderKindGoogle.String(),
+		}),
+	}
+}
+
+// Destination is registered before a connector for the destination is
+// installed, so that grants can be created for the destination.
+type Destination struct {
+	Name string
+	Kind string
+}
+
+func (d Destination) ValidationRules() []validate.ValidationRule {
+	return []validate.ValidationRule{
+		validate.Required("name", d.Name),
+		validate.Enum("kind", d.Kind, []string{
+			string(models.DestinationKindKubernetes),
+			string(models.DestinationKindSSH),
+		}),
+	}
+}
+
 func (c BootstrapConfig) ValidationRules() []validate.ValidationRule {
 	// no-op implement to satisfy the interface
 	return nil
@@ -74,14 +157,21 @@ func (s Secret) GoString() string {
 	return "<redacted>"
 }
 
-func (s Server) loadConfig(config BootstrapConfig) error {
+func (s *Server) loadConfig(ctx context.Context, config BootstrapConfig) error {
 	if err := validate.Validate(config); err != nil {
 		return err
 	}
 
+	// The auth server info is requested before the transaction starts, so
+	// that a slow or unavailable provider does not hold the transaction open.
+	authServerInfo, err := s.loadProvidersAuthServerInfo(ctx, config.Providers)
+	if err != nil {
+		return err
+	}
+
 	org := s.db.DefaultOrg
 
-	tx, err := s.db.Begin(context.Background(), nil)
+	tx, err := s.db.Begin(ctx, nil)
 	if err != nil {
 		return err
 	}
@@ -95,15 +185,224 @@ func (s Server) loadConfig(config BootstrapConfig) error {
 		}
 	}
 
+	for _, p := range config.Providers {
+		if err := loadProvider(tx, p, authServerInfo[p.Name]); err != nil {
+			return fmt.Errorf("load provider %v: %w", p.Name, err)
+		}
+	}
+
 	for _, u := range config.Users {
 		if err := s.loadUser(tx, u); err != nil {
 			return fmt.Errorf("load user %v: %w", u.Name, err)
 		}
 	}
 
+	for _, g := range config.Groups {
+		if err := loadGroup(tx, g); err != nil {
+			return fmt.Errorf("load group %v: %w", g.Name, err)
+		}
+	}
+
+	for _, d := range config.Destinations {
+		if err := loadDestination(tx, d); err != nil {
+			return fmt.Errorf("load destination %v: %w", d.Name, err)
+		}
+	}
+
+	for _, g := range config.Grants {
+		if err := loadConfigGrant(tx, g); err != nil {
+			return fmt.Errorf("load grant of %v on %v: %w", g.Role, g.Resource, err)
+		}
+	}
+
 	return tx.Commit()
 }
 
+// loadProvidersAuthServerInfo requests the auth server info from each provider
+// that loadProvider will create or update. The auth server info is only
+// requested from the provider when the provider is created, or when its URL,
+// client ID, or kind change.
+func (s *Server) loadProvidersAuthServerInfo(ctx context.Context, inputs []Provider) (map[string]*providers.AuthServerInfo, error) {
+	result := make(map[string]*providers.AuthServerInfo)
+	for _, input := range inputs {
+		provider, changed, err := providerFromConfig(s.db, input)
+		if err != nil {
+			return nil, fmt.Errorf("load provider %v: %w", input.Name, err)
+		}
+		if !changed {
+			continue
+		}
+
+		oidc, err := s.providerClient(ctx, provider, "")
+		if err != nil {
+			return nil, fmt.Errorf("load provider %v: %w", input.Name, err)
+		}
+		info, err := oidc.AuthServerInfo(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("load provider %v: auth server info: %w", input.Name, err)
+		}
+		result[input.Name] = info
+	}
+	return result, nil
+}
+
+// providerFromConfig returns the provider with the fields from the config,
+// and true if the provider is new, or its URL, client ID, or kind changed.
+func providerFromConfig(tx data.ReadTxn, input Provider) (*models.Provider, bool, error) {
+	kind := models.ProviderKindOIDC
+	if input.Kind != "" {
+		var err error
+		if kind, err = models.ParseProviderKind(input.Kind); err != nil {
+			return nil, false, err
+		}
+	}
+
+	provider, err := data.GetProvider(tx, data.GetProviderOptions{ByName: input.Name})
+	switch {
+	case errors.Is(err, internal.ErrNotFound):
+		provider = &models.Provider{Name: input.Name, CreatedBy: models.CreatedBySystem}
+	case err != nil:
+		return nil, false, err
+	}
+
+	url := cleanupURL(input.URL)
+	changed := provider.URL != url || provider.ClientID != input.ClientID || provider.Kind != kind
+	provider.URL = url
+	provider.ClientID = input.ClientID
+	provider.ClientSecret = models.EncryptedAtRest(input.ClientSecret)
+	provider.Kind = kind
+	return provider, provider.ID == 0 || changed, nil
+}
+
+// loadProvider creates or updates the provider. info is the auth server info
+// from loadProvidersAuthServerInfo, which is nil when the provider has not
+// changed.
+func loadProvider(tx data.WriteTxn, input Provider, info *providers.AuthServerInfo) error {
+	provider, changed, err := providerFromConfig(tx, input)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case info != nil:
+		provider.AuthURL = info.AuthURL
+		provider.Scopes = info.ScopesSupported
+	case changed:
+		// the provider was modified after the auth server info was requested
+		return fmt.Errorf("provider was modified while the config was loading, restart to load the config again")
+	}
+
+	if provider.ID == 0 {
+		return data.CreateProvider(tx, provider)
+	}
+	return data.UpdateProvider(tx, provider)
+}
+
+// loadGroup creates the group if it does not exist, and replaces the members
+// of the group.
+func loadGroup(tx data.WriteTxn, input Group) error {
+	group, err := data.GetGroup(tx, data.GetGroupOptions{ByName: input.Name})
+	switch {
+	case errors.Is(err, internal.ErrNotFound):
+		group = &models.Group{Name: input.Name, CreatedBy: models.CreatedBySystem}
+		if err := data.CreateGroup(tx, group); err != nil {
+			return err
+		}
+	case err != nil:
+		return err
+	}
+
+	members := make(map[uid.ID]struct{}, len(input.Members))
+	for _, name := range input.Members {
+		identity, err := loadIdentity(tx, name)
+		if err != nil {
+			return fmt.Errorf("load member %v: %w", name, err)
+		}
+		members[identity.ID] = struct{}{}
+	}
+
+	current, err := data.ListIdentities(tx, data.ListIdentityOptions{ByGroupID: group.ID})
+	if err != nil {
+		return err
+	}
+
+	var toRemove []uid.ID
+	for _, identity := range current {
+		if _, ok := members[identity.ID]; ok {
+			delete(members, identity.ID)
+			continue
+		}
+		toRemove = append(toRemove, identity.ID)
+	}
+
+	if len(toRemove) > 0 {
+		if err := data.RemoveUsersFromGroup(tx, group.ID, toRemove); err != nil {
+			return err
+		}
+	}
+	if len(members) > 0 {
+		toAdd := make([]uid.ID, 0, len(members))
+		for id := range members {
+			toAdd = append(toAdd, id)
+		}
+		if err := data.AddUsersToGroup(tx, group.ID, toAdd); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadDestination creates the destination if it does not exist. The
+// connector for the destination updates the rest of the fields when it
+// connects.
+func loadDestination(tx data.WriteTxn, input Destination) error {
+	_, err := data.GetDestination(tx, data.GetDestinationOptions{ByName: input.Name})
+	if err == nil || !errors.Is(err, internal.ErrNotFound) {
+		return err
+	}
+
+	kind := models.DestinationKind(input.Kind)
+	if kind == "" {
+		kind = models.DestinationKindKubernetes
+	}
+	return data.CreateDestination(tx, &models.Destination{Name: input.Name, Kind: kind})
+}
+
+// loadConfigGrant creates the grant if it does not exist.
+func loadConfigGrant(tx data.WriteTxn, input Grant) error {
+	var subject models.Subject
+	switch {
+	case input.User != "":
+		identity, err := data.GetIdentity(tx, data.GetIdentityOptions{ByName: input.User})
+		if err != nil {
+			return fmt.Errorf("user %v: %w", input.User, err)
+		}
+		subject = models.NewSubjectForUser(identity.ID)
+	default:
+		group, err := data.GetGroup(tx, data.GetGroupOptions{ByName: input.Group})
+		if err != nil {
+			return fmt.Errorf("group %v: %w", input.Group, err)
+		}
+		subject = models.NewSubjectForGroup(group.ID)
+	}
+
+	_, err := data.GetGrant(tx, data.GetGrantOptions{
+		BySubject:   subject,
+		ByResource:  input.Resource,
+		ByPrivilege: input.Role,
+	})
+	if err == nil || !errors.Is(err, internal.ErrNotFound) {
+		return err
+	}
+
+	return data.CreateGrant(tx, &models.Grant{
+		Subject:   subject,
+		Resource:  input.Resource,
+		Privilege: input.Role,
+		CreatedBy: models.CreatedBySystem,
+	})
+}
+
 func loadGrant(tx data.WriteTxn, userID uid.ID, role string) error {
 	if role == "" {
 		return nil
@@ -126,33 +425,10 @@ func loadGrant(tx data.WriteTxn, userID uid.ID, role string) error {
 	return data.CreateGrant(tx, grant)
 }
 
-func (s Server) loadUser(db data.WriteTxn, input User) error {
-	identity, err := data.GetIdentity(db, data.GetIdentityOptions{ByName: input.Name})
+func (s *Server) loadUser(db data.WriteTxn, input User) error {
+	identity, err := loadIdentity(db, input.Name)
 	if err != nil {
-		if !errors.Is(err, internal.ErrNotFound) {
-			return err
-		}
-
-		if input.Name != models.InternalInfraConnectorIdentityName {
-			_, err := mail.ParseAddress(input.Name)
-			if err != nil {
-				logging.Warnf("user name %q in server configuration is not a valid email, please update this name to a valid email", input.Name)
-			}
-		}
-
-		identity = &models.Identity{
-			Name:      input.Name,
-			CreatedBy: models.CreatedBySystem,
-		}
-
-		if err := data.CreateIdentity(db, identity); err != nil {
-			return err
-		}
-
-		_, err = data.CreateProviderUser(db, data.InfraProvider(db), identity)
-		if err != nil {
-			return err
-		}
+		return err
 	}
 
 	if err := s.loadCredential(db, identity, input.Password); err != nil {
@@ -170,7 +446,37 @@ func (s Server) loadUser(db data.WriteTxn, input User) error {
 	return nil
 }
 
-func (s Server) loadCredential(db data.WriteTxn, identity *models.Identity, password Secret) error {
+// loadIdentity returns the user with name, and creates the user if it does
+// not exist.
+func loadIdentity(db data.WriteTxn, name string) (*models.Identity, error) {
+	identity, err := data.GetIdentity(db, data.GetIdentityOptions{ByName: name})
+	if err == nil || !errors.Is(err, internal.ErrNotFound) {
+		return identity, err
+	}
+
+	if name != models.InternalInfraConnectorIdentityName {
+		_, err := mail.ParseAddress(name)
+		if err != nil {
+			logging.Warnf("user name %q in server configuration is not a valid email, please update this name to a valid email", name)
+		}
+	}
+
+	identity = &models.Identity{
+		Name:      name,
+		CreatedBy: models.CreatedBySystem,
+	}
+
+	if err := data.CreateIdentity(db, identity); err != nil {
+		return nil, err
+	}
+
+	if _, err := data.CreateProviderUser(db, data.InfraProvider(db), identity); err != nil {
+		return nil, err
+	}
+	return identity, nil
+}
+
+func (s *Server) loadCredential(db data.WriteTxn, identity *models.Identity, password Secret) error {
 	if password == "" {
 		return nil
 	}
@@ -207,7 +513,7 @@ func (s Server) loadCredential(db data.WriteTxn, identity *models.Identity, pass
 	return data.UpdateCredential(db, credential)
 }
 
-func (s Server) loadAccessKey(db data.WriteTxn, identity *models.Identity, key Secret) error {
+func (s *Server) loadAccessKey(db data.WriteTxn, identity *models.Identity, key Secret) error {
 	if key == "" {
 		return nil
 	}
@@ -2,6 +2,7 @@ package server
 
 import (
 	"bytes"
+	"context"
 	"crypto/sha256"
 	"errors"
 	"testing"
@@ -13,12 +14,13 @@ import (
 	"github.com/infrahq/infra/internal"
 	"github.com/infrahq/infra/internal/server/data"
 	"github.com/infrahq/infra/internal/server/models"
+	"github.com/infrahq/infra/internal/server/providers"
 )
 
 func TestLoadConfigEmpty(t *testing.T) {
 	s := setupServer(t)
 
-	err := s.loadConfig(BootstrapConfig{})
+	err := s.loadConfig(context.Background(), BootstrapConfig{})
 	assert.NilError(t, err)
 }
 
@@ -46,7 +48,7 @@ func TestLoadConfigWithUsers(t *testing.T) {
 		},
 	}
 
-	err := s.loadConfig(config)
+	err := s.loadConfig(context.Background(), config)
 	assert.NilError(t, err)
 
 	user, _, _ := getTestDefaultOrgUserDetails(t, s, "bob@example.com")
@@ -93,7 +95,7 @@ func TestLoadConfigUpdate(t *testing.T) {
 		},
 	}
 
-	err := s.loadConfig(config)
+	err := s.loadConfig(context.Background(), config)
 	assert.NilError(t, err)
 
 	tx := txnForTestCase(t, s.db, s.db.DefaultOrg.ID)
@@ -140,7 +142,7 @@ func TestLoadConfigUpdate(t *testing.T) {
 		},
 	}
 
-	err = s.loadConfig(updatedConfig)
+	err = s.loadConfig(context.Background(), updatedConfig)
 	assert.NilError(t, err)
 
 	grants, err = data.ListGrants(tx, data.ListGrantsOptions{})
@@ -166,6 +168,120 @@ func TestLoadConfigUpdate(t *testing.T) {
 	assert.Equal(t, int64(4), identities)
 }
 
+func TestLoadConfigWithGroupsGrantsProvidersDestinations(t *testing.T) {
+	s := setupServer(t)
+	ctx := providers.WithOIDCClient(context.Background(), &fakeOIDCImplementation{})
+
+	config := BootstrapConfig{
+		Users: []User{
+			{Name: "alice@example.com"},
+		},
+		Groups: []Group{
+			{Name: "developers", Members: []string{"alice@example.com", "bob@example.com"}},
+		},
+		Grants: []Grant{
+			{User: "alice@example.com", Role: "admin", Resource: "production"},
+			{Group: "developers", Role: "view", Resource: "production.default"},
+		},
+		Providers: []Provider{
+			{
+				Name:         "okta",
+				Kind:         "okta",
+				URL:          "https://example.okta.com/",
+				ClientID:     "client-id",
+				ClientSecret: "client-secret",
+			},
+			{
+				Name:         "sso",
+				URL:          "https://sso.example.com/",
+				ClientID:     "sso-client-id",
+				ClientSecret: "sso-client-secret",
+			},
+		},
+		Destinations: []Destination{
+			{Name: "production"},
+			{Name: "bastion", Kind: "ssh"},
+		},
+	}
+
+	checkConfig := func(t *testing.T, members []string) {
+		t.Helper()
+		tx := txnForTestCase(t, s.db, s.db.DefaultOrg.ID)
+
+		group, err := data.GetGroup(tx, data.GetGroupOptions{ByName: "developers"})
+		assert.NilError(t, err)
+		users, err := data.ListIdentities(tx, data.ListIdentityOptions{ByGroupID: group.ID})
+		assert.NilError(t, err)
+		var names []string
+		for _, user := range users {
+			names = append(names, user.Name)
+		}
+		assert.DeepEqual(t, names, members)
+
+		alice, err := data.GetIdentity(tx, data.GetIdentityOptions{ByName: "alice@example.com"})
+		assert.NilError(t, err)
+		grants, err := data.ListGrants(tx, data.ListGrantsOptions{BySubject: models.NewSubjectForUser(alice.ID)})
+		assert.NilError(t, err)
+		assert.Assert(t, is.Len(grants, 1))
+		assert.Equal(t, grants[0].Privilege, "admin")
+		assert.Equal(t, grants[0].Resource, "production")
+
+		grants, err = data.ListGrants(tx, data.ListGrantsOptions{BySubject: models.NewSubjectForGroup(group.ID)})
+		assert.NilError(t, err)
+		assert.Assert(t, is.Len(grants, 1))
+		assert.Equal(t, grants[0].Privilege, "view")
+		assert.Equal(t, grants[0].Resource, "production.default")
+
+		provider, err := data.GetProvider(tx, data.GetProviderOptions{ByName: "okta"})
+		assert.NilError(t, err)
+		assert.Equal(t, provider.URL, "example.okta.com")
+		assert.Equal(t, provider.Kind, models.ProviderKindOkta)
+		assert.Equal(t, provider.ClientID, "client-id")
+		assert.Equal(t, string(provider.ClientSecret), "client-secret")
+		assert.Equal(t, provider.AuthURL, "example.com/v1/auth")
+
+		// kind defaults to oidc
+		provider, err = data.GetProvider(tx, data.GetProviderOptions{ByName: "sso"})
+		assert.NilError(t, err)
+		assert.Equal(t, provider.Kind, models.ProviderKindOIDC)
+
+		destination, err := data.GetDestination(tx, data.GetDestinationOptions{ByName: "production"})
+		assert.NilError(t, err)
+		assert.Equal(t, destination.Kind, models.DestinationKindKubernetes)
+		destination, err = data.GetDestination(tx, data.GetDestinationOptions{ByName: "bastion"})
+		assert.NilError(t, err)
+		assert.Equal(t, destination.Kind, models.DestinationKindSSH)
+	}
+
+	err := s.loadConfig(ctx, config)
+	assert.NilError(t, err)
+	checkConfig(t, []string{"alice@example.com", "bob@example.com"})
+
+	// loading the same config again does not change anything
+	err = s.loadConfig(ctx, config)
+	assert.NilError(t, err)
+	checkConfig(t, []string{"alice@example.com", "bob@example.com"})
+
+	// members removed from the config are removed from the group
+	config.Groups[0].Members = []string{"alice@example.com"}
+	err = s.loadConfig(ctx, config)
+	assert.NilError(t, err)
+	checkConfig(t, []string{"alice@example.com"})
+}
+
+func TestLoadConfigGrantWithUnknownSubject(t *testing.T) {
+	s := setupServer(t)
+
+	config := BootstrapConfig{
+		Grants: []Grant{
+			{Group: "not-a-group", Role: "view", Resource: "production"},
+		},
+	}
+	err := s.loadConfig(context.Background(), config)
+	assert.ErrorContains(t, err, "group not-a-group")
+	assert.ErrorIs(t, err, internal.ErrNotFound)
+}
+
 func TestLoadAccessKey(t *testing.T) {
 	s := setupServer(t)
 
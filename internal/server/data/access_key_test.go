@@ -372,6 +372,7 @@ func TestListAccessKeys(t *testing.T) {
 			ProviderID: InfraProvider(db).ID,
 			ExpiresAt:  time.Now().Add(time.Hour).UTC(),
 			KeyID:      "1234567894",
+			RateLimit:  100,
 		}
 
 		createAccessKeys(t, db, forth, third, second, first, deleted)
@@ -461,6 +462,16 @@ func TestListAccessKeys(t *testing.T) {
 			assert.DeepEqual(t, actual, expected, cmpAccessKeyShallow)
 		})
 
+		t.Run("only rate limited", func(t *testing.T) {
+			actual, err := ListAccessKeys(db, ListAccessKeyOptions{OnlyRateLimited: true})
+			assert.NilError(t, err)
+
+			expected := []models.AccessKey{
+				{Model: models.Model{ID: 9}, IssuedForName: "admin@infrahq.com"},
+			}
+			assert.DeepEqual(t, actual, expected, cmpAccessKeyShallow)
+		})
+
 		t.Run("by name and expired", func(t *testing.T) {
 			actual, err := ListAccessKeys(db, ListAccessKeyOptions{
 				ByName:         "beta",
@@ -0,0 +1,70 @@
+package data
+
+import (
+	"time"
+)
+
+// BackgroundJob is the most recent run of a background job by any server.
+type BackgroundJob struct {
+	Name      string
+	StartedAt time.Time
+	Duration  time.Duration
+	// Error is the error returned by the job, or empty when the job was
+	// successful.
+	Error string
+	// Hostname is the hostname of the server that ran the job.
+	Hostname string
+}
+
+// TryLockBackgroundJob attempts to acquire a transaction level advisory lock
+// for the background job. It returns false if the lock is held by another
+// transaction, which means another server is running the job. The lock is
+// released when tx is committed or rolled back.
+func TryLockBackgroundJob(tx WriteTxn, name string) (bool, error) {
+	var locked bool
+	err := tx.QueryRow(`SELECT pg_try_advisory_xact_lock(hashtext(?))`, "background-job:"+name).Scan(&locked)
+	if err != nil {
+		return false, handleError(err)
+	}
+	return locked, nil
+}
+
+// GetBackgroundJob returns the most recent run of the job. It returns
+// internal.ErrNotFound if the job has never run.
+func GetBackgroundJob(tx ReadTxn, name string) (*BackgroundJob, error) {
+	job := &BackgroundJob{}
+	err := tx.QueryRow(`
+		SELECT name, started_at, duration, error, hostname
+		FROM background_jobs WHERE name = ?`, name).
+		Scan(&job.Name, &job.StartedAt, &job.Duration, &job.Error, &job.Hostname)
+	if err != nil {
+		return nil, handleError(err)
+	}
+	return job, nil
+}
+
+// ListBackgroundJobs returns the most recent run of every job, sorted by
+// name.
+func ListBackgroundJobs(tx ReadTxn) ([]BackgroundJob, error) {
+	rows, err := tx.Query(`
+		SELECT name, started_at, duration, error, hostname
+		FROM background_jobs ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	return scanRows(rows, func(job *BackgroundJob) []any {
+		return []any{&job.Name, &job.StartedAt, &job.Duration, &job.Error, &job.Hostname}
+	})
+}
+
+// UpdateBackgroundJob records a run of the job, replacing any previous run.
+func UpdateBackgroundJob(tx WriteTxn, job *BackgroundJob) error {
+	_, err := tx.Exec(`
+		INSERT INTO background_jobs (name, started_at, duration, error, hostname)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (name) DO UPDATE
+		SET started_at = excluded.started_at, duration = excluded.duration,
+			error = excluded.error, hostname = excluded.hostname`,
+		job.Name, job.StartedAt, int64(job.Duration), job.Error, job.Hostname)
+	return handleError(err)
+}
@@ -0,0 +1,63 @@
+package data
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+
+	"github.com/infrahq/infra/internal"
+)
+
+func TestBackgroundJobs(t *testing.T) {
+	runDBTests(t, func(t *testing.T, db *DB) {
+		_, err := GetBackgroundJob(db, "TheJob")
+		assert.ErrorIs(t, err, internal.ErrNotFound)
+
+		first := &BackgroundJob{
+			Name:      "TheJob",
+			StartedAt: time.Date(2023, 2, 6, 10, 0, 0, 0, time.UTC),
+			Duration:  3 * time.Second,
+			Hostname:  "server-1",
+		}
+		assert.NilError(t, UpdateBackgroundJob(db, first))
+
+		actual, err := GetBackgroundJob(db, "TheJob")
+		assert.NilError(t, err)
+		assert.DeepEqual(t, actual, first, cmpTimeWithDBPrecision)
+
+		second := &BackgroundJob{
+			Name:      "TheJob",
+			StartedAt: time.Date(2023, 2, 6, 11, 0, 0, 0, time.UTC),
+			Duration:  time.Second,
+			Error:     "failed",
+			Hostname:  "server-2",
+		}
+		assert.NilError(t, UpdateBackgroundJob(db, second))
+		other := &BackgroundJob{Name: "AnotherJob", StartedAt: time.Date(2023, 2, 6, 11, 0, 0, 0, time.UTC)}
+		assert.NilError(t, UpdateBackgroundJob(db, other))
+
+		jobs, err := ListBackgroundJobs(db)
+		assert.NilError(t, err)
+		assert.DeepEqual(t, jobs, []BackgroundJob{*other, *second}, cmpTimeWithDBPrecision)
+	})
+}
+
+func TestTryLockBackgroundJob(t *testing.T) {
+	runDBTests(t, func(t *testing.T, db *DB) {
+		tx1 := txnForTestCase(t, db, db.DefaultOrg.ID)
+		tx2 := txnForTestCase(t, db, db.DefaultOrg.ID)
+
+		locked, err := TryLockBackgroundJob(tx1, "TheJob")
+		assert.NilError(t, err)
+		assert.Assert(t, locked)
+
+		locked, err = TryLockBackgroundJob(tx2, "TheJob")
+		assert.NilError(t, err)
+		assert.Assert(t, !locked)
+
+		locked, err = TryLockBackgroundJob(tx2, "AnotherJob")
+		assert.NilError(t, err)
+		assert.Assert(t, locked)
+	})
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 	"unicode"
@@ -80,12 +81,29 @@ type DB struct {
 	DefaultOrg *models.Organization
 	// DefaultOrgSettings are the settings for DefaultOrg
 	DefaultOrgSettings *models.Settings
+
+	notifyOnce sync.Once
+	notify     *NotifyHub
 }
 
 func (d *DB) Close() error {
+	// prevent a hub from being created after close
+	d.notifyOnce.Do(func() {})
+	if d.notify != nil {
+		d.notify.Close()
+	}
 	return d.DB.Close()
 }
 
+// NotifyHub returns the hub used to receive postgres notifications. The hub
+// is started on first use, and stopped by Close.
+func (d *DB) NotifyHub() *NotifyHub {
+	d.notifyOnce.Do(func() {
+		d.notify = newNotifyHub(d.DB)
+	})
+	return d.notify
+}
+
 func (d *DB) SQLdb() *sql.DB {
 	return d.DB
 }
@@ -327,6 +345,10 @@ func (e UniqueConstraintError) Error() string {
 		table = "user"
 	case "access_keys":
 		table = "access key"
+	case "role_templates":
+		table = "role template"
+	case "proxy_policies":
+		table = "proxy policy"
 	default:
 		table = strings.TrimSuffix(table, "s")
 	}
@@ -375,6 +397,8 @@ func handleError(err error) error {
 				"idx_credentials_identity_id": "identityID",
 				"idx_organizations_domain":    "domain",
 				"idx_user_ssh_login_name":     "sshLoginName",
+				"idx_role_templates_name":     "name",
+				"idx_proxy_policies_name":     "name",
 			}
 
 			columnName := constraintFields[pgErr.ConstraintName]
@@ -0,0 +1,115 @@
+package data
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/infrahq/infra/internal/logging"
+	"github.com/infrahq/infra/internal/server/data/querybuilder"
+	"github.com/infrahq/infra/internal/server/models"
+)
+
+// DestinationActivityRetention is how long destination activity records are
+// kept before they are deleted by DeleteExpiredDestinationActivity.
+const DestinationActivityRetention = 400 * 24 * time.Hour
+
+type destinationActivityTable models.DestinationActivity
+
+func (d destinationActivityTable) Table() string {
+	return "destination_activity"
+}
+
+func (d destinationActivityTable) Columns() []string {
+	return []string{"api_group", "created_at", "destination_name", "groups", "id", "latency_ms", "name", "namespace", "organization_id", "path", "resource", "status_code", "subresource", "time", "user_name", "verb"}
+}
+
+func (d destinationActivityTable) Values() []any {
+	return []any{d.APIGroup, d.CreatedAt, d.DestinationName, d.Groups, d.ID, d.LatencyMS, d.Name, d.Namespace, d.OrganizationID, d.Path, d.Resource, d.StatusCode, d.Subresource, d.Time, d.UserName, d.Verb}
+}
+
+func (d *destinationActivityTable) ScanFields() []any {
+	return []any{&d.APIGroup, &d.CreatedAt, &d.DestinationName, &d.Groups, &d.ID, &d.LatencyMS, &d.Name, &d.Namespace, &d.OrganizationID, &d.Path, &d.Resource, &d.StatusCode, &d.Subresource, &d.Time, &d.UserName, &d.Verb}
+}
+
+// CreateDestinationActivity inserts all the records.
+func CreateDestinationActivity(tx WriteTxn, records []models.DestinationActivity) error {
+	for i := range records {
+		record := &records[i]
+		switch {
+		case record.DestinationName == "":
+			return fmt.Errorf("DestinationActivity.DestinationName is required")
+		case record.Time.IsZero():
+			return fmt.Errorf("DestinationActivity.Time is required")
+		}
+		if err := insert(tx, (*destinationActivityTable)(record)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type ListDestinationActivityOptions struct {
+	ByDestinationName string
+	ByUserName        string
+	// Since instructs ListDestinationActivity to only return records with a
+	// time after this value.
+	Since time.Time
+
+	Pagination *Pagination
+}
+
+// ListDestinationActivity returns activity records ordered by time, with the
+// most recent record first.
+func ListDestinationActivity(tx ReadTxn, opts ListDestinationActivityOptions) ([]models.DestinationActivity, error) {
+	table := &destinationActivityTable{}
+	query := querybuilder.New("SELECT")
+	query.B(columnsForSelect(table))
+	if opts.Pagination != nil {
+		query.B(", count(*) OVER()")
+	}
+	query.B("FROM destination_activity")
+	query.B("WHERE organization_id = ?", tx.OrganizationID())
+
+	if opts.ByDestinationName != "" {
+		query.B("AND destination_name = ?", opts.ByDestinationName)
+	}
+	if opts.ByUserName != "" {
+		query.B("AND user_name = ?", opts.ByUserName)
+	}
+	if !opts.Since.IsZero() {
+		query.B("AND time > ?", opts.Since)
+	}
+
+	query.B("ORDER BY time DESC, id DESC")
+	if opts.Pagination != nil {
+		opts.Pagination.PaginateQuery(query)
+	}
+
+	rows, err := tx.Query(query.String(), query.Args...)
+	if err != nil {
+		return nil, err
+	}
+	return scanRows(rows, func(record *models.DestinationActivity) []any {
+		fields := (*destinationActivityTable)(record).ScanFields()
+		if opts.Pagination != nil {
+			fields = append(fields, &opts.Pagination.TotalCount)
+		}
+		return fields
+	})
+}
+
+func DeleteExpiredDestinationActivity(tx WriteTxn) error {
+	result, err := tx.Exec(`DELETE FROM destination_activity WHERE time < ?`,
+		time.Now().UTC().Add(-DestinationActivityRetention))
+	if err != nil {
+		return handleError(err)
+	}
+	count, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		logging.L.Info().Int64("count", count).Msg("removed expired destination activity")
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package data
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+
+	"github.com/infrahq/infra/internal/server/models"
+)
+
+func TestDestinationActivity(t *testing.T) {
+	runDBTests(t, func(t *testing.T, db *DB) {
+		tx := txnForTestCase(t, db, db.DefaultOrg.ID)
+
+		now := time.Now().UTC().Truncate(time.Millisecond)
+		records := []models.DestinationActivity{
+			{
+				DestinationName: "production",
+				Time:            now.Add(-2 * time.Hour),
+				UserName:        "alice@example.com",
+				Groups:          models.CommaSeparatedStrings{"developers"},
+				Verb:            "get",
+				Resource:        "pods",
+				Namespace:       "default",
+				Name:            "web",
+				Path:            "/api/v1/namespaces/default/pods/web",
+				StatusCode:      200,
+				LatencyMS:       12,
+			},
+			{
+				DestinationName: "production",
+				Time:            now.Add(-time.Hour),
+				UserName:        "bob@example.com",
+				Verb:            "create",
+				Resource:        "pods",
+				Subresource:     "exec",
+				StatusCode:      403,
+			},
+			{
+				DestinationName: "staging",
+				Time:            now,
+				UserName:        "alice@example.com",
+				Verb:            "list",
+				Resource:        "secrets",
+				StatusCode:      200,
+			},
+			{
+				DestinationName: "production",
+				Time:            now.Add(-DestinationActivityRetention - time.Hour),
+				UserName:        "alice@example.com",
+				Verb:            "list",
+				Resource:        "pods",
+			},
+		}
+		assert.NilError(t, CreateDestinationActivity(tx, records))
+
+		t.Run("by destination", func(t *testing.T) {
+			actual, err := ListDestinationActivity(tx, ListDestinationActivityOptions{
+				ByDestinationName: "production",
+			})
+			assert.NilError(t, err)
+			expected := []models.DestinationActivity{records[1], records[0], records[3]}
+			assert.DeepEqual(t, actual, expected, cmpModel)
+		})
+
+		t.Run("by user since", func(t *testing.T) {
+			actual, err := ListDestinationActivity(tx, ListDestinationActivityOptions{
+				ByUserName: "alice@example.com",
+				Since:      now.Add(-3 * time.Hour),
+			})
+			assert.NilError(t, err)
+			expected := []models.DestinationActivity{records[2], records[0]}
+			assert.DeepEqual(t, actual, expected, cmpModel)
+		})
+
+		t.Run("delete expired", func(t *testing.T) {
+			assert.NilError(t, DeleteExpiredDestinationActivity(tx))
+
+			actual, err := ListDestinationActivity(tx, ListDestinationActivityOptions{})
+			assert.NilError(t, err)
+			assert.Equal(t, len(actual), 3)
+		})
+	})
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/jackc/pgconn"
 	"github.com/jackc/pgerrcode"
+	"golang.org/x/exp/maps"
 
 	"github.com/infrahq/infra/internal/server/data/querybuilder"
 	"github.com/infrahq/infra/internal/server/models"
@@ -134,6 +135,13 @@ type ListGrantsOptions struct {
 	// privilege=connector and resource=infra.
 	ExcludeConnectorGrant bool
 
+	// IncludeSubjectDetails instructs ListGrants to populate the name and
+	// SSH login name of the subject of each grant.
+	IncludeSubjectDetails bool
+	// IncludeGroupMembers instructs ListGrants to also populate the members of
+	// each group. Only used when IncludeSubjectDetails is true.
+	IncludeGroupMembers bool
+
 	Pagination *Pagination
 }
 
@@ -142,7 +150,7 @@ func ListGrants(tx ReadTxn, opts ListGrantsOptions) ([]models.Grant, error) {
 	query := querybuilder.New("SELECT")
 	query.B(columnsForSelect(table))
 	query.B(", update_index")
-	if opts.Pagination != nil {
+	if opts.Pagination.includeCount() {
 		query.B(", count(*) OVER()")
 	}
 	query.B("FROM grants")
@@ -187,6 +195,14 @@ func ListGrants(tx ReadTxn, opts ListGrantsOptions) ([]models.Grant, error) {
 		query.B("AND NOT (privilege = 'connector' AND resource = 'infra')")
 	}
 
+	cursor, err := opts.Pagination.cursor()
+	if err != nil {
+		return nil, err
+	}
+	if cursor != nil {
+		query.B("AND id > ?", cursor.ID)
+	}
+
 	query.B("ORDER BY id ASC")
 	if opts.Pagination != nil {
 		opts.Pagination.PaginateQuery(query)
@@ -196,13 +212,118 @@ func ListGrants(tx ReadTxn, opts ListGrantsOptions) ([]models.Grant, error) {
 	if err != nil {
 		return nil, err
 	}
-	return scanRows(rows, func(grant *models.Grant) []any {
+	result, err := scanRows(rows, func(grant *models.Grant) []any {
 		fields := append((*grantsTable)(grant).ScanFields(), &grant.UpdateIndex)
-		if opts.Pagination != nil {
+		if opts.Pagination.includeCount() {
 			fields = append(fields, &opts.Pagination.TotalCount)
 		}
 		return fields
 	})
+	if err != nil {
+		return nil, err
+	}
+	if len(result) > 0 {
+		opts.Pagination.setNextCursor(len(result), keysetCursor{ID: result[len(result)-1].ID})
+	}
+
+	if opts.IncludeSubjectDetails {
+		if err := loadGrantsSubjectDetails(tx, result, opts.IncludeGroupMembers); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// loadGrantsSubjectDetails populates the subject details of grants using one
+// query for each kind of subject. When includeMembers is true, one more query
+// loads the members of all the groups.
+func loadGrantsSubjectDetails(tx ReadTxn, grants []models.Grant, includeMembers bool) error {
+	userIDs := make(map[uid.ID]bool)
+	groupIDs := make(map[uid.ID]bool)
+	for _, grant := range grants {
+		switch grant.Subject.Kind {
+		case models.SubjectKindUser:
+			userIDs[grant.Subject.ID] = true
+		case models.SubjectKindGroup:
+			groupIDs[grant.Subject.ID] = true
+		}
+	}
+
+	users := make(map[uid.ID]models.Identity, len(userIDs))
+	if len(userIDs) > 0 {
+		identities, err := ListIdentities(tx, ListIdentityOptions{ByIDs: maps.Keys(userIDs)})
+		if err != nil {
+			return fmt.Errorf("list users: %w", err)
+		}
+		for _, identity := range identities {
+			users[identity.ID] = identity
+		}
+	}
+
+	groups := make(map[uid.ID]models.Group, len(groupIDs))
+	members := make(map[uid.ID][]models.GrantSubjectMember, len(groupIDs))
+	if len(groupIDs) > 0 {
+		result, err := ListGroups(tx, ListGroupsOptions{ByIDs: maps.Keys(groupIDs)})
+		if err != nil {
+			return fmt.Errorf("list groups: %w", err)
+		}
+		for _, group := range result {
+			groups[group.ID] = group
+		}
+
+		if includeMembers {
+			members, err = listGroupsMembers(tx, maps.Keys(groupIDs))
+			if err != nil {
+				return fmt.Errorf("list group members: %w", err)
+			}
+		}
+	}
+
+	for i, grant := range grants {
+		switch grant.Subject.Kind {
+		case models.SubjectKindUser:
+			user := users[grant.Subject.ID]
+			grants[i].SubjectName = user.Name
+			grants[i].SubjectSSHLoginName = user.SSHLoginName
+		case models.SubjectKindGroup:
+			grants[i].SubjectName = groups[grant.Subject.ID].Name
+			grants[i].SubjectMembers = members[grant.Subject.ID]
+		}
+	}
+	return nil
+}
+
+func listGroupsMembers(tx ReadTxn, groupIDs []uid.ID) (map[uid.ID][]models.GrantSubjectMember, error) {
+	query := querybuilder.New("SELECT identities_groups.group_id, identities.id, identities.name, identities.ssh_login_name")
+	query.B("FROM identities")
+	query.B("JOIN identities_groups ON identities_groups.identity_id = identities.id")
+	query.B("WHERE identities.deleted_at IS NULL")
+	query.B("AND identities.organization_id = ?", tx.OrganizationID())
+	query.B("AND identities_groups.group_id IN")
+	queryInClause(query, groupIDs)
+	query.B("ORDER BY identities.name ASC")
+
+	rows, err := tx.Query(query.String(), query.Args...)
+	if err != nil {
+		return nil, err
+	}
+
+	type groupMember struct {
+		GroupID uid.ID
+		models.GrantSubjectMember
+	}
+	items, err := scanRows(rows, func(item *groupMember) []any {
+		return []any{&item.GroupID, &item.ID, &item.Name, &item.SSHLoginName}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[uid.ID][]models.GrantSubjectMember)
+	for _, item := range items {
+		result[item.GroupID] = append(result[item.GroupID], item.GrantSubjectMember)
+	}
+	return result, nil
 }
 
 func grantsByDestination(query *querybuilder.Query, destination string) {
@@ -211,22 +332,83 @@ func grantsByDestination(query *querybuilder.Query, destination string) {
 
 type GrantsMaxUpdateIndexOptions struct {
 	ByDestination string
+	// ByUser limits the result to the grants of the user. When
+	// IncludeInheritedFromGroups is true the grants of the groups of the user,
+	// and changes to the groups of the user, are included as well.
+	ByUser                     uid.ID
+	IncludeInheritedFromGroups bool
 }
 
 // GrantsMaxUpdateIndex returns the maximum update_index all the grants that
 // match the query. This MUST include soft-deleted rows as well.
 //
+// When the query is not for a user, the membership_update_index of groups that
+// are the subject of a matching grant is included as well, so that a change to
+// the members of a group changes the result. The update_index of role
+// templates is included because the privilege of a grant may refer to a role
+// template.
+//
 // Returns 1 if no records match the query, so that the caller can block until
 // a record exists.
 //
 // TODO: any way to assert this tx has the right isolation level?
 func GrantsMaxUpdateIndex(tx ReadTxn, opts GrantsMaxUpdateIndexOptions) (int64, error) {
-	query := querybuilder.New("SELECT max(update_index) FROM grants")
+	if opts.ByUser != 0 {
+		return grantsMaxUpdateIndexForUser(tx, opts)
+	}
+
+	query := querybuilder.New("SELECT GREATEST(")
+	query.B("(SELECT max(update_index) FROM grants")
 	query.B("WHERE organization_id = ?", tx.OrganizationID())
+	if opts.ByDestination != "" {
+		grantsByDestination(query, opts.ByDestination)
+	}
+	query.B("),")
 
+	query.B("(SELECT max(groups.membership_update_index) FROM groups")
+	query.B("JOIN grants ON grants.subject_id = groups.id AND grants.subject_kind = ?", models.SubjectKindGroup)
+	query.B("WHERE groups.organization_id = ?", tx.OrganizationID())
+	query.B("AND grants.deleted_at is null")
 	if opts.ByDestination != "" {
 		grantsByDestination(query, opts.ByDestination)
 	}
+	query.B("),")
+
+	query.B("(SELECT max(update_index) FROM role_templates")
+	query.B("WHERE organization_id = ?", tx.OrganizationID())
+	query.B("))")
+
+	var result *int64
+	err := tx.QueryRow(query.String(), query.Args...).Scan(&result)
+	if err != nil || result == nil {
+		return 1, err
+	}
+	return *result, err
+}
+
+// grantsMaxUpdateIndexForUser returns the maximum update_index of the grants
+// of a user. The membership_update_index of the user changes when the user is
+// added to or removed from a group, so that the result changes when the
+// inherited grants of the user change.
+func grantsMaxUpdateIndexForUser(tx ReadTxn, opts GrantsMaxUpdateIndexOptions) (int64, error) {
+	query := querybuilder.New("SELECT GREATEST(")
+	query.B("(SELECT max(update_index) FROM grants")
+	query.B("WHERE organization_id = ?", tx.OrganizationID())
+	query.B("AND subject_kind = ? AND subject_id = ?", models.SubjectKindUser, opts.ByUser)
+	query.B(")")
+
+	if opts.IncludeInheritedFromGroups {
+		query.B(", (SELECT max(update_index) FROM grants")
+		query.B("WHERE organization_id = ?", tx.OrganizationID())
+		query.B("AND subject_kind = ?", models.SubjectKindGroup)
+		query.B("AND subject_id IN (SELECT group_id FROM identities_groups WHERE identity_id = ?)", opts.ByUser)
+		query.B(")")
+
+		query.B(", (SELECT membership_update_index FROM identities")
+		query.B("WHERE organization_id = ? AND id = ?", tx.OrganizationID(), opts.ByUser)
+		query.B(")")
+	}
+	query.B(")")
 
 	var result *int64
 	err := tx.QueryRow(query.String(), query.Args...).Scan(&result)
@@ -240,7 +422,16 @@ func GrantsMaxUpdateIndex(tx ReadTxn, opts GrantsMaxUpdateIndexOptions) (int64,
 // models.Grant does not work because it expects to decode uid.ID from a string
 // not a number.
 type grantJSON struct {
-	Resource string
+	Resource    string
+	SubjectID   int64              `json:"subject_id"`
+	SubjectKind models.SubjectKind `json:"subject_kind"`
+	// MembershipChanged is set when the members of a group changed. The
+	// notification does not include the resource, so it matches every
+	// listener.
+	MembershipChanged bool
+	// RoleTemplatesChanged is set when a role template changed. It matches
+	// every listener for the grants of a destination.
+	RoleTemplatesChanged bool
 }
 
 type DeleteGrantsOptions struct {
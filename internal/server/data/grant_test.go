@@ -89,7 +89,7 @@ func TestCreateGrant(t *testing.T) {
 			})
 			assert.NilError(t, err)
 			t.Cleanup(func() {
-				assert.NilError(t, listener.Release(context.Background()))
+				listener.Release()
 			})
 
 			tx := txnForTestCase(t, db, db.DefaultOrg.ID)
@@ -216,7 +216,7 @@ func TestDeleteGrants(t *testing.T) {
 			})
 			assert.NilError(t, err)
 			t.Cleanup(func() {
-				assert.NilError(t, listener.Release(context.Background()))
+				listener.Release()
 			})
 
 			tx := txnForTestCase(t, db, db.DefaultOrg.ID)
@@ -672,6 +672,84 @@ func TestListGrants(t *testing.T) {
 			expected := []models.Grant{*grant1, *grant2, *grant3, *grant5}
 			assert.DeepEqual(t, actual, expected, cmpModelByID)
 		})
+		t.Run("with cursor pagination", func(t *testing.T) {
+			p := &Pagination{Limit: 4}
+			actual, err := ListGrants(tx, ListGrantsOptions{Pagination: p})
+			assert.NilError(t, err)
+			assert.DeepEqual(t, actual, []models.Grant{*connector, *grant1, *grant2, *grant3}, cmpModelByID)
+			assert.Assert(t, p.NextCursor != "")
+
+			p = &Pagination{Limit: 4, Cursor: p.NextCursor}
+			actual, err = ListGrants(tx, ListGrantsOptions{Pagination: p})
+			assert.NilError(t, err)
+			assert.DeepEqual(t, actual, []models.Grant{*grant4, *grant5, *gGrant1, *gGrant2}, cmpModelByID)
+			assert.Assert(t, p.NextCursor != "")
+
+			// the last page
+			p = &Pagination{Limit: 4, Cursor: p.NextCursor}
+			actual, err = ListGrants(tx, ListGrantsOptions{Pagination: p})
+			assert.NilError(t, err)
+			assert.DeepEqual(t, actual, []models.Grant{*gGrant3}, cmpModelByID)
+			assert.Equal(t, p.NextCursor, "")
+
+			_, err = ListGrants(tx, ListGrantsOptions{Pagination: &Pagination{Limit: 4, Cursor: "not-valid"}})
+			assert.ErrorIs(t, err, internal.ErrBadRequest)
+		})
+	})
+}
+
+func TestListGrants_IncludeSubjectDetails(t *testing.T) {
+	runDBTests(t, func(t *testing.T, db *DB) {
+		tx := txnForTestCase(t, db, db.DefaultOrg.ID)
+
+		user := &models.Identity{Name: "one@example.com"}
+		member1 := &models.Identity{Name: "two@example.com"}
+		member2 := &models.Identity{Name: "three@example.com"}
+		createIdentities(t, tx, user, member1, member2)
+
+		group := &models.Group{Name: "the-group"}
+		assert.NilError(t, CreateGroup(tx, group))
+		assert.NilError(t, AddUsersToGroup(tx, group.ID, []uid.ID{member1.ID, member2.ID}))
+
+		userGrant := &models.Grant{
+			Subject:   models.NewSubjectForUser(user.ID),
+			Privilege: "connect",
+			Resource:  "mydest",
+		}
+		groupGrant := &models.Grant{
+			Subject:   models.NewSubjectForGroup(group.ID),
+			Privilege: "connect",
+			Resource:  "mydest",
+		}
+		createGrants(t, tx, userGrant, groupGrant)
+
+		actual, err := ListGrants(tx, ListGrantsOptions{
+			ByDestination:         "mydest",
+			IncludeSubjectDetails: true,
+		})
+		assert.NilError(t, err)
+		assert.Equal(t, len(actual), 2)
+		assert.Equal(t, actual[1].SubjectName, "the-group")
+		assert.Equal(t, len(actual[1].SubjectMembers), 0)
+
+		actual, err = ListGrants(tx, ListGrantsOptions{
+			ByDestination:         "mydest",
+			IncludeSubjectDetails: true,
+			IncludeGroupMembers:   true,
+		})
+		assert.NilError(t, err)
+		assert.Equal(t, len(actual), 2)
+
+		assert.Equal(t, actual[0].SubjectName, "one@example.com")
+		assert.Equal(t, actual[0].SubjectSSHLoginName, "one")
+		assert.Equal(t, len(actual[0].SubjectMembers), 0)
+
+		assert.Equal(t, actual[1].SubjectName, "the-group")
+		expected := []models.GrantSubjectMember{
+			{ID: member2.ID, Name: "three@example.com", SSHLoginName: "three"},
+			{ID: member1.ID, Name: "two@example.com", SSHLoginName: "two"},
+		}
+		assert.DeepEqual(t, actual[1].SubjectMembers, expected)
 	})
 }
 
@@ -684,6 +762,108 @@ func TestGrantsMaxUpdateIndex(t *testing.T) {
 			assert.NilError(t, err)
 			assert.Equal(t, idx, int64(1))
 		})
+		t.Run("group membership changes", func(t *testing.T) {
+			tx := txnForTestCase(t, db, db.DefaultOrg.ID)
+
+			user := &models.Identity{Name: "member@example.com"}
+			createIdentities(t, tx, user)
+			group := &models.Group{Name: "the-group"}
+			assert.NilError(t, CreateGroup(tx, group))
+			otherGroup := &models.Group{Name: "other-group"}
+			assert.NilError(t, CreateGroup(tx, otherGroup))
+
+			assert.NilError(t, CreateGrant(tx, &models.Grant{
+				Subject:   models.NewSubjectForGroup(group.ID),
+				Resource:  "mydest.ns1",
+				Privilege: "view",
+			}))
+
+			initial, err := GrantsMaxUpdateIndex(tx, GrantsMaxUpdateIndexOptions{ByDestination: "mydest"})
+			assert.NilError(t, err)
+
+			// a group without a grant for the destination does not change the index
+			assert.NilError(t, AddUsersToGroup(tx, otherGroup.ID, []uid.ID{user.ID}))
+			idx, err := GrantsMaxUpdateIndex(tx, GrantsMaxUpdateIndexOptions{ByDestination: "mydest"})
+			assert.NilError(t, err)
+			assert.Equal(t, idx, initial)
+
+			assert.NilError(t, AddUsersToGroup(tx, group.ID, []uid.ID{user.ID}))
+			added, err := GrantsMaxUpdateIndex(tx, GrantsMaxUpdateIndexOptions{ByDestination: "mydest"})
+			assert.NilError(t, err)
+			assert.Assert(t, added > initial, "added=%v initial=%v", added, initial)
+
+			assert.NilError(t, RemoveUsersFromGroup(tx, group.ID, []uid.ID{user.ID}))
+			removed, err := GrantsMaxUpdateIndex(tx, GrantsMaxUpdateIndexOptions{ByDestination: "mydest"})
+			assert.NilError(t, err)
+			assert.Assert(t, removed > added, "removed=%v added=%v", removed, added)
+		})
+		t.Run("role template changes", func(t *testing.T) {
+			tx := txnForTestCase(t, db, db.DefaultOrg.ID)
+
+			assert.NilError(t, CreateGrant(tx, &models.Grant{
+				Subject:   models.NewSubjectForUser(1234),
+				Resource:  "mydest",
+				Privilege: "debug",
+			}))
+			initial, err := GrantsMaxUpdateIndex(tx, GrantsMaxUpdateIndexOptions{ByDestination: "mydest"})
+			assert.NilError(t, err)
+
+			template := &models.RoleTemplate{
+				Name:  "debug",
+				Rules: models.RoleRules{{Resources: []string{"pods"}, Verbs: []string{"get"}}},
+			}
+			assert.NilError(t, CreateRoleTemplate(tx, template))
+			created, err := GrantsMaxUpdateIndex(tx, GrantsMaxUpdateIndexOptions{ByDestination: "mydest"})
+			assert.NilError(t, err)
+			assert.Assert(t, created > initial, "created=%v initial=%v", created, initial)
+
+			assert.NilError(t, DeleteRoleTemplate(tx, template.ID))
+			deleted, err := GrantsMaxUpdateIndex(tx, GrantsMaxUpdateIndexOptions{ByDestination: "mydest"})
+			assert.NilError(t, err)
+			assert.Assert(t, deleted > created, "deleted=%v created=%v", deleted, created)
+		})
+		t.Run("by user", func(t *testing.T) {
+			tx := txnForTestCase(t, db, db.DefaultOrg.ID)
+
+			user := &models.Identity{Name: "user@example.com"}
+			createIdentities(t, tx, user)
+			group := &models.Group{Name: "the-group"}
+			assert.NilError(t, CreateGroup(tx, group))
+
+			opts := GrantsMaxUpdateIndexOptions{ByUser: user.ID, IncludeInheritedFromGroups: true}
+			assert.NilError(t, CreateGrant(tx, &models.Grant{
+				Subject:   models.NewSubjectForUser(user.ID),
+				Resource:  "mydest",
+				Privilege: "view",
+			}))
+			initial, err := GrantsMaxUpdateIndex(tx, opts)
+			assert.NilError(t, err)
+
+			// grants for other users and groups do not change the index
+			assert.NilError(t, CreateGrant(tx, &models.Grant{
+				Subject:   models.NewSubjectForUser(user.ID + 1),
+				Resource:  "mydest",
+				Privilege: "view",
+			}))
+			assert.NilError(t, CreateGrant(tx, &models.Grant{
+				Subject:   models.NewSubjectForGroup(group.ID),
+				Resource:  "mydest",
+				Privilege: "admin",
+			}))
+			idx, err := GrantsMaxUpdateIndex(tx, opts)
+			assert.NilError(t, err)
+			assert.Equal(t, idx, initial)
+
+			assert.NilError(t, AddUsersToGroup(tx, group.ID, []uid.ID{user.ID}))
+			added, err := GrantsMaxUpdateIndex(tx, opts)
+			assert.NilError(t, err)
+			assert.Assert(t, added > initial, "added=%v initial=%v", added, initial)
+
+			// without inherited grants the groups of the user are ignored
+			direct, err := GrantsMaxUpdateIndex(tx, GrantsMaxUpdateIndexOptions{ByUser: user.ID})
+			assert.NilError(t, err)
+			assert.Equal(t, direct, initial)
+		})
 	})
 }
 
@@ -796,6 +976,19 @@ func TestListenForGrantsNotify(t *testing.T) {
 						},
 						expectMatch: true,
 					},
+					{
+						name: "group membership changed",
+						run: func(t *testing.T, tx WriteTxn) {
+							group := &models.Group{Name: "the-group"}
+							assert.NilError(t, CreateGroup(tx, group))
+							user := &models.Identity{Name: "member@example.com"}
+							createIdentities(t, tx, user)
+
+							err := AddUsersToGroup(tx, group.ID, []uid.ID{user.ID})
+							assert.NilError(t, err)
+						},
+						expectMatch: true,
+					},
 					{
 						name: "different org",
 						run: func(t *testing.T, tx WriteTxn) {
@@ -812,6 +1005,72 @@ func TestListenForGrantsNotify(t *testing.T) {
 					},
 				},
 			},
+			{
+				name: "by user",
+				opts: ListenForNotifyOptions{
+					GrantsByUser:       1999,
+					GrantsByUserGroups: []uid.ID{2999},
+					OrgID:              mainOrg.ID,
+				},
+				ops: []operation{
+					{
+						name: "grant for the user",
+						run: func(t *testing.T, tx WriteTxn) {
+							err := CreateGrant(tx, &models.Grant{
+								Subject:   models.NewSubjectForUser(1999),
+								Resource:  "mydest",
+								Privilege: "view",
+							})
+							assert.NilError(t, err)
+						},
+						expectMatch: true,
+					},
+					{
+						name: "grant for another user",
+						run: func(t *testing.T, tx WriteTxn) {
+							err := CreateGrant(tx, &models.Grant{
+								Subject:   models.NewSubjectForUser(1998),
+								Resource:  "mydest",
+								Privilege: "view",
+							})
+							assert.NilError(t, err)
+						},
+					},
+					{
+						name: "grant for a group of the user",
+						run: func(t *testing.T, tx WriteTxn) {
+							err := CreateGrant(tx, &models.Grant{
+								Subject:   models.NewSubjectForGroup(2999),
+								Resource:  "mydest",
+								Privilege: "view",
+							})
+							assert.NilError(t, err)
+						},
+						expectMatch: true,
+					},
+					{
+						name: "grant for another group",
+						run: func(t *testing.T, tx WriteTxn) {
+							err := CreateGrant(tx, &models.Grant{
+								Subject:   models.NewSubjectForGroup(2998),
+								Resource:  "mydest",
+								Privilege: "view",
+							})
+							assert.NilError(t, err)
+						},
+					},
+					{
+						name: "role template changed",
+						run: func(t *testing.T, tx WriteTxn) {
+							err := CreateRoleTemplate(tx, &models.RoleTemplate{
+								Name:  "debug",
+								Rules: models.RoleRules{{Resources: []string{"pods"}, Verbs: []string{"get"}}},
+							})
+							assert.NilError(t, err)
+						},
+					},
+				},
+			},
 		}
 
 		for _, tc := range testcases {
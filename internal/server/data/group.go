@@ -83,7 +83,7 @@ func ListGroups(tx ReadTxn, opts ListGroupsOptions) ([]models.Group, error) {
 	table := groupsTable{}
 	query := querybuilder.New("SELECT")
 	query.B(columnsForSelect(table))
-	if opts.Pagination != nil {
+	if opts.Pagination.includeCount() {
 		query.B(", count(*) OVER()")
 	}
 	query.B("FROM groups")
@@ -102,7 +102,15 @@ func ListGroups(tx ReadTxn, opts ListGroupsOptions) ([]models.Group, error) {
 		queryInClause(query, opts.ByIDs)
 	}
 
-	query.B("ORDER BY name ASC")
+	cursor, err := opts.Pagination.cursor()
+	if err != nil {
+		return nil, err
+	}
+	if cursor != nil {
+		query.B("AND (name, groups.id) > (?, ?)", cursor.Value, cursor.ID)
+	}
+
+	query.B("ORDER BY name ASC, groups.id ASC")
 	if opts.Pagination != nil {
 		opts.Pagination.PaginateQuery(query)
 	}
@@ -113,7 +121,7 @@ func ListGroups(tx ReadTxn, opts ListGroupsOptions) ([]models.Group, error) {
 	}
 	result, err := scanRows(rows, func(group *models.Group) []any {
 		fields := (*groupsTable)(group).ScanFields()
-		if opts.Pagination != nil {
+		if opts.Pagination.includeCount() {
 			fields = append(fields, &opts.Pagination.TotalCount)
 		}
 		return fields
@@ -121,6 +129,10 @@ func ListGroups(tx ReadTxn, opts ListGroupsOptions) ([]models.Group, error) {
 	if err != nil {
 		return nil, err
 	}
+	if len(result) > 0 {
+		last := result[len(result)-1]
+		opts.Pagination.setNextCursor(len(result), keysetCursor{Value: last.Name, ID: last.ID})
+	}
 
 	// TODO: do this in a single query
 	for i := range result {
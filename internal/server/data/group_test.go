@@ -215,6 +215,31 @@ func TestListGroups(t *testing.T) {
 			}
 			assert.DeepEqual(t, actual, expected, cmpGroupShallow)
 		})
+		t.Run("cursor pagination", func(t *testing.T) {
+			p := &Pagination{Limit: 3}
+			actual, err := ListGroups(db, ListGroupsOptions{Pagination: p})
+			assert.NilError(t, err)
+			expected := []models.Group{
+				{Name: "Empty", TotalUsers: 0},
+				{Name: "Engineering", TotalUsers: 1},
+				{Name: "Everyone", TotalUsers: 2},
+			}
+			assert.DeepEqual(t, actual, expected, cmpGroupShallow)
+			assert.Assert(t, p.NextCursor != "")
+
+			// the last page
+			p = &Pagination{Limit: 3, Cursor: p.NextCursor}
+			actual, err = ListGroups(db, ListGroupsOptions{Pagination: p})
+			assert.NilError(t, err)
+			expected = []models.Group{
+				{Name: "Product", TotalUsers: 1},
+			}
+			assert.DeepEqual(t, actual, expected, cmpGroupShallow)
+			assert.Equal(t, p.NextCursor, "")
+
+			_, err = ListGroups(db, ListGroupsOptions{Pagination: &Pagination{Limit: 3, Cursor: "not-valid"}})
+			assert.ErrorIs(t, err, internal.ErrBadRequest)
+		})
 	})
 }
 
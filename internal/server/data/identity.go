@@ -439,7 +439,7 @@ func ListIdentities(tx ReadTxn, opts ListIdentityOptions) ([]models.Identity, er
 	identities := &identitiesTable{}
 	query := querybuilder.New("SELECT")
 	query.B(columnsForSelect(identities))
-	if opts.Pagination != nil {
+	if opts.Pagination.includeCount() {
 		query.B(", count(*) OVER()")
 	}
 	query.B("FROM")
@@ -469,7 +469,14 @@ func ListIdentities(tx ReadTxn, opts ListIdentityOptions) ([]models.Identity, er
 	if opts.ByGroupID != 0 {
 		query.B("AND identities_groups.group_id = ?", opts.ByGroupID)
 	}
-	query.B("ORDER BY identities.name ASC")
+	cursor, err := opts.Pagination.cursor()
+	if err != nil {
+		return nil, err
+	}
+	if cursor != nil {
+		query.B("AND (identities.name, identities.id) > (?, ?)", cursor.Value, cursor.ID)
+	}
+	query.B("ORDER BY identities.name ASC, identities.id ASC")
 	if opts.Pagination != nil {
 		opts.Pagination.PaginateQuery(query)
 	}
@@ -480,7 +487,7 @@ func ListIdentities(tx ReadTxn, opts ListIdentityOptions) ([]models.Identity, er
 	}
 	result, err := scanRows(rows, func(identity *models.Identity) []any {
 		fields := (*identitiesTable)(identity).ScanFields()
-		if opts.Pagination != nil {
+		if opts.Pagination.includeCount() {
 			fields = append(fields, &opts.Pagination.TotalCount)
 		}
 		return fields
@@ -488,6 +495,10 @@ func ListIdentities(tx ReadTxn, opts ListIdentityOptions) ([]models.Identity, er
 	if err != nil {
 		return nil, err
 	}
+	if len(result) > 0 {
+		last := result[len(result)-1]
+		opts.Pagination.setNextCursor(len(result), keysetCursor{Value: last.Name, ID: last.ID})
+	}
 
 	if len(result) == 0 {
 		// return without attempting pre-loads
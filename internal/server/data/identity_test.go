@@ -386,6 +386,31 @@ func TestListIdentities(t *testing.T) {
 			assert.DeepEqual(t, actual, expected, cmpModelsIdentityShallow)
 		})
 
+		t.Run("cursor pagination", func(t *testing.T) {
+			p := &Pagination{Limit: 2}
+			actual, err := ListIdentities(db, ListIdentityOptions{Pagination: p})
+			assert.NilError(t, err)
+			assert.DeepEqual(t, actual, []models.Identity{*connector, bauer}, cmpModelsIdentityShallow)
+			assert.Equal(t, p.TotalCount, 5)
+			assert.Assert(t, p.NextCursor != "")
+
+			p = &Pagination{Limit: 2, Cursor: p.NextCursor}
+			actual, err = ListIdentities(db, ListIdentityOptions{Pagination: p})
+			assert.NilError(t, err)
+			assert.DeepEqual(t, actual, []models.Identity{bond, bourne}, cmpModelsIdentityShallow)
+			assert.Equal(t, p.TotalCount, 0)
+			assert.Assert(t, p.NextCursor != "")
+
+			p = &Pagination{Limit: 2, Cursor: p.NextCursor}
+			actual, err = ListIdentities(db, ListIdentityOptions{Pagination: p})
+			assert.NilError(t, err)
+			assert.DeepEqual(t, actual, []models.Identity{salt}, cmpModelsIdentityShallow)
+			assert.Equal(t, p.NextCursor, "")
+
+			_, err = ListIdentities(db, ListIdentityOptions{Pagination: &Pagination{Limit: 2, Cursor: "not-valid"}})
+			assert.ErrorIs(t, err, internal.ErrBadRequest)
+		})
+
 		t.Run("load groups", func(t *testing.T) {
 			actual, err := ListIdentities(db, ListIdentityOptions{LoadGroups: true})
 			assert.NilError(t, err)
@@ -87,6 +87,17 @@ func migrations() []*migrator.Migration {
 		addUserPublicKeyUserIDIndex(),
 		addGrantsSubjectID(),
 		removeSettingsPasswordPolicy(),
+		addRoleTemplates(),
+		addProxyPolicies(),
+		addDestinationActivity(),
+		addSessionRecordings(),
+		addGroupMembershipUpdateIndex(),
+		addUpdateIndexToWatchedTables(),
+		addRateLimits(),
+		addRateLimitTables(),
+		addBackgroundJobs(),
+		addRoleTemplatesUpdateIndex(),
+		addIdentityMembershipUpdateIndex(),
 		// next one here, then run `go test -run TestMigrations ./internal/server/data -update`
 	}
 }
@@ -1258,3 +1269,392 @@ func removeSettingsPasswordPolicy() *migrator.Migration {
 		},
 	}
 }
+
+func addRoleTemplates() *migrator.Migration {
+	return &migrator.Migration{
+		ID: "2023-01-25T10:00",
+		Migrate: func(tx migrator.DB) error {
+			_, err := tx.Exec(`
+CREATE TABLE IF NOT EXISTS role_templates (
+    id bigint NOT NULL,
+    created_at timestamp with time zone,
+    updated_at timestamp with time zone,
+    deleted_at timestamp with time zone,
+    organization_id bigint NOT NULL,
+    name text NOT NULL,
+    rules text NOT NULL
+);
+
+ALTER TABLE ONLY role_templates DROP CONSTRAINT IF EXISTS role_templates_pkey;
+ALTER TABLE ONLY role_templates
+    ADD CONSTRAINT role_templates_pkey PRIMARY KEY (id);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_role_templates_name ON role_templates
+    USING btree (organization_id, name) WHERE (deleted_at IS NULL);
+`)
+			return err
+		},
+	}
+}
+
+func addProxyPolicies() *migrator.Migration {
+	return &migrator.Migration{
+		ID: "2023-01-26T10:00",
+		Migrate: func(tx migrator.DB) error {
+			_, err := tx.Exec(`
+CREATE TABLE IF NOT EXISTS proxy_policies (
+    id bigint NOT NULL,
+    created_at timestamp with time zone,
+    updated_at timestamp with time zone,
+    deleted_at timestamp with time zone,
+    organization_id bigint NOT NULL,
+    name text NOT NULL,
+    destination_name text DEFAULT ''::text NOT NULL,
+    reason text DEFAULT ''::text NOT NULL,
+    rule text NOT NULL
+);
+
+ALTER TABLE ONLY proxy_policies DROP CONSTRAINT IF EXISTS proxy_policies_pkey;
+ALTER TABLE ONLY proxy_policies
+    ADD CONSTRAINT proxy_policies_pkey PRIMARY KEY (id);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_proxy_policies_name ON proxy_policies
+    USING btree (organization_id, name) WHERE (deleted_at IS NULL);
+`)
+			return err
+		},
+	}
+}
+
+func addDestinationActivity() *migrator.Migration {
+	return &migrator.Migration{
+		ID: "2023-01-27T10:00",
+		Migrate: func(tx migrator.DB) error {
+			_, err := tx.Exec(`
+CREATE TABLE IF NOT EXISTS destination_activity (
+    id bigint NOT NULL,
+    created_at timestamp with time zone,
+    organization_id bigint NOT NULL,
+    destination_name text NOT NULL,
+    "time" timestamp with time zone NOT NULL,
+    user_name text DEFAULT ''::text NOT NULL,
+    groups text DEFAULT ''::text NOT NULL,
+    verb text DEFAULT ''::text NOT NULL,
+    api_group text DEFAULT ''::text NOT NULL,
+    resource text DEFAULT ''::text NOT NULL,
+    subresource text DEFAULT ''::text NOT NULL,
+    namespace text DEFAULT ''::text NOT NULL,
+    name text DEFAULT ''::text NOT NULL,
+    path text DEFAULT ''::text NOT NULL,
+    status_code integer DEFAULT 0 NOT NULL,
+    latency_ms bigint DEFAULT 0 NOT NULL
+);
+
+ALTER TABLE ONLY destination_activity DROP CONSTRAINT IF EXISTS destination_activity_pkey;
+ALTER TABLE ONLY destination_activity
+    ADD CONSTRAINT destination_activity_pkey PRIMARY KEY (id);
+
+CREATE INDEX IF NOT EXISTS idx_destination_activity_time ON destination_activity
+    USING btree (organization_id, destination_name, "time");
+`)
+			return err
+		},
+	}
+}
+
+func addSessionRecordings() *migrator.Migration {
+	return &migrator.Migration{
+		ID: "2023-01-30T10:00",
+		Migrate: func(tx migrator.DB) error {
+			_, err := tx.Exec(`
+CREATE TABLE IF NOT EXISTS session_recordings (
+    id bigint NOT NULL,
+    created_at timestamp with time zone,
+    organization_id bigint NOT NULL,
+    destination_name text NOT NULL,
+    user_id bigint NOT NULL,
+    user_name text DEFAULT ''::text NOT NULL,
+    login_name text DEFAULT ''::text NOT NULL,
+    started_at timestamp with time zone NOT NULL,
+    ended_at timestamp with time zone NOT NULL,
+    size bigint DEFAULT 0 NOT NULL,
+    content bytea NOT NULL
+);
+
+ALTER TABLE ONLY session_recordings DROP CONSTRAINT IF EXISTS session_recordings_pkey;
+ALTER TABLE ONLY session_recordings
+    ADD CONSTRAINT session_recordings_pkey PRIMARY KEY (id);
+
+CREATE INDEX IF NOT EXISTS idx_session_recordings_started_at ON session_recordings
+    USING btree (organization_id, destination_name, started_at);
+`)
+			return err
+		},
+	}
+}
+
+// addGroupMembershipUpdateIndex tracks changes to group membership with
+// update_index, and notifies grant listeners, so that a blocking list of grants
+// returns when the members of a group with a grant change. The triggers are
+// statement level, so that adding or removing many members of a group updates
+// the group and notifies grant listeners once per group, instead of once per
+// member. Transition tables can only be used by triggers for a single event,
+// so there is one trigger for insert and one for delete.
+func addGroupMembershipUpdateIndex() *migrator.Migration {
+	return &migrator.Migration{
+		ID: "2023-01-31T10:00",
+		Migrate: func(tx migrator.DB) error {
+			stmt := `ALTER TABLE groups ADD COLUMN IF NOT EXISTS membership_update_index bigint`
+			if _, err := tx.Exec(stmt); err != nil {
+				return err
+			}
+
+			fn := `
+CREATE OR REPLACE FUNCTION identities_groups_notify() RETURNS trigger
+	LANGUAGE PLPGSQL
+	AS $$
+DECLARE
+    changed record;
+BEGIN
+    FOR changed IN
+        UPDATE groups SET membership_update_index = nextval('seq_update_index')
+            WHERE id IN (SELECT DISTINCT group_id FROM changed_members)
+            RETURNING id, organization_id
+    LOOP
+        PERFORM pg_notify(current_schema() || '.grants_' || changed.organization_id,
+            json_build_object('membershipChanged', true, 'groupID', changed.id)::text);
+    END LOOP;
+    RETURN NULL;
+END; $$;
+
+DROP TRIGGER IF EXISTS identities_groups_insert_notify_trigger ON identities_groups;
+CREATE TRIGGER identities_groups_insert_notify_trigger AFTER INSERT ON identities_groups
+REFERENCING NEW TABLE AS changed_members
+FOR EACH STATEMENT EXECUTE FUNCTION identities_groups_notify();
+
+DROP TRIGGER IF EXISTS identities_groups_delete_notify_trigger ON identities_groups;
+CREATE TRIGGER identities_groups_delete_notify_trigger AFTER DELETE ON identities_groups
+REFERENCING OLD TABLE AS changed_members
+FOR EACH STATEMENT EXECUTE FUNCTION identities_groups_notify();
+`
+			_, err := tx.Exec(fn)
+			return err
+		},
+	}
+}
+
+// addUpdateIndexToWatchedTables adds update_index to the tables that support
+// blocking list requests. The update_index is set by a trigger, so that every
+// write is included, and the trigger notifies listeners of the change.
+// The trigger arguments are the columns that are ignored when comparing
+// the old and new row, so that updating only those columns does not notify.
+func addUpdateIndexToWatchedTables() *migrator.Migration {
+	return &migrator.Migration{
+		ID: "2023-02-01T10:00",
+		Migrate: func(tx migrator.DB) error {
+			stmt := `
+CREATE OR REPLACE FUNCTION update_index_notify() RETURNS trigger
+	LANGUAGE PLPGSQL
+	AS $$
+BEGIN
+    IF TG_OP = 'UPDATE' AND
+        (to_jsonb(NEW) - 'update_index' - TG_ARGV) = (to_jsonb(OLD) - 'update_index' - TG_ARGV) THEN
+        RETURN NEW;
+    END IF;
+    NEW.update_index := nextval('seq_update_index');
+    IF NEW.organization_id IS NOT NULL THEN
+        PERFORM pg_notify(current_schema() || '.' || TG_TABLE_NAME || '_' || NEW.organization_id,
+            json_build_object('id', NEW.id)::text);
+    END IF;
+    RETURN NEW;
+END; $$;
+`
+			if _, err := tx.Exec(stmt); err != nil {
+				return err
+			}
+
+			tables := []struct {
+				name    string
+				ignored string
+			}{
+				{name: "destinations", ignored: "'updated_at', 'last_seen_at'"},
+				{name: "groups", ignored: "'updated_at'"},
+				{name: "identities", ignored: "'updated_at', 'last_seen_at'"},
+				{name: "providers", ignored: "'updated_at'"},
+			}
+			for _, table := range tables {
+				stmt := fmt.Sprintf(`
+ALTER TABLE %[1]v ADD COLUMN IF NOT EXISTS update_index bigint;
+UPDATE %[1]v SET update_index = nextval('seq_update_index') WHERE update_index IS NULL;
+
+DROP TRIGGER IF EXISTS %[1]v_update_index_trigger ON %[1]v;
+
+CREATE TRIGGER %[1]v_update_index_trigger BEFORE INSERT OR UPDATE
+ON %[1]v
+FOR EACH ROW EXECUTE FUNCTION update_index_notify(%[2]v);
+`, table.name, table.ignored)
+				if _, err := tx.Exec(stmt); err != nil {
+					return fmt.Errorf("table %v: %w", table.name, err)
+				}
+			}
+			return nil
+		},
+	}
+}
+
+// addRateLimits adds the rate limit settings for organizations and access
+// keys. A value of 0 uses the server default.
+func addRateLimits() *migrator.Migration {
+	return &migrator.Migration{
+		ID: "2023-02-02T10:00",
+		Migrate: func(tx migrator.DB) error {
+			stmt := `
+ALTER TABLE organizations ADD COLUMN IF NOT EXISTS rate_limit integer DEFAULT 0 NOT NULL;
+ALTER TABLE organizations ADD COLUMN IF NOT EXISTS login_rate_limit integer DEFAULT 0 NOT NULL;
+ALTER TABLE access_keys ADD COLUMN IF NOT EXISTS rate_limit integer DEFAULT 0 NOT NULL;
+`
+			_, err := tx.Exec(stmt)
+			return err
+		},
+	}
+}
+
+// addRateLimitTables adds the tables used by the postgres rate limiter. The
+// tables are unlogged because the counts are short lived, and losing them
+// after a crash is better than the cost of writing them to the WAL.
+func addRateLimitTables() *migrator.Migration {
+	return &migrator.Migration{
+		ID: "2023-02-03T10:00",
+		Migrate: func(tx migrator.DB) error {
+			stmt := `
+CREATE UNLOGGED TABLE IF NOT EXISTS rate_limits (
+    key text NOT NULL,
+    window_start timestamp with time zone NOT NULL,
+    count integer DEFAULT 0 NOT NULL
+);
+
+ALTER TABLE ONLY rate_limits DROP CONSTRAINT IF EXISTS rate_limits_pkey;
+ALTER TABLE ONLY rate_limits
+    ADD CONSTRAINT rate_limits_pkey PRIMARY KEY (key, window_start);
+
+CREATE UNLOGGED TABLE IF NOT EXISTS login_failures (
+    key text NOT NULL,
+    failures integer DEFAULT 0 NOT NULL,
+    locked_until timestamp with time zone,
+    last_failure_at timestamp with time zone NOT NULL
+);
+
+ALTER TABLE ONLY login_failures DROP CONSTRAINT IF EXISTS login_failures_pkey;
+ALTER TABLE ONLY login_failures
+    ADD CONSTRAINT login_failures_pkey PRIMARY KEY (key);
+`
+			_, err := tx.Exec(stmt)
+			return err
+		},
+	}
+}
+
+func addBackgroundJobs() *migrator.Migration {
+	return &migrator.Migration{
+		ID: "2023-02-06T10:00",
+		Migrate: func(tx migrator.DB) error {
+			stmt := `
+CREATE TABLE IF NOT EXISTS background_jobs (
+    name text NOT NULL,
+    started_at timestamp with time zone NOT NULL,
+    duration bigint DEFAULT 0 NOT NULL,
+    error text DEFAULT ''::text NOT NULL,
+    hostname text DEFAULT ''::text NOT NULL
+);
+
+ALTER TABLE ONLY background_jobs DROP CONSTRAINT IF EXISTS background_jobs_pkey;
+ALTER TABLE ONLY background_jobs
+    ADD CONSTRAINT background_jobs_pkey PRIMARY KEY (name);
+`
+			_, err := tx.Exec(stmt)
+			return err
+		},
+	}
+}
+
+// addRoleTemplatesUpdateIndex tracks changes to role templates with
+// update_index, and notifies grant listeners, so that a blocking list of grants
+// returns when a role template is created, updated, or deleted. Connectors
+// reconcile role templates when the grants change.
+func addRoleTemplatesUpdateIndex() *migrator.Migration {
+	return &migrator.Migration{
+		ID: "2023-02-08T10:00",
+		Migrate: func(tx migrator.DB) error {
+			stmt := `
+ALTER TABLE role_templates ADD COLUMN IF NOT EXISTS update_index bigint;
+UPDATE role_templates SET update_index = nextval('seq_update_index') WHERE update_index IS NULL;
+
+CREATE OR REPLACE FUNCTION role_templates_notify() RETURNS trigger
+	LANGUAGE PLPGSQL
+	AS $$
+BEGIN
+    IF TG_OP = 'UPDATE' AND
+        (to_jsonb(NEW) - 'update_index' - 'updated_at') = (to_jsonb(OLD) - 'update_index' - 'updated_at') THEN
+        RETURN NEW;
+    END IF;
+    NEW.update_index := nextval('seq_update_index');
+    PERFORM pg_notify(current_schema() || '.grants_' || NEW.organization_id,
+        json_build_object('roleTemplatesChanged', true)::text);
+    RETURN NEW;
+END; $$;
+
+DROP TRIGGER IF EXISTS role_templates_notify_trigger ON role_templates;
+
+CREATE TRIGGER role_templates_notify_trigger BEFORE INSERT OR UPDATE
+ON role_templates
+FOR EACH ROW EXECUTE FUNCTION role_templates_notify();
+`
+			_, err := tx.Exec(stmt)
+			return err
+		},
+	}
+}
+
+// addIdentityMembershipUpdateIndex tracks changes to the groups of a user with
+// membership_update_index, so that a blocking list of grants for a user returns
+// when the user is added to or removed from a group. The column is ignored by
+// the identities update_index trigger, so that a change of membership does not
+// notify identities listeners.
+func addIdentityMembershipUpdateIndex() *migrator.Migration {
+	return &migrator.Migration{
+		ID: "2023-02-09T10:00",
+		Migrate: func(tx migrator.DB) error {
+			stmt := `
+ALTER TABLE identities ADD COLUMN IF NOT EXISTS membership_update_index bigint;
+
+DROP TRIGGER IF EXISTS identities_update_index_trigger ON identities;
+
+CREATE TRIGGER identities_update_index_trigger BEFORE INSERT OR UPDATE
+ON identities
+FOR EACH ROW EXECUTE FUNCTION update_index_notify('updated_at', 'last_seen_at', 'membership_update_index');
+
+CREATE OR REPLACE FUNCTION identities_groups_notify() RETURNS trigger
+	LANGUAGE PLPGSQL
+	AS $$
+DECLARE
+    changed record;
+BEGIN
+    UPDATE identities SET membership_update_index = nextval('seq_update_index')
+        WHERE id IN (SELECT DISTINCT identity_id FROM changed_members);
+
+    FOR changed IN
+        UPDATE groups SET membership_update_index = nextval('seq_update_index')
+            WHERE id IN (SELECT DISTINCT group_id FROM changed_members)
+            RETURNING id, organization_id
+    LOOP
+        PERFORM pg_notify(current_schema() || '.grants_' || changed.organization_id,
+            json_build_object('membershipChanged', true, 'groupID', changed.id)::text);
+    END LOOP;
+    RETURN NULL;
+END; $$;
+`
+			_, err := tx.Exec(stmt)
+			return err
+		},
+	}
+}
@@ -1058,6 +1058,109 @@ INSERT INTO providers(id, name) VALUES (12345, 'okta');
 				// schema changes are tested with schema comparison
 			},
 		},
+		{
+			label: testCaseLine(addRoleTemplates().ID),
+			expected: func(t *testing.T, tx WriteTxn) {
+				// schema changes are tested with schema comparison
+			},
+		},
+		{
+			label: testCaseLine(addProxyPolicies().ID),
+			expected: func(t *testing.T, tx WriteTxn) {
+				// schema changes are tested with schema comparison
+			},
+		},
+		{
+			label: testCaseLine(addDestinationActivity().ID),
+			expected: func(t *testing.T, tx WriteTxn) {
+				// schema changes are tested with schema comparison
+			},
+		},
+		{
+			label: testCaseLine(addSessionRecordings().ID),
+			expected: func(t *testing.T, tx WriteTxn) {
+				// schema changes are tested with schema comparison
+			},
+		},
+		{
+			label: testCaseLine(addGroupMembershipUpdateIndex().ID),
+			expected: func(t *testing.T, tx WriteTxn) {
+				// schema changes are tested with schema comparison
+			},
+		},
+		{
+			label: testCaseLine(addUpdateIndexToWatchedTables().ID),
+			expected: func(t *testing.T, tx WriteTxn) {
+				// schema changes are tested with schema comparison
+			},
+		},
+		{
+			label: testCaseLine(addRateLimits().ID),
+			expected: func(t *testing.T, tx WriteTxn) {
+				// schema changes are tested with schema comparison
+			},
+		},
+		{
+			label: testCaseLine(addRateLimitTables().ID),
+			expected: func(t *testing.T, tx WriteTxn) {
+				// schema changes are tested with schema comparison
+			},
+		},
+		{
+			label: testCaseLine(addBackgroundJobs().ID),
+			expected: func(t *testing.T, tx WriteTxn) {
+				// schema changes are tested with schema comparison
+			},
+		},
+		{
+			label: testCaseLine(addRoleTemplatesUpdateIndex().ID),
+			setup: func(t *testing.T, tx WriteTxn) {
+				stmt := `
+INSERT INTO role_templates(id, organization_id, name, rules)
+VALUES (5001, 1000, 'debug', '[]');`
+				_, err := tx.Exec(stmt)
+				assert.NilError(t, err)
+			},
+			cleanup: func(t *testing.T, tx WriteTxn) {
+				_, err := tx.Exec(`DELETE FROM role_templates`)
+				assert.NilError(t, err)
+			},
+			expected: func(t *testing.T, tx WriteTxn) {
+				var index *int64
+				err := tx.QueryRow(`SELECT update_index FROM role_templates WHERE id = 5001`).Scan(&index)
+				assert.NilError(t, err)
+				assert.Assert(t, index != nil)
+			},
+		},
+		{
+			label: testCaseLine(addIdentityMembershipUpdateIndex().ID),
+			setup: func(t *testing.T, tx WriteTxn) {
+				stmt := `
+INSERT INTO identities(id, organization_id, name) VALUES (5101, 1000, 'member@example.com');
+INSERT INTO groups(id, organization_id, name) VALUES (5102, 1000, 'members');`
+				_, err := tx.Exec(stmt)
+				assert.NilError(t, err)
+			},
+			cleanup: func(t *testing.T, tx WriteTxn) {
+				_, err := tx.Exec(`DELETE FROM identities_groups; DELETE FROM groups; DELETE FROM identities WHERE id = 5101`)
+				assert.NilError(t, err)
+			},
+			expected: func(t *testing.T, tx WriteTxn) {
+				var before int64
+				err := tx.QueryRow(`SELECT update_index FROM identities WHERE id = 5101`).Scan(&before)
+				assert.NilError(t, err)
+
+				_, err = tx.Exec(`INSERT INTO identities_groups(identity_id, group_id) VALUES (5101, 5102)`)
+				assert.NilError(t, err)
+
+				var after int64
+				var membershipIndex *int64
+				err = tx.QueryRow(`SELECT update_index, membership_update_index FROM identities WHERE id = 5101`).Scan(&after, &membershipIndex)
+				assert.NilError(t, err)
+				assert.Equal(t, before, after)
+				assert.Assert(t, membershipIndex != nil)
+			},
+		},
 	}
 
 	ids := make(map[string]struct{}, len(testCases))
@@ -7,124 +7,485 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/cenkalti/backoff/v4"
 	"github.com/jackc/pgx/v4"
 	pgxstdlib "github.com/jackc/pgx/v4/stdlib"
+	"github.com/prometheus/client_golang/prometheus"
 
 	"github.com/infrahq/infra/internal/logging"
+	"github.com/infrahq/infra/internal/server/models"
 	"github.com/infrahq/infra/uid"
 )
 
-type Listener struct {
-	sqlDB   *sql.DB
-	pgxConn *pgx.Conn
+// NotifyHub listens for postgres notifications using a single database
+// connection, and delivers each notification to the listeners of the channel.
+// Listeners do not hold a database connection while they wait.
+//
+// Notifications are delivered without blocking. A listener that has not yet
+// received a previous notification does not receive another one, multiple
+// notifications are coalesced into one. Callers are expected to query for
+// changes after every notification.
+type NotifyHub struct {
+	sqlDB *sql.DB
 
-	isMatchingNotify func(payload string) error
+	mu       sync.Mutex
+	channels map[string]*notifyChannel
+	// dirty is true when a channel needs to be listened or unlistened.
+	dirty bool
+	// wake interrupts the wait for notifications so that the hub can update
+	// the channels it is listening on.
+	wake context.CancelFunc
+
+	stop context.CancelFunc
+	done chan struct{}
+
+	listeners     prometheus.Gauge
+	channelsGauge prometheus.Gauge
+	notifications *prometheus.CounterVec
+	reconnects    prometheus.Counter
 }
 
-var errNotificationNoMatch = fmt.Errorf("notification did not match")
+type notifyChannel struct {
+	listening bool
+	listeners map[*Listener]struct{}
+}
+
+func newNotifyHub(sqlDB *sql.DB) *NotifyHub {
+	ctx, cancel := context.WithCancel(context.Background())
+	hub := &NotifyHub{
+		sqlDB:    sqlDB,
+		channels: map[string]*notifyChannel{},
+		stop:     cancel,
+		done:     make(chan struct{}),
+		listeners: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: "infra",
+			Subsystem: "notify",
+			Name:      "listeners",
+			Help:      "The number of requests waiting for a postgres notification.",
+		}),
+		channelsGauge: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: "infra",
+			Subsystem: "notify",
+			Name:      "channels",
+			Help:      "The number of postgres channels being listened on.",
+		}),
+		notifications: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "infra",
+			Subsystem: "notify",
+			Name:      "notifications_total",
+			Help:      "The number of notifications, by the result of delivering them to a listener.",
+		}, []string{"result"}),
+		reconnects: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "infra",
+			Subsystem: "notify",
+			Name:      "reconnects_total",
+			Help:      "The number of times the notification connection was re-established.",
+		}),
+	}
+	go hub.run(ctx)
+	return hub
+}
+
+// Collectors returns the prometheus metrics for the hub.
+func (h *NotifyHub) Collectors() []prometheus.Collector {
+	return []prometheus.Collector{h.listeners, h.channelsGauge, h.notifications, h.reconnects}
+}
+
+// Close stops the hub. Any listeners waiting for a notification return an
+// error.
+func (h *NotifyHub) Close() {
+	h.stop()
+	<-h.done
+}
+
+func (h *NotifyHub) run(ctx context.Context) {
+	defer close(h.done)
+
+	backOff := &backoff.ExponentialBackOff{
+		InitialInterval:     100 * time.Millisecond,
+		MaxInterval:         10 * time.Second,
+		RandomizationFactor: 0.2,
+		Multiplier:          2,
+		Clock:               backoff.SystemClock,
+	}
+	backOff.Reset()
 
-// WaitForNotification blocks until the listener receivers a notification on
-// one of the channels, or until the context is cancelled.
-// Returns the notification on success, or an error on failure or timeout.
-func (l *Listener) WaitForNotification(ctx context.Context) error {
 	for {
-		notficaition, err := l.pgxConn.WaitForNotification(ctx)
-		if err != nil {
+		err := h.connectAndServe(ctx, backOff)
+		if ctx.Err() != nil {
+			return
+		}
+		logging.L.Warn().Err(err).Msg("postgres notification connection failed")
+		h.reset()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backOff.NextBackOff()):
+			h.reconnects.Inc()
+		}
+	}
+}
+
+// reset marks all channels as not listening, and wakes all listeners because
+// notifications may have been missed while the connection was down.
+func (h *NotifyHub) reset() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, ch := range h.channels {
+		ch.listening = false
+		for l := range ch.listeners {
+			l.deliver()
+		}
+	}
+	h.dirty = true
+	h.channelsGauge.Set(0)
+}
+
+func (h *NotifyHub) connectAndServe(ctx context.Context, backOff backoff.BackOff) error {
+	conn, err := pgxstdlib.AcquireConn(h.sqlDB)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		// The connection returns to the pool, so it must stop listening. ctx
+		// may already be done, so use a new context.
+		unlistenCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if !conn.IsClosed() {
+			if _, err := conn.Exec(unlistenCtx, "UNLISTEN *"); err != nil {
+				logging.L.Debug().Err(err).Msg("unlisten notification connection")
+				_ = conn.Close(unlistenCtx)
+			}
+		}
+		if err := pgxstdlib.ReleaseConn(h.sqlDB, conn); err != nil {
+			logging.L.Debug().Err(err).Msg("release notification connection")
+		}
+	}()
+
+	var schema string
+	if err := conn.QueryRow(ctx, "SELECT current_schema()").Scan(&schema); err != nil {
+		return err
+	}
+
+	for {
+		if err := h.updateChannels(ctx, conn, schema); err != nil {
 			return err
 		}
 
-		if l.isMatchingNotify == nil {
-			return nil
+		waitCtx, cancel := context.WithCancel(ctx)
+		h.mu.Lock()
+		if h.dirty {
+			cancel()
 		}
+		h.wake = cancel
+		h.mu.Unlock()
+
+		notification, err := conn.WaitForNotification(waitCtx)
+		cancel()
+
+		h.mu.Lock()
+		h.wake = nil
+		h.mu.Unlock()
 
-		err = l.isMatchingNotify(notficaition.Payload)
 		switch {
-		case errors.Is(err, errNotificationNoMatch):
-			continue
-		case err != nil:
-			return err
+		case err == nil:
+			backOff.Reset()
+			h.dispatch(schema, notification.Channel, notification.Payload)
+		case ctx.Err() != nil:
+			return ctx.Err()
+		case waitCtx.Err() != nil:
+			// woken to update the channels
 		default:
-			return nil
+			return err
 		}
 	}
 }
 
-func (l *Listener) Release(ctx context.Context) error {
-	var errs []error
-	logging.Debugf("unlisten *")
-	if _, err := l.pgxConn.Exec(ctx, `UNLISTEN *`); err != nil {
-		errs = append(errs, err)
+// updateChannels listens on any channels that have new listeners, and unlistens
+// from any channels that no longer have listeners.
+func (h *NotifyHub) updateChannels(ctx context.Context, conn *pgx.Conn, schema string) error {
+	h.mu.Lock()
+	h.dirty = false
+	var toListen, toUnlisten []string
+	for name, ch := range h.channels {
+		switch {
+		case len(ch.listeners) > 0 && !ch.listening:
+			toListen = append(toListen, name)
+		case len(ch.listeners) == 0 && ch.listening:
+			toUnlisten = append(toUnlisten, name)
+		case len(ch.listeners) == 0:
+			delete(h.channels, name)
+		}
 	}
-	if err := pgxstdlib.ReleaseConn(l.sqlDB, l.pgxConn); err != nil {
-		errs = append(errs, err)
+	h.mu.Unlock()
+
+	for _, name := range toListen {
+		logging.Debugf("listen for notify on %s", name)
+		if _, err := conn.Exec(ctx, "SELECT listen_on_chan($1)", name); err != nil {
+			return err
+		}
+
+		h.mu.Lock()
+		ch := h.channels[name]
+		ch.listening = true
+		for l := range ch.listeners {
+			l.markReady()
+		}
+		h.channelsGauge.Inc()
+		h.mu.Unlock()
 	}
-	if len(errs) > 0 {
-		return fmt.Errorf("failed to unlisten to postgres channels: %v", errs)
+
+	for _, name := range toUnlisten {
+		logging.Debugf("unlisten for notify on %s", name)
+		stmt := "UNLISTEN " + pgx.Identifier{schema + "." + name}.Sanitize()
+		if _, err := conn.Exec(ctx, stmt); err != nil {
+			return err
+		}
+
+		h.mu.Lock()
+		ch := h.channels[name]
+		ch.listening = false
+		if len(ch.listeners) == 0 {
+			delete(h.channels, name)
+		} else {
+			// a new listener was added while unlistening
+			h.dirty = true
+		}
+		h.channelsGauge.Dec()
+		h.mu.Unlock()
 	}
 	return nil
 }
 
+func (h *NotifyHub) dispatch(schema string, channel string, payload string) {
+	name := strings.TrimPrefix(channel, schema+".")
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	ch, ok := h.channels[name]
+	if !ok {
+		return
+	}
+	for l := range ch.listeners {
+		if l.isMatchingNotify != nil {
+			err := l.isMatchingNotify(payload)
+			switch {
+			case errors.Is(err, errNotificationNoMatch):
+				h.notifications.WithLabelValues("unmatched").Inc()
+				continue
+			case err != nil:
+				logging.L.Warn().Err(err).Str("channel", name).Msg("invalid notification payload")
+			}
+		}
+		if l.deliver() {
+			h.notifications.WithLabelValues("delivered").Inc()
+		} else {
+			h.notifications.WithLabelValues("coalesced").Inc()
+		}
+	}
+}
+
+// subscribe adds a listener for the channel, and blocks until the hub is
+// listening on the channel, so that no notification sent after subscribe
+// returns is missed.
+func (h *NotifyHub) subscribe(ctx context.Context, channel string, isMatching func(string) error) (*Listener, error) {
+	l := &Listener{
+		hub:              h,
+		channel:          channel,
+		ready:            make(chan struct{}),
+		notify:           make(chan struct{}, 1),
+		isMatchingNotify: isMatching,
+	}
+
+	h.mu.Lock()
+	ch, ok := h.channels[channel]
+	if !ok {
+		ch = &notifyChannel{listeners: map[*Listener]struct{}{}}
+		h.channels[channel] = ch
+	}
+	ch.listeners[l] = struct{}{}
+	if ch.listening {
+		l.markReady()
+	} else {
+		h.dirty = true
+		if h.wake != nil {
+			h.wake()
+		}
+	}
+	h.listeners.Inc()
+	h.mu.Unlock()
+
+	select {
+	case <-l.ready:
+		return l, nil
+	case <-h.done:
+		l.Release()
+		return nil, errNotifyHubClosed
+	case <-ctx.Done():
+		l.Release()
+		return nil, ctx.Err()
+	}
+}
+
+func (h *NotifyHub) unsubscribe(l *Listener) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	ch, ok := h.channels[l.channel]
+	if !ok {
+		return
+	}
+	if _, ok := ch.listeners[l]; !ok {
+		return
+	}
+	delete(ch.listeners, l)
+	h.listeners.Dec()
+	if len(ch.listeners) == 0 {
+		h.dirty = true
+		if h.wake != nil {
+			h.wake()
+		}
+	}
+}
+
+var errNotifyHubClosed = fmt.Errorf("notification hub is closed")
+
+// Listener receives notifications for a single channel from a NotifyHub.
+type Listener struct {
+	hub     *NotifyHub
+	channel string
+
+	readyOnce sync.Once
+	ready     chan struct{}
+	notify    chan struct{}
+
+	isMatchingNotify func(payload string) error
+}
+
+func (l *Listener) markReady() {
+	l.readyOnce.Do(func() {
+		close(l.ready)
+	})
+}
+
+// deliver sends a notification to the listener without blocking. Returns false
+// if the listener already had a pending notification.
+func (l *Listener) deliver() bool {
+	select {
+	case l.notify <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+var errNotificationNoMatch = fmt.Errorf("notification did not match")
+
+// WaitForNotification blocks until the listener receives a notification on
+// the channel, or until the context is cancelled.
+func (l *Listener) WaitForNotification(ctx context.Context) error {
+	select {
+	case <-l.notify:
+		return nil
+	case <-l.hub.done:
+		return errNotifyHubClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Release stops the listener from receiving notifications.
+func (l *Listener) Release() {
+	l.hub.unsubscribe(l)
+}
+
 type ListenForNotifyOptions struct {
 	OrgID                                 uid.ID
 	GrantsByDestination                   string
 	DestinationCredentialsByDestinationID uid.ID
 	DestinationCredentialsByID            uid.ID
+	// GrantsByUser listens for changes to the grants of the user, the grants
+	// of GrantsByUserGroups, and to group membership. Changes to the groups of
+	// the user are not known in advance, so every group membership change
+	// matches.
+	GrantsByUser       uid.ID
+	GrantsByUserGroups []uid.ID
+	// UpdatesToTable listens for any write to the table, see WatchTable.
+	UpdatesToTable WatchTable
 }
 
-// ListenForNotify starts listening for notification on one or more
-// postgres channels for notifications that a grant has changed. The channels to
-// listen on are determined by opts. Use Listener.WaitForNotification to block
-// and receive notifications.
+// ListenForNotify starts listening for notification on a postgres channel.
+// The channel to listen on is determined by opts. Use
+// Listener.WaitForNotification to block and receive notifications.
 //
-// If error is nil the caller must call Listener.Release to return the database
-// connection to the pool.
+// The listener does not hold a database connection, notifications are received
+// by the NotifyHub of db. If error is nil the caller must call Listener.Release
+// to stop receiving notifications.
 func ListenForNotify(ctx context.Context, db *DB, opts ListenForNotifyOptions) (*Listener, error) {
 	if opts.OrgID == 0 {
 		return nil, fmt.Errorf("OrgID is required")
 	}
 
-	sqlDB := db.SQLdb()
-	pgxConn, err := pgxstdlib.AcquireConn(sqlDB)
-	if err != nil {
-		return nil, err
-	}
-
-	listener := &Listener{sqlDB: sqlDB, pgxConn: pgxConn}
-
 	var channel string
+	var isMatching func(payload string) error
 	switch {
 	case opts.GrantsByDestination != "":
 		channel = fmt.Sprintf("grants_%d", opts.OrgID)
-	case opts.DestinationCredentialsByDestinationID != 0:
-		channel = fmt.Sprintf("credreq_%s_%s", opts.OrgID.String(), opts.DestinationCredentialsByDestinationID.String())
-	case opts.DestinationCredentialsByID != 0:
-		channel = fmt.Sprintf("credans_%s_%s", opts.OrgID.String(), opts.DestinationCredentialsByID.String())
-	}
-
-	logging.Debugf("listing for notify on %s", channel)
-	_, err = pgxConn.Exec(ctx, "SELECT listen_on_chan($1)", channel)
-	if err != nil {
-		if err := pgxstdlib.ReleaseConn(sqlDB, pgxConn); err != nil {
-			logging.L.Warn().Err(err).Msgf("release pgx conn")
-		}
-		return nil, err
-	}
-
-	switch {
-	case opts.GrantsByDestination != "":
-		listener.isMatchingNotify = func(payload string) error {
+		isMatching = func(payload string) error {
 			var grant grantJSON
 			err := json.Unmarshal([]byte(payload), &grant)
 			if err != nil {
 				return err
 			}
+			if grant.MembershipChanged || grant.RoleTemplatesChanged {
+				return nil
+			}
 			destination, _, _ := strings.Cut(grant.Resource, ".")
 			if destination != opts.GrantsByDestination {
 				return errNotificationNoMatch
 			}
 			return nil
 		}
+	case opts.GrantsByUser != 0:
+		channel = fmt.Sprintf("grants_%d", opts.OrgID)
+		groups := make(map[int64]bool, len(opts.GrantsByUserGroups))
+		for _, id := range opts.GrantsByUserGroups {
+			groups[int64(id)] = true
+		}
+		isMatching = func(payload string) error {
+			var grant grantJSON
+			err := json.Unmarshal([]byte(payload), &grant)
+			if err != nil {
+				return err
+			}
+			switch {
+			case grant.MembershipChanged:
+				return nil
+			case grant.SubjectKind == models.SubjectKindUser && grant.SubjectID == int64(opts.GrantsByUser):
+				return nil
+			case grant.SubjectKind == models.SubjectKindGroup && groups[grant.SubjectID]:
+				return nil
+			}
+			return errNotificationNoMatch
+		}
+	case opts.DestinationCredentialsByDestinationID != 0:
+		channel = fmt.Sprintf("credreq_%s_%s", opts.OrgID.String(), opts.DestinationCredentialsByDestinationID.String())
+	case opts.DestinationCredentialsByID != 0:
+		channel = fmt.Sprintf("credans_%s_%s", opts.OrgID.String(), opts.DestinationCredentialsByID.String())
+	case opts.UpdatesToTable != "":
+		if _, err := opts.UpdatesToTable.table(); err != nil {
+			return nil, err
+		}
+		channel = fmt.Sprintf("%s_%d", opts.UpdatesToTable, opts.OrgID)
+	default:
+		return nil, fmt.Errorf("a channel is required to listen for notify")
 	}
-	return listener, nil
+
+	return db.NotifyHub().subscribe(ctx, channel, isMatching)
 }
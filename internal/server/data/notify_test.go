@@ -0,0 +1,112 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"gotest.tools/v3/assert"
+
+	"github.com/infrahq/infra/internal/server/models"
+)
+
+func TestNotifyHub(t *testing.T) {
+	runDBTests(t, func(t *testing.T, db *DB) {
+		ctx := context.Background()
+		hub := db.NotifyHub()
+
+		listen := func(t *testing.T, destination string) *Listener {
+			t.Helper()
+			listener, err := ListenForNotify(ctx, db, ListenForNotifyOptions{
+				GrantsByDestination: destination,
+				OrgID:               defaultOrganizationID,
+			})
+			assert.NilError(t, err)
+			t.Cleanup(listener.Release)
+			return listener
+		}
+
+		createGrant := func(t *testing.T, resource string) {
+			t.Helper()
+			tx := txnForTestCase(t, db, db.DefaultOrg.ID)
+			g := models.Grant{
+				Subject:   models.NewSubjectForUser(1234567),
+				Privilege: "view",
+				Resource:  resource,
+			}
+			assert.NilError(t, CreateGrant(tx, &g))
+			assert.NilError(t, tx.Commit())
+		}
+
+		t.Run("fan out to listeners on the same channel", func(t *testing.T) {
+			first := listen(t, "fanout")
+			second := listen(t, "fanout")
+			other := listen(t, "other")
+			assert.Equal(t, testutil.ToFloat64(hub.listeners), float64(3))
+			assert.Equal(t, testutil.ToFloat64(hub.channelsGauge), float64(1))
+
+			createGrant(t, "fanout.ns1")
+
+			ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+			defer cancel()
+			assert.NilError(t, first.WaitForNotification(ctx))
+			assert.NilError(t, second.WaitForNotification(ctx))
+
+			// the grant was for a different destination
+			ctx, cancel = context.WithTimeout(ctx, 100*time.Millisecond)
+			defer cancel()
+			err := other.WaitForNotification(ctx)
+			assert.Assert(t, errors.Is(err, context.DeadlineExceeded), err)
+		})
+
+		t.Run("notifications are coalesced", func(t *testing.T) {
+			listener := listen(t, "coalesce")
+
+			createGrant(t, "coalesce.ns1")
+			createGrant(t, "coalesce.ns2")
+
+			// wait for the hub to dispatch both notifications before reading
+			time.Sleep(200 * time.Millisecond)
+			assert.Assert(t, testutil.ToFloat64(hub.notifications.WithLabelValues("coalesced")) >= 1)
+
+			ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+			defer cancel()
+			assert.NilError(t, listener.WaitForNotification(ctx))
+
+			ctx, cancel = context.WithTimeout(ctx, 100*time.Millisecond)
+			defer cancel()
+			err := listener.WaitForNotification(ctx)
+			assert.Assert(t, errors.Is(err, context.DeadlineExceeded), err)
+		})
+
+		t.Run("grants by user", func(t *testing.T) {
+			listener, err := ListenForNotify(ctx, db, ListenForNotifyOptions{
+				GrantsByUser: 1234567,
+				OrgID:        defaultOrganizationID,
+			})
+			assert.NilError(t, err)
+			t.Cleanup(listener.Release)
+
+			createGrant(t, "anydestination.ns1")
+
+			ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+			defer cancel()
+			assert.NilError(t, listener.WaitForNotification(ctx))
+		})
+
+		t.Run("release removes the listener", func(t *testing.T) {
+			before := testutil.ToFloat64(hub.listeners)
+			listener, err := ListenForNotify(ctx, db, ListenForNotifyOptions{
+				GrantsByDestination: "release",
+				OrgID:               defaultOrganizationID,
+			})
+			assert.NilError(t, err)
+			listener.Release()
+			// a second release is a no-op
+			listener.Release()
+			assert.Equal(t, testutil.ToFloat64(hub.listeners), before)
+		})
+	})
+}
@@ -1,12 +1,33 @@
 package data
 
-import "github.com/infrahq/infra/internal/server/data/querybuilder"
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	"github.com/infrahq/infra/internal"
+	"github.com/infrahq/infra/internal/server/data/querybuilder"
+	"github.com/infrahq/infra/uid"
+)
 
 // Internal Pagination Data
 type Pagination struct {
 	Page       int
 	Limit      int
 	TotalCount int
+
+	// Cursor is the opaque value of NextCursor from a previous page. When
+	// Cursor is set list functions that support cursors return the items
+	// after the cursor, and Page and TotalCount are not used. List functions
+	// that do not support cursors ignore this field.
+	Cursor string
+	// NextCursor is set by list functions that support cursors when the
+	// page is full, which means there may be more results.
+	NextCursor string
+
+	// keyset is true when the query uses a WHERE clause from Cursor instead
+	// of an offset.
+	keyset bool
 }
 
 func (p *Pagination) SetTotalCount(count int) {
@@ -19,9 +40,60 @@ func (p *Pagination) PaginateQuery(query *querybuilder.Query) {
 	if p.Limit == 0 {
 		return
 	}
+	if p.keyset {
+		query.B("LIMIT ?", p.Limit)
+		return
+	}
 	if p.Page == 0 {
 		p.Page = 1
 	}
 	offset := p.Limit * (p.Page - 1)
 	query.B("LIMIT ? OFFSET ?", p.Limit, offset)
 }
+
+// includeCount returns true if the query should select count(*) OVER() into
+// TotalCount. The count is expensive on large tables, so it is not used with
+// cursor pagination.
+func (p *Pagination) includeCount() bool {
+	return p != nil && p.Cursor == ""
+}
+
+// keysetCursor identifies the last item of a page. Value is the value of the
+// column used to sort the results, and is empty when the results are sorted
+// only by ID.
+type keysetCursor struct {
+	Value string `json:"v,omitempty"`
+	ID    uid.ID `json:"id"`
+}
+
+// cursor returns the decoded Cursor, or nil if Cursor is not set. List
+// functions that support cursors must call cursor before PaginateQuery.
+func (p *Pagination) cursor() (*keysetCursor, error) {
+	if p == nil || p.Cursor == "" {
+		return nil, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(p.Cursor)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid cursor", internal.ErrBadRequest)
+	}
+	var c keysetCursor
+	if err := json.Unmarshal(raw, &c); err != nil || c.ID == 0 {
+		return nil, fmt.Errorf("%w: invalid cursor", internal.ErrBadRequest)
+	}
+	p.keyset = true
+	return &c, nil
+}
+
+// setNextCursor sets NextCursor to identify the last item when the page is
+// full. count is the number of items on the page.
+func (p *Pagination) setNextCursor(count int, last keysetCursor) {
+	if p == nil || p.Limit == 0 || count < p.Limit {
+		return
+	}
+	raw, err := json.Marshal(last)
+	if err != nil {
+		// marshaling a string and an integer can not fail
+		panic(err)
+	}
+	p.NextCursor = base64.RawURLEncoding.EncodeToString(raw)
+}
@@ -0,0 +1,116 @@
+package data
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/infrahq/infra/internal/server/data/querybuilder"
+	"github.com/infrahq/infra/internal/server/models"
+	"github.com/infrahq/infra/uid"
+)
+
+type proxyPoliciesTable models.ProxyPolicy
+
+func (p proxyPoliciesTable) Table() string {
+	return "proxy_policies"
+}
+
+func (p proxyPoliciesTable) Columns() []string {
+	return []string{"created_at", "deleted_at", "destination_name", "id", "name", "organization_id", "reason", "rule", "updated_at"}
+}
+
+func (p proxyPoliciesTable) Values() []any {
+	return []any{p.CreatedAt, p.DeletedAt, p.DestinationName, p.ID, p.Name, p.OrganizationID, p.Reason, p.Rule, p.UpdatedAt}
+}
+
+func (p *proxyPoliciesTable) ScanFields() []any {
+	return []any{&p.CreatedAt, &p.DeletedAt, &p.DestinationName, &p.ID, &p.Name, &p.OrganizationID, &p.Reason, &p.Rule, &p.UpdatedAt}
+}
+
+func validateProxyPolicy(policy *models.ProxyPolicy) error {
+	if policy.Name == "" {
+		return fmt.Errorf("ProxyPolicy.Name is required")
+	}
+	return nil
+}
+
+func CreateProxyPolicy(tx WriteTxn, policy *models.ProxyPolicy) error {
+	if err := validateProxyPolicy(policy); err != nil {
+		return err
+	}
+	return insert(tx, (*proxyPoliciesTable)(policy))
+}
+
+func UpdateProxyPolicy(tx WriteTxn, policy *models.ProxyPolicy) error {
+	if err := validateProxyPolicy(policy); err != nil {
+		return err
+	}
+	return update(tx, (*proxyPoliciesTable)(policy))
+}
+
+func GetProxyPolicy(tx ReadTxn, id uid.ID) (*models.ProxyPolicy, error) {
+	policy := &proxyPoliciesTable{}
+	query := querybuilder.New("SELECT")
+	query.B(columnsForSelect(policy))
+	query.B("FROM proxy_policies")
+	query.B("WHERE deleted_at is null")
+	query.B("AND organization_id = ?", tx.OrganizationID())
+	query.B("AND id = ?", id)
+
+	err := tx.QueryRow(query.String(), query.Args...).Scan(policy.ScanFields()...)
+	if err != nil {
+		return nil, handleError(err)
+	}
+	return (*models.ProxyPolicy)(policy), nil
+}
+
+type ListProxyPoliciesOptions struct {
+	// ByDestination instructs ListProxyPolicies to return the policies for
+	// the destination with this name, and the policies that apply to all
+	// destinations.
+	ByDestination string
+
+	Pagination *Pagination
+}
+
+func ListProxyPolicies(tx ReadTxn, opts ListProxyPoliciesOptions) ([]models.ProxyPolicy, error) {
+	table := &proxyPoliciesTable{}
+	query := querybuilder.New("SELECT")
+	query.B(columnsForSelect(table))
+	if opts.Pagination != nil {
+		query.B(", count(*) OVER()")
+	}
+	query.B("FROM proxy_policies")
+	query.B("WHERE deleted_at is null")
+	query.B("AND organization_id = ?", tx.OrganizationID())
+
+	if opts.ByDestination != "" {
+		query.B("AND (destination_name = ? OR destination_name = '')", opts.ByDestination)
+	}
+
+	query.B("ORDER BY name")
+	if opts.Pagination != nil {
+		opts.Pagination.PaginateQuery(query)
+	}
+
+	rows, err := tx.Query(query.String(), query.Args...)
+	if err != nil {
+		return nil, err
+	}
+	return scanRows(rows, func(policy *models.ProxyPolicy) []any {
+		fields := (*proxyPoliciesTable)(policy).ScanFields()
+		if opts.Pagination != nil {
+			fields = append(fields, &opts.Pagination.TotalCount)
+		}
+		return fields
+	})
+}
+
+func DeleteProxyPolicy(tx WriteTxn, id uid.ID) error {
+	stmt := `
+		UPDATE proxy_policies SET deleted_at = ?
+		WHERE id = ? AND organization_id = ? AND deleted_at is null
+	`
+	_, err := tx.Exec(stmt, time.Now(), id, tx.OrganizationID())
+	return handleError(err)
+}
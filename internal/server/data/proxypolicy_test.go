@@ -0,0 +1,66 @@
+package data
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+
+	"github.com/infrahq/infra/api"
+	"github.com/infrahq/infra/internal"
+	"github.com/infrahq/infra/internal/server/models"
+)
+
+func TestProxyPolicies(t *testing.T) {
+	runDBTests(t, func(t *testing.T, db *DB) {
+		tx := txnForTestCase(t, db, db.DefaultOrg.ID)
+
+		all := &models.ProxyPolicy{
+			Name:   "no-exec",
+			Reason: "exec is not allowed",
+			Rule: models.ProxyPolicyRule{
+				Resources:    []string{"pods"},
+				Subresources: []string{"exec"},
+				Schedule:     &api.PolicySchedule{Start: "09:00", End: "17:00", Outside: true},
+			},
+		}
+		production := &models.ProxyPolicy{
+			Name:            "production-secrets",
+			DestinationName: "production",
+			Rule:            models.ProxyPolicyRule{Resources: []string{"secrets"}},
+		}
+		assert.NilError(t, CreateProxyPolicy(tx, all))
+		assert.NilError(t, CreateProxyPolicy(tx, production))
+
+		t.Run("get", func(t *testing.T) {
+			actual, err := GetProxyPolicy(tx, all.ID)
+			assert.NilError(t, err)
+			assert.DeepEqual(t, actual, all, cmpModel)
+		})
+
+		t.Run("list by destination", func(t *testing.T) {
+			actual, err := ListProxyPolicies(tx, ListProxyPoliciesOptions{ByDestination: "production"})
+			assert.NilError(t, err)
+			assert.DeepEqual(t, actual, []models.ProxyPolicy{*all, *production}, cmpModel)
+
+			actual, err = ListProxyPolicies(tx, ListProxyPoliciesOptions{ByDestination: "staging"})
+			assert.NilError(t, err)
+			assert.DeepEqual(t, actual, []models.ProxyPolicy{*all}, cmpModel)
+		})
+
+		t.Run("update", func(t *testing.T) {
+			production.Reason = "secrets are read only"
+			assert.NilError(t, UpdateProxyPolicy(tx, production))
+
+			actual, err := GetProxyPolicy(tx, production.ID)
+			assert.NilError(t, err)
+			assert.Equal(t, actual.Reason, "secrets are read only")
+		})
+
+		t.Run("delete", func(t *testing.T) {
+			assert.NilError(t, DeleteProxyPolicy(tx, production.ID))
+
+			_, err := GetProxyPolicy(tx, production.ID)
+			assert.ErrorIs(t, err, internal.ErrNotFound)
+		})
+	})
+}
@@ -0,0 +1,157 @@
+package data
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/infrahq/infra/internal/logging"
+	"github.com/infrahq/infra/internal/server/ratelimit"
+)
+
+// RateLimiter is a ratelimit.Limiter that stores counts in the rate_limits
+// and login_failures tables. The counts are shared by all the replicas that
+// use the same database.
+type RateLimiter struct {
+	db  *DB
+	now func() time.Time
+}
+
+var _ ratelimit.Limiter = (*RateLimiter)(nil)
+
+func NewRateLimiter(db *DB) *RateLimiter {
+	return &RateLimiter{db: db, now: time.Now}
+}
+
+func (lim *RateLimiter) Rate(key string, limit int) (ratelimit.Result, error) {
+	return lim.allowN(key, limit, 1)
+}
+
+func (lim *RateLimiter) Usage(key string, limit int) (ratelimit.Result, error) {
+	return lim.allowN(key, limit, 0)
+}
+
+func (lim *RateLimiter) allowN(key string, limit int, n int) (ratelimit.Result, error) {
+	now := lim.now().UTC()
+	start := ratelimit.WindowStart(now)
+	sw := ratelimit.SlidingWindow{Elapsed: now.Sub(start)}
+
+	// the previous window has ended, so its count no longer changes
+	previous, err := lim.windowCount(key, start.Add(-ratelimit.Window))
+	if err != nil {
+		return ratelimit.Result{}, err
+	}
+	sw.Previous = previous
+
+	if maxCount := sw.MaxCurrent(limit); n > 0 && n <= maxCount {
+		// Count the requests with a single statement, without a transaction
+		// or a row lock. The count is only incremented when the result is
+		// within the limit, so concurrent requests from other replicas can
+		// not exceed the limit.
+		var count int
+		err := lim.db.QueryRow(`
+			INSERT INTO rate_limits (key, window_start, count) VALUES (?, ?, ?)
+			ON CONFLICT (key, window_start) DO UPDATE
+			SET count = rate_limits.count + excluded.count
+			WHERE rate_limits.count + excluded.count <= ?
+			RETURNING rate_limits.count`, key, start, n, maxCount).Scan(&count)
+		switch {
+		case err == nil:
+			sw.Current = count - n
+			return sw.Allow(limit, n)
+		case !errors.Is(err, sql.ErrNoRows):
+			return ratelimit.Result{}, handleError(err)
+		}
+		// no rows were updated because the requests would exceed the limit
+	}
+
+	current, err := lim.windowCount(key, start)
+	if err != nil {
+		return ratelimit.Result{}, err
+	}
+	sw.Current = current
+	return sw.Allow(limit, n)
+}
+
+// windowCount returns the count of requests for key in the window that starts
+// at start.
+func (lim *RateLimiter) windowCount(key string, start time.Time) (int, error) {
+	var count int
+	err := lim.db.QueryRow(`SELECT count FROM rate_limits WHERE key = ? AND window_start = ?`,
+		key, start).Scan(&count)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return 0, nil
+	case err != nil:
+		return 0, handleError(err)
+	}
+	return count, nil
+}
+
+func (lim *RateLimiter) LoginOK(key string) error {
+	var lockedUntil sql.NullTime
+	err := lim.db.QueryRow(`SELECT locked_until FROM login_failures WHERE key = ?`, key).Scan(&lockedUntil)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		// no previous failures
+		return nil
+	case err != nil:
+		return handleError(err)
+	}
+
+	if retryAfter := lockedUntil.Time.Sub(lim.now()); lockedUntil.Valid && retryAfter > 0 {
+		return ratelimit.OverLimitError{RetryAfter: retryAfter}
+	}
+	return nil
+}
+
+func (lim *RateLimiter) LoginGood(key string) {
+	if _, err := lim.db.Exec(`DELETE FROM login_failures WHERE key = ?`, key); err != nil {
+		logging.L.Error().Err(err).Msg("could not reset lockout timer")
+	}
+}
+
+func (lim *RateLimiter) LoginBad(key string, limit int) {
+	now := lim.now().UTC()
+
+	var failures int
+	err := lim.db.QueryRow(`
+		INSERT INTO login_failures (key, failures, last_failure_at) VALUES (?, 1, ?)
+		ON CONFLICT (key) DO UPDATE
+		SET failures = login_failures.failures + 1, last_failure_at = excluded.last_failure_at
+		RETURNING failures`, key, now).Scan(&failures)
+	if err != nil {
+		logging.L.Error().Err(err).Msg("could not increment lockout timer")
+		return
+	}
+
+	if failures < limit {
+		return
+	}
+
+	// only set the lockout when there is no active lockout
+	lockout := now.Add(ratelimit.LockoutDuration(failures))
+	_, err = lim.db.Exec(`UPDATE login_failures SET locked_until = ?
+		WHERE key = ? AND (locked_until IS NULL OR locked_until <= ?)`, lockout, key, now)
+	if err != nil {
+		logging.L.Error().Err(err).Msg("could not set lockout timer")
+	}
+}
+
+// loginFailureRetention is how long failed login attempts are remembered after
+// the last failed attempt.
+const loginFailureRetention = 24 * time.Hour
+
+// DeleteExpiredRateLimits removes the rate limit windows that are no longer
+// used, and the failed login attempts that have expired.
+func DeleteExpiredRateLimits(tx WriteTxn) error {
+	now := time.Now().UTC()
+	_, err := tx.Exec(`DELETE FROM rate_limits WHERE window_start < ?`,
+		ratelimit.WindowStart(now).Add(-ratelimit.Window))
+	if err != nil {
+		return handleError(err)
+	}
+	_, err = tx.Exec(`DELETE FROM login_failures WHERE last_failure_at < ?`,
+		now.Add(-loginFailureRetention))
+	return handleError(err)
+}
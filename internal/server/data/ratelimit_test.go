@@ -0,0 +1,57 @@
+package data
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+
+	"github.com/infrahq/infra/internal/server/ratelimit"
+	"github.com/infrahq/infra/internal/server/ratelimit/ratelimittest"
+)
+
+func TestRateLimiter(t *testing.T) {
+	runDBTests(t, func(t *testing.T, db *DB) {
+		ratelimittest.TestLimiter(t, func(t *testing.T) (ratelimit.Limiter, func(time.Duration)) {
+			_, err := db.Exec(`DELETE FROM rate_limits; DELETE FROM login_failures`)
+			assert.NilError(t, err)
+
+			now := time.Date(2023, 2, 3, 10, 20, 30, 0, time.UTC)
+			lim := NewRateLimiter(db)
+			lim.now = func() time.Time { return now }
+			return lim, func(d time.Duration) { now = now.Add(d) }
+		})
+	})
+}
+
+func TestDeleteExpiredRateLimits(t *testing.T) {
+	runDBTests(t, func(t *testing.T, db *DB) {
+		now := time.Now().UTC()
+		start := ratelimit.WindowStart(now)
+
+		_, err := db.Exec(`INSERT INTO rate_limits (key, window_start, count)
+			VALUES ('current', ?, 1), ('previous', ?, 1), ('expired', ?, 1)`,
+			start, start.Add(-ratelimit.Window), start.Add(-2*ratelimit.Window))
+		assert.NilError(t, err)
+
+		_, err = db.Exec(`INSERT INTO login_failures (key, failures, last_failure_at)
+			VALUES ('recent', 1, ?), ('expired', 1, ?)`,
+			now.Add(-time.Hour), now.Add(-25*time.Hour))
+		assert.NilError(t, err)
+
+		err = DeleteExpiredRateLimits(db)
+		assert.NilError(t, err)
+
+		rows, err := db.Query(`SELECT key FROM rate_limits ORDER BY key`)
+		assert.NilError(t, err)
+		keys, err := scanRows(rows, func(key *string) []any { return []any{key} })
+		assert.NilError(t, err)
+		assert.DeepEqual(t, keys, []string{"current", "previous"})
+
+		rows, err = db.Query(`SELECT key FROM login_failures ORDER BY key`)
+		assert.NilError(t, err)
+		keys, err = scanRows(rows, func(key *string) []any { return []any{key} })
+		assert.NilError(t, err)
+		assert.DeepEqual(t, keys, []string{"recent"})
+	})
+}
@@ -0,0 +1,132 @@
+package data
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/infrahq/infra/internal/server/data/querybuilder"
+	"github.com/infrahq/infra/internal/server/models"
+	"github.com/infrahq/infra/uid"
+)
+
+type roleTemplatesTable models.RoleTemplate
+
+func (r roleTemplatesTable) Table() string {
+	return "role_templates"
+}
+
+func (r roleTemplatesTable) Columns() []string {
+	return []string{"created_at", "deleted_at", "id", "name", "organization_id", "rules", "updated_at"}
+}
+
+func (r roleTemplatesTable) Values() []any {
+	return []any{r.CreatedAt, r.DeletedAt, r.ID, r.Name, r.OrganizationID, r.Rules, r.UpdatedAt}
+}
+
+func (r *roleTemplatesTable) ScanFields() []any {
+	return []any{&r.CreatedAt, &r.DeletedAt, &r.ID, &r.Name, &r.OrganizationID, &r.Rules, &r.UpdatedAt}
+}
+
+func validateRoleTemplate(template *models.RoleTemplate) error {
+	switch {
+	case template.Name == "":
+		return fmt.Errorf("RoleTemplate.Name is required")
+	case len(template.Rules) == 0:
+		return fmt.Errorf("RoleTemplate.Rules is required")
+	}
+	return nil
+}
+
+func CreateRoleTemplate(tx WriteTxn, template *models.RoleTemplate) error {
+	if err := validateRoleTemplate(template); err != nil {
+		return err
+	}
+	return insert(tx, (*roleTemplatesTable)(template))
+}
+
+func UpdateRoleTemplate(tx WriteTxn, template *models.RoleTemplate) error {
+	if err := validateRoleTemplate(template); err != nil {
+		return err
+	}
+	return update(tx, (*roleTemplatesTable)(template))
+}
+
+type GetRoleTemplateOptions struct {
+	// ByID instructs GetRoleTemplate to return the row matching this ID. When
+	// this value is set, all other fields on this struct will be ignored.
+	ByID uid.ID
+	// ByName instructs GetRoleTemplate to return the row matching this name.
+	ByName string
+}
+
+func GetRoleTemplate(tx ReadTxn, opts GetRoleTemplateOptions) (*models.RoleTemplate, error) {
+	template := &roleTemplatesTable{}
+	query := querybuilder.New("SELECT")
+	query.B(columnsForSelect(template))
+	query.B("FROM role_templates")
+	query.B("WHERE deleted_at is null")
+	query.B("AND organization_id = ?", tx.OrganizationID())
+
+	switch {
+	case opts.ByID != 0:
+		query.B("AND id = ?", opts.ByID)
+	case opts.ByName != "":
+		query.B("AND name = ?", opts.ByName)
+	default:
+		return nil, fmt.Errorf("an ID or name is required to GetRoleTemplate")
+	}
+
+	err := tx.QueryRow(query.String(), query.Args...).Scan(template.ScanFields()...)
+	if err != nil {
+		return nil, handleError(err)
+	}
+	return (*models.RoleTemplate)(template), nil
+}
+
+type ListRoleTemplatesOptions struct {
+	ByName string
+
+	Pagination *Pagination
+}
+
+func ListRoleTemplates(tx ReadTxn, opts ListRoleTemplatesOptions) ([]models.RoleTemplate, error) {
+	table := &roleTemplatesTable{}
+	query := querybuilder.New("SELECT")
+	query.B(columnsForSelect(table))
+	if opts.Pagination != nil {
+		query.B(", count(*) OVER()")
+	}
+	query.B("FROM role_templates")
+	query.B("WHERE deleted_at is null")
+	query.B("AND organization_id = ?", tx.OrganizationID())
+
+	if opts.ByName != "" {
+		query.B("AND name = ?", opts.ByName)
+	}
+
+	query.B("ORDER BY name")
+	if opts.Pagination != nil {
+		opts.Pagination.PaginateQuery(query)
+	}
+
+	rows, err := tx.Query(query.String(), query.Args...)
+	if err != nil {
+		return nil, err
+	}
+	return scanRows(rows, func(template *models.RoleTemplate) []any {
+		fields := (*roleTemplatesTable)(template).ScanFields()
+		if opts.Pagination != nil {
+			fields = append(fields, &opts.Pagination.TotalCount)
+		}
+		return fields
+	})
+}
+
+func DeleteRoleTemplate(tx WriteTxn, id uid.ID) error {
+	stmt := `
+		UPDATE role_templates SET deleted_at = ?
+		WHERE id = ? AND organization_id = ? AND deleted_at is null
+	`
+	_, err := tx.Exec(stmt, time.Now(), id, tx.OrganizationID())
+	return handleError(err)
+}
@@ -0,0 +1,144 @@
+package data
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+
+	"github.com/infrahq/infra/api"
+	"github.com/infrahq/infra/internal"
+	"github.com/infrahq/infra/internal/server/models"
+)
+
+func TestCreateRoleTemplate(t *testing.T) {
+	runDBTests(t, func(t *testing.T, db *DB) {
+		t.Run("success", func(t *testing.T) {
+			tx := txnForTestCase(t, db, db.DefaultOrg.ID)
+
+			template := &models.RoleTemplate{
+				Name: "debug",
+				Rules: models.RoleRules{
+					{APIGroups: []string{""}, Resources: []string{"pods/exec"}, Verbs: []string{"create"}},
+				},
+			}
+			err := CreateRoleTemplate(tx, template)
+			assert.NilError(t, err)
+			assert.Assert(t, template.ID != 0)
+
+			actual, err := GetRoleTemplate(tx, GetRoleTemplateOptions{ByID: template.ID})
+			assert.NilError(t, err)
+
+			expected := &models.RoleTemplate{
+				Model: models.Model{
+					ID:        template.ID,
+					CreatedAt: time.Now(),
+					UpdatedAt: time.Now(),
+				},
+				OrganizationMember: models.OrganizationMember{OrganizationID: defaultOrganizationID},
+				Name:               "debug",
+				Rules: models.RoleRules{
+					{APIGroups: []string{""}, Resources: []string{"pods/exec"}, Verbs: []string{"create"}},
+				},
+			}
+			assert.DeepEqual(t, actual, expected, cmpModel)
+		})
+		t.Run("conflict on name", func(t *testing.T) {
+			tx := txnForTestCase(t, db, db.DefaultOrg.ID)
+
+			rules := models.RoleRules{{Resources: []string{"pods"}, Verbs: []string{"get"}}}
+			err := CreateRoleTemplate(tx, &models.RoleTemplate{Name: "pods", Rules: rules})
+			assert.NilError(t, err)
+
+			err = CreateRoleTemplate(tx, &models.RoleTemplate{Name: "pods", Rules: rules})
+			var ucErr UniqueConstraintError
+			assert.Assert(t, errors.As(err, &ucErr))
+			expected := UniqueConstraintError{Table: "role_templates", Column: "name"}
+			assert.DeepEqual(t, ucErr, expected)
+		})
+	})
+}
+
+func TestUpdateRoleTemplate(t *testing.T) {
+	runDBTests(t, func(t *testing.T, db *DB) {
+		tx := txnForTestCase(t, db, db.DefaultOrg.ID)
+
+		template := &models.RoleTemplate{
+			Name:  "logs",
+			Rules: models.RoleRules{{Resources: []string{"pods/log"}, Verbs: []string{"get"}}},
+		}
+		assert.NilError(t, CreateRoleTemplate(tx, template))
+
+		template.Rules = append(template.Rules, api.RoleRule{Resources: []string{"pods"}, Verbs: []string{"list"}})
+		assert.NilError(t, UpdateRoleTemplate(tx, template))
+
+		actual, err := GetRoleTemplate(tx, GetRoleTemplateOptions{ByName: "logs"})
+		assert.NilError(t, err)
+		assert.DeepEqual(t, actual.Rules, template.Rules)
+	})
+}
+
+func TestListRoleTemplates(t *testing.T) {
+	runDBTests(t, func(t *testing.T, db *DB) {
+		tx := txnForTestCase(t, db, db.DefaultOrg.ID)
+
+		rules := models.RoleRules{{Resources: []string{"pods"}, Verbs: []string{"get"}}}
+		second := &models.RoleTemplate{Name: "second", Rules: rules}
+		first := &models.RoleTemplate{Name: "first", Rules: rules}
+		deleted := &models.RoleTemplate{Name: "deleted", Rules: rules}
+		createRoleTemplates(t, tx, second, first, deleted)
+		assert.NilError(t, DeleteRoleTemplate(tx, deleted.ID))
+
+		t.Run("all", func(t *testing.T) {
+			actual, err := ListRoleTemplates(tx, ListRoleTemplatesOptions{})
+			assert.NilError(t, err)
+			expected := []models.RoleTemplate{*first, *second}
+			assert.DeepEqual(t, actual, expected, cmpModel)
+		})
+		t.Run("by name", func(t *testing.T) {
+			actual, err := ListRoleTemplates(tx, ListRoleTemplatesOptions{ByName: "second"})
+			assert.NilError(t, err)
+			expected := []models.RoleTemplate{*second}
+			assert.DeepEqual(t, actual, expected, cmpModel)
+		})
+		t.Run("with pagination", func(t *testing.T) {
+			p := &Pagination{Page: 2, Limit: 1}
+			actual, err := ListRoleTemplates(tx, ListRoleTemplatesOptions{Pagination: p})
+			assert.NilError(t, err)
+			expected := []models.RoleTemplate{*second}
+			assert.DeepEqual(t, actual, expected, cmpModel)
+			assert.Equal(t, p.TotalCount, 2)
+		})
+	})
+}
+
+func TestDeleteRoleTemplate(t *testing.T) {
+	runDBTests(t, func(t *testing.T, db *DB) {
+		tx := txnForTestCase(t, db, db.DefaultOrg.ID)
+
+		template := &models.RoleTemplate{
+			Name:  "todelete",
+			Rules: models.RoleRules{{Resources: []string{"pods"}, Verbs: []string{"get"}}},
+		}
+		assert.NilError(t, CreateRoleTemplate(tx, template))
+
+		assert.NilError(t, DeleteRoleTemplate(tx, template.ID))
+
+		_, err := GetRoleTemplate(tx, GetRoleTemplateOptions{ByID: template.ID})
+		assert.ErrorIs(t, err, internal.ErrNotFound)
+
+		// the name can be used again after delete
+		assert.NilError(t, CreateRoleTemplate(tx, &models.RoleTemplate{
+			Name:  "todelete",
+			Rules: template.Rules,
+		}))
+	})
+}
+
+func createRoleTemplates(t *testing.T, tx WriteTxn, templates ...*models.RoleTemplate) {
+	t.Helper()
+	for _, template := range templates {
+		assert.NilError(t, CreateRoleTemplate(tx, template))
+	}
+}
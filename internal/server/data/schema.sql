@@ -30,6 +30,26 @@ PERFORM pg_notify(current_schema() || '.grants_' || NEW.organization_id, row_to_
 RETURN NULL;
 END; $$;
 
+CREATE FUNCTION identities_groups_notify() RETURNS trigger
+    LANGUAGE plpgsql
+    AS $$
+DECLARE
+    changed record;
+BEGIN
+    UPDATE identities SET membership_update_index = nextval('seq_update_index')
+        WHERE id IN (SELECT DISTINCT identity_id FROM changed_members);
+
+    FOR changed IN
+        UPDATE groups SET membership_update_index = nextval('seq_update_index')
+            WHERE id IN (SELECT DISTINCT group_id FROM changed_members)
+            RETURNING id, organization_id
+    LOOP
+        PERFORM pg_notify(current_schema() || '.grants_' || changed.organization_id,
+            json_build_object('membershipChanged', true, 'groupID', changed.id)::text);
+    END LOOP;
+    RETURN NULL;
+END; $$;
+
 CREATE FUNCTION listen_on_chan(chan text) RETURNS void
     LANGUAGE plpgsql
     AS $$
@@ -37,6 +57,20 @@ BEGIN
     EXECUTE format('LISTEN %I', current_schema() || '.' || chan);
 END; $$;
 
+CREATE FUNCTION role_templates_notify() RETURNS trigger
+    LANGUAGE plpgsql
+    AS $$
+BEGIN
+    IF TG_OP = 'UPDATE' AND
+        (to_jsonb(NEW) - 'update_index' - 'updated_at') = (to_jsonb(OLD) - 'update_index' - 'updated_at') THEN
+        RETURN NEW;
+    END IF;
+    NEW.update_index := nextval('seq_update_index');
+    PERFORM pg_notify(current_schema() || '.grants_' || NEW.organization_id,
+        json_build_object('roleTemplatesChanged', true)::text);
+    RETURN NEW;
+END; $$;
+
 CREATE FUNCTION uidinttostr(id bigint) RETURNS text
     LANGUAGE plpgsql
     AS $$
@@ -107,6 +141,22 @@ CREATE FUNCTION uidstrtoint(encoded text) RETURNS bigint
 			
 			END; $$;
 
+CREATE FUNCTION update_index_notify() RETURNS trigger
+    LANGUAGE plpgsql
+    AS $$
+BEGIN
+    IF TG_OP = 'UPDATE' AND
+        (to_jsonb(NEW) - 'update_index' - TG_ARGV) = (to_jsonb(OLD) - 'update_index' - TG_ARGV) THEN
+        RETURN NEW;
+    END IF;
+    NEW.update_index := nextval('seq_update_index');
+    IF NEW.organization_id IS NOT NULL THEN
+        PERFORM pg_notify(current_schema() || '.' || TG_TABLE_NAME || '_' || NEW.organization_id,
+            json_build_object('id', NEW.id)::text);
+    END IF;
+    RETURN NEW;
+END; $$;
+
 CREATE TABLE access_keys (
     id bigint NOT NULL,
     created_at timestamp with time zone,
@@ -121,7 +171,16 @@ CREATE TABLE access_keys (
     key_id text,
     secret_checksum bytea,
     scopes text,
-    organization_id bigint
+    organization_id bigint,
+    rate_limit integer DEFAULT 0 NOT NULL
+);
+
+CREATE TABLE background_jobs (
+    name text NOT NULL,
+    started_at timestamp with time zone NOT NULL,
+    duration bigint DEFAULT 0 NOT NULL,
+    error text DEFAULT ''::text NOT NULL,
+    hostname text DEFAULT ''::text NOT NULL
 );
 
 CREATE TABLE credentials (
@@ -135,6 +194,25 @@ CREATE TABLE credentials (
     organization_id bigint
 );
 
+CREATE TABLE destination_activity (
+    id bigint NOT NULL,
+    created_at timestamp with time zone,
+    organization_id bigint NOT NULL,
+    destination_name text NOT NULL,
+    "time" timestamp with time zone NOT NULL,
+    user_name text DEFAULT ''::text NOT NULL,
+    groups text DEFAULT ''::text NOT NULL,
+    verb text DEFAULT ''::text NOT NULL,
+    api_group text DEFAULT ''::text NOT NULL,
+    resource text DEFAULT ''::text NOT NULL,
+    subresource text DEFAULT ''::text NOT NULL,
+    namespace text DEFAULT ''::text NOT NULL,
+    name text DEFAULT ''::text NOT NULL,
+    path text DEFAULT ''::text NOT NULL,
+    status_code integer DEFAULT 0 NOT NULL,
+    latency_ms bigint DEFAULT 0 NOT NULL
+);
+
 CREATE TABLE destination_credentials (
     id bigint NOT NULL,
     organization_id bigint NOT NULL,
@@ -161,7 +239,8 @@ CREATE TABLE destinations (
     resources text,
     roles text,
     organization_id bigint,
-    kind text DEFAULT 'kubernetes'::text NOT NULL
+    kind text DEFAULT 'kubernetes'::text NOT NULL,
+    update_index bigint
 );
 
 CREATE TABLE device_flow_auth_requests (
@@ -210,7 +289,9 @@ CREATE TABLE groups (
     name text,
     created_by bigint,
     created_by_provider bigint,
-    organization_id bigint
+    organization_id bigint,
+    membership_update_index bigint,
+    update_index bigint
 );
 
 CREATE TABLE identities (
@@ -224,7 +305,9 @@ CREATE TABLE identities (
     organization_id bigint,
     verified boolean DEFAULT false NOT NULL,
     verification_token text DEFAULT substr(replace(translate(encode(decode(md5((random())::text), 'hex'::text), 'base64'::text), '/+'::text, '=='::text), '='::text, ''::text), 1, 10) NOT NULL,
-    ssh_login_name text
+    ssh_login_name text,
+    update_index bigint,
+    membership_update_index bigint
 );
 
 CREATE TABLE identities_groups (
@@ -232,6 +315,13 @@ CREATE TABLE identities_groups (
     group_id bigint NOT NULL
 );
 
+CREATE UNLOGGED TABLE login_failures (
+    key text NOT NULL,
+    failures integer DEFAULT 0 NOT NULL,
+    locked_until timestamp with time zone,
+    last_failure_at timestamp with time zone NOT NULL
+);
+
 CREATE TABLE organizations (
     id bigint NOT NULL,
     created_at timestamp with time zone,
@@ -240,7 +330,9 @@ CREATE TABLE organizations (
     name text,
     created_by bigint,
     domain text,
-    allowed_domains text DEFAULT ''::text
+    allowed_domains text DEFAULT ''::text,
+    rate_limit integer DEFAULT 0 NOT NULL,
+    login_rate_limit integer DEFAULT 0 NOT NULL
 );
 
 CREATE TABLE password_reset_tokens (
@@ -282,7 +374,37 @@ CREATE TABLE providers (
     private_key text,
     client_email text,
     domain_admin_email text,
-    organization_id bigint
+    organization_id bigint,
+    update_index bigint
+);
+
+CREATE TABLE proxy_policies (
+    id bigint NOT NULL,
+    created_at timestamp with time zone,
+    updated_at timestamp with time zone,
+    deleted_at timestamp with time zone,
+    organization_id bigint NOT NULL,
+    name text NOT NULL,
+    destination_name text DEFAULT ''::text NOT NULL,
+    reason text DEFAULT ''::text NOT NULL,
+    rule text NOT NULL
+);
+
+CREATE UNLOGGED TABLE rate_limits (
+    key text NOT NULL,
+    window_start timestamp with time zone NOT NULL,
+    count integer DEFAULT 0 NOT NULL
+);
+
+CREATE TABLE role_templates (
+    id bigint NOT NULL,
+    created_at timestamp with time zone,
+    updated_at timestamp with time zone,
+    deleted_at timestamp with time zone,
+    organization_id bigint NOT NULL,
+    name text NOT NULL,
+    rules text NOT NULL,
+    update_index bigint
 );
 
 CREATE SEQUENCE seq_update_index
@@ -292,6 +414,20 @@ CREATE SEQUENCE seq_update_index
     NO MAXVALUE
     CACHE 1;
 
+CREATE TABLE session_recordings (
+    id bigint NOT NULL,
+    created_at timestamp with time zone,
+    organization_id bigint NOT NULL,
+    destination_name text NOT NULL,
+    user_id bigint NOT NULL,
+    user_name text DEFAULT ''::text NOT NULL,
+    login_name text DEFAULT ''::text NOT NULL,
+    started_at timestamp with time zone NOT NULL,
+    ended_at timestamp with time zone NOT NULL,
+    size bigint DEFAULT 0 NOT NULL,
+    content bytea NOT NULL
+);
+
 CREATE TABLE settings (
     id bigint NOT NULL,
     created_at timestamp with time zone,
@@ -318,9 +454,15 @@ CREATE TABLE user_public_keys (
 ALTER TABLE ONLY access_keys
     ADD CONSTRAINT access_keys_pkey PRIMARY KEY (id);
 
+ALTER TABLE ONLY background_jobs
+    ADD CONSTRAINT background_jobs_pkey PRIMARY KEY (name);
+
 ALTER TABLE ONLY credentials
     ADD CONSTRAINT credentials_pkey PRIMARY KEY (id);
 
+ALTER TABLE ONLY destination_activity
+    ADD CONSTRAINT destination_activity_pkey PRIMARY KEY (id);
+
 ALTER TABLE ONLY destinations
     ADD CONSTRAINT destinations_pkey PRIMARY KEY (id);
 
@@ -339,6 +481,9 @@ ALTER TABLE ONLY identities_groups
 ALTER TABLE ONLY identities
     ADD CONSTRAINT identities_pkey PRIMARY KEY (id);
 
+ALTER TABLE ONLY login_failures
+    ADD CONSTRAINT login_failures_pkey PRIMARY KEY (key);
+
 ALTER TABLE ONLY organizations
     ADD CONSTRAINT organizations_pkey PRIMARY KEY (id);
 
@@ -351,6 +496,18 @@ ALTER TABLE ONLY provider_users
 ALTER TABLE ONLY providers
     ADD CONSTRAINT providers_pkey PRIMARY KEY (id);
 
+ALTER TABLE ONLY proxy_policies
+    ADD CONSTRAINT proxy_policies_pkey PRIMARY KEY (id);
+
+ALTER TABLE ONLY rate_limits
+    ADD CONSTRAINT rate_limits_pkey PRIMARY KEY (key, window_start);
+
+ALTER TABLE ONLY role_templates
+    ADD CONSTRAINT role_templates_pkey PRIMARY KEY (id);
+
+ALTER TABLE ONLY session_recordings
+    ADD CONSTRAINT session_recordings_pkey PRIMARY KEY (id);
+
 ALTER TABLE ONLY settings
     ADD CONSTRAINT settings_pkey PRIMARY KEY (id);
 
@@ -367,6 +524,8 @@ CREATE INDEX idx_cred_req_org_dest ON destination_credentials USING btree (organ
 
 CREATE UNIQUE INDEX idx_credentials_identity_id ON credentials USING btree (organization_id, identity_id) WHERE (deleted_at IS NULL);
 
+CREATE INDEX idx_destination_activity_time ON destination_activity USING btree (organization_id, destination_name, "time");
+
 CREATE UNIQUE INDEX idx_destinations_name ON destinations USING btree (organization_id, name) WHERE (deleted_at IS NULL);
 
 CREATE UNIQUE INDEX idx_destinations_unique_id ON destinations USING btree (organization_id, unique_id) WHERE (deleted_at IS NULL);
@@ -397,6 +556,12 @@ CREATE UNIQUE INDEX idx_password_reset_tokens_token ON password_reset_tokens USI
 
 CREATE UNIQUE INDEX idx_providers_name ON providers USING btree (organization_id, name) WHERE (deleted_at IS NULL);
 
+CREATE UNIQUE INDEX idx_proxy_policies_name ON proxy_policies USING btree (organization_id, name) WHERE (deleted_at IS NULL);
+
+CREATE UNIQUE INDEX idx_role_templates_name ON role_templates USING btree (organization_id, name) WHERE (deleted_at IS NULL);
+
+CREATE INDEX idx_session_recordings_started_at ON session_recordings USING btree (organization_id, destination_name, started_at);
+
 CREATE UNIQUE INDEX idx_user_public_keys_user_fingerprint ON user_public_keys USING btree (fingerprint) WHERE (deleted_at IS NULL);
 
 CREATE INDEX idx_user_public_keys_user_id ON user_public_keys USING btree (user_id) WHERE (deleted_at IS NULL);
@@ -409,4 +574,18 @@ CREATE TRIGGER credreq_notify_insert_trigger AFTER INSERT ON destination_credent
 
 CREATE TRIGGER credreq_notify_update_trigger AFTER UPDATE ON destination_credentials FOR EACH ROW EXECUTE FUNCTION destination_credential_update_notify();
 
+CREATE TRIGGER destinations_update_index_trigger BEFORE INSERT OR UPDATE ON destinations FOR EACH ROW EXECUTE FUNCTION update_index_notify('updated_at', 'last_seen_at');
+
 CREATE TRIGGER grants_notify_trigger AFTER INSERT OR UPDATE ON grants FOR EACH ROW EXECUTE FUNCTION grants_notify();
+
+CREATE TRIGGER groups_update_index_trigger BEFORE INSERT OR UPDATE ON groups FOR EACH ROW EXECUTE FUNCTION update_index_notify('updated_at');
+
+CREATE TRIGGER identities_groups_delete_notify_trigger AFTER DELETE ON identities_groups REFERENCING OLD TABLE AS changed_members FOR EACH STATEMENT EXECUTE FUNCTION identities_groups_notify();
+
+CREATE TRIGGER identities_groups_insert_notify_trigger AFTER INSERT ON identities_groups REFERENCING NEW TABLE AS changed_members FOR EACH STATEMENT EXECUTE FUNCTION identities_groups_notify();
+
+CREATE TRIGGER identities_update_index_trigger BEFORE INSERT OR UPDATE ON identities FOR EACH ROW EXECUTE FUNCTION update_index_notify('updated_at', 'last_seen_at', 'membership_update_index');
+
+CREATE TRIGGER providers_update_index_trigger BEFORE INSERT OR UPDATE ON providers FOR EACH ROW EXECUTE FUNCTION update_index_notify('updated_at');
+
+CREATE TRIGGER role_templates_notify_trigger BEFORE INSERT OR UPDATE ON role_templates FOR EACH ROW EXECUTE FUNCTION role_templates_notify();
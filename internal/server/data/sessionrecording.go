@@ -0,0 +1,139 @@
+package data
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/infrahq/infra/internal/server/data/querybuilder"
+	"github.com/infrahq/infra/internal/server/models"
+	"github.com/infrahq/infra/uid"
+)
+
+type sessionRecordingsTable models.SessionRecording
+
+func (s sessionRecordingsTable) Table() string {
+	return "session_recordings"
+}
+
+func (s sessionRecordingsTable) Columns() []string {
+	return []string{"content", "created_at", "destination_name", "ended_at", "id", "login_name", "organization_id", "size", "started_at", "user_id", "user_name"}
+}
+
+func (s sessionRecordingsTable) Values() []any {
+	return []any{s.Content, s.CreatedAt, s.DestinationName, s.EndedAt, s.ID, s.LoginName, s.OrganizationID, s.Size, s.StartedAt, s.UserID, s.UserName}
+}
+
+func (s *sessionRecordingsTable) ScanFields() []any {
+	return []any{&s.Content, &s.CreatedAt, &s.DestinationName, &s.EndedAt, &s.ID, &s.LoginName, &s.OrganizationID, &s.Size, &s.StartedAt, &s.UserID, &s.UserName}
+}
+
+// sessionRecordingMetadataColumns returns all the columns except for content,
+// which can be large and is only read by GetSessionRecordingContent.
+func sessionRecordingMetadataColumns() string {
+	table := sessionRecordingsTable{}
+	columns := make([]string, 0, len(table.Columns()))
+	for _, column := range table.Columns() {
+		if column != "content" {
+			columns = append(columns, table.Table()+"."+column)
+		}
+	}
+	return strings.Join(columns, ", ")
+}
+
+func (s *sessionRecordingsTable) metadataScanFields() []any {
+	return []any{&s.CreatedAt, &s.DestinationName, &s.EndedAt, &s.ID, &s.LoginName, &s.OrganizationID, &s.Size, &s.StartedAt, &s.UserID, &s.UserName}
+}
+
+func CreateSessionRecording(tx WriteTxn, recording *models.SessionRecording) error {
+	switch {
+	case recording.DestinationName == "":
+		return fmt.Errorf("SessionRecording.DestinationName is required")
+	case recording.UserID == 0:
+		return fmt.Errorf("SessionRecording.UserID is required")
+	case recording.StartedAt.IsZero():
+		return fmt.Errorf("SessionRecording.StartedAt is required")
+	case len(recording.Content) == 0:
+		return fmt.Errorf("SessionRecording.Content is required")
+	}
+	if recording.EndedAt.IsZero() {
+		recording.EndedAt = recording.StartedAt
+	}
+	recording.Size = int64(len(recording.Content))
+	return insert(tx, (*sessionRecordingsTable)(recording))
+}
+
+// GetSessionRecording returns the recording with the ID, without the content.
+func GetSessionRecording(tx ReadTxn, id uid.ID) (*models.SessionRecording, error) {
+	recording := &sessionRecordingsTable{}
+	query := querybuilder.New("SELECT")
+	query.B(sessionRecordingMetadataColumns())
+	query.B("FROM session_recordings")
+	query.B("WHERE organization_id = ?", tx.OrganizationID())
+	query.B("AND id = ?", id)
+
+	err := tx.QueryRow(query.String(), query.Args...).Scan(recording.metadataScanFields()...)
+	if err != nil {
+		return nil, handleError(err)
+	}
+	return (*models.SessionRecording)(recording), nil
+}
+
+// GetSessionRecordingContent returns the recording with the ID, including the
+// content.
+func GetSessionRecordingContent(tx ReadTxn, id uid.ID) (*models.SessionRecording, error) {
+	recording := &sessionRecordingsTable{}
+	query := querybuilder.New("SELECT")
+	query.B(columnsForSelect(recording))
+	query.B("FROM session_recordings")
+	query.B("WHERE organization_id = ?", tx.OrganizationID())
+	query.B("AND id = ?", id)
+
+	err := tx.QueryRow(query.String(), query.Args...).Scan(recording.ScanFields()...)
+	if err != nil {
+		return nil, handleError(err)
+	}
+	return (*models.SessionRecording)(recording), nil
+}
+
+type ListSessionRecordingsOptions struct {
+	ByDestinationName string
+	ByUserID          uid.ID
+
+	Pagination *Pagination
+}
+
+// ListSessionRecordings returns recordings, without the content, ordered by
+// the time the session started, with the most recent session first.
+func ListSessionRecordings(tx ReadTxn, opts ListSessionRecordingsOptions) ([]models.SessionRecording, error) {
+	query := querybuilder.New("SELECT")
+	query.B(sessionRecordingMetadataColumns())
+	if opts.Pagination != nil {
+		query.B(", count(*) OVER()")
+	}
+	query.B("FROM session_recordings")
+	query.B("WHERE organization_id = ?", tx.OrganizationID())
+
+	if opts.ByDestinationName != "" {
+		query.B("AND destination_name = ?", opts.ByDestinationName)
+	}
+	if opts.ByUserID != 0 {
+		query.B("AND user_id = ?", opts.ByUserID)
+	}
+
+	query.B("ORDER BY started_at DESC, id DESC")
+	if opts.Pagination != nil {
+		opts.Pagination.PaginateQuery(query)
+	}
+
+	rows, err := tx.Query(query.String(), query.Args...)
+	if err != nil {
+		return nil, err
+	}
+	return scanRows(rows, func(recording *models.SessionRecording) []any {
+		fields := (*sessionRecordingsTable)(recording).metadataScanFields()
+		if opts.Pagination != nil {
+			fields = append(fields, &opts.Pagination.TotalCount)
+		}
+		return fields
+	})
+}
@@ -0,0 +1,69 @@
+package data
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+
+	"github.com/infrahq/infra/internal"
+	"github.com/infrahq/infra/internal/server/models"
+)
+
+func TestSessionRecordings(t *testing.T) {
+	runDBTests(t, func(t *testing.T, db *DB) {
+		tx := txnForTestCase(t, db, db.DefaultOrg.ID)
+
+		now := time.Now().UTC().Truncate(time.Millisecond)
+		first := &models.SessionRecording{
+			DestinationName: "bastion",
+			UserID:          1234,
+			UserName:        "alice@example.com",
+			LoginName:       "alice",
+			StartedAt:       now.Add(-2 * time.Hour),
+			EndedAt:         now.Add(-time.Hour),
+			Content:         []byte(`{"version":2}` + "\n"),
+		}
+		second := &models.SessionRecording{
+			DestinationName: "web",
+			UserID:          1235,
+			UserName:        "bob@example.com",
+			LoginName:       "bob",
+			StartedAt:       now.Add(-time.Hour),
+			Content:         []byte(`{"version":2}` + "\n" + `[0.1,"o","$ "]` + "\n"),
+		}
+		assert.NilError(t, CreateSessionRecording(tx, first))
+		assert.NilError(t, CreateSessionRecording(tx, second))
+		assert.Equal(t, second.EndedAt, second.StartedAt)
+		assert.Equal(t, second.Size, int64(len(second.Content)))
+
+		t.Run("list", func(t *testing.T) {
+			actual, err := ListSessionRecordings(tx, ListSessionRecordingsOptions{})
+			assert.NilError(t, err)
+			assert.Equal(t, len(actual), 2)
+			assert.Equal(t, actual[0].ID, second.ID)
+			assert.Equal(t, actual[1].ID, first.ID)
+			// content is not loaded by list
+			assert.Assert(t, actual[0].Content == nil)
+		})
+
+		t.Run("list by destination", func(t *testing.T) {
+			actual, err := ListSessionRecordings(tx, ListSessionRecordingsOptions{ByDestinationName: "bastion"})
+			assert.NilError(t, err)
+			assert.Equal(t, len(actual), 1)
+			assert.Equal(t, actual[0].LoginName, "alice")
+		})
+
+		t.Run("get content", func(t *testing.T) {
+			actual, err := GetSessionRecordingContent(tx, second.ID)
+			assert.NilError(t, err)
+			assert.DeepEqual(t, actual.Content, second.Content)
+		})
+
+		t.Run("not found", func(t *testing.T) {
+			_, err := GetSessionRecording(tx, 1)
+			assert.Assert(t, errors.Is(err, internal.ErrNotFound))
+		})
+	})
+}
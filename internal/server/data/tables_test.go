@@ -16,6 +16,7 @@ import (
 var tables = []tabler{
 	accessKeyTable{},
 	credentialsTable{},
+	destinationActivityTable{},
 	destinationsTable{},
 	encryptionKeysTable{},
 	grantsTable{},
@@ -25,6 +26,9 @@ var tables = []tabler{
 	passwordResetToken{},
 	providersTable{},
 	providerUserTable{},
+	roleTemplatesTable{},
+	proxyPoliciesTable{},
+	sessionRecordingsTable{},
 	settingsTable{},
 	userPublicKeysTable{},
 }
@@ -0,0 +1,58 @@
+package data
+
+import (
+	"fmt"
+
+	"github.com/infrahq/infra/internal/server/data/querybuilder"
+)
+
+// WatchTable identifies a table that supports blocking list requests. Every
+// write to one of these tables sets the update_index of the row, and notifies
+// listeners on a channel for the organization.
+type WatchTable string
+
+const (
+	WatchUsers        WatchTable = "identities"
+	WatchGroups       WatchTable = "groups"
+	WatchDestinations WatchTable = "destinations"
+	WatchProviders    WatchTable = "providers"
+)
+
+func (w WatchTable) table() (Table, error) {
+	switch w {
+	case WatchUsers:
+		return identitiesTable{}, nil
+	case WatchGroups:
+		return groupsTable{}, nil
+	case WatchDestinations:
+		return destinationsTable{}, nil
+	case WatchProviders:
+		return providersTable{}, nil
+	default:
+		return nil, fmt.Errorf("table %q does not support watch", string(w))
+	}
+}
+
+// MaxUpdateIndex returns the maximum update_index of all the rows in the
+// table for the organization. This includes soft-deleted rows, so that a
+// delete changes the result.
+//
+// Returns 1 if there are no rows, so that the caller can block until a row
+// exists.
+func MaxUpdateIndex(tx ReadTxn, watch WatchTable) (int64, error) {
+	table, err := watch.table()
+	if err != nil {
+		return 0, err
+	}
+
+	query := querybuilder.New("SELECT max(update_index) FROM")
+	query.B(table.Table())
+	query.B("WHERE organization_id = ?", tx.OrganizationID())
+
+	var result *int64
+	err = tx.QueryRow(query.String(), query.Args...).Scan(&result)
+	if err != nil || result == nil {
+		return 1, err
+	}
+	return *result, err
+}
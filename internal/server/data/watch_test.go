@@ -0,0 +1,63 @@
+package data
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+
+	"github.com/infrahq/infra/internal/server/models"
+	"github.com/infrahq/infra/uid"
+)
+
+func TestMaxUpdateIndex(t *testing.T) {
+	runDBTests(t, func(t *testing.T, db *DB) {
+		t.Run("changes to users", func(t *testing.T) {
+			tx := txnForTestCase(t, db, db.DefaultOrg.ID)
+
+			initial, err := MaxUpdateIndex(tx, WatchUsers)
+			assert.NilError(t, err)
+
+			user := &models.Identity{Name: "watched@example.com"}
+			createIdentities(t, tx, user)
+
+			created, err := MaxUpdateIndex(tx, WatchUsers)
+			assert.NilError(t, err)
+			assert.Assert(t, created > initial, "created=%v initial=%v", created, initial)
+
+			// updating only the last seen time does not change the index
+			user.LastSeenAt = time.Now().Add(-time.Hour)
+			assert.NilError(t, UpdateIdentityLastSeenAt(tx, user))
+			idx, err := MaxUpdateIndex(tx, WatchUsers)
+			assert.NilError(t, err)
+			assert.Equal(t, idx, created)
+
+			assert.NilError(t, DeleteIdentities(tx, DeleteIdentitiesOptions{ByID: user.ID}))
+			deleted, err := MaxUpdateIndex(tx, WatchUsers)
+			assert.NilError(t, err)
+			assert.Assert(t, deleted > created, "deleted=%v created=%v", deleted, created)
+		})
+		t.Run("group membership changes", func(t *testing.T) {
+			tx := txnForTestCase(t, db, db.DefaultOrg.ID)
+
+			group := &models.Group{Name: "watched"}
+			assert.NilError(t, CreateGroup(tx, group))
+			user := &models.Identity{Name: "member@example.com"}
+			createIdentities(t, tx, user)
+
+			initial, err := MaxUpdateIndex(tx, WatchGroups)
+			assert.NilError(t, err)
+
+			assert.NilError(t, AddUsersToGroup(tx, group.ID, []uid.ID{user.ID}))
+			idx, err := MaxUpdateIndex(tx, WatchGroups)
+			assert.NilError(t, err)
+			assert.Assert(t, idx > initial, "idx=%v initial=%v", idx, initial)
+		})
+		t.Run("unsupported table", func(t *testing.T) {
+			tx := txnForTestCase(t, db, db.DefaultOrg.ID)
+
+			_, err := MaxUpdateIndex(tx, WatchTable("grants"))
+			assert.ErrorContains(t, err, `table "grants" does not support watch`)
+		})
+	})
+}
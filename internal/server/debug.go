@@ -2,13 +2,16 @@ package server
 
 import (
 	"database/sql"
+	"fmt"
 	"net/http"
 	"net/http/pprof"
 
 	"github.com/gin-gonic/gin"
 
 	"github.com/infrahq/infra/api"
+	"github.com/infrahq/infra/internal"
 	"github.com/infrahq/infra/internal/access"
+	"github.com/infrahq/infra/internal/server/data"
 	"github.com/infrahq/infra/internal/server/models"
 )
 
@@ -49,3 +52,89 @@ func pprofHandler(c *gin.Context, _ *pprofRequest) (*api.EmptyResponse, error) {
 	}
 	return nil, nil
 }
+
+func (a *API) ListBackgroundJobsRoute() route[api.EmptyRequest, *api.ListResponse[api.BackgroundJob]] {
+	return route[api.EmptyRequest, *api.ListResponse[api.BackgroundJob]]{
+		handler: a.ListBackgroundJobs,
+		routeSettings: routeSettings{
+			omitFromTelemetry: true,
+			omitFromDocs:      true,
+			txnOptions:        &sql.TxOptions{ReadOnly: true},
+		},
+	}
+}
+
+func (a *API) ListBackgroundJobs(c *gin.Context, _ *api.EmptyRequest) (*api.ListResponse[api.BackgroundJob], error) {
+	rCtx := getRequestContext(c)
+	if err := access.IsAuthorized(rCtx, models.InfraSupportAdminRole); err != nil {
+		return nil, access.HandleAuthErr(err, "background jobs", "list", models.InfraSupportAdminRole)
+	}
+
+	runs, err := data.ListBackgroundJobs(rCtx.DBTxn)
+	if err != nil {
+		return nil, err
+	}
+	lastRuns := make(map[string]data.BackgroundJob, len(runs))
+	for _, run := range runs {
+		lastRuns[run.Name] = run
+	}
+
+	return api.NewListResponse(a.server.jobs.list(), api.PaginationResponse{}, func(entry *backgroundJobEntry) api.BackgroundJob {
+		job := api.BackgroundJob{Name: entry.name, Interval: api.Duration(entry.every)}
+		if run, ok := lastRuns[entry.name]; ok {
+			job.LastRun = backgroundJobRunToAPI(&run)
+		}
+		return job
+	}), nil
+}
+
+func (a *API) RunBackgroundJobRoute() route[api.RunBackgroundJobRequest, *api.BackgroundJob] {
+	return route[api.RunBackgroundJobRequest, *api.BackgroundJob]{
+		handler: a.RunBackgroundJob,
+		routeSettings: routeSettings{
+			omitFromTelemetry: true,
+			omitFromDocs:      true,
+		},
+	}
+}
+
+// RunBackgroundJob runs a background job immediately, even if it has already
+// run within the current interval.
+func (a *API) RunBackgroundJob(c *gin.Context, r *api.RunBackgroundJobRequest) (*api.BackgroundJob, error) {
+	rCtx := getRequestContext(c)
+	if err := access.IsAuthorized(rCtx, models.InfraSupportAdminRole); err != nil {
+		return nil, access.HandleAuthErr(err, "background jobs", "run", models.InfraSupportAdminRole)
+	}
+
+	entry, ok := a.server.jobs.get(r.Name)
+	if !ok {
+		return nil, fmt.Errorf("%w: background job %v", internal.ErrNotFound, r.Name)
+	}
+
+	run, err := a.server.jobs.run(c.Request.Context(), entry, true)
+	switch {
+	case run == nil && err == nil:
+		return nil, api.Error{
+			Code:    http.StatusConflict,
+			Message: fmt.Sprintf("background job %v is already running", r.Name),
+		}
+	case run == nil:
+		return nil, err
+	}
+
+	// an error from the job is returned in LastRun.Error
+	return &api.BackgroundJob{
+		Name:     entry.name,
+		Interval: api.Duration(entry.every),
+		LastRun:  backgroundJobRunToAPI(run),
+	}, nil
+}
+
+func backgroundJobRunToAPI(run *data.BackgroundJob) *api.BackgroundJobRun {
+	return &api.BackgroundJobRun{
+		StartedAt: api.Time(run.StartedAt),
+		Duration:  api.Duration(run.Duration),
+		Error:     run.Error,
+		Hostname:  run.Hostname,
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"gotest.tools/v3/assert"
 	is "gotest.tools/v3/assert/cmp"
@@ -99,3 +100,83 @@ func responseBodyAPIErrorWithCode(code int32) func(t *testing.T, resp *httptest.
 		assert.Equal(t, apiError.Code, code)
 	}
 }
+
+func TestAPI_BackgroundJobs(t *testing.T) {
+	srv := setupServer(t, withSupportAdminGrant)
+	routes := srv.GenerateRoutes()
+
+	var calls int
+	entry := srv.jobs.register(func(tx data.WriteTxn) error {
+		calls++
+		return nil
+	}, time.Hour)
+
+	userKey, _ := createAccessKey(t, srv.DB(), "user@example.com")
+
+	listJobs := func(t *testing.T) api.ListResponse[api.BackgroundJob] {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, "/api/debug/jobs", nil)
+		req.Header.Set("Authorization", "Bearer "+adminAccessKey(srv))
+		req.Header.Set("Infra-Version", apiVersionLatest)
+
+		resp := httptest.NewRecorder()
+		routes.ServeHTTP(resp, req)
+		assert.Equal(t, resp.Code, http.StatusOK, resp.Body.String())
+
+		var respBody api.ListResponse[api.BackgroundJob]
+		assert.NilError(t, json.Unmarshal(resp.Body.Bytes(), &respBody))
+		return respBody
+	}
+
+	t.Run("list before run", func(t *testing.T) {
+		jobs := listJobs(t)
+		expected := []api.BackgroundJob{
+			{Name: entry.name, Interval: api.Duration(time.Hour)},
+		}
+		assert.DeepEqual(t, jobs.Items, expected)
+	})
+
+	t.Run("run requires support admin", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/api/debug/jobs/"+entry.name+"/run", nil)
+		req.Header.Set("Authorization", "Bearer "+userKey)
+		req.Header.Set("Infra-Version", apiVersionLatest)
+
+		resp := httptest.NewRecorder()
+		routes.ServeHTTP(resp, req)
+		assert.Equal(t, resp.Code, http.StatusForbidden, resp.Body.String())
+		assert.Equal(t, calls, 0)
+	})
+
+	t.Run("run unknown job", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/api/debug/jobs/NotAJob/run", nil)
+		req.Header.Set("Authorization", "Bearer "+adminAccessKey(srv))
+		req.Header.Set("Infra-Version", apiVersionLatest)
+
+		resp := httptest.NewRecorder()
+		routes.ServeHTTP(resp, req)
+		assert.Equal(t, resp.Code, http.StatusNotFound, resp.Body.String())
+	})
+
+	t.Run("run", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/api/debug/jobs/"+entry.name+"/run", nil)
+		req.Header.Set("Authorization", "Bearer "+adminAccessKey(srv))
+		req.Header.Set("Infra-Version", apiVersionLatest)
+
+		resp := httptest.NewRecorder()
+		routes.ServeHTTP(resp, req)
+		assert.Equal(t, resp.Code, http.StatusOK, resp.Body.String())
+		assert.Equal(t, calls, 1)
+
+		var respBody api.BackgroundJob
+		assert.NilError(t, json.Unmarshal(resp.Body.Bytes(), &respBody))
+		assert.Equal(t, respBody.Name, entry.name)
+		assert.Assert(t, respBody.LastRun != nil)
+		assert.Equal(t, respBody.LastRun.Error, "")
+	})
+
+	t.Run("list after run", func(t *testing.T) {
+		jobs := listJobs(t)
+		assert.Equal(t, len(jobs.Items), 1)
+		assert.Assert(t, jobs.Items[0].LastRun != nil)
+	})
+}
@@ -0,0 +1,68 @@
+package server
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/infrahq/infra/api"
+	"github.com/infrahq/infra/internal"
+	"github.com/infrahq/infra/internal/access"
+	"github.com/infrahq/infra/internal/server/data"
+	"github.com/infrahq/infra/internal/server/models"
+)
+
+func (a *API) CreateDestinationActivity(c *gin.Context, r *api.CreateDestinationActivityRequest) (*api.EmptyResponse, error) {
+	records := make([]models.DestinationActivity, 0, len(r.Records))
+	for _, record := range r.Records {
+		records = append(records, models.DestinationActivity{
+			DestinationName: r.Destination,
+			Time:            time.Time(record.Time),
+			UserName:        record.User,
+			Groups:          record.Groups,
+			Verb:            record.Verb,
+			APIGroup:        record.APIGroup,
+			Resource:        record.Resource,
+			Subresource:     record.Subresource,
+			Namespace:       record.Namespace,
+			Name:            record.Name,
+			Path:            record.Path,
+			StatusCode:      record.StatusCode,
+			LatencyMS:       record.LatencyMS,
+		})
+	}
+
+	return nil, access.CreateDestinationActivity(getRequestContext(c), r.Destination, records)
+}
+
+func (a *API) ListDestinationActivity(c *gin.Context, r *api.ListDestinationActivityRequest) (*api.ListResponse[api.DestinationActivity], error) {
+	rCtx := getRequestContext(c)
+	p, err := PaginationFromRequest(r.PaginationRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := data.ListDestinationActivityOptions{
+		ByDestinationName: r.Destination,
+		ByUserName:        r.User,
+		Pagination:        &p,
+	}
+	if r.Since != "" {
+		since, err := time.Parse(time.RFC3339, r.Since)
+		if err != nil {
+			return nil, fmt.Errorf("%w: since must be a time in RFC3339 format", internal.ErrBadRequest)
+		}
+		opts.Since = since
+	}
+
+	records, err := access.ListDestinationActivity(rCtx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	result := api.NewListResponse(records, PaginationToResponse(p), func(record models.DestinationActivity) api.DestinationActivity {
+		return *record.ToAPI()
+	})
+	return result, nil
+}
@@ -0,0 +1,96 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+
+	"github.com/infrahq/infra/api"
+	"github.com/infrahq/infra/internal/server/data"
+	"github.com/infrahq/infra/internal/server/models"
+)
+
+func TestAPI_CreateDestinationActivity(t *testing.T) {
+	srv := setupServer(t, withAdminUser)
+	routes := srv.GenerateRoutes()
+
+	prodKey := createConnectorAccessKey(t, srv.DB(), "prod")
+	unboundKey := createConnectorAccessKey(t, srv.DB(), "")
+	prod := &models.Destination{Name: "prod", UniqueID: "prod", Kind: models.DestinationKindKubernetes}
+	assert.NilError(t, data.CreateDestination(srv.DB(), prod))
+
+	record := api.DestinationActivity{
+		Time:       api.Time(time.Now()),
+		User:       "admin@example.com",
+		Verb:       "get",
+		Resource:   "pods",
+		Path:       "/api/v1/pods",
+		StatusCode: http.StatusOK,
+	}
+
+	create := func(t *testing.T, key string, body *api.CreateDestinationActivityRequest) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, "/api/destination-activity", jsonBody(t, body))
+		req.Header.Set("Authorization", "Bearer "+key)
+		req.Header.Set("Infra-Version", apiVersionLatest)
+
+		resp := httptest.NewRecorder()
+		routes.ServeHTTP(resp, req)
+		return resp
+	}
+
+	t.Run("connector for the destination", func(t *testing.T) {
+		resp := create(t, prodKey, &api.CreateDestinationActivityRequest{
+			Destination: "prod",
+			Records:     []api.DestinationActivity{record},
+		})
+		assert.Equal(t, resp.Code, http.StatusCreated, resp.Body.String())
+	})
+
+	t.Run("connector for another destination", func(t *testing.T) {
+		resp := create(t, prodKey, &api.CreateDestinationActivityRequest{
+			Destination: "staging",
+			Records:     []api.DestinationActivity{record},
+		})
+		assert.Equal(t, resp.Code, http.StatusForbidden, resp.Body.String())
+	})
+
+	t.Run("connector key not bound to a destination", func(t *testing.T) {
+		resp := create(t, unboundKey, &api.CreateDestinationActivityRequest{
+			Destination: "prod",
+			Records:     []api.DestinationActivity{record},
+		})
+		assert.Equal(t, resp.Code, http.StatusCreated, resp.Body.String())
+	})
+
+	t.Run("connector key not bound to a destination that is not registered", func(t *testing.T) {
+		resp := create(t, unboundKey, &api.CreateDestinationActivityRequest{
+			Destination: "staging",
+			Records:     []api.DestinationActivity{record},
+		})
+		assert.Equal(t, resp.Code, http.StatusForbidden, resp.Body.String())
+	})
+
+	t.Run("admin", func(t *testing.T) {
+		resp := create(t, adminAccessKey(srv), &api.CreateDestinationActivityRequest{
+			Destination: "staging",
+			Records:     []api.DestinationActivity{record},
+		})
+		assert.Equal(t, resp.Code, http.StatusCreated, resp.Body.String())
+	})
+
+	t.Run("too many records", func(t *testing.T) {
+		records := make([]api.DestinationActivity, api.MaxDestinationActivityBatch+1)
+		for i := range records {
+			records[i] = record
+		}
+		resp := create(t, prodKey, &api.CreateDestinationActivityRequest{
+			Destination: "prod",
+			Records:     records,
+		})
+		assert.Equal(t, resp.Code, http.StatusBadRequest, resp.Body.String())
+	})
+}
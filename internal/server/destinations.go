@@ -14,7 +14,10 @@ import (
 
 func (a *API) ListDestinations(c *gin.Context, r *api.ListDestinationsRequest) (*api.ListResponse[api.Destination], error) {
 	rCtx := getRequestContext(c)
-	p := PaginationFromRequest(r.PaginationRequest)
+	p, err := PaginationFromRequest(r.PaginationRequest)
+	if err != nil {
+		return nil, err
+	}
 
 	opts := data.ListDestinationsOptions{
 		ByUniqueID: r.UniqueID,
@@ -22,14 +25,18 @@ func (a *API) ListDestinations(c *gin.Context, r *api.ListDestinationsRequest) (
 		ByKind:     r.Kind,
 		Pagination: &p,
 	}
-	destinations, err := data.ListDestinations(rCtx.DBTxn, opts)
+	destinations, err := access.WatchList(rCtx, data.WatchDestinations, r.LastUpdateIndex,
+		func(rCtx access.RequestContext) ([]models.Destination, error) {
+			return data.ListDestinations(rCtx.DBTxn, opts)
+		})
 	if err != nil {
 		return nil, err
 	}
 
-	result := api.NewListResponse(destinations, PaginationToResponse(p), func(destination models.Destination) api.Destination {
+	result := api.NewListResponse(destinations.Items, PaginationToResponse(p), func(destination models.Destination) api.Destination {
 		return *destination.ToAPI()
 	})
+	result.LastUpdateIndex.Index = destinations.MaxUpdateIndex
 
 	return result, nil
 }
@@ -14,7 +14,7 @@ import (
 	"github.com/infrahq/infra/internal/access"
 	"github.com/infrahq/infra/internal/logging"
 	"github.com/infrahq/infra/internal/server/data"
-	"github.com/infrahq/infra/internal/server/redis"
+	"github.com/infrahq/infra/internal/server/ratelimit"
 	"github.com/infrahq/infra/internal/validate"
 )
 
@@ -29,7 +29,7 @@ func sendAPIError(writer http.ResponseWriter, req *http.Request, err error) {
 
 	var validationError validate.Error
 	var uniqueConstraintError data.UniqueConstraintError
-	var overLimitError redis.OverLimitError
+	var overLimitError ratelimit.OverLimitError
 	var authnError AuthenticationError
 	var apiError api.Error
 
@@ -6,13 +6,12 @@ import (
 	"github.com/infrahq/infra/api"
 	"github.com/infrahq/infra/internal/server/data"
 	"github.com/infrahq/infra/internal/server/email"
-	"github.com/infrahq/infra/internal/server/redis"
 )
 
 func (a *API) RequestForgotDomains(c *gin.Context, r *api.ForgotDomainRequest) (*api.EmptyResponse, error) {
 	rCtx := getRequestContext(c)
 
-	if err := redis.NewLimiter(a.server.redis).RateOK(r.Email, 10); err != nil {
+	if _, err := a.server.limiter.Rate(r.Email, 10); err != nil {
 		return nil, err
 	}
 
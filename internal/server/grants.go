@@ -38,12 +38,15 @@ func (a *API) ListGrants(c *gin.Context, r *api.ListGrantsRequest) (*api.ListRes
 		ByDestination:              r.Destination,
 		ExcludeConnectorGrant:      !r.ShowSystem,
 		IncludeInheritedFromGroups: r.ShowInherited,
+		IncludeSubjectDetails:      r.ShowSubjectDetails,
+		IncludeGroupMembers:        r.ShowGroupMembers,
 	}
 	if r.Privilege != "" {
 		opts.ByPrivileges = []string{r.Privilege}
 	}
-	if !r.IsBlockingRequest() {
-		p = PaginationFromRequest(r.PaginationRequest)
+	// blocking requests return all grants, unless a page is requested
+	if !r.IsBlockingRequest() || r.Page != 0 || r.Limit != 0 || r.Cursor != "" {
+		p = CursorPaginationFromRequest(r.PaginationRequest)
 		opts.Pagination = &p
 	}
 
@@ -177,6 +177,22 @@ func TestAPI_ListGrants(t *testing.T) {
 				assert.Equal(t, len(grants.Items), 0) // no grants for this resource
 			},
 		},
+		"show subject details": {
+			urlPath: "/api/grants?resource=res1.ns1&showSubjectDetails=true",
+			setup: func(t *testing.T, req *http.Request) {
+				req.Header.Set("Authorization", "Bearer "+adminAccessKey(srv))
+			},
+			expected: func(t *testing.T, resp *httptest.ResponseRecorder) {
+				assert.Equal(t, resp.Code, http.StatusOK, resp.Body.String())
+				var grants api.ListResponse[api.Grant]
+				err := json.NewDecoder(resp.Body).Decode(&grants)
+				assert.NilError(t, err)
+				assert.Equal(t, len(grants.Items), 1)
+				assert.Equal(t, grants.Items[0].User, idOther)
+				assert.Equal(t, grants.Items[0].UserName, "other@example.com")
+				assert.Equal(t, grants.Items[0].UserSSHLoginName, "other")
+			},
+		},
 		"authorized by identity matching subject": {
 			urlPath: "/api/grants?user=" + idInGroup.String(),
 			expected: func(t *testing.T, resp *httptest.ResponseRecorder) {
@@ -194,6 +210,18 @@ func TestAPI_ListGrants(t *testing.T) {
 				assert.DeepEqual(t, grants.Items, expected, cmpAPIGrantShallow)
 			},
 		},
+		"not authorized, show subject details for own group": {
+			urlPath: "/api/grants?group=" + groupID.String() + "&showSubjectDetails=true",
+			expected: func(t *testing.T, resp *httptest.ResponseRecorder) {
+				assert.Equal(t, resp.Code, http.StatusForbidden, resp.Body.String())
+			},
+		},
+		"not authorized, show subject details for own grants": {
+			urlPath: "/api/grants?user=" + idInGroup.String() + "&showSubjectDetails=true",
+			expected: func(t *testing.T, resp *httptest.ResponseRecorder) {
+				assert.Equal(t, resp.Code, http.StatusForbidden, resp.Body.String())
+			},
+		},
 		"authorized by group matching subject": {
 			urlPath: "/api/grants?group=" + groupID.String(),
 			expected: func(t *testing.T, resp *httptest.ResponseRecorder) {
@@ -504,6 +532,15 @@ func TestAPI_ListGrants(t *testing.T) {
 				assert.Equal(t, resp.Result().Header.Get("Last-Update-Index"), "10004")
 			},
 		},
+		"blocking request with group members": {
+			urlPath: "/api/grants?destination=res1&lastUpdateIndex=1&showSubjectDetails=true&showGroupMembers=true",
+			setup: func(t *testing.T, req *http.Request) {
+				req.Header.Set("Authorization", "Bearer "+adminAccessKey(srv))
+			},
+			expected: func(t *testing.T, resp *httptest.ResponseRecorder) {
+				assert.Equal(t, resp.Code, http.StatusOK, resp.Body.String())
+			},
+		},
 		"migration from <= 0.18.1": {
 			urlPath: "/api/grants?user=" + idInGroup.String(),
 			setup: func(t *testing.T, req *http.Request) {
@@ -929,6 +966,59 @@ func TestAPI_ListGrants_BlockingRequest_NotFoundBlocksUntilUpdate(t *testing.T)
 	assert.Equal(t, len(respBody.Items), 1)
 }
 
+func TestAPI_ListGrants_BlockingRequest_ByUser(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too long for short run")
+	}
+
+	srv := setupServer(t, withAdminUser)
+	routes := srv.GenerateRoutes()
+
+	user := &models.Identity{Name: "blocking@example.com"}
+	assert.NilError(t, data.CreateIdentity(srv.db, user))
+
+	listGrants := func(lastUpdateIndex string) *httptest.ResponseRecorder {
+		urlPath := "/api/grants?showInherited=true&user=" + user.ID.String() + "&lastUpdateIndex=" + lastUpdateIndex
+		req := httptest.NewRequest(http.MethodGet, urlPath, nil)
+		req.Header.Set("Authorization", "Bearer "+adminAccessKey(srv))
+		req.Header.Add("Infra-Version", apiVersionLatest)
+
+		resp := httptest.NewRecorder()
+		routes.ServeHTTP(resp, req)
+		return resp
+	}
+
+	// the first request returns immediately with the current update index
+	resp := listGrants("1")
+	assert.Equal(t, resp.Code, http.StatusOK, (*responseDebug)(resp))
+	lastUpdateIndex := resp.Result().Header.Get("Last-Update-Index")
+
+	g := errgroup.Group{}
+	respCh := make(chan *httptest.ResponseRecorder)
+	g.Go(func() error {
+		respCh <- listGrants(lastUpdateIndex)
+		return nil
+	})
+
+	isBlocked(t, respCh)
+
+	err := data.CreateGrant(srv.db, &models.Grant{
+		Subject:   models.NewSubjectForUser(user.ID),
+		Privilege: "view",
+		Resource:  "anything",
+	})
+	assert.NilError(t, err)
+
+	resp = isNotBlocked(t, respCh)
+	assert.Equal(t, resp.Code, http.StatusOK, (*responseDebug)(resp))
+
+	respBody := &api.ListResponse[api.Grant]{}
+	assert.NilError(t, json.NewDecoder(resp.Body).Decode(respBody))
+
+	expected := []api.Grant{{User: user.ID, Privilege: "view", Resource: "anything"}}
+	assert.DeepEqual(t, respBody.Items, expected, cmpAPIGrantShallow)
+}
+
 func TestAPI_CreateGrant(t *testing.T) {
 	srv := setupServer(t, withAdminUser, withMultiOrgEnabled)
 	routes := srv.GenerateRoutes()
@@ -11,15 +11,19 @@ import (
 
 func (a *API) ListGroups(c *gin.Context, r *api.ListGroupsRequest) (*api.ListResponse[api.Group], error) {
 	rCtx := getRequestContext(c)
-	p := PaginationFromRequest(r.PaginationRequest)
-	groups, err := access.ListGroups(rCtx, r.Name, r.UserID, &p)
+	p := CursorPaginationFromRequest(r.PaginationRequest)
+	groups, err := access.WatchList(rCtx, data.WatchGroups, r.LastUpdateIndex,
+		func(rCtx access.RequestContext) ([]models.Group, error) {
+			return access.ListGroups(rCtx, r.Name, r.UserID, &p)
+		})
 	if err != nil {
 		return nil, err
 	}
 
-	result := api.NewListResponse(groups, PaginationToResponse(p), func(group models.Group) api.Group {
+	result := api.NewListResponse(groups.Items, PaginationToResponse(p), func(group models.Group) api.Group {
 		return *group.ToAPI()
 	})
+	result.LastUpdateIndex.Index = groups.MaxUpdateIndex
 
 	return result, nil
 }
@@ -18,7 +18,6 @@ import (
 	"github.com/infrahq/infra/internal/server/authn"
 	"github.com/infrahq/infra/internal/server/data"
 	"github.com/infrahq/infra/internal/server/models"
-	"github.com/infrahq/infra/internal/server/redis"
 )
 
 type API struct {
@@ -124,12 +123,17 @@ func (a *API) Login(c *gin.Context, r *api.LoginRequest) (*api.LoginResponse, er
 	case r.AccessKey != "":
 		loginMethod = authn.NewKeyExchangeAuthentication(r.AccessKey)
 	case r.PasswordCredentials != nil:
-		if err := redis.NewLimiter(a.server.redis).RateOK(r.PasswordCredentials.Name, 10); err != nil {
+		org := rCtx.Authenticated.Organization
+		limiter := a.server.limiter
+		result, err := limiter.Rate(loginRateLimitKey(org.ID, r.PasswordCredentials.Name), orgLoginRateLimit(org))
+		// the login limit is lower than the organization limit, so it replaces
+		// the headers set by the middleware
+		setRateLimitHeaders(c.Writer.Header(), result)
+		if err != nil {
 			return nil, err
 		}
 
-		usernameWithOrganization := fmt.Sprintf("%s:%s", r.PasswordCredentials.Name, rCtx.Authenticated.Organization.ID)
-		limiter := redis.NewLimiter(a.server.redis)
+		usernameWithOrganization := fmt.Sprintf("%s:%s", r.PasswordCredentials.Name, org.ID)
 		if err := limiter.LoginOK(usernameWithOrganization); err != nil {
 			return nil, err
 		}
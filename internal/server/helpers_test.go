@@ -126,6 +126,24 @@ func createAccessKey(t *testing.T, db data.WriteTxn, email string) (string, *mod
 	return body, user
 }
 
+// createConnectorAccessKey creates an access key for the connector. When
+// destination is not empty the key is bound to that destination.
+func createConnectorAccessKey(t *testing.T, db data.WriteTxn, destination string) string {
+	t.Helper()
+	token := &models.AccessKey{
+		IssuedFor:  data.InfraConnectorIdentity(db).ID,
+		ProviderID: data.InfraProvider(db).ID,
+		ExpiresAt:  time.Now().Add(time.Minute),
+	}
+	if destination != "" {
+		token.Scopes = models.CommaSeparatedStrings{models.ScopeDestinationPrefix + destination}
+	}
+
+	body, err := data.CreateAccessKey(db, token)
+	assert.NilError(t, err)
+	return body
+}
+
 func createIdentities(t *testing.T, db data.WriteTxn, identities ...*models.Identity) {
 	t.Helper()
 	for i := range identities {
@@ -12,6 +12,7 @@ import (
 func setupMetrics(db *data.DB) *prometheus.Registry {
 	registry := metrics.NewRegistry(productVersion())
 	registry.MustRegister(collectors.NewDBStatsCollector(db.SQLdb(), "postgres"))
+	registry.MustRegister(db.NotifyHub().Collectors()...)
 
 	registry.MustRegister(metrics.NewCollector(prometheus.Opts{
 		Namespace: "infra",
@@ -16,7 +16,6 @@ import (
 	"github.com/infrahq/infra/internal/logging"
 	"github.com/infrahq/infra/internal/server/data"
 	"github.com/infrahq/infra/internal/server/models"
-	"github.com/infrahq/infra/internal/server/redis"
 )
 
 func handleInfraDestinationHeader(tx *data.Transaction, authned access.Authenticated, headers http.Header) error {
@@ -102,8 +101,7 @@ func authenticateRequest(c *gin.Context, route routeSettings, srv *Server) (acce
 	}
 
 	if org != nil {
-		// TODO: limit should be a per-organization setting
-		if err := redis.NewLimiter(srv.redis).RateOK(org.ID.String(), 5000); err != nil {
+		if err := rateLimitRequest(c, srv, org, authned.AccessKey); err != nil {
 			return authned, err
 		}
 	}
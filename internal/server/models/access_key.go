@@ -1,6 +1,7 @@
 package models
 
 import (
+	"strings"
 	"time"
 
 	"github.com/infrahq/infra/api"
@@ -15,6 +16,11 @@ var (
 const (
 	ScopePasswordReset        string = "password-reset"
 	ScopeAllowCreateAccessKey string = "create-key"
+
+	// ScopeDestinationPrefix is the prefix of the scope that binds a connector
+	// access key to a destination. The name of the destination follows the
+	// prefix.
+	ScopeDestinationPrefix string = "destination:"
 )
 
 // AccessKey is a session token presented to the Infra server as proof of authentication
@@ -39,6 +45,10 @@ type AccessKey struct {
 	SecretChecksum []byte
 
 	Scopes CommaSeparatedStrings // if set, scopes limit what the key can be used for
+
+	// RateLimit is the number of requests per minute allowed for the key. The
+	// limit of the organization also applies. Zero means no limit for the key.
+	RateLimit int
 }
 
 func (ak *AccessKey) ToAPI() *api.AccessKey {
@@ -53,7 +63,19 @@ func (ak *AccessKey) ToAPI() *api.AccessKey {
 		Expires:           api.Time(ak.ExpiresAt),
 		InactivityTimeout: api.Time(ak.InactivityTimeout),
 		Scopes:            ak.Scopes,
+		RateLimit:         ak.RateLimit,
+	}
+}
+
+// Destination returns the name of the destination the access key is bound to,
+// or an empty string when the key is not bound to a destination.
+func (ak *AccessKey) Destination() string {
+	for _, scope := range ak.Scopes {
+		if strings.HasPrefix(scope, ScopeDestinationPrefix) {
+			return strings.TrimPrefix(scope, ScopeDestinationPrefix)
+		}
 	}
+	return ""
 }
 
 // Token is only set when creating a key from CreateAccessKey
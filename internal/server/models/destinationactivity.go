@@ -0,0 +1,48 @@
+package models
+
+import (
+	"time"
+
+	"github.com/infrahq/infra/api"
+)
+
+// DestinationActivity is a record of a single request proxied by a connector
+// to a destination. The Model.CreatedAt is the time the record was received by
+// the server, Time is the time the request was received by the connector.
+type DestinationActivity struct {
+	Model
+	OrganizationMember
+
+	DestinationName string
+	Time            time.Time
+	UserName        string
+	Groups          CommaSeparatedStrings
+	Verb            string
+	APIGroup        string
+	Resource        string
+	Subresource     string
+	Namespace       string
+	Name            string
+	Path            string
+	StatusCode      int
+	LatencyMS       int64
+}
+
+func (a *DestinationActivity) ToAPI() *api.DestinationActivity {
+	return &api.DestinationActivity{
+		ID:          a.ID,
+		Destination: a.DestinationName,
+		Time:        api.Time(a.Time),
+		User:        a.UserName,
+		Groups:      a.Groups,
+		Verb:        a.Verb,
+		APIGroup:    a.APIGroup,
+		Resource:    a.Resource,
+		Subresource: a.Subresource,
+		Namespace:   a.Namespace,
+		Name:        a.Name,
+		Path:        a.Path,
+		StatusCode:  a.StatusCode,
+		LatencyMS:   a.LatencyMS,
+	}
+}
@@ -29,6 +29,19 @@ type Grant struct {
 	Resource    string
 	CreatedBy   uid.ID
 	UpdateIndex int64 `db:"-"`
+
+	// SubjectName, SubjectSSHLoginName, and SubjectMembers may be populated by
+	// some queries to contain details about the subject of the grant.
+	SubjectName         string               `db:"-"`
+	SubjectSSHLoginName string               `db:"-"`
+	SubjectMembers      []GrantSubjectMember `db:"-"`
+}
+
+// GrantSubjectMember is a member of the group that is the subject of a grant.
+type GrantSubjectMember struct {
+	ID           uid.ID
+	Name         string
+	SSHLoginName string
 }
 
 type Subject struct {
@@ -67,8 +80,18 @@ func (r *Grant) ToAPI() *api.Grant {
 	switch r.Subject.Kind {
 	case SubjectKindUser:
 		grant.User = r.Subject.ID
+		grant.UserName = r.SubjectName
+		grant.UserSSHLoginName = r.SubjectSSHLoginName
 	case SubjectKindGroup:
 		grant.Group = r.Subject.ID
+		grant.GroupName = r.SubjectName
+		for _, member := range r.SubjectMembers {
+			grant.GroupMembers = append(grant.GroupMembers, api.GrantGroupMember{
+				ID:           member.ID,
+				Name:         member.Name,
+				SSHLoginName: member.SSHLoginName,
+			})
+		}
 	}
 	return grant
 }
@@ -12,6 +12,13 @@ type Organization struct {
 	Domain         string
 	AllowedDomains CommaSeparatedStrings // the email domains that are allowed to login to this org
 
+	// RateLimit is the number of requests per minute allowed for the
+	// organization. Zero uses the server default.
+	RateLimit int
+	// LoginRateLimit is the number of password login attempts per minute
+	// allowed for each user in the organization. Zero uses the server default.
+	LoginRateLimit int
+
 	CreatedBy uid.ID
 }
 
@@ -23,6 +30,10 @@ func (o *Organization) ToAPI() *api.Organization {
 		Updated:        api.Time(o.UpdatedAt),
 		Domain:         o.Domain,
 		AllowedDomains: o.AllowedDomains,
+		RateLimits: api.OrganizationRateLimits{
+			RequestsPerMinute:      o.RateLimit,
+			LoginAttemptsPerMinute: o.LoginRateLimit,
+		},
 	}
 }
 
@@ -0,0 +1,58 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+
+	"github.com/infrahq/infra/api"
+)
+
+// ProxyPolicy denies requests proxied by a connector that match the rule.
+type ProxyPolicy struct {
+	Model
+	OrganizationMember
+
+	Name string
+	// DestinationName is the name of the destination the policy applies to.
+	// When empty the policy applies to all destinations.
+	DestinationName string
+	Reason          string
+	Rule            ProxyPolicyRule
+}
+
+func (p *ProxyPolicy) ToAPI() *api.ProxyPolicy {
+	return &api.ProxyPolicy{
+		ID:          p.ID,
+		Name:        p.Name,
+		Destination: p.DestinationName,
+		Reason:      p.Reason,
+		Rule:        api.ProxyPolicyRule(p.Rule),
+		Created:     api.Time(p.CreatedAt),
+		Updated:     api.Time(p.UpdatedAt),
+	}
+}
+
+// ProxyPolicyRule is stored in the database as a JSON encoded object.
+type ProxyPolicyRule api.ProxyPolicyRule
+
+func (r ProxyPolicyRule) Value() (driver.Value, error) {
+	raw, err := json.Marshal(r)
+	if err != nil {
+		return nil, err
+	}
+	return string(raw), nil
+}
+
+func (r *ProxyPolicyRule) Scan(v any) error {
+	switch value := v.(type) {
+	case nil:
+		return nil
+	case string:
+		return json.Unmarshal([]byte(value), r)
+	case []byte:
+		return json.Unmarshal(value, r)
+	default:
+		return fmt.Errorf("expected string type for proxy policy rule, got %T", v)
+	}
+}
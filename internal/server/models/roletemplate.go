@@ -0,0 +1,56 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+
+	"github.com/infrahq/infra/api"
+)
+
+// RoleTemplate is a named set of rules that connectors create as a role on
+// every destination.
+type RoleTemplate struct {
+	Model
+	OrganizationMember
+
+	Name  string
+	Rules RoleRules
+}
+
+func (r *RoleTemplate) ToAPI() *api.RoleTemplate {
+	return &api.RoleTemplate{
+		ID:      r.ID,
+		Name:    r.Name,
+		Rules:   r.Rules,
+		Created: api.Time(r.CreatedAt),
+		Updated: api.Time(r.UpdatedAt),
+	}
+}
+
+// RoleRules are stored in the database as a JSON encoded list.
+type RoleRules []api.RoleRule
+
+func (r RoleRules) Value() (driver.Value, error) {
+	if r == nil {
+		r = RoleRules{}
+	}
+	raw, err := json.Marshal(r)
+	if err != nil {
+		return nil, err
+	}
+	return string(raw), nil
+}
+
+func (r *RoleRules) Scan(v any) error {
+	switch value := v.(type) {
+	case nil:
+		return nil
+	case string:
+		return json.Unmarshal([]byte(value), r)
+	case []byte:
+		return json.Unmarshal(value, r)
+	default:
+		return fmt.Errorf("expected string type for role rules, got %T", v)
+	}
+}
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+
+	"github.com/infrahq/infra/api"
+	"github.com/infrahq/infra/uid"
+)
+
+// SessionRecording is a recording of an SSH session, uploaded by a connector.
+// Content is the recording in asciicast v2 format, and is only populated by
+// GetSessionRecordingContent.
+type SessionRecording struct {
+	Model
+	OrganizationMember
+
+	DestinationName string
+	UserID          uid.ID
+	UserName        string
+	LoginName       string
+	StartedAt       time.Time
+	EndedAt         time.Time
+	Size            int64
+	Content         []byte
+}
+
+func (r *SessionRecording) ToAPI() *api.SessionRecording {
+	return &api.SessionRecording{
+		ID:          r.ID,
+		Destination: r.DestinationName,
+		UserID:      r.UserID,
+		User:        r.UserName,
+		LoginName:   r.LoginName,
+		StartedAt:   api.Time(r.StartedAt),
+		EndedAt:     api.Time(r.EndedAt),
+		Size:        r.Size,
+		Created:     api.Time(r.CreatedAt),
+	}
+}
@@ -12,7 +12,10 @@ import (
 
 func (a *API) ListOrganizations(c *gin.Context, r *api.ListOrganizationsRequest) (*api.ListResponse[api.Organization], error) {
 	rCtx := getRequestContext(c)
-	p := PaginationFromRequest(r.PaginationRequest)
+	p, err := PaginationFromRequest(r.PaginationRequest)
+	if err != nil {
+		return nil, err
+	}
 	orgs, err := access.ListOrganizations(rCtx, r.Name, &p)
 	if err != nil {
 		return nil, err
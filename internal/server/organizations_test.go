@@ -158,7 +158,8 @@ func TestAPI_GetOrganization(t *testing.T) {
 						"created": "%[3]v",
 						"updated": "%[3]v",
 						"domain": "%[4]v",
-						"allowedDomains": "%[5]v"
+						"allowedDomains": "%[5]v",
+						"rateLimits": {"requestsPerMinute": 0, "loginAttemptsPerMinute": 0}
 					}`,
 					srv.db.DefaultOrg.ID.String(),
 					srv.db.DefaultOrg.Name,
@@ -5,11 +5,22 @@ import (
 
 	"github.com/infrahq/infra/api"
 	"github.com/infrahq/infra/internal/server/data"
+	"github.com/infrahq/infra/internal/validate"
 )
 
 // PaginationFromRequest translates an api.PaginationRequest into the internal
-// Pagination type.
-func PaginationFromRequest(pr api.PaginationRequest) data.Pagination {
+// Pagination type. It returns an error when the request has a cursor, because
+// only the endpoints that use CursorPaginationFromRequest support cursors.
+func PaginationFromRequest(pr api.PaginationRequest) (data.Pagination, error) {
+	if pr.Cursor != "" {
+		return data.Pagination{}, validate.Error{"cursor": {"not supported by this endpoint, use page"}}
+	}
+	return CursorPaginationFromRequest(pr), nil
+}
+
+// CursorPaginationFromRequest translates an api.PaginationRequest into the
+// internal Pagination type, for endpoints that support cursor pagination.
+func CursorPaginationFromRequest(pr api.PaginationRequest) data.Pagination {
 	page, limit := 1, 100
 
 	if pr.Limit != 0 {
@@ -20,6 +31,10 @@ func PaginationFromRequest(pr api.PaginationRequest) data.Pagination {
 		page = pr.Page
 	}
 
+	if pr.Cursor != "" {
+		return data.Pagination{Limit: limit, Cursor: pr.Cursor}
+	}
+
 	return data.Pagination{
 		Page:  page,
 		Limit: limit,
@@ -32,10 +47,14 @@ func PaginationToResponse(p data.Pagination) api.PaginationResponse {
 	if p.Limit == 0 {
 		return api.PaginationResponse{}
 	}
+	if p.Cursor != "" {
+		return api.PaginationResponse{Limit: p.Limit, NextCursor: p.NextCursor}
+	}
 	return api.PaginationResponse{
 		Page:       p.Page,
 		Limit:      p.Limit,
 		TotalCount: p.TotalCount,
 		TotalPages: int(math.Ceil(float64(p.TotalCount) / float64(p.Limit))),
+		NextCursor: p.NextCursor,
 	}
 }
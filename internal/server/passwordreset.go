@@ -12,13 +12,12 @@ import (
 	"github.com/infrahq/infra/internal/access"
 	"github.com/infrahq/infra/internal/server/data"
 	"github.com/infrahq/infra/internal/server/email"
-	"github.com/infrahq/infra/internal/server/redis"
 )
 
 func (a *API) RequestPasswordReset(c *gin.Context, r *api.PasswordResetRequest) (*api.EmptyResponse, error) {
 	rCtx := getRequestContext(c)
 	// no authorization required
-	if err := redis.NewLimiter(a.server.redis).RateOK(r.Email, 10); err != nil {
+	if _, err := a.server.limiter.Rate(r.Email, 10); err != nil {
 		return nil, err
 	}
 
@@ -20,25 +20,32 @@ import (
 // caution: this endpoint is unauthenticated, do not return sensitive info
 func (a *API) ListProviders(c *gin.Context, r *api.ListProvidersRequest) (*api.ListResponse[api.Provider], error) {
 	rCtx := getRequestContext(c)
-	p := PaginationFromRequest(r.PaginationRequest)
+	p, err := PaginationFromRequest(r.PaginationRequest)
+	if err != nil {
+		return nil, err
+	}
 	opts := data.ListProvidersOptions{
 		ByName:               r.Name,
 		ExcludeInfraProvider: true,
 		Pagination:           &p,
 	}
-	providers, err := data.ListProviders(rCtx.DBTxn, opts)
+	providers, err := access.WatchList(rCtx, data.WatchProviders, r.LastUpdateIndex,
+		func(rCtx access.RequestContext) ([]models.Provider, error) {
+			return data.ListProviders(rCtx.DBTxn, opts)
+		})
 	if err != nil {
 		return nil, err
 	}
 
 	// if social login is configured, also return that option
 	if a.server.Google != nil {
-		providers = append(providers, *a.server.Google)
+		providers.Items = append(providers.Items, *a.server.Google)
 	}
 
-	result := api.NewListResponse(providers, PaginationToResponse(p), func(provider models.Provider) api.Provider {
+	result := api.NewListResponse(providers.Items, PaginationToResponse(p), func(provider models.Provider) api.Provider {
 		return *provider.ToAPI()
 	})
+	result.LastUpdateIndex.Index = providers.MaxUpdateIndex
 
 	return result, nil
 }
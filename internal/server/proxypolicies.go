@@ -0,0 +1,107 @@
+package server
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/infrahq/infra/api"
+	"github.com/infrahq/infra/internal"
+	"github.com/infrahq/infra/internal/access"
+	"github.com/infrahq/infra/internal/server/data"
+	"github.com/infrahq/infra/internal/server/models"
+)
+
+func (a *API) ListProxyPolicies(c *gin.Context, r *api.ListProxyPoliciesRequest) (*api.ListResponse[api.ProxyPolicy], error) {
+	rCtx := getRequestContext(c)
+	p, err := PaginationFromRequest(r.PaginationRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := data.ListProxyPoliciesOptions{
+		ByDestination: r.Destination,
+		Pagination:    &p,
+	}
+	policies, err := access.ListProxyPolicies(rCtx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	result := api.NewListResponse(policies, PaginationToResponse(p), func(policy models.ProxyPolicy) api.ProxyPolicy {
+		return *policy.ToAPI()
+	})
+
+	return result, nil
+}
+
+func (a *API) GetProxyPolicy(c *gin.Context, r *api.Resource) (*api.ProxyPolicy, error) {
+	policy, err := access.GetProxyPolicy(getRequestContext(c), r.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return policy.ToAPI(), nil
+}
+
+func (a *API) CreateProxyPolicy(c *gin.Context, r *api.CreateProxyPolicyRequest) (*api.ProxyPolicy, error) {
+	if err := validateProxyPolicyRule(r.Rule); err != nil {
+		return nil, err
+	}
+
+	policy := &models.ProxyPolicy{
+		Name:            r.Name,
+		DestinationName: r.Destination,
+		Reason:          r.Reason,
+		Rule:            models.ProxyPolicyRule(r.Rule),
+	}
+
+	if err := access.CreateProxyPolicy(getRequestContext(c), policy); err != nil {
+		return nil, err
+	}
+
+	return policy.ToAPI(), nil
+}
+
+func (a *API) UpdateProxyPolicy(c *gin.Context, r *api.UpdateProxyPolicyRequest) (*api.ProxyPolicy, error) {
+	if err := validateProxyPolicyRule(r.Rule); err != nil {
+		return nil, err
+	}
+
+	rCtx := getRequestContext(c)
+
+	// Start with the existing value, so that non-update fields are not set to zero.
+	policy, err := access.GetProxyPolicy(rCtx, r.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	policy.Name = r.Name
+	policy.DestinationName = r.Destination
+	policy.Reason = r.Reason
+	policy.Rule = models.ProxyPolicyRule(r.Rule)
+
+	if err := access.UpdateProxyPolicy(rCtx, policy); err != nil {
+		return nil, err
+	}
+
+	return policy.ToAPI(), nil
+}
+
+func (a *API) DeleteProxyPolicy(c *gin.Context, r *api.Resource) (*api.EmptyResponse, error) {
+	return nil, access.DeleteProxyPolicy(getRequestContext(c), r.ID)
+}
+
+// validateProxyPolicyRule checks the fields that can not be validated by the
+// api validation rules, so that connectors never receive a schedule they can
+// not evaluate.
+func validateProxyPolicyRule(rule api.ProxyPolicyRule) error {
+	if rule.Schedule == nil {
+		return nil
+	}
+	if _, err := rule.Schedule.Matches(time.Now()); err != nil {
+		return fmt.Errorf("%w: schedule: %v", internal.ErrBadRequest, err)
+	}
+	return nil
+}
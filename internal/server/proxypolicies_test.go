@@ -0,0 +1,81 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gotest.tools/v3/assert"
+
+	"github.com/infrahq/infra/api"
+)
+
+func TestAPI_ProxyPolicies(t *testing.T) {
+	srv := setupServer(t, withAdminUser)
+	routes := srv.GenerateRoutes()
+
+	do := func(t *testing.T, method, path string, body any) *httptest.ResponseRecorder {
+		t.Helper()
+		var req *http.Request
+		if body != nil {
+			req = httptest.NewRequest(method, path, jsonBody(t, body))
+		} else {
+			req = httptest.NewRequest(method, path, nil)
+		}
+		req.Header.Set("Authorization", "Bearer "+adminAccessKey(srv))
+		req.Header.Set("Infra-Version", apiVersionLatest)
+
+		resp := httptest.NewRecorder()
+		routes.ServeHTTP(resp, req)
+		return resp
+	}
+
+	t.Run("create with invalid schedule", func(t *testing.T) {
+		resp := do(t, http.MethodPost, "/api/proxy-policies", &api.CreateProxyPolicyRequest{
+			Name: "invalid",
+			Rule: api.ProxyPolicyRule{
+				Resources: []string{"secrets"},
+				Schedule:  &api.PolicySchedule{Start: "9am", End: "17:00"},
+			},
+		})
+		assert.Equal(t, resp.Code, http.StatusBadRequest, resp.Body.String())
+	})
+
+	resp := do(t, http.MethodPost, "/api/proxy-policies", &api.CreateProxyPolicyRequest{
+		Name:   "no-exec",
+		Reason: "exec is not allowed in kube-system",
+		Rule: api.ProxyPolicyRule{
+			Resources:    []string{"pods"},
+			Subresources: []string{"exec"},
+			Namespaces:   []string{"kube-system"},
+		},
+	})
+	assert.Equal(t, resp.Code, http.StatusCreated, resp.Body.String())
+
+	resp = do(t, http.MethodPost, "/api/proxy-policies", &api.CreateProxyPolicyRequest{
+		Name:        "production-only",
+		Destination: "production",
+		Rule:        api.ProxyPolicyRule{Resources: []string{"secrets"}},
+	})
+	assert.Equal(t, resp.Code, http.StatusCreated, resp.Body.String())
+
+	listNames := func(t *testing.T, destination string) []string {
+		t.Helper()
+		resp := do(t, http.MethodGet, "/api/proxy-policies?destination="+destination, nil)
+		assert.Equal(t, resp.Code, http.StatusOK, resp.Body.String())
+
+		var list api.ListResponse[api.ProxyPolicy]
+		assert.NilError(t, json.NewDecoder(resp.Body).Decode(&list))
+		var names []string
+		for _, item := range list.Items {
+			names = append(names, item.Name)
+		}
+		return names
+	}
+
+	t.Run("list by destination", func(t *testing.T) {
+		assert.DeepEqual(t, listNames(t, "production"), []string{"no-exec", "production-only"})
+		assert.DeepEqual(t, listNames(t, "staging"), []string{"no-exec"})
+	})
+}
@@ -0,0 +1,164 @@
+package server
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/infrahq/infra/api"
+	"github.com/infrahq/infra/internal/access"
+	"github.com/infrahq/infra/internal/server/data"
+	"github.com/infrahq/infra/internal/server/models"
+	"github.com/infrahq/infra/internal/server/ratelimit"
+	"github.com/infrahq/infra/internal/server/redis"
+	"github.com/infrahq/infra/uid"
+)
+
+const (
+	// defaultOrgRateLimit is the number of requests per minute allowed for an
+	// organization that does not set a rate limit.
+	defaultOrgRateLimit = 5000
+	// defaultLoginRateLimit is the number of password login attempts per
+	// minute allowed for each user of an organization that does not set a
+	// login rate limit.
+	defaultLoginRateLimit = 10
+)
+
+func orgRateLimit(org *models.Organization) int {
+	if org.RateLimit > 0 {
+		return org.RateLimit
+	}
+	return defaultOrgRateLimit
+}
+
+func orgLoginRateLimit(org *models.Organization) int {
+	if org.LoginRateLimit > 0 {
+		return org.LoginRateLimit
+	}
+	return defaultLoginRateLimit
+}
+
+func orgRateLimitKey(orgID uid.ID) string {
+	return "org:" + orgID.String()
+}
+
+func accessKeyRateLimitKey(keyID uid.ID) string {
+	return "key:" + keyID.String()
+}
+
+// loginRateLimitKey is the key used to count login attempts. Login attempts
+// are counted separately from other requests, so that a user who is making
+// many requests can still login, and so that guessing passwords is limited
+// to far fewer attempts than the organization limit.
+func loginRateLimitKey(orgID uid.ID, username string) string {
+	return fmt.Sprintf("login:%v:%v", orgID, username)
+}
+
+// newLimiter returns the ratelimit.Limiter selected by name. When name is
+// empty redis is used if it is configured, otherwise postgres is used.
+func newLimiter(name string, r *redis.Redis, db *data.DB) (ratelimit.Limiter, error) {
+	if name == "" {
+		name = "postgres"
+		if r != nil {
+			name = "redis"
+		}
+	}
+
+	switch name {
+	case "redis":
+		if r == nil {
+			return nil, fmt.Errorf("rateLimiter is redis, but redis is not configured")
+		}
+		return redis.NewLimiter(r), nil
+	case "postgres":
+		return data.NewRateLimiter(db), nil
+	case "memory":
+		return ratelimit.NewMemoryLimiter(), nil
+	default:
+		return nil, fmt.Errorf("unknown rateLimiter %q, must be one of redis, postgres, or memory", name)
+	}
+}
+
+// rateLimitRequest counts the request against the rate limit of the
+// organization, and the rate limit of the access key if it has one. The
+// RateLimit headers are set from whichever limit has fewer remaining requests.
+func rateLimitRequest(c *gin.Context, srv *Server, org *models.Organization, key *models.AccessKey) error {
+	limiter := srv.limiter
+	result, err := limiter.Rate(orgRateLimitKey(org.ID), orgRateLimit(org))
+	if err == nil && key != nil && key.RateLimit > 0 {
+		var keyResult ratelimit.Result
+		keyResult, err = limiter.Rate(accessKeyRateLimitKey(key.ID), key.RateLimit)
+		if err != nil || keyResult.Remaining < result.Remaining {
+			result = keyResult
+		}
+	}
+	setRateLimitHeaders(c.Writer.Header(), result)
+	return err
+}
+
+// setRateLimitHeaders sets the RateLimit-Limit, RateLimit-Remaining, and
+// RateLimit-Reset headers described by
+// https://datatracker.ietf.org/doc/draft-ietf-httpapi-ratelimit-headers/
+func setRateLimitHeaders(header http.Header, result ratelimit.Result) {
+	header.Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+	header.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	header.Set("RateLimit-Reset", strconv.Itoa(int(math.Ceil(result.ResetAfter.Seconds()))))
+}
+
+func (a *API) UpdateOrganizationRateLimits(c *gin.Context, r *api.UpdateOrganizationRateLimitsRequest) (*api.Organization, error) {
+	rCtx := getRequestContext(c)
+	org, err := access.UpdateOrganizationRateLimits(rCtx, r.ID, r.RequestsPerMinute, r.LoginAttemptsPerMinute)
+	if err != nil {
+		return nil, err
+	}
+	return org.ToAPI(), nil
+}
+
+func (a *API) GetRateLimitUsage(c *gin.Context, r *api.GetRateLimitUsageRequest) (*api.GetRateLimitUsageResponse, error) {
+	rCtx := getRequestContext(c)
+	if r.ID.IsSelf {
+		org := rCtx.Authenticated.Organization
+		if org == nil {
+			return nil, fmt.Errorf("no authenticated user")
+		}
+		r.ID.ID = org.ID
+	}
+
+	org, keys, err := access.GetOrganizationRateLimits(rCtx, r.ID.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	usage := func(key string, limit int) (api.RateLimitUsage, error) {
+		result, err := a.server.limiter.Usage(key, limit)
+		if err != nil {
+			return api.RateLimitUsage{}, err
+		}
+		return api.RateLimitUsage{
+			Limit:      result.Limit,
+			Remaining:  result.Remaining,
+			ResetAfter: api.Duration(result.ResetAfter),
+		}, nil
+	}
+
+	resp := &api.GetRateLimitUsageResponse{AccessKeys: []api.AccessKeyRateLimitUsage{}}
+	resp.Organization, err = usage(orgRateLimitKey(org.ID), orgRateLimit(org))
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range keys {
+		keyUsage, err := usage(accessKeyRateLimitKey(key.ID), key.RateLimit)
+		if err != nil {
+			return nil, err
+		}
+		resp.AccessKeys = append(resp.AccessKeys, api.AccessKeyRateLimitUsage{
+			ID:    key.ID,
+			Name:  key.Name,
+			Usage: keyUsage,
+		})
+	}
+	return resp, nil
+}
@@ -0,0 +1,7 @@
+package ratelimit
+
+import "time"
+
+func SetNow(lim *MemoryLimiter, now func() time.Time) {
+	lim.now = now
+}
@@ -0,0 +1,143 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// MemoryLimiter is a Limiter that stores counts in memory. The counts are
+// not shared with other processes, so MemoryLimiter is only appropriate when
+// the server is run as a single replica.
+type MemoryLimiter struct {
+	now func() time.Time
+
+	mu        sync.Mutex
+	windows   map[string]*memoryWindow
+	logins    map[string]*memoryLogin
+	lastSweep time.Time
+}
+
+type memoryWindow struct {
+	start    time.Time
+	previous int
+	current  int
+}
+
+type memoryLogin struct {
+	failures    int
+	lockedUntil time.Time
+	lastFailure time.Time
+}
+
+// loginFailureExpiry is how long failed login attempts are remembered after
+// the last failed attempt.
+const loginFailureExpiry = 24 * time.Hour
+
+func NewMemoryLimiter() *MemoryLimiter {
+	return &MemoryLimiter{
+		now:     time.Now,
+		windows: make(map[string]*memoryWindow),
+		logins:  make(map[string]*memoryLogin),
+	}
+}
+
+func (lim *MemoryLimiter) Rate(key string, limit int) (Result, error) {
+	return lim.allowN(key, limit, 1)
+}
+
+func (lim *MemoryLimiter) Usage(key string, limit int) (Result, error) {
+	return lim.allowN(key, limit, 0)
+}
+
+func (lim *MemoryLimiter) allowN(key string, limit int, n int) (Result, error) {
+	lim.mu.Lock()
+	defer lim.mu.Unlock()
+
+	now := lim.now()
+	lim.sweep(now)
+
+	start := WindowStart(now)
+	w := lim.windows[key]
+	if w == nil {
+		w = &memoryWindow{start: start}
+	}
+	switch {
+	case w.start.Equal(start):
+	case w.start.Add(Window).Equal(start):
+		w.previous, w.current = w.current, 0
+		w.start = start
+	default:
+		w.previous, w.current = 0, 0
+		w.start = start
+	}
+
+	sw := SlidingWindow{Previous: w.previous, Current: w.current, Elapsed: now.Sub(start)}
+	result, err := sw.Allow(limit, n)
+	if err != nil {
+		return result, err
+	}
+	if n > 0 {
+		w.current += n
+		lim.windows[key] = w
+	}
+	return result, nil
+}
+
+// sweep removes windows and login failures that are no longer used. sweep
+// must be called with lim.mu held.
+func (lim *MemoryLimiter) sweep(now time.Time) {
+	if now.Sub(lim.lastSweep) < Window {
+		return
+	}
+	lim.lastSweep = now
+
+	for key, w := range lim.windows {
+		if now.Sub(w.start) >= 2*Window {
+			delete(lim.windows, key)
+		}
+	}
+	for key, login := range lim.logins {
+		if now.Sub(login.lastFailure) >= loginFailureExpiry {
+			delete(lim.logins, key)
+		}
+	}
+}
+
+func (lim *MemoryLimiter) LoginOK(key string) error {
+	lim.mu.Lock()
+	defer lim.mu.Unlock()
+
+	login := lim.logins[key]
+	if login == nil {
+		return nil
+	}
+	if retryAfter := login.lockedUntil.Sub(lim.now()); retryAfter > 0 {
+		return OverLimitError{RetryAfter: retryAfter}
+	}
+	return nil
+}
+
+func (lim *MemoryLimiter) LoginGood(key string) {
+	lim.mu.Lock()
+	defer lim.mu.Unlock()
+
+	delete(lim.logins, key)
+}
+
+func (lim *MemoryLimiter) LoginBad(key string, limit int) {
+	lim.mu.Lock()
+	defer lim.mu.Unlock()
+
+	now := lim.now()
+	login := lim.logins[key]
+	if login == nil {
+		login = &memoryLogin{}
+		lim.logins[key] = login
+	}
+	login.failures++
+	login.lastFailure = now
+
+	if login.failures >= limit && !login.lockedUntil.After(now) {
+		login.lockedUntil = now.Add(LockoutDuration(login.failures))
+	}
+}
@@ -0,0 +1,18 @@
+package ratelimit_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/infrahq/infra/internal/server/ratelimit"
+	"github.com/infrahq/infra/internal/server/ratelimit/ratelimittest"
+)
+
+func TestMemoryLimiter(t *testing.T) {
+	ratelimittest.TestLimiter(t, func(t *testing.T) (ratelimit.Limiter, func(time.Duration)) {
+		lim := ratelimit.NewMemoryLimiter()
+		now := time.Date(2023, 2, 3, 10, 20, 30, 0, time.UTC)
+		ratelimit.SetNow(lim, func() time.Time { return now })
+		return lim, func(d time.Duration) { now = now.Add(d) }
+	})
+}
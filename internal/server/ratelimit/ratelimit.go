@@ -0,0 +1,146 @@
+// Package ratelimit defines the Limiter used to rate limit requests and lock
+// out users after failed login attempts. Implementations are provided for
+// redis (in the redis package), postgres (in the data package), and memory.
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// Limiter counts requests for a key, and tracks failed login attempts.
+type Limiter interface {
+	// Rate counts a request against the rate per minute for the key. Rate
+	// returns an OverLimitError if the limit has been reached.
+	Rate(key string, limit int) (Result, error)
+	// Usage returns the state of the rate per minute for the key, without
+	// counting a request.
+	Usage(key string, limit int) (Result, error)
+
+	// LoginOK returns an OverLimitError if the key is locked out because of
+	// failed login attempts.
+	LoginOK(key string) error
+	// LoginGood resets the failed login attempts for the key.
+	LoginGood(key string)
+	// LoginBad records a failed login attempt for the key. Once limit
+	// failed attempts have been recorded the key is locked out for a
+	// duration that increases with every failed attempt.
+	LoginBad(key string, limit int)
+}
+
+type OverLimitError struct {
+	RetryAfter time.Duration
+}
+
+func (e OverLimitError) Error() string {
+	return fmt.Sprintf("over limit; retry after %v", e.RetryAfter.Truncate(time.Second))
+}
+
+// Result is the state of a rate limit after a request.
+type Result struct {
+	// Limit is the number of requests allowed per minute.
+	Limit int
+	// Remaining is the number of requests that are allowed before the limit
+	// is reached.
+	Remaining int
+	// ResetAfter is the time until Remaining returns to Limit.
+	ResetAfter time.Duration
+}
+
+// LockoutDuration returns how long a key is locked out after the number of
+// failed login attempts.
+func LockoutDuration(failures int) time.Duration {
+	return time.Duration(math.Pow(1.5, float64(failures)) * float64(time.Second))
+}
+
+// Window is the duration of the window used by SlidingWindow.
+const Window = time.Minute
+
+// SlidingWindow approximates the number of requests in the last Window from
+// the count of requests in the current and previous fixed windows. The
+// requests in the previous window are assumed to be evenly distributed.
+type SlidingWindow struct {
+	// Previous is the number of requests in the previous window.
+	Previous int
+	// Current is the number of requests in the current window.
+	Current int
+	// Elapsed is the time since the start of the current window.
+	Elapsed time.Duration
+}
+
+// WindowStart returns the start of the fixed window that contains now.
+func WindowStart(now time.Time) time.Time {
+	return now.Truncate(Window)
+}
+
+func (w SlidingWindow) estimate() float64 {
+	weight := 1 - float64(w.Elapsed)/float64(Window)
+	return float64(w.Previous)*weight + float64(w.Current)
+}
+
+// MaxCurrent returns the largest value of Current that is within limit. It is
+// used by limiters that count requests with a conditional update, instead of
+// reading Current before counting the requests.
+func (w SlidingWindow) MaxCurrent(limit int) int {
+	weight := 1 - float64(w.Elapsed)/float64(Window)
+	return int(math.Floor(float64(limit) - float64(w.Previous)*weight))
+}
+
+// Allow returns the result of counting n more requests in the window. Allow
+// returns an OverLimitError if n is greater than 0 and the requests would
+// exceed limit. The caller is responsible for adding n to Current when the
+// requests are allowed.
+func (w SlidingWindow) Allow(limit int, n int) (Result, error) {
+	estimate := w.estimate()
+	if n > 0 && estimate+float64(n) > float64(limit) {
+		return Result{Limit: limit, ResetAfter: w.resetAfter(w.Current)}, OverLimitError{
+			RetryAfter: w.retryAfter(limit, n),
+		}
+	}
+
+	remaining := limit - int(math.Ceil(estimate)) - n
+	if remaining < 0 {
+		remaining = 0
+	}
+	return Result{
+		Limit:      limit,
+		Remaining:  remaining,
+		ResetAfter: w.resetAfter(w.Current + n),
+	}, nil
+}
+
+// resetAfter returns the time until all the requests have left the window.
+func (w SlidingWindow) resetAfter(current int) time.Duration {
+	switch {
+	case current > 0:
+		return 2*Window - w.Elapsed
+	case w.Previous > 0:
+		return Window - w.Elapsed
+	default:
+		return 0
+	}
+}
+
+// retryAfter returns the time until n more requests would be allowed.
+func (w SlidingWindow) retryAfter(limit int, n int) time.Duration {
+	available := float64(limit - n)
+	if float64(w.Current) <= available && w.Previous > 0 {
+		// allowed later in the current window, once enough of the previous
+		// window has left the sliding window.
+		fraction := 1 - (available-float64(w.Current))/float64(w.Previous)
+		return time.Duration(fraction*float64(Window)) - w.Elapsed
+	}
+
+	// allowed in the next window, once enough of the current window has left
+	// the sliding window.
+	untilNext := Window - w.Elapsed
+	if w.Current == 0 {
+		return untilNext
+	}
+	fraction := 1 - available/float64(w.Current)
+	if fraction < 0 {
+		fraction = 0
+	}
+	return untilNext + time.Duration(fraction*float64(Window))
+}
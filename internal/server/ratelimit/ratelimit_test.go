@@ -0,0 +1,29 @@
+package ratelimit_test
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+
+	"github.com/infrahq/infra/internal/server/ratelimit"
+)
+
+func TestSlidingWindow_MaxCurrent(t *testing.T) {
+	for _, elapsed := range []time.Duration{0, 15 * time.Second, 40 * time.Second, 59 * time.Second} {
+		for _, previous := range []int{0, 3, 10, 25} {
+			sw := ratelimit.SlidingWindow{Previous: previous, Elapsed: elapsed}
+			maxCurrent := sw.MaxCurrent(10)
+
+			// MaxCurrent must agree with Allow, so that a conditional update
+			// allows the same requests as Allow.
+			for current := 0; current <= 12; current++ {
+				sw.Current = current
+				_, err := sw.Allow(10, 1)
+				allowed := current+1 <= maxCurrent
+				assert.Equal(t, err == nil, allowed,
+					"previous=%d current=%d elapsed=%v", previous, current, elapsed)
+			}
+		}
+	}
+}
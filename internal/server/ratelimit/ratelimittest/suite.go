@@ -0,0 +1,242 @@
+// Package ratelimittest provides a test suite for implementations of
+// ratelimit.Limiter.
+package ratelimittest
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/assert/opt"
+
+	"github.com/infrahq/infra/internal/server/ratelimit"
+)
+
+// Setup returns a new Limiter with no previous requests, and a function that
+// advances the time seen by the Limiter.
+type Setup func(t *testing.T) (lim ratelimit.Limiter, fastForward func(time.Duration))
+
+// TestLimiter runs the test suite against the Limiter returned by setup. Every
+// implementation of ratelimit.Limiter should pass this suite.
+func TestLimiter(t *testing.T, setup Setup) {
+	t.Run("Rate", func(t *testing.T) {
+		testRate(t, setup)
+	})
+	t.Run("Usage", func(t *testing.T) {
+		testUsage(t, setup)
+	})
+	t.Run("LoginOK", func(t *testing.T) {
+		testLoginOK(t, setup)
+	})
+}
+
+func testRate(t *testing.T, setup Setup) {
+	t.Run("under limit", func(t *testing.T) {
+		lim, _ := setup(t)
+		result, err := lim.Rate("key1", 10)
+		assert.NilError(t, err)
+		assert.Equal(t, result.Limit, 10)
+		assert.Equal(t, result.Remaining, 9)
+		assert.Assert(t, result.ResetAfter > 0, result.ResetAfter)
+		assert.Assert(t, result.ResetAfter <= 2*time.Minute, result.ResetAfter)
+	})
+
+	t.Run("over limit", func(t *testing.T) {
+		lim, _ := setup(t)
+
+		_, err := lim.Rate("key1", 1)
+		assert.NilError(t, err)
+
+		result, err := lim.Rate("key1", 1)
+		assert.ErrorContains(t, err, "over limit")
+		assert.Equal(t, result.Limit, 1)
+		assert.Equal(t, result.Remaining, 0)
+
+		var overLimit ratelimit.OverLimitError
+		assert.Assert(t, errors.As(err, &overLimit))
+		assert.Assert(t, overLimit.RetryAfter > 0, overLimit.RetryAfter)
+		assert.Assert(t, overLimit.RetryAfter <= 2*time.Minute, overLimit.RetryAfter)
+	})
+
+	t.Run("limit reset after 2 minutes", func(t *testing.T) {
+		lim, fastForward := setup(t)
+
+		_, err := lim.Rate("key1", 1)
+		assert.NilError(t, err)
+
+		_, err = lim.Rate("key1", 1)
+		assert.ErrorContains(t, err, "over limit")
+
+		fastForward(2 * time.Minute)
+		_, err = lim.Rate("key1", 1)
+		assert.NilError(t, err)
+	})
+
+	t.Run("consistently under limit", func(t *testing.T) {
+		lim, fastForward := setup(t)
+
+		for i := 0; i < 20; i++ {
+			_, err := lim.Rate("key1", 10)
+			assert.NilError(t, err, "request %d", i)
+			fastForward(10 * time.Second)
+		}
+	})
+
+	t.Run("keys are counted separately", func(t *testing.T) {
+		lim, _ := setup(t)
+
+		keys := []string{"key1", "key2", "key3"}
+		for _, key := range keys {
+			_, err := lim.Rate(key, 1)
+			assert.NilError(t, err)
+
+			_, err = lim.Rate(key, 1)
+			assert.ErrorContains(t, err, "over limit")
+		}
+	})
+}
+
+func testUsage(t *testing.T, setup Setup) {
+	t.Run("no requests", func(t *testing.T) {
+		lim, _ := setup(t)
+
+		result, err := lim.Usage("key1", 10)
+		assert.NilError(t, err)
+		assert.DeepEqual(t, result, ratelimit.Result{Limit: 10, Remaining: 10})
+	})
+
+	t.Run("does not count a request", func(t *testing.T) {
+		lim, _ := setup(t)
+
+		_, err := lim.Rate("key1", 10)
+		assert.NilError(t, err)
+
+		for i := 0; i < 2; i++ {
+			result, err := lim.Usage("key1", 10)
+			assert.NilError(t, err)
+			assert.Equal(t, result.Limit, 10)
+			assert.Equal(t, result.Remaining, 9)
+		}
+	})
+
+	t.Run("over limit", func(t *testing.T) {
+		lim, _ := setup(t)
+
+		_, err := lim.Rate("key1", 1)
+		assert.NilError(t, err)
+
+		result, err := lim.Usage("key1", 1)
+		assert.NilError(t, err)
+		assert.Equal(t, result.Remaining, 0)
+	})
+}
+
+func testLoginOK(t *testing.T, setup Setup) {
+	t.Run("under limit", func(t *testing.T) {
+		lim, _ := setup(t)
+		err := lim.LoginOK("admin@example.com")
+		assert.NilError(t, err)
+	})
+
+	t.Run("over limit", func(t *testing.T) {
+		lim, _ := setup(t)
+
+		lim.LoginBad("admin@example.com", 1)
+
+		expected, _ := time.ParseDuration("1.5s")
+		err := lim.LoginOK("admin@example.com")
+		assert.DeepEqual(t, err, ratelimit.OverLimitError{
+			RetryAfter: expected,
+		}, opt.DurationWithThreshold(100*time.Millisecond))
+	})
+
+	t.Run("way over limit", func(t *testing.T) {
+		lim, _ := setup(t)
+
+		for i := 0; i < 10; i++ {
+			lim.LoginBad("admin@example.com", 10)
+		}
+
+		expected, _ := time.ParseDuration("57s")
+		err := lim.LoginOK("admin@example.com")
+		assert.DeepEqual(t, err, ratelimit.OverLimitError{
+			RetryAfter: expected,
+		}, opt.DurationWithThreshold(time.Second))
+	})
+
+	t.Run("reset limit", func(t *testing.T) {
+		lim, _ := setup(t)
+
+		for i := 0; i < 10; i++ {
+			lim.LoginBad("admin@example.com", 10)
+		}
+
+		expected, _ := time.ParseDuration("57s")
+		err := lim.LoginOK("admin@example.com")
+		assert.DeepEqual(t, err, ratelimit.OverLimitError{
+			RetryAfter: expected,
+		}, opt.DurationWithThreshold(time.Second))
+
+		lim.LoginGood("admin@example.com")
+		err = lim.LoginOK("admin@example.com")
+		assert.NilError(t, err)
+	})
+
+	t.Run("over limit reset after lockout period", func(t *testing.T) {
+		lim, fastForward := setup(t)
+
+		for i := 0; i < 10; i++ {
+			lim.LoginBad("admin@example.com", 10)
+		}
+
+		expected, _ := time.ParseDuration("57s")
+		err := lim.LoginOK("admin@example.com")
+		assert.DeepEqual(t, err, ratelimit.OverLimitError{
+			RetryAfter: expected,
+		}, opt.DurationWithThreshold(time.Second))
+
+		fastForward(time.Minute)
+
+		err = lim.LoginOK("admin@example.com")
+		assert.NilError(t, err)
+	})
+
+	t.Run("failed after lockout period", func(t *testing.T) {
+		lim, fastForward := setup(t)
+
+		for i := 0; i < 10; i++ {
+			lim.LoginBad("admin@example.com", 10)
+		}
+
+		expected, _ := time.ParseDuration("57s")
+		err := lim.LoginOK("admin@example.com")
+		assert.DeepEqual(t, err, ratelimit.OverLimitError{
+			RetryAfter: expected,
+		}, opt.DurationWithThreshold(time.Second))
+
+		fastForward(time.Minute)
+
+		err = lim.LoginOK("admin@example.com")
+		assert.NilError(t, err)
+
+		lim.LoginBad("admin@example.com", 10)
+
+		expected, _ = time.ParseDuration("1m26s")
+		err = lim.LoginOK("admin@example.com")
+		assert.DeepEqual(t, err, ratelimit.OverLimitError{
+			RetryAfter: expected,
+		}, opt.DurationWithThreshold(time.Second))
+	})
+
+	t.Run("keys are counted separately", func(t *testing.T) {
+		lim, _ := setup(t)
+
+		lim.LoginBad("admin@example.com", 1)
+		err := lim.LoginOK("admin@example.com")
+		assert.ErrorContains(t, err, "over limit")
+
+		err = lim.LoginOK("other@example.com")
+		assert.NilError(t, err)
+	})
+}
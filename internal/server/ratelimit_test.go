@@ -0,0 +1,148 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"gotest.tools/v3/assert"
+
+	"github.com/infrahq/infra/api"
+	"github.com/infrahq/infra/internal/server/data"
+	"github.com/infrahq/infra/internal/server/models"
+	"github.com/infrahq/infra/internal/server/ratelimit"
+)
+
+func TestRateLimitRequest(t *testing.T) {
+	setup := func(t *testing.T) *Server {
+		return &Server{limiter: ratelimit.NewMemoryLimiter()}
+	}
+
+	newContext := func() *gin.Context {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		return c
+	}
+
+	t.Run("organization limit", func(t *testing.T) {
+		srv := setup(t)
+		org := &models.Organization{Model: models.Model{ID: 1234}, RateLimit: 2}
+
+		c := newContext()
+		assert.NilError(t, rateLimitRequest(c, srv, org, nil))
+		assert.Equal(t, c.Writer.Header().Get("RateLimit-Limit"), "2")
+		assert.Equal(t, c.Writer.Header().Get("RateLimit-Remaining"), "1")
+		reset, err := strconv.Atoi(c.Writer.Header().Get("RateLimit-Reset"))
+		assert.NilError(t, err)
+		assert.Assert(t, reset > 0 && reset <= 120, reset)
+
+		assert.NilError(t, rateLimitRequest(newContext(), srv, org, nil))
+
+		c = newContext()
+		err = rateLimitRequest(c, srv, org, nil)
+		assert.ErrorContains(t, err, "over limit")
+		assert.Equal(t, c.Writer.Header().Get("RateLimit-Remaining"), "0")
+	})
+
+	t.Run("default organization limit", func(t *testing.T) {
+		srv := setup(t)
+		org := &models.Organization{Model: models.Model{ID: 1234}}
+
+		c := newContext()
+		assert.NilError(t, rateLimitRequest(c, srv, org, nil))
+		assert.Equal(t, c.Writer.Header().Get("RateLimit-Limit"), strconv.Itoa(defaultOrgRateLimit))
+	})
+
+	t.Run("access key limit", func(t *testing.T) {
+		srv := setup(t)
+		org := &models.Organization{Model: models.Model{ID: 1234}}
+		key := &models.AccessKey{Model: models.Model{ID: 5678}, RateLimit: 1}
+
+		c := newContext()
+		assert.NilError(t, rateLimitRequest(c, srv, org, key))
+		assert.Equal(t, c.Writer.Header().Get("RateLimit-Limit"), "1")
+		assert.Equal(t, c.Writer.Header().Get("RateLimit-Remaining"), "0")
+
+		err := rateLimitRequest(newContext(), srv, org, key)
+		assert.ErrorContains(t, err, "over limit")
+
+		// other requests in the organization are not limited by the key
+		other := &models.AccessKey{Model: models.Model{ID: 5679}}
+		assert.NilError(t, rateLimitRequest(newContext(), srv, org, other))
+	})
+}
+
+func TestAPI_OrganizationRateLimits(t *testing.T) {
+	srv := setupServer(t, withAdminUser)
+	routes := srv.GenerateRoutes()
+
+	orgID := srv.db.DefaultOrg.ID.String()
+	userKey, _ := createAccessKey(t, srv.DB(), "user@example.com")
+
+	t.Run("get usage", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/organizations/"+orgID+"/ratelimits", nil)
+		req.Header.Set("Authorization", "Bearer "+adminAccessKey(srv))
+		req.Header.Set("Infra-Version", apiVersionLatest)
+
+		resp := httptest.NewRecorder()
+		routes.ServeHTTP(resp, req)
+		assert.Equal(t, resp.Code, http.StatusOK, resp.Body.String())
+
+		var respBody api.GetRateLimitUsageResponse
+		assert.NilError(t, json.Unmarshal(resp.Body.Bytes(), &respBody))
+		expected := api.GetRateLimitUsageResponse{
+			Organization: api.RateLimitUsage{Limit: defaultOrgRateLimit, Remaining: defaultOrgRateLimit},
+			AccessKeys:   []api.AccessKeyRateLimitUsage{},
+		}
+		assert.DeepEqual(t, respBody, expected)
+	})
+
+	t.Run("get usage requires admin", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/organizations/"+orgID+"/ratelimits", nil)
+		req.Header.Set("Authorization", "Bearer "+userKey)
+		req.Header.Set("Infra-Version", apiVersionLatest)
+
+		resp := httptest.NewRecorder()
+		routes.ServeHTTP(resp, req)
+		assert.Equal(t, resp.Code, http.StatusForbidden, resp.Body.String())
+	})
+
+	t.Run("update requires support admin", func(t *testing.T) {
+		body := jsonBody(t, api.UpdateOrganizationRateLimitsRequest{RequestsPerMinute: 10})
+		req := httptest.NewRequest(http.MethodPut, "/api/organizations/"+orgID+"/ratelimits", body)
+		req.Header.Set("Authorization", "Bearer "+adminAccessKey(srv))
+		req.Header.Set("Infra-Version", apiVersionLatest)
+
+		resp := httptest.NewRecorder()
+		routes.ServeHTTP(resp, req)
+		assert.Equal(t, resp.Code, http.StatusForbidden, resp.Body.String())
+	})
+}
+
+func TestAPI_UpdateOrganizationRateLimits(t *testing.T) {
+	srv := setupServer(t, withSupportAdminGrant)
+	routes := srv.GenerateRoutes()
+
+	orgID := srv.db.DefaultOrg.ID.String()
+
+	body := jsonBody(t, api.UpdateOrganizationRateLimitsRequest{RequestsPerMinute: 100, LoginAttemptsPerMinute: 5})
+	req := httptest.NewRequest(http.MethodPut, "/api/organizations/"+orgID+"/ratelimits", body)
+	req.Header.Set("Authorization", "Bearer "+adminAccessKey(srv))
+	req.Header.Set("Infra-Version", apiVersionLatest)
+
+	resp := httptest.NewRecorder()
+	routes.ServeHTTP(resp, req)
+	assert.Equal(t, resp.Code, http.StatusOK, resp.Body.String())
+
+	var respBody api.Organization
+	assert.NilError(t, json.Unmarshal(resp.Body.Bytes(), &respBody))
+	expected := api.OrganizationRateLimits{RequestsPerMinute: 100, LoginAttemptsPerMinute: 5}
+	assert.DeepEqual(t, respBody.RateLimits, expected)
+
+	org, err := data.GetOrganization(srv.DB(), data.GetOrganizationOptions{ByID: srv.db.DefaultOrg.ID})
+	assert.NilError(t, err)
+	assert.Equal(t, orgRateLimit(org), 100)
+	assert.Equal(t, orgLoginRateLimit(org), 5)
+}
@@ -4,23 +4,18 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"math"
 	"time"
 
 	"github.com/go-redis/redis/v8"
 	rate "github.com/go-redis/redis_rate/v9"
 
 	"github.com/infrahq/infra/internal/logging"
+	"github.com/infrahq/infra/internal/server/ratelimit"
 )
 
-type OverLimitError struct {
-	RetryAfter time.Duration
-}
-
-func (e OverLimitError) Error() string {
-	return fmt.Sprintf("over limit; retry after %v", e.RetryAfter.Truncate(time.Second))
-}
+var _ ratelimit.Limiter = (*Limiter)(nil)
 
+// Limiter is a ratelimit.Limiter that stores counts in redis.
 type Limiter struct {
 	redis *Redis
 }
@@ -31,17 +26,28 @@ func NewLimiter(redis *Redis) *Limiter {
 	}
 }
 
-// RateOK checks if the rate per minute is acceptable for the specified key
-func (lim *Limiter) RateOK(key string, limit int) error {
+// Rate counts a request against the rate per minute for the specified key.
+// The result is the zero value when redis is not configured.
+func (lim *Limiter) Rate(key string, limit int) (ratelimit.Result, error) {
+	return lim.allowN(key, limit, 1)
+}
+
+// Usage returns the state of the rate per minute for the specified key,
+// without counting a request.
+func (lim *Limiter) Usage(key string, limit int) (ratelimit.Result, error) {
+	return lim.allowN(key, limit, 0)
+}
+
+func (lim *Limiter) allowN(key string, limit int, n int) (ratelimit.Result, error) {
 	if lim.redis == nil {
-		return nil
+		return ratelimit.Result{}, nil
 	}
 
 	ctx := context.TODO()
 	limiter := rate.NewLimiter(lim.redis.client)
-	result, err := limiter.Allow(ctx, key, rate.PerMinute(limit))
+	result, err := limiter.AllowN(ctx, key, rate.PerMinute(limit), n)
 	if err != nil {
-		return err
+		return ratelimit.Result{}, err
 	}
 
 	logging.L.Debug().
@@ -52,13 +58,18 @@ func (lim *Limiter) RateOK(key string, limit int) error {
 		Dur("retry_after", result.RetryAfter).
 		Msg("rate limit check")
 
-	if result.Allowed <= 0 {
-		return OverLimitError{
+	rateResult := ratelimit.Result{
+		Limit:      limit,
+		Remaining:  result.Remaining,
+		ResetAfter: result.ResetAfter,
+	}
+	if n > 0 && result.Allowed <= 0 {
+		return rateResult, ratelimit.OverLimitError{
 			RetryAfter: result.RetryAfter,
 		}
 	}
 
-	return nil
+	return rateResult, nil
 }
 
 func loginKey(key string) string {
@@ -93,7 +104,7 @@ func (lim *Limiter) LoginOK(key string) error {
 		Msg("login limit check")
 
 	if ok {
-		return OverLimitError{
+		return ratelimit.OverLimitError{
 			RetryAfter: retryAfter,
 		}
 	}
@@ -124,7 +135,7 @@ func (lim *Limiter) LoginBad(key string, limit int) {
 		}
 
 		if rate >= int64(limit) {
-			retryAfter := time.Duration(math.Pow(1.5, float64(rate)) * float64(time.Second))
+			retryAfter := ratelimit.LockoutDuration(int(rate))
 			lockout := time.Now().Add(retryAfter)
 
 			logging.L.Debug().
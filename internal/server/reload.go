@@ -0,0 +1,140 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"reflect"
+	"unicode"
+
+	"github.com/rs/zerolog"
+
+	"github.com/infrahq/infra/internal/logging"
+	"github.com/infrahq/infra/internal/validate"
+)
+
+// Reload applies the options that can be changed while the server is
+// running. The bootstrap config is loaded again, the log level is set, and the
+// TLS keypair is replaced. Existing connections continue to use the previous
+// keypair, new connections use the new one.
+//
+// Reload returns the names of any other options that were changed. Those
+// changes are ignored until the server is restarted.
+func (s *Server) Reload(ctx context.Context, options Options) (notReloaded []string, err error) {
+	s.reloadLock.Lock()
+	defer s.reloadLock.Unlock()
+
+	// validate and load everything before applying any changes, so that an
+	// invalid config file does not leave the server partially reloaded.
+	if err := validate.Validate(options.BootstrapConfig); err != nil {
+		return nil, err
+	}
+
+	changeLogLevel := options.LogLevel != s.options.LogLevel && options.LogLevel != ""
+	if changeLogLevel {
+		if _, err := zerolog.ParseLevel(options.LogLevel); err != nil {
+			return nil, fmt.Errorf("log level: %w", err)
+		}
+	}
+
+	var tlsConfig *tls.Config
+	if options.TLS != s.options.TLS && options.TLS.ACME == s.options.TLS.ACME {
+		tlsConfig, err = tlsConfigFromOptions(options.TLS)
+		if err != nil {
+			return nil, fmt.Errorf("tls config: %w", err)
+		}
+	}
+
+	// the bootstrap config is loaded in a transaction, so nothing is changed
+	// when it fails.
+	if err := s.loadConfig(ctx, options.BootstrapConfig); err != nil {
+		return nil, fmt.Errorf("configs: %w", err)
+	}
+	s.options.BootstrapConfig = options.BootstrapConfig
+
+	if changeLogLevel {
+		if err := logging.SetLevel(options.LogLevel); err != nil {
+			return nil, fmt.Errorf("log level: %w", err)
+		}
+	}
+	s.options.LogLevel = options.LogLevel
+
+	if tlsConfig != nil {
+		s.tlsConfig.Store(tlsConfig)
+		s.options.TLS = options.TLS
+		logging.Infof("reloaded TLS configuration")
+	}
+
+	notReloaded = changedOptions(s.options, options)
+	if len(notReloaded) > 0 {
+		logging.L.Warn().
+			Strs("options", notReloaded).
+			Msg("configuration changes to these options will not be applied until the server is restarted")
+	}
+	return notReloaded, nil
+}
+
+// changedOptions returns the config names of the fields that are different
+// between current and next.
+func changedOptions(current, next Options) []string {
+	var changed []string
+	var compare func(a, b reflect.Value)
+	compare = func(a, b reflect.Value) {
+		for i := 0; i < a.NumField(); i++ {
+			field := a.Type().Field(i)
+			if field.Anonymous && field.Type.Kind() == reflect.Struct {
+				// embedded structs are squashed into the parent in the config
+				compare(a.Field(i), b.Field(i))
+				continue
+			}
+			if !reflect.DeepEqual(a.Field(i).Interface(), b.Field(i).Interface()) {
+				changed = append(changed, configFieldName(field.Name))
+			}
+		}
+	}
+	compare(reflect.ValueOf(current), reflect.ValueOf(next))
+	return changed
+}
+
+// configFieldName returns the name of a field as it is commonly written in
+// the config file, ex: DBHost is dbHost, and TLSCache is tlsCache.
+func configFieldName(name string) string {
+	runes := []rune(name)
+	for i := range runes {
+		if !unicode.IsUpper(runes[i]) {
+			break
+		}
+		// the last upper case letter of an acronym starts the next word
+		if i > 0 && i+1 < len(runes) && unicode.IsLower(runes[i+1]) {
+			break
+		}
+		runes[i] = unicode.ToLower(runes[i])
+	}
+	return string(runes)
+}
+
+// reloadableTLSConfig returns a tls.Config that uses the most recent config
+// stored in s.tlsConfig for every new connection.
+func (s *Server) reloadableTLSConfig(initial *tls.Config) *tls.Config {
+	s.tlsConfig.Store(initial)
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		NextProtos: initial.NextProtos,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			return s.tlsConfig.Load(), nil
+		},
+		// GetCertificate is only used if GetConfigForClient returns nil, but
+		// must be set so that http.Server.ServeTLS does not look for a
+		// certificate file.
+		GetCertificate: func(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+			cfg := s.tlsConfig.Load()
+			if cfg.GetCertificate != nil {
+				return cfg.GetCertificate(hello)
+			}
+			if len(cfg.Certificates) == 0 {
+				return nil, fmt.Errorf("no TLS certificate configured")
+			}
+			return &cfg.Certificates[0], nil
+		},
+	}
+}
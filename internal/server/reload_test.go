@@ -0,0 +1,85 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"gotest.tools/v3/assert"
+
+	"github.com/infrahq/infra/internal/server/data"
+)
+
+func TestConfigFieldName(t *testing.T) {
+	testCases := map[string]string{
+		"DBHost":          "dbHost",
+		"TLSCache":        "tlsCache",
+		"TLS":             "tls",
+		"Addr":            "addr",
+		"EnableSignup":    "enableSignup",
+		"DBEncryptionKey": "dbEncryptionKey",
+	}
+	for name, expected := range testCases {
+		assert.Equal(t, configFieldName(name), expected, name)
+	}
+}
+
+func TestChangedOptions(t *testing.T) {
+	current := Options{
+		DBHost:   "localhost",
+		LogLevel: "info",
+		BootstrapConfig: BootstrapConfig{
+			DefaultOrganizationDomain: "example.com",
+		},
+	}
+
+	t.Run("no changes", func(t *testing.T) {
+		assert.Assert(t, changedOptions(current, current) == nil)
+	})
+
+	t.Run("changed options", func(t *testing.T) {
+		next := current
+		next.DBHost = "db.example.com"
+		next.EnableSignup = true
+		next.BootstrapConfig.DefaultOrganizationDomain = "other.example.com"
+
+		expected := []string{"enableSignup", "dbHost", "defaultOrganizationDomain"}
+		assert.DeepEqual(t, changedOptions(current, next), expected)
+	})
+}
+
+func TestServer_Reload(t *testing.T) {
+	srv := setupServer(t, withAdminUser)
+	ctx := context.Background()
+
+	options := srv.options
+	options.DBHost = "db.example.com"
+	options.BootstrapConfig.Users = append(options.BootstrapConfig.Users, User{Name: "reloaded@example.com"})
+
+	notReloaded, err := srv.Reload(ctx, options)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, notReloaded, []string{"dbHost"})
+
+	_, err = data.GetIdentity(srv.db, data.GetIdentityOptions{ByName: "reloaded@example.com"})
+	assert.NilError(t, err)
+
+	t.Run("invalid config is not applied", func(t *testing.T) {
+		invalid := options
+		invalid.LogLevel = "debug"
+		invalid.BootstrapConfig.Grants = []Grant{{Role: "admin"}}
+
+		_, err := srv.Reload(ctx, invalid)
+		assert.ErrorContains(t, err, "one of (user, group) is required")
+		assert.Equal(t, srv.options.LogLevel, options.LogLevel)
+	})
+
+	t.Run("config that fails to load is not applied", func(t *testing.T) {
+		invalid := options
+		invalid.LogLevel = "debug"
+		invalid.BootstrapConfig.Grants = []Grant{{Group: "not-a-group", Role: "view", Resource: "production"}}
+
+		_, err := srv.Reload(ctx, invalid)
+		assert.ErrorContains(t, err, "group not-a-group")
+		assert.Equal(t, srv.options.LogLevel, options.LogLevel)
+		assert.Equal(t, len(srv.options.BootstrapConfig.Grants), len(options.BootstrapConfig.Grants))
+	})
+}
@@ -0,0 +1,78 @@
+package server
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/infrahq/infra/api"
+	"github.com/infrahq/infra/internal/access"
+	"github.com/infrahq/infra/internal/server/data"
+	"github.com/infrahq/infra/internal/server/models"
+)
+
+func (a *API) ListRoleTemplates(c *gin.Context, r *api.ListRoleTemplatesRequest) (*api.ListResponse[api.RoleTemplate], error) {
+	rCtx := getRequestContext(c)
+	p, err := PaginationFromRequest(r.PaginationRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := data.ListRoleTemplatesOptions{
+		ByName:     r.Name,
+		Pagination: &p,
+	}
+	templates, err := access.ListRoleTemplates(rCtx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	result := api.NewListResponse(templates, PaginationToResponse(p), func(template models.RoleTemplate) api.RoleTemplate {
+		return *template.ToAPI()
+	})
+
+	return result, nil
+}
+
+func (a *API) GetRoleTemplate(c *gin.Context, r *api.Resource) (*api.RoleTemplate, error) {
+	template, err := access.GetRoleTemplate(getRequestContext(c), r.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return template.ToAPI(), nil
+}
+
+func (a *API) CreateRoleTemplate(c *gin.Context, r *api.CreateRoleTemplateRequest) (*api.RoleTemplate, error) {
+	template := &models.RoleTemplate{
+		Name:  r.Name,
+		Rules: r.Rules,
+	}
+
+	if err := access.CreateRoleTemplate(getRequestContext(c), template); err != nil {
+		return nil, err
+	}
+
+	return template.ToAPI(), nil
+}
+
+func (a *API) UpdateRoleTemplate(c *gin.Context, r *api.UpdateRoleTemplateRequest) (*api.RoleTemplate, error) {
+	rCtx := getRequestContext(c)
+
+	// Start with the existing value, so that non-update fields are not set to zero.
+	template, err := access.GetRoleTemplate(rCtx, r.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	template.Name = r.Name
+	template.Rules = r.Rules
+
+	if err := access.UpdateRoleTemplate(rCtx, template); err != nil {
+		return nil, err
+	}
+
+	return template.ToAPI(), nil
+}
+
+func (a *API) DeleteRoleTemplate(c *gin.Context, r *api.Resource) (*api.EmptyResponse, error) {
+	return nil, access.DeleteRoleTemplate(getRequestContext(c), r.ID)
+}
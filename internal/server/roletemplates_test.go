@@ -0,0 +1,96 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gotest.tools/v3/assert"
+
+	"github.com/infrahq/infra/api"
+)
+
+func TestAPI_RoleTemplates(t *testing.T) {
+	srv := setupServer(t, withAdminUser)
+	routes := srv.GenerateRoutes()
+
+	do := func(t *testing.T, method, path string, body any) *httptest.ResponseRecorder {
+		t.Helper()
+		var req *http.Request
+		if body != nil {
+			req = httptest.NewRequest(method, path, jsonBody(t, body))
+		} else {
+			req = httptest.NewRequest(method, path, nil)
+		}
+		req.Header.Set("Authorization", "Bearer "+adminAccessKey(srv))
+		req.Header.Set("Infra-Version", apiVersionLatest)
+
+		resp := httptest.NewRecorder()
+		routes.ServeHTTP(resp, req)
+		return resp
+	}
+
+	rules := []api.RoleRule{
+		{APIGroups: []string{""}, Resources: []string{"pods", "pods/log"}, Verbs: []string{"get", "list"}},
+	}
+
+	var created api.RoleTemplate
+	t.Run("create", func(t *testing.T) {
+		resp := do(t, http.MethodPost, "/api/role-templates", &api.CreateRoleTemplateRequest{
+			Name:  "logs",
+			Rules: rules,
+		})
+		assert.Equal(t, resp.Code, http.StatusCreated, resp.Body.String())
+		assert.NilError(t, json.NewDecoder(resp.Body).Decode(&created))
+		assert.Equal(t, created.Name, "logs")
+		assert.DeepEqual(t, created.Rules, rules)
+	})
+
+	t.Run("create with invalid name", func(t *testing.T) {
+		resp := do(t, http.MethodPost, "/api/role-templates", &api.CreateRoleTemplateRequest{
+			Name:  "Not Valid",
+			Rules: rules,
+		})
+		assert.Equal(t, resp.Code, http.StatusBadRequest, resp.Body.String())
+	})
+
+	t.Run("create with duplicate name", func(t *testing.T) {
+		resp := do(t, http.MethodPost, "/api/role-templates", &api.CreateRoleTemplateRequest{
+			Name:  "logs",
+			Rules: rules,
+		})
+		assert.Equal(t, resp.Code, http.StatusConflict, resp.Body.String())
+	})
+
+	t.Run("update", func(t *testing.T) {
+		updated := append(rules, api.RoleRule{Resources: []string{"events"}, Verbs: []string{"list"}})
+		resp := do(t, http.MethodPut, "/api/role-templates/"+created.ID.String(), &api.UpdateRoleTemplateRequest{
+			Name:  "logs",
+			Rules: updated,
+		})
+		assert.Equal(t, resp.Code, http.StatusOK, resp.Body.String())
+
+		var actual api.RoleTemplate
+		assert.NilError(t, json.NewDecoder(resp.Body).Decode(&actual))
+		assert.DeepEqual(t, actual.Rules, updated)
+	})
+
+	t.Run("list", func(t *testing.T) {
+		resp := do(t, http.MethodGet, "/api/role-templates?name=logs", nil)
+		assert.Equal(t, resp.Code, http.StatusOK, resp.Body.String())
+
+		var actual api.ListResponse[api.RoleTemplate]
+		assert.NilError(t, json.NewDecoder(resp.Body).Decode(&actual))
+		assert.Equal(t, len(actual.Items), 1)
+		assert.Equal(t, actual.Items[0].ID, created.ID)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		resp := do(t, http.MethodDelete, "/api/role-templates/"+created.ID.String(), nil)
+		assert.Equal(t, resp.Code, http.StatusNoContent, resp.Body.String())
+
+		resp = do(t, http.MethodGet, "/api/role-templates/"+created.ID.String(), nil)
+		assert.Equal(t, resp.Code, http.StatusNotFound, resp.Body.String())
+	})
+}
@@ -78,6 +78,8 @@ func (s *Server) GenerateRoutes() Routes {
 	get(a, authn, "/api/organizations/:id", a.GetOrganization)
 	del(a, authn, "/api/organizations/:id", a.DeleteOrganization)
 	put(a, authn, "/api/organizations/:id", a.UpdateOrganization)
+	get(a, authn, "/api/organizations/:id/ratelimits", a.GetRateLimitUsage)
+	put(a, authn, "/api/organizations/:id/ratelimits", a.UpdateOrganizationRateLimits)
 
 	get(a, authn, "/api/grants", a.ListGrants)
 	get(a, authn, "/api/grants/:id", a.GetGrant)
@@ -96,6 +98,26 @@ func (s *Server) GenerateRoutes() Routes {
 	put(a, authn, "/api/destinations/:id", a.UpdateDestination)
 	del(a, authn, "/api/destinations/:id", a.DeleteDestination)
 
+	get(a, authn, "/api/destination-activity", a.ListDestinationActivity)
+	post(a, authn, "/api/destination-activity", a.CreateDestinationActivity)
+
+	get(a, authn, "/api/session-recordings", a.ListSessionRecordings)
+	get(a, authn, "/api/session-recordings/:id", a.GetSessionRecording)
+	get(a, authn, "/api/session-recordings/:id/content", a.GetSessionRecordingContent)
+	add(a, authn, http.MethodPost, "/api/session-recordings", createSessionRecordingRoute)
+
+	get(a, authn, "/api/role-templates", a.ListRoleTemplates)
+	get(a, authn, "/api/role-templates/:id", a.GetRoleTemplate)
+	post(a, authn, "/api/role-templates", a.CreateRoleTemplate)
+	put(a, authn, "/api/role-templates/:id", a.UpdateRoleTemplate)
+	del(a, authn, "/api/role-templates/:id", a.DeleteRoleTemplate)
+
+	get(a, authn, "/api/proxy-policies", a.ListProxyPolicies)
+	get(a, authn, "/api/proxy-policies/:id", a.GetProxyPolicy)
+	post(a, authn, "/api/proxy-policies", a.CreateProxyPolicy)
+	put(a, authn, "/api/proxy-policies/:id", a.UpdateProxyPolicy)
+	del(a, authn, "/api/proxy-policies/:id", a.DeleteProxyPolicy)
+
 	add(a, authn, http.MethodPost, "/api/tokens", createTokenRoute)
 	post(a, authn, "/api/logout", a.Logout)
 
@@ -108,6 +130,8 @@ func (s *Server) GenerateRoutes() Routes {
 	add(a, authn, http.MethodDelete, "/api/scim/v2/Users/:id", deleteProviderUserRoute)
 
 	add(a, authn, http.MethodGet, "/api/debug/pprof/*profile", pprofRoute)
+	add(a, authn, http.MethodGet, "/api/debug/jobs", a.ListBackgroundJobsRoute())
+	add(a, authn, http.MethodPost, "/api/debug/jobs/:name/run", a.RunBackgroundJobRoute())
 
 	// no auth required, org not required
 	noAuthnNoOrg := &routeGroup{RouterGroup: apiGroup.Group("/"), authenticationOptional: true, organizationOptional: true}
@@ -163,6 +187,9 @@ type routeSettings struct {
 	organizationOptional       bool
 	idpSync                    bool // when true the user session will be syncronized with the identity provider on a timed interval
 	txnOptions                 *sql.TxOptions
+	// maxBodySize is the maximum size of the request body in bytes. Zero
+	// means no limit.
+	maxBodySize int64
 }
 
 type routeIdentifier struct {
@@ -231,6 +258,14 @@ func wrapRoute[Req, Res any](a *API, routeID routeIdentifier, route route[Req, R
 			return err
 		}
 
+		if route.maxBodySize > 0 {
+			if c.Request.ContentLength > route.maxBodySize {
+				return fmt.Errorf("%w: request body must be at most %d bytes",
+					internal.ErrBadRequest, route.maxBodySize)
+			}
+			c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, route.maxBodySize)
+		}
+
 		req := new(Req)
 		if err := readRequest(c, req); err != nil {
 			return err
@@ -2,6 +2,7 @@ package server
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
 	"fmt"
 	"io/fs"
@@ -11,6 +12,8 @@ import (
 	"net/http/httputil"
 	"os"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/cenkalti/backoff/v4"
@@ -28,6 +31,7 @@ import (
 	"github.com/infrahq/infra/internal/server/email"
 	"github.com/infrahq/infra/internal/server/models"
 	"github.com/infrahq/infra/internal/server/providers"
+	"github.com/infrahq/infra/internal/server/ratelimit"
 	"github.com/infrahq/infra/internal/server/redis"
 	"github.com/infrahq/infra/metrics"
 	"github.com/infrahq/infra/uid"
@@ -56,6 +60,16 @@ type Options struct {
 	// Redis contains configuration options to the cache server.
 	Redis redis.Options
 
+	// LogLevel sets the level of the server logs. One of error, warn, info,
+	// or debug. When empty the level is set by the --log-level flag.
+	LogLevel string
+
+	// RateLimiter selects where rate limits and login lockouts are counted.
+	// It may be one of "redis", "postgres", or "memory". When empty, redis is
+	// used if it is configured, otherwise postgres is used. The memory limiter
+	// should only be used when the server is run as a single replica.
+	RateLimiter string
+
 	GoogleClientID     string
 	GoogleClientSecret string
 
@@ -97,8 +111,6 @@ type Options struct {
 // longer supported.
 type DeprecatedConfig struct {
 	DBEncryptionKeyProvider string
-	Providers               any
-	Grants                  any
 }
 
 type ListenerOptions struct {
@@ -135,6 +147,10 @@ type Server struct {
 	options         Options
 	db              *data.DB
 	redis           *redis.Redis
+	limiter         ratelimit.Limiter
+	jobs            *backgroundJobs
+	reloadLock      sync.Mutex
+	tlsConfig       atomic.Pointer[tls.Config]
 	tel             *Telemetry
 	Addrs           Addrs
 	routines        []routine
@@ -163,13 +179,11 @@ func New(options Options) (*Server, error) {
 		return nil, errors.New("dbEncryptionKeyProvider is no longer supported, " +
 			"use a file for the root key and set dbEncryptionKey to the path of the file")
 	}
-	if options.Grants != nil {
-		return nil, fmt.Errorf("grants can no longer be defined from config. " +
-			"Please use https://github.com/infrahq/terraform-provider-infra or the API")
-	}
-	if options.Providers != nil {
-		return nil, fmt.Errorf("providers can no longer be defined from config. " +
-			"Please use https://github.com/infrahq/terraform-provider-infra or the API")
+
+	if options.LogLevel != "" {
+		if err := logging.SetLevel(options.LogLevel); err != nil {
+			return nil, fmt.Errorf("log level: %w", err)
+		}
 	}
 
 	server := newServer(options)
@@ -192,6 +206,7 @@ func New(options Options) (*Server, error) {
 		return nil, fmt.Errorf("db: %w", err)
 	}
 	server.db = db
+	server.jobs = newBackgroundJobs(db)
 	server.metricsRegistry = setupMetrics(server.db)
 
 	server.redis, err = redis.NewRedis(options.Redis)
@@ -199,6 +214,11 @@ func New(options Options) (*Server, error) {
 		return nil, err
 	}
 
+	server.limiter, err = newLimiter(options.RateLimiter, server.redis, server.db)
+	if err != nil {
+		return nil, err
+	}
+
 	if options.EnableTelemetry {
 		server.tel = NewTelemetry(server.db, db.DefaultOrgSettings.ID)
 	}
@@ -219,7 +239,7 @@ func New(options Options) (*Server, error) {
 		}
 	}
 
-	if err := server.loadConfig(server.options.BootstrapConfig); err != nil {
+	if err := server.loadConfig(context.Background(), server.options.BootstrapConfig); err != nil {
 		return nil, fmt.Errorf("configs: %w", err)
 	}
 
@@ -245,10 +265,12 @@ func (s *Server) Options() Options {
 func (s *Server) Run(ctx context.Context) error {
 	group, ctx := errgroup.WithContext(ctx)
 
-	group.Go(backgroundJob(ctx, s.db, data.DeleteExpiredDeviceFlowAuthRequests, 10*time.Minute))
-	group.Go(backgroundJob(ctx, s.db, data.RemoveExpiredAccessKeys, 12*time.Hour))
-	group.Go(backgroundJob(ctx, s.db, data.RemoveExpiredPasswordResetTokens, 15*time.Minute))
-	group.Go(backgroundJob(ctx, s.db, data.DeleteExpiredUserPublicKeys, time.Hour))
+	group.Go(s.jobs.backgroundJob(ctx, data.DeleteExpiredDeviceFlowAuthRequests, 10*time.Minute))
+	group.Go(s.jobs.backgroundJob(ctx, data.RemoveExpiredAccessKeys, 12*time.Hour))
+	group.Go(s.jobs.backgroundJob(ctx, data.RemoveExpiredPasswordResetTokens, 15*time.Minute))
+	group.Go(s.jobs.backgroundJob(ctx, data.DeleteExpiredUserPublicKeys, time.Hour))
+	group.Go(s.jobs.backgroundJob(ctx, data.DeleteExpiredDestinationActivity, 12*time.Hour))
+	group.Go(s.jobs.backgroundJob(ctx, data.DeleteExpiredRateLimits, 10*time.Minute))
 
 	if s.tel != nil {
 		group.Go(func() error {
@@ -355,7 +377,7 @@ func (s *Server) listen() error {
 		ReadHeaderTimeout: 30 * time.Second,
 		ReadTimeout:       60 * time.Second,
 		Addr:              s.options.Addr.HTTPS,
-		TLSConfig:         tlsConfig,
+		TLSConfig:         s.reloadableTLSConfig(tlsConfig),
 		Handler:           router,
 		ErrorLog:          httpErrorLog,
 	}
@@ -26,6 +26,7 @@ import (
 	"github.com/infrahq/infra/internal/server/data"
 	"github.com/infrahq/infra/internal/server/models"
 	"github.com/infrahq/infra/internal/server/providers"
+	"github.com/infrahq/infra/internal/server/ratelimit"
 	"github.com/infrahq/infra/internal/testing/database"
 )
 
@@ -46,8 +47,10 @@ func setupServer(t *testing.T, ops ...func(*testing.T, *Options)) *Server {
 	}
 	s := newServer(options)
 	s.db = setupDB(t)
+	s.limiter = ratelimit.NewMemoryLimiter()
+	s.jobs = newBackgroundJobs(s.db)
 
-	err := s.loadConfig(s.options.BootstrapConfig)
+	err := s.loadConfig(context.Background(), s.options.BootstrapConfig)
 	assert.NilError(t, err)
 
 	s.metricsRegistry = prometheus.NewRegistry()
@@ -404,7 +407,7 @@ func TestServer_PersistSignupUser(t *testing.T) {
 	checkAuthenticated()
 
 	// reload server config
-	err = s.loadConfig(s.options.BootstrapConfig)
+	err = s.loadConfig(context.Background(), s.options.BootstrapConfig)
 	assert.NilError(t, err)
 
 	// retry the authenticated endpoint
@@ -0,0 +1,81 @@
+package server
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/infrahq/infra/api"
+	"github.com/infrahq/infra/internal"
+	"github.com/infrahq/infra/internal/access"
+	"github.com/infrahq/infra/internal/server/data"
+	"github.com/infrahq/infra/internal/server/models"
+)
+
+// createSessionRecordingMaxBodySize is the maximum size of the request body of
+// CreateSessionRecording. The content is base64 encoded in the request body.
+const createSessionRecordingMaxBodySize = api.MaxSessionRecordingSize/3*4 + 1024*1024
+
+var createSessionRecordingRoute = route[api.CreateSessionRecordingRequest, *api.SessionRecording]{
+	routeSettings: routeSettings{maxBodySize: createSessionRecordingMaxBodySize},
+	handler:       CreateSessionRecording,
+}
+
+func CreateSessionRecording(c *gin.Context, r *api.CreateSessionRecordingRequest) (*api.SessionRecording, error) {
+	if len(r.Content) > api.MaxSessionRecordingSize {
+		return nil, fmt.Errorf("%w: recording must be at most %d bytes",
+			internal.ErrBadRequest, api.MaxSessionRecordingSize)
+	}
+
+	recording := &models.SessionRecording{
+		DestinationName: r.Destination,
+		UserID:          r.UserID,
+		LoginName:       r.LoginName,
+		StartedAt:       time.Time(r.StartedAt),
+		EndedAt:         time.Time(r.EndedAt),
+		Content:         r.Content,
+	}
+	if err := access.CreateSessionRecording(getRequestContext(c), recording); err != nil {
+		return nil, err
+	}
+	return recording.ToAPI(), nil
+}
+
+func (a *API) ListSessionRecordings(c *gin.Context, r *api.ListSessionRecordingsRequest) (*api.ListResponse[api.SessionRecording], error) {
+	rCtx := getRequestContext(c)
+	p, err := PaginationFromRequest(r.PaginationRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	recordings, err := access.ListSessionRecordings(rCtx, data.ListSessionRecordingsOptions{
+		ByDestinationName: r.Destination,
+		ByUserID:          r.UserID,
+		Pagination:        &p,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := api.NewListResponse(recordings, PaginationToResponse(p), func(recording models.SessionRecording) api.SessionRecording {
+		return *recording.ToAPI()
+	})
+	return result, nil
+}
+
+func (a *API) GetSessionRecording(c *gin.Context, r *api.Resource) (*api.SessionRecording, error) {
+	recording, err := access.GetSessionRecording(getRequestContext(c), r.ID)
+	if err != nil {
+		return nil, err
+	}
+	return recording.ToAPI(), nil
+}
+
+func (a *API) GetSessionRecordingContent(c *gin.Context, r *api.Resource) (*api.SessionRecordingContent, error) {
+	recording, err := access.GetSessionRecordingContent(getRequestContext(c), r.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &api.SessionRecordingContent{ID: recording.ID, Content: recording.Content}, nil
+}
@@ -0,0 +1,148 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+
+	"github.com/infrahq/infra/api"
+	"github.com/infrahq/infra/internal/server/data"
+)
+
+func TestAPI_SessionRecordings(t *testing.T) {
+	srv := setupServer(t, withAdminUser)
+	routes := srv.GenerateRoutes()
+
+	admin, err := data.GetIdentity(srv.DB(), data.GetIdentityOptions{ByName: "admin@example.com"})
+	assert.NilError(t, err)
+
+	doWithKey := func(t *testing.T, key, method, path string, body any) *httptest.ResponseRecorder {
+		t.Helper()
+		var req *http.Request
+		if body != nil {
+			req = httptest.NewRequest(method, path, jsonBody(t, body))
+		} else {
+			req = httptest.NewRequest(method, path, nil)
+		}
+		req.Header.Set("Authorization", "Bearer "+key)
+		req.Header.Set("Infra-Version", apiVersionLatest)
+
+		resp := httptest.NewRecorder()
+		routes.ServeHTTP(resp, req)
+		return resp
+	}
+	do := func(t *testing.T, method, path string, body any) *httptest.ResponseRecorder {
+		t.Helper()
+		return doWithKey(t, adminAccessKey(srv), method, path, body)
+	}
+
+	content := []byte("{\"version\":2,\"width\":80,\"height\":24}\n[0.5,\"o\",\"$ \"]\n")
+	started := time.Now().Add(-time.Hour).UTC().Truncate(time.Second)
+
+	var created api.SessionRecording
+	t.Run("create", func(t *testing.T) {
+		resp := do(t, http.MethodPost, "/api/session-recordings", &api.CreateSessionRecordingRequest{
+			Destination: "bastion",
+			UserID:      admin.ID,
+			LoginName:   "admin",
+			StartedAt:   api.Time(started),
+			EndedAt:     api.Time(started.Add(time.Minute)),
+			Content:     content,
+		})
+		assert.Equal(t, resp.Code, http.StatusCreated, resp.Body.String())
+		assert.NilError(t, json.NewDecoder(resp.Body).Decode(&created))
+		assert.Equal(t, created.User, "admin@example.com")
+		assert.Equal(t, created.Size, int64(len(content)))
+	})
+
+	t.Run("create without content", func(t *testing.T) {
+		resp := do(t, http.MethodPost, "/api/session-recordings", &api.CreateSessionRecordingRequest{
+			Destination: "bastion",
+			UserID:      admin.ID,
+			LoginName:   "admin",
+			StartedAt:   api.Time(started),
+		})
+		assert.Equal(t, resp.Code, http.StatusBadRequest, resp.Body.String())
+	})
+
+	t.Run("create with connector key", func(t *testing.T) {
+		key := createConnectorAccessKey(t, srv.DB(), "jumphost")
+		resp := doWithKey(t, key, http.MethodPost, "/api/session-recordings", &api.CreateSessionRecordingRequest{
+			Destination: "jumphost",
+			UserID:      admin.ID,
+			LoginName:   "admin",
+			StartedAt:   api.Time(started),
+			EndedAt:     api.Time(started.Add(time.Minute)),
+			Content:     content,
+		})
+		assert.Equal(t, resp.Code, http.StatusCreated, resp.Body.String())
+	})
+
+	t.Run("create for another destination", func(t *testing.T) {
+		key := createConnectorAccessKey(t, srv.DB(), "jumphost")
+		resp := doWithKey(t, key, http.MethodPost, "/api/session-recordings", &api.CreateSessionRecordingRequest{
+			Destination: "bastion",
+			UserID:      admin.ID,
+			LoginName:   "admin",
+			StartedAt:   api.Time(started),
+			EndedAt:     api.Time(started.Add(time.Minute)),
+			Content:     content,
+		})
+		assert.Equal(t, resp.Code, http.StatusForbidden, resp.Body.String())
+	})
+
+	t.Run("create with connector key not bound to a destination that is not registered", func(t *testing.T) {
+		key := createConnectorAccessKey(t, srv.DB(), "")
+		resp := doWithKey(t, key, http.MethodPost, "/api/session-recordings", &api.CreateSessionRecordingRequest{
+			Destination: "bastion",
+			UserID:      admin.ID,
+			LoginName:   "admin",
+			StartedAt:   api.Time(started),
+			EndedAt:     api.Time(started.Add(time.Minute)),
+			Content:     content,
+		})
+		assert.Equal(t, resp.Code, http.StatusForbidden, resp.Body.String())
+	})
+
+	t.Run("create with body too large", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/api/session-recordings", strings.NewReader("{}"))
+		req.ContentLength = createSessionRecordingMaxBodySize + 1
+		req.Header.Set("Authorization", "Bearer "+adminAccessKey(srv))
+		req.Header.Set("Infra-Version", apiVersionLatest)
+
+		resp := httptest.NewRecorder()
+		routes.ServeHTTP(resp, req)
+		assert.Equal(t, resp.Code, http.StatusBadRequest, resp.Body.String())
+	})
+
+	t.Run("list", func(t *testing.T) {
+		resp := do(t, http.MethodGet, "/api/session-recordings?destination=bastion", nil)
+		assert.Equal(t, resp.Code, http.StatusOK, resp.Body.String())
+
+		var actual api.ListResponse[api.SessionRecording]
+		assert.NilError(t, json.NewDecoder(resp.Body).Decode(&actual))
+		assert.Equal(t, len(actual.Items), 1)
+		assert.Equal(t, actual.Items[0].ID, created.ID)
+		assert.Equal(t, actual.Items[0].LoginName, "admin")
+	})
+
+	t.Run("list with cursor", func(t *testing.T) {
+		// session recordings do not support cursor pagination
+		resp := do(t, http.MethodGet, "/api/session-recordings?cursor=abcd", nil)
+		assert.Equal(t, resp.Code, http.StatusBadRequest, resp.Body.String())
+	})
+
+	t.Run("download", func(t *testing.T) {
+		resp := do(t, http.MethodGet, "/api/session-recordings/"+created.ID.String()+"/content", nil)
+		assert.Equal(t, resp.Code, http.StatusOK, resp.Body.String())
+
+		var actual api.SessionRecordingContent
+		assert.NilError(t, json.NewDecoder(resp.Body).Decode(&actual))
+		assert.DeepEqual(t, actual.Content, content)
+	})
+}
@@ -22,7 +22,7 @@ import (
 
 func (a *API) ListUsers(c *gin.Context, r *api.ListUsersRequest) (*api.ListResponse[api.User], error) {
 	rCtx := getRequestContext(c)
-	p := PaginationFromRequest(r.PaginationRequest)
+	p := CursorPaginationFromRequest(r.PaginationRequest)
 
 	opts := data.ListIdentityOptions{
 		Pagination:             &p,
@@ -37,14 +37,18 @@ func (a *API) ListUsers(c *gin.Context, r *api.ListUsersRequest) (*api.ListRespo
 		opts.ByNotName = models.InternalInfraConnectorIdentityName
 	}
 
-	users, err := access.ListIdentities(rCtx, opts)
+	users, err := access.WatchList(rCtx, data.WatchUsers, r.LastUpdateIndex,
+		func(rCtx access.RequestContext) ([]models.Identity, error) {
+			return access.ListIdentities(rCtx, opts)
+		})
 	if err != nil {
 		return nil, err
 	}
 
-	result := api.NewListResponse(users, PaginationToResponse(p), func(identity models.Identity) api.User {
+	result := api.NewListResponse(users.Items, PaginationToResponse(p), func(identity models.Identity) api.User {
 		return *identity.ToAPI()
 	})
+	result.LastUpdateIndex.Index = users.MaxUpdateIndex
 
 	return result, nil
 }
@@ -498,6 +498,60 @@ func TestAPI_ListUsers(t *testing.T) {
 	}
 }
 
+func TestAPI_ListUsers_BlockingRequest_BlocksUntilUpdate(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too long for short run")
+	}
+
+	srv := setupServer(t, withAdminUser)
+	routes := srv.GenerateRoutes()
+
+	listUsers := func(lastUpdateIndex string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/users?lastUpdateIndex="+lastUpdateIndex, nil)
+		req.Header.Set("Authorization", "Bearer "+adminAccessKey(srv))
+		req.Header.Add("Infra-Version", apiVersionLatest)
+
+		resp := httptest.NewRecorder()
+		routes.ServeHTTP(resp, req)
+		return resp
+	}
+
+	// a non-blocking request returns the index to use for the next request
+	resp := listUsers("0")
+	assert.Equal(t, resp.Code, http.StatusOK, (*responseDebug)(resp))
+	initialIndex := resp.Result().Header.Get("Last-Update-Index")
+	assert.Assert(t, initialIndex != "")
+
+	respCh := make(chan *httptest.ResponseRecorder)
+	go func() {
+		respCh <- listUsers(initialIndex)
+	}()
+
+	isBlocked(t, respCh)
+
+	// updating only the last seen time does not unblock the request
+	admin, err := data.GetIdentity(srv.DB(), data.GetIdentityOptions{ByName: "admin@example.com"})
+	assert.NilError(t, err)
+	admin.LastSeenAt = time.Now().Add(-time.Hour)
+	assert.NilError(t, data.UpdateIdentityLastSeenAt(srv.DB(), admin))
+	isBlocked(t, respCh)
+
+	user := &models.Identity{Name: "newuser@example.com"}
+	assert.NilError(t, data.CreateIdentity(srv.DB(), user))
+
+	resp = isNotBlocked(t, respCh)
+	assert.Equal(t, resp.Code, http.StatusOK, (*responseDebug)(resp))
+	assert.Assert(t, resp.Result().Header.Get("Last-Update-Index") != initialIndex)
+
+	respBody := &api.ListResponse[api.User]{}
+	assert.NilError(t, json.NewDecoder(resp.Body).Decode(respBody))
+	var names []string
+	for _, user := range respBody.Items {
+		names = append(names, user.Name)
+	}
+	assert.DeepEqual(t, names, []string{"admin@example.com", "newuser@example.com"})
+}
+
 var cmpAPIUserShallow = gocmp.Comparer(func(x, y api.User) bool {
 	return x.Name == y.Name
 })